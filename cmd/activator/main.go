@@ -176,6 +176,7 @@ func main() {
 	// Create activation handler chain
 	// Note: innermost handlers are specified first, ie. the last handler in the chain will be executed first
 	var ah http.Handler = activatorhandler.New(ctx, throttler, transport)
+	ah = activatorhandler.NewMirrorHandler(ctx, env.PodName, throttler, concurrencyReporter, transport, ah)
 	ah = concurrencyReporter.Handler(ah)
 	ah = tracing.HTTPSpanMiddleware(ah)
 	ah = configStore.HTTPMiddleware(ah)
@@ -40,6 +40,7 @@ import (
 
 	configmap "knative.dev/pkg/configmap/informer"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/hash"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/metrics"
 	"knative.dev/pkg/profiling"
@@ -49,6 +50,7 @@ import (
 	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/autoscaler/bucket"
+	"knative.dev/serving/pkg/autoscaler/historystore"
 	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
 	"knative.dev/serving/pkg/autoscaler/scaling"
 	"knative.dev/serving/pkg/autoscaler/statforwarder"
@@ -160,13 +162,16 @@ func main() {
 		logger.Info("Running with StatefulSet leader election")
 		ctx = leaderelection.WithStatefulSetElectorBuilder(ctx, cc, b)
 		f = statforwarder.New(ctx, bs)
+		persistHistory(ctx, f, bs, collector)
 		if err := statforwarder.StatefulSetBasedProcessor(ctx, f, accept); err != nil {
 			logger.Fatalw("Failed to set up statefulset processors", zap.Error(err))
 		}
 	} else {
 		logger.Info("Running with Standard leader election")
 		ctx = leaderelection.WithStandardLeaderElectorBuilder(ctx, kubeClient, cc)
-		f = statforwarder.New(ctx, bucket.AutoscalerBucketSet(cc.Buckets))
+		bs := bucket.AutoscalerBucketSet(cc.Buckets)
+		f = statforwarder.New(ctx, bs)
+		persistHistory(ctx, f, bs, collector)
 		if err := statforwarder.LeaseBasedProcessor(ctx, f, accept); err != nil {
 			logger.Fatalw("Failed to set up lease tracking", zap.Error(err))
 		}
@@ -174,6 +179,8 @@ func main() {
 
 	// Set up a statserver.
 	statsServer := statserver.New(statsServerAddr, statsCh, logger, f.IsBucketOwner)
+	// Serve the queue-proxies the ready pods they divide the rate limits by.
+	statsServer.Handle(asmetrics.ReadyPodsPath, asmetrics.NewReadyPodsHandler(podLister, logger))
 
 	defer f.Cancel()
 
@@ -245,6 +252,19 @@ func statsScraperFactoryFunc(podLister corev1listers.PodLister) asmetrics.StatsS
 	}
 }
 
+// persistHistory periodically saves the metric history of the buckets owned
+// by this autoscaler and restores it as the buckets are acquired, so that the
+// scaling decisions don't suffer from the history loss on restarts.
+// It must be called before the processors of the forwarder are set up.
+func persistHistory(ctx context.Context, f *statforwarder.Forwarder, bs *hash.BucketSet,
+	collector *asmetrics.MetricCollector) {
+	store := historystore.NewConfigMapStore(kubeclient.Get(ctx), system.Namespace())
+	persister := asmetrics.NewHistoryPersister(collector, store, bs, f.IsBucketOwner,
+		logging.FromContext(ctx))
+	f.OnBucketAcquired(persister.Restore)
+	go persister.Run(ctx.Done())
+}
+
 func flush(logger *zap.SugaredLogger) {
 	logger.Sync()
 	metrics.FlushExporter()
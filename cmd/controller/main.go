@@ -18,6 +18,7 @@ package main
 
 import (
 	// The set of controllers this controller process runs.
+	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/reconciler/configuration"
 	"knative.dev/serving/pkg/reconciler/gc"
 	"knative.dev/serving/pkg/reconciler/labeler"
@@ -27,8 +28,10 @@ import (
 	"knative.dev/serving/pkg/reconciler/service"
 
 	// This defines the shared main for injected controllers.
+	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
 )
 
 var ctors = []injection.ControllerConstructor{
@@ -42,5 +45,8 @@ var ctors = []injection.ControllerConstructor{
 }
 
 func main() {
-	sharedmain.Main("controller", ctors...)
+	// Only the ConfigMaps labeled with their Configuration, the revision
+	// archives, are watched.
+	ctx := filteredFactory.WithSelectors(signals.NewContext(), serving.ConfigurationLabelKey)
+	sharedmain.MainWithContext(ctx, "controller", ctors...)
 }
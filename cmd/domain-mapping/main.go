@@ -18,12 +18,17 @@ package main
 
 import (
 	// The set of controllers this controller process runs.
+	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/reconciler/domainmapping"
 
 	// This defines the shared main for injected controllers.
+	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
 )
 
 func main() {
-	sharedmain.Main("domainmapping", domainmapping.NewController)
+	// Only the Secrets labeled for the DomainMappings are watched.
+	ctx := filteredFactory.WithSelectors(signals.NewContext(), serving.DomainMappingTLSLabelKey)
+	sharedmain.MainWithContext(ctx, "domainmapping", domainmapping.NewController)
 }
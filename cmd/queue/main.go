@@ -41,10 +41,13 @@ import (
 	pkgnet "knative.dev/pkg/network"
 	"knative.dev/pkg/profiling"
 	"knative.dev/pkg/signals"
+	"knative.dev/pkg/system"
 	"knative.dev/pkg/tracing"
 	tracingconfig "knative.dev/pkg/tracing/config"
 	"knative.dev/pkg/tracing/propagation/tracecontextb3"
 	"knative.dev/serving/pkg/activator"
+	"knative.dev/serving/pkg/apis/serving"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
 	pkghttp "knative.dev/serving/pkg/http"
 	"knative.dev/serving/pkg/http/handler"
 	"knative.dev/serving/pkg/logging"
@@ -57,6 +60,10 @@ import (
 const (
 	// reportingPeriod is the interval of time between reporting stats by queue proxy.
 	reportingPeriod = 1 * time.Second
+
+	// readyPodsPollPeriod is the interval of time between polling the
+	// autoscaler for the ready pods the rate limits are divided across.
+	readyPodsPollPeriod = 5 * time.Second
 )
 
 var (
@@ -90,6 +97,13 @@ type config struct {
 	ServingService               string `split_words:"true"` // optional
 	ServingRequestMetricsBackend string `split_words:"true"` // optional
 	MetricsCollectorAddress      string `split_words:"true"` // optional
+	CustomMetricName             string `split_words:"true"` // optional
+	CustomMetricPath             string `split_words:"true"` // optional
+
+	// Queueing configuration
+	QueuePriorityClasses string `split_words:"true"` // optional
+	QueueMaxWait         string `split_words:"true"` // optional
+	QueueRateLimits      string `split_words:"true"` // optional
 
 	// Tracing configuration
 	TracingConfigDebug                bool                      `split_words:"true"` // optional
@@ -155,20 +169,42 @@ func main() {
 	reportTicker := time.NewTicker(reportingPeriod)
 	defer reportTicker.Stop()
 
+	priorityClasses := buildPriorityClasses(logger, env)
+	breaker := buildBreaker(logger, env, priorityClasses)
+	// The names of the priority classes, from the highest priority to the lowest.
+	priorityClassNames := make([]string, len(priorityClasses))
+	for i, c := range priorityClasses {
+		priorityClassNames[i] = c.Name
+	}
+
+	rateLimiter := buildRateLimiter(logger, env)
+	if rateLimiter != nil {
+		// The rate limits are divided across the ready pods, as reported by the autoscaler.
+		go queue.PollReadyPods(ctx, logger, rateLimiter, asmetrics.ReadyPodsURL(system.Namespace(),
+			env.ServingNamespace, env.ServingRevision, env.ServingPod), readyPodsPollPeriod)
+	}
+
 	stats := network.NewRequestStats(time.Now())
 	go func() {
 		for now := range reportTicker.C {
 			stat := stats.Report(now)
 			promStatReporter.Report(stat)
 			protoStatReporter.Report(stat)
+			if len(priorityClassNames) > 0 {
+				promStatReporter.ReportQueueDepths(priorityClassNames, breaker.PriorityInFlight())
+			}
 		}
 	}()
 
+	if env.CustomMetricName != "" {
+		go scrapeCustomMetric(ctx, logger, env, protoStatReporter)
+	}
+
 	// Setup probe to run for checking user-application healthiness.
 	probe := buildProbe(ctx, logger, env.ServingReadinessProbe)
 	healthState := health.NewState()
 
-	mainServer := buildServer(ctx, env, healthState, probe, stats, logger)
+	mainServer := buildServer(ctx, env, healthState, probe, stats, breaker, priorityClassNames, rateLimiter, logger)
 	servers := map[string]*http.Server{
 		"main":    mainServer,
 		"admin":   buildAdminServer(logger, healthState),
@@ -263,7 +299,7 @@ func buildProbe(ctx context.Context, logger *zap.SugaredLogger, probeJSON string
 }
 
 func buildServer(ctx context.Context, env config, healthState *health.State, rp *readiness.Probe, stats *network.RequestStats,
-	logger *zap.SugaredLogger) *http.Server {
+	breaker *queue.Breaker, priorityClasses []string, rateLimiter *queue.RateLimiter, logger *zap.SugaredLogger) *http.Server {
 
 	maxIdleConns := 1000 // TODO: somewhat arbitrary value for CC=0, needs experimental validation.
 	if env.ContainerConcurrency > 0 {
@@ -278,7 +314,6 @@ func buildServer(ctx context.Context, env config, healthState *health.State, rp
 	httpProxy.BufferPool = network.NewBufferPool()
 	httpProxy.FlushInterval = network.FlushInterval
 
-	breaker := buildBreaker(logger, env)
 	metricsSupported := supportsMetrics(ctx, logger, env)
 	tracingEnabled := env.TracingConfigBackend != tracingconfig.None
 	timeout := time.Duration(env.RevisionTimeoutSeconds) * time.Second
@@ -290,7 +325,14 @@ func buildServer(ctx context.Context, env config, healthState *health.State, rp
 		composedHandler = requestAppMetricsHandler(logger, composedHandler, breaker, env)
 	}
 	composedHandler = queue.ProxyHandler(breaker, stats, tracingEnabled, composedHandler)
+	if len(priorityClasses) > 0 {
+		composedHandler = queue.PriorityHandler(priorityClasses, composedHandler)
+	}
+	if rateLimiter != nil {
+		composedHandler = queue.RateLimitHandler(rateLimiter, composedHandler)
+	}
 	composedHandler = queue.ForwardedShimHandler(composedHandler)
+	composedHandler = queue.PathRewriteHandler(composedHandler)
 	composedHandler = handler.NewTimeToFirstByteTimeoutHandler(composedHandler, "request timeout", timeout)
 
 	if metricsSupported {
@@ -330,7 +372,22 @@ func buildTransport(env config, logger *zap.SugaredLogger, maxConns int) http.Ro
 	}
 }
 
-func buildBreaker(logger *zap.SugaredLogger, env config) *queue.Breaker {
+// buildPriorityClasses returns the priority classes of the breaker, from
+// the highest priority to the lowest.
+func buildPriorityClasses(logger *zap.SugaredLogger, env config) []serving.PriorityClass {
+	if env.ContainerConcurrency < 1 || env.QueuePriorityClasses == "" {
+		return nil
+	}
+	classes, err := serving.ParsePriorityClasses(env.QueuePriorityClasses)
+	if err != nil {
+		// The annotation is validated by the webhook, so this should not happen.
+		logger.Errorw("Failed to parse the priority classes, ignoring them", zap.Error(err))
+		return nil
+	}
+	return classes
+}
+
+func buildBreaker(logger *zap.SugaredLogger, env config, priorityClasses []serving.PriorityClass) *queue.Breaker {
 	if env.ContainerConcurrency < 1 {
 		return nil
 	}
@@ -343,10 +400,33 @@ func buildBreaker(logger *zap.SugaredLogger, env config) *queue.Breaker {
 		MaxConcurrency:  env.ContainerConcurrency,
 		InitialCapacity: env.ContainerConcurrency,
 	}
+	for _, c := range priorityClasses {
+		params.PriorityQueueDepths = append(params.PriorityQueueDepths, c.QueueDepth)
+	}
+	if env.QueueMaxWait != "" {
+		maxWait, err := time.ParseDuration(env.QueueMaxWait)
+		if err != nil || maxWait < 0 {
+			// The annotation is validated by the webhook, so this should not happen.
+			logger.Errorw("Failed to parse the maximum queue wait, ignoring it", zap.Error(err))
+		} else {
+			params.MaxQueueWait = maxWait
+		}
+	}
 	logger.Infof("Queue container is starting with BreakerParams = %#v", params)
 	return queue.NewBreaker(params)
 }
 
+// buildRateLimiter returns the rate limiter of the revision, if it has rate limits.
+func buildRateLimiter(logger *zap.SugaredLogger, env config) *queue.RateLimiter {
+	limits, err := serving.ParseRateLimits(env.QueueRateLimits)
+	if err != nil {
+		// The annotation is validated by the webhook, so this should not happen.
+		logger.Errorw("Failed to parse the rate limits, ignoring them", zap.Error(err))
+		return nil
+	}
+	return queue.NewRateLimiter(limits)
+}
+
 func supportsMetrics(ctx context.Context, logger *zap.SugaredLogger, env config) bool {
 	// Setup request metrics reporting for end-user metrics.
 	if env.ServingRequestMetricsBackend == "" {
@@ -375,6 +455,28 @@ func buildAdminServer(logger *zap.SugaredLogger, healthState *health.State) *htt
 	}
 }
 
+// scrapeCustomMetric periodically scrapes the application-defined metric from
+// the user container and feeds it to the protobuf stats reporter.
+func scrapeCustomMetric(ctx context.Context, logger *zap.SugaredLogger, env config, reporter *queue.ProtobufStatsReporter) {
+	url := "http://127.0.0.1:" + env.UserPort + env.CustomMetricPath
+	scraper := queue.NewCustomMetricScraper(&http.Client{Timeout: reportingPeriod}, url, env.CustomMetricName)
+
+	ticker := time.NewTicker(reportingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stale, err := scraper.Update(ctx, reporter.SetCustomMetricValue); stale {
+				logger.Errorw("Failed to scrape the custom metric repeatedly, reporting 0", zap.Error(err))
+			} else if err != nil {
+				logger.Warnw("Failed to scrape the custom metric", zap.Error(err))
+			}
+		}
+	}
+}
+
 func buildMetricsServer(promStatReporter *queue.PrometheusStatsReporter, protobufStatReporter *queue.ProtobufStatsReporter) *http.Server {
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", queue.NewStatsHandler(promStatReporter, protobufStatReporter))
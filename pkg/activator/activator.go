@@ -23,6 +23,12 @@ const (
 	RevisionHeaderName = "Knative-Serving-Revision"
 	// RevisionHeaderNamespace is the header key for revision's namespace.
 	RevisionHeaderNamespace = "Knative-Serving-Namespace"
+	// MirrorRevisionHeaderName is the header key for the name of the revision
+	// receiving the copies of the requests, in the revision's namespace.
+	MirrorRevisionHeaderName = "Knative-Serving-Mirror-Revision"
+	// MirrorPercentHeaderName is the header key for the percentage of the
+	// requests which are copied to the mirror revision.
+	MirrorPercentHeaderName = "Knative-Serving-Mirror-Percent"
 )
 
 var (
@@ -31,5 +37,7 @@ var (
 	RevisionHeaders = []string{
 		RevisionHeaderName,
 		RevisionHeaderNamespace,
+		MirrorRevisionHeaderName,
+		MirrorPercentHeaderName,
 	}
 )
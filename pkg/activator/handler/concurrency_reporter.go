@@ -242,13 +242,16 @@ func (cr *ConcurrencyReporter) run(stopCh <-chan struct{}, reportCh <-chan time.
 // machinery.
 func (cr *ConcurrencyReporter) Handler(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		revisionKey := RevIDFrom(r.Context())
-
-		stat := cr.handleRequestIn(network.ReqEvent{Key: revisionKey, Type: network.ReqIn, Time: time.Now()})
-		defer func() {
-			cr.handleRequestOut(stat, network.ReqEvent{Key: revisionKey, Type: network.ReqOut, Time: time.Now()})
-		}()
-
+		defer cr.TrackRequest(RevIDFrom(r.Context()))()
 		next.ServeHTTP(w, r)
 	}
 }
+
+// TrackRequest records a request to the revision coming in, and returns the
+// function recording it being done.
+func (cr *ConcurrencyReporter) TrackRequest(revisionKey types.NamespacedName) func() {
+	stat := cr.handleRequestIn(network.ReqEvent{Key: revisionKey, Type: network.ReqIn, Time: time.Now()})
+	return func() {
+		cr.handleRequestOut(stat, network.ReqEvent{Key: revisionKey, Type: network.ReqOut, Time: time.Now()})
+	}
+}
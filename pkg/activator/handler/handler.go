@@ -21,6 +21,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/trace"
@@ -34,6 +35,7 @@ import (
 	"knative.dev/pkg/tracing/propagation/tracecontextb3"
 	"knative.dev/serving/pkg/activator"
 	activatorconfig "knative.dev/serving/pkg/activator/config"
+	activatornet "knative.dev/serving/pkg/activator/net"
 	pkghttp "knative.dev/serving/pkg/http"
 	"knative.dev/serving/pkg/queue"
 )
@@ -73,6 +75,10 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if tracingEnabled {
 		tryContext, trySpan = trace.StartSpan(r.Context(), "throttler_try")
 	}
+	// The header aware load balancing policies pick the pod based on the request headers,
+	// and the session affinity hands its token out on the response headers.
+	tryContext = activatornet.WithRequestHeader(tryContext, r.Header)
+	tryContext = activatornet.WithResponseHeader(tryContext, w.Header())
 
 	if err := a.throttler.Try(tryContext, RevIDFrom(r.Context()), func(dest string) error {
 		trySpan.End()
@@ -92,9 +98,20 @@ func (a *activationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		logger.Errorw("Throttler try error", zap.Error(err))
 
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, queue.ErrRequestQueueFull) {
+		var (
+			shed        *queue.ShedError
+			rateLimited *queue.RateLimitError
+		)
+		switch {
+		case errors.As(err, &rateLimited):
+			rateLimited.SetHeaders(w.Header())
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.As(err, &shed):
+			w.Header().Set("Retry-After", strconv.Itoa(shed.RetryAfterSeconds()))
 			http.Error(w, err.Error(), http.StatusServiceUnavailable)
-		} else {
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, queue.ErrRequestQueueFull):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}
@@ -80,6 +80,8 @@ func TestActivationHandler(t *testing.T) {
 		probeCode int
 		probeResp []string
 		throttler Throttler
+		// wantRetryAfter is the expected Retry-After header, if any.
+		wantRetryAfter string
 	}{{
 		name:      "active endpoint",
 		wantBody:  wantBody,
@@ -101,6 +103,20 @@ func TestActivationHandler(t *testing.T) {
 		wantBody:  "pending request queue full\n",
 		wantCode:  http.StatusServiceUnavailable,
 		throttler: fakeThrottler{err: queue.ErrRequestQueueFull},
+	}, {
+		name:     "shed",
+		wantBody: "maximum queue wait exceeded\n",
+		wantCode: http.StatusServiceUnavailable,
+		throttler: fakeThrottler{err: &queue.ShedError{
+			Err: queue.ErrQueueWaitExceeded, RetryAfter: 2500 * time.Millisecond}},
+		wantRetryAfter: "3",
+	}, {
+		name:     "rate limited",
+		wantBody: "rate limit exceeded\n",
+		wantCode: http.StatusTooManyRequests,
+		throttler: fakeThrottler{err: &queue.RateLimitError{
+			Limit: serving.RateLimit{Rate: 10, Burst: 10}, RetryAfter: 100 * time.Millisecond}},
+		wantRetryAfter: "1",
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -141,6 +157,9 @@ func TestActivationHandler(t *testing.T) {
 			if resp.Code != test.wantCode {
 				t.Fatalf("Unexpected response status. Want %d, got %d", test.wantCode, resp.Code)
 			}
+			if got := resp.Header().Get("Retry-After"); got != test.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want: %q", got, test.wantRetryAfter)
+			}
 
 			gotBody, err := ioutil.ReadAll(resp.Body)
 			if err != nil {
@@ -0,0 +1,242 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+
+	network "knative.dev/networking/pkg"
+	"knative.dev/pkg/logging"
+	pkgmetrics "knative.dev/pkg/metrics"
+	"knative.dev/serving/pkg/activator"
+	"knative.dev/serving/pkg/apis/serving"
+	revisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision"
+	routeinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/route"
+	servinglisters "knative.dev/serving/pkg/client/listers/serving/v1"
+	"knative.dev/serving/pkg/metrics"
+)
+
+const (
+	// ShadowTrafficType is the value of the traffic type tag of the metrics
+	// of the mirrored requests.
+	ShadowTrafficType = "shadow"
+
+	// maxMirrorBodyBytes is the size of the largest request body which is
+	// mirrored, since the body has to be buffered for the copy.
+	maxMirrorBodyBytes = 1 << 20
+
+	// maxInFlightMirrors bounds the number of copies in flight, which each
+	// hold their body. The copies beyond are dropped.
+	maxInFlightMirrors = 32
+
+	// mirrorTimeout bounds the time a copy of a request may take.
+	mirrorTimeout = 30 * time.Second
+
+	// mirrorQueueTimeout bounds the time a copy waits for capacity of the
+	// mirror revision, which is dropped otherwise. The copy still counts
+	// towards the concurrency of the mirror revision, so that it is activated.
+	mirrorQueueTimeout = time.Second
+)
+
+var errBodyTooLarge = errors.New("the request body is too large to be mirrored")
+
+// NewMirrorHandler creates a handler that sends a copy of a sample of the
+// requests to the mirror revision, in the background, as instructed by the
+// mirror headers set by the ingress. Since any client reaching the activator
+// could set these headers too, they are only honored for a mirror configured
+// on a traffic target of a Route routing to the revision, and for at most the
+// configured percent of the requests. The responses to the copies are
+// discarded, but their status codes and latencies are recorded as shadow
+// traffic of the mirror revision.
+func NewMirrorHandler(ctx context.Context, podName string, t Throttler, cr *ConcurrencyReporter,
+	transport http.RoundTripper, next http.Handler) http.Handler {
+	mirrors := newMirrorIndex(logging.FromContext(ctx))
+	routeinformer.Get(ctx).Informer().AddEventHandler(mirrors.handler())
+	return &mirrorHandler{
+		nextHandler:    next,
+		throttler:      t,
+		reporter:       cr,
+		transport:      transport,
+		revisionLister: revisioninformer.Get(ctx).Lister(),
+		mirrors:        mirrors,
+		podName:        podName,
+		inFlight:       make(chan struct{}, maxInFlightMirrors),
+		sample: func(percent int) bool {
+			return rand.Intn(100) < percent
+		},
+	}
+}
+
+// mirrorHandler copies the sampled requests to the mirror revision.
+type mirrorHandler struct {
+	nextHandler    http.Handler
+	throttler      Throttler
+	reporter       *ConcurrencyReporter
+	transport      http.RoundTripper
+	revisionLister servinglisters.RevisionLister
+	mirrors        *mirrorIndex
+	podName        string
+
+	// inFlight holds a token for each copy in flight.
+	inFlight chan struct{}
+
+	// sample returns true if a request is sampled, with the given chance in percent.
+	sample func(percent int) bool
+}
+
+func (h *mirrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if mirror := h.mirrorRequest(r); mirror != nil {
+		go mirror()
+	}
+	h.nextHandler.ServeHTTP(w, r)
+}
+
+// mirrorRequest returns the function sending the copy of the request to the
+// mirror revision, or nil if the request is not mirrored.
+func (h *mirrorHandler) mirrorRequest(r *http.Request) func() {
+	name := r.Header.Get(activator.MirrorRevisionHeaderName)
+	if name == "" {
+		return nil
+	}
+	percent, err := strconv.Atoi(r.Header.Get(activator.MirrorPercentHeaderName))
+	if err != nil {
+		return nil
+	}
+
+	revID := types.NamespacedName{Namespace: RevIDFrom(r.Context()).Namespace, Name: name}
+	logger := logging.FromContext(r.Context()).With(zap.String("mirror", revID.String()))
+	configured, ok := h.mirrors.get(RevisionFrom(r.Context()), name)
+	if !ok {
+		logger.Debug("Mirror is not configured for the revision, request is not mirrored")
+		return nil
+	}
+	if int64(percent) > configured {
+		percent = int(configured)
+	}
+	if !h.sample(percent) {
+		return nil
+	}
+
+	rev, err := h.revisionLister.Revisions(revID.Namespace).Get(revID.Name)
+	if err != nil {
+		logger.Warnw("Failed to get the mirror revision", zap.Error(err))
+		return nil
+	}
+
+	select {
+	case h.inFlight <- struct{}{}:
+	default:
+		logger.Debug("Too many copies in flight, request is not mirrored")
+		return nil
+	}
+	body, err := bufferBody(r)
+	if err != nil {
+		<-h.inFlight
+		logger.Debugw("Request is not mirrored", zap.Error(err))
+		return nil
+	}
+
+	// The copy outlives the request.
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	ctx = logging.WithLogger(ctx, logger)
+	mr := r.Clone(ctx)
+	mr.Body, mr.ContentLength = http.NoBody, 0
+	if len(body) > 0 {
+		mr.Body, mr.ContentLength = ioutil.NopCloser(bytes.NewReader(body)), int64(len(body))
+	}
+	mr.RequestURI = ""
+	for _, hdr := range activator.RevisionHeaders {
+		mr.Header.Del(hdr)
+	}
+	network.RewriteHostIn(mr)
+	mr.Header.Set(network.ProxyHeaderName, activator.Name)
+
+	reporterCtx, _ := metrics.PodRevisionContext(h.podName, activator.Name,
+		rev.Namespace, rev.Labels[serving.ServiceLabelKey], rev.Labels[serving.ConfigurationLabelKey], rev.Name)
+	reporterCtx, _ = tag.New(reporterCtx, tag.Upsert(metrics.TrafficTypeKey, ShadowTrafficType))
+
+	return func() {
+		defer func() { <-h.inFlight }()
+		defer cancel()
+		defer h.reporter.TrackRequest(revID)()
+		start := time.Now()
+		code := h.send(ctx, revID, mr)
+		pkgmetrics.RecordBatch(metrics.AugmentWithResponse(reporterCtx, code),
+			responseTimeInMsecM.M(float64(time.Since(start).Milliseconds())), requestCountM.M(1))
+	}
+}
+
+// send sends the request to the mirror revision, discards the response and
+// returns its status code.
+func (h *mirrorHandler) send(ctx context.Context, revID types.NamespacedName, r *http.Request) int {
+	code := http.StatusServiceUnavailable
+	tryCtx, cancel := context.WithTimeout(ctx, mirrorQueueTimeout)
+	defer cancel()
+	if err := h.throttler.Try(tryCtx, revID, func(dest string) error {
+		r.URL.Scheme = "http"
+		r.URL.Host = dest
+		resp, err := h.transport.RoundTrip(r)
+		if err != nil {
+			code = http.StatusBadGateway
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(ioutil.Discard, resp.Body)
+		code = resp.StatusCode
+		return nil
+	}); err != nil {
+		logging.FromContext(ctx).Debugw("Failed to mirror the request", zap.Error(err))
+	}
+	return code
+}
+
+// bufferBody reads the body of the request, up to maxMirrorBodyBytes, and
+// replaces it with an equivalent one. It returns an error if the body is
+// larger, in which case the request still has its full body.
+func bufferBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.ContentLength > maxMirrorBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxMirrorBodyBytes+1))
+	// Whatever was read is replayed ahead of the rest of the body.
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxMirrorBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	return body, nil
+}
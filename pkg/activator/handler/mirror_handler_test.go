@@ -0,0 +1,396 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.opencensus.io/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	network "knative.dev/networking/pkg"
+	"knative.dev/pkg/metrics/metricskey"
+	"knative.dev/pkg/metrics/metricstest"
+	pkgnet "knative.dev/pkg/network"
+	rtesting "knative.dev/pkg/reconciler/testing"
+	"knative.dev/serving/pkg/activator"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
+	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/route/fake"
+)
+
+// mirrorRoute returns a Route with a traffic target mirroring the given
+// percent of its requests to the mirror revision.
+func mirrorRoute(target v1.TrafficTarget, mirror string, percent int64) *v1.Route {
+	target.Mirror = &v1.TrafficMirror{RevisionName: mirror, Percent: percent}
+	return &v1.Route{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "route-" + mirror},
+		Spec:       v1.RouteSpec{Traffic: []v1.TrafficTarget{target}},
+	}
+}
+
+// indexRoutes adds the mirrors of the routes to the index of the handler,
+// as the events of the Route informer do.
+func indexRoutes(h *mirrorHandler, routes ...*v1.Route) {
+	for _, route := range routes {
+		h.mirrors.update(route)
+	}
+}
+
+// mirrorContext returns the context of a request routed to the test revision.
+func mirrorContext() context.Context {
+	ctx := WithRevID(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: testRevName})
+	return WithRevision(ctx, revision(testNamespace, testRevName))
+}
+
+func TestMirrorHandler(t *testing.T) {
+	const (
+		mirrorName = "mirror-name"
+		testPod    = "testPod"
+	)
+	mirrorHeaders := map[string]string{
+		activator.MirrorRevisionHeaderName: mirrorName,
+		activator.MirrorPercentHeaderName:  "10",
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		sampled    bool
+		body       string
+		throttler  fakeThrottler
+		wantMirror bool
+		wantCode   int
+	}{{
+		name:    "not mirrored",
+		sampled: true,
+		body:    wantBody,
+	}, {
+		name:    "not sampled",
+		headers: mirrorHeaders,
+		body:    wantBody,
+	}, {
+		name: "unknown mirror revision",
+		headers: map[string]string{
+			activator.MirrorRevisionHeaderName: "unknown",
+			activator.MirrorPercentHeaderName:  "10",
+		},
+		sampled: true,
+		body:    wantBody,
+	}, {
+		name: "invalid percent",
+		headers: map[string]string{
+			activator.MirrorRevisionHeaderName: mirrorName,
+			activator.MirrorPercentHeaderName:  "ten",
+		},
+		sampled: true,
+		body:    wantBody,
+	}, {
+		name:    "body too large",
+		headers: mirrorHeaders,
+		sampled: true,
+		body:    strings.Repeat("a", maxMirrorBodyBytes+1),
+	}, {
+		name:       "mirrored",
+		headers:    mirrorHeaders,
+		sampled:    true,
+		body:       wantBody,
+		wantMirror: true,
+		wantCode:   http.StatusAccepted,
+	}, {
+		name:       "mirrored without body",
+		headers:    mirrorHeaders,
+		sampled:    true,
+		wantMirror: true,
+		wantCode:   http.StatusAccepted,
+	}, {
+		name:       "mirror unavailable",
+		headers:    mirrorHeaders,
+		sampled:    true,
+		body:       wantBody,
+		throttler:  fakeThrottler{err: context.DeadlineExceeded},
+		wantMirror: true,
+		wantCode:   http.StatusServiceUnavailable,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer reset()
+			ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+			defer cancel()
+			mirrorRev := revision(testNamespace, mirrorName)
+			revisionInformer(ctx, mirrorRev)
+
+			var mirrored *http.Request
+			var mirroredBody string
+			rt := pkgnet.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				mirrored = r
+				b, _ := ioutil.ReadAll(r.Body)
+				mirroredBody = string(b)
+				return &http.Response{
+					StatusCode: http.StatusAccepted,
+					Body:       ioutil.NopCloser(strings.NewReader("discarded")),
+				}, nil
+			})
+			statCh := make(chan []asmetrics.StatMessage, 1)
+			h := NewMirrorHandler(ctx, testPod, test.throttler, NewConcurrencyReporter(ctx, testPod, statCh),
+				rt, nil).(*mirrorHandler)
+			indexRoutes(h, mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, mirrorName, 10))
+			h.sample = func(percent int) bool {
+				if percent != 10 {
+					t.Errorf("Sampled percent = %d, want: 10", percent)
+				}
+				return test.sampled
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/path", strings.NewReader(test.body))
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			req.Header.Set(activator.RevisionHeaderName, testRevName)
+			req.Header.Set(activator.RevisionHeaderNamespace, testNamespace)
+			req.Header.Set(network.OriginalHostHeader, "mirrored.example.com")
+			req = req.WithContext(mirrorContext())
+
+			mirror := h.mirrorRequest(req)
+			if got, err := ioutil.ReadAll(req.Body); err != nil || string(got) != test.body {
+				t.Errorf("Request body has %d bytes, err = %v, want: %d bytes", len(got), err, len(test.body))
+			}
+			if (mirror != nil) != test.wantMirror {
+				t.Fatalf("Mirrored = %v, want: %v", mirror != nil, test.wantMirror)
+			}
+			if mirror == nil {
+				return
+			}
+			mirror()
+
+			// The copy counts towards the concurrency of the mirror revision,
+			// activating it.
+			select {
+			case msgs := <-statCh:
+				if got, want := msgs[0].Key, (types.NamespacedName{Namespace: testNamespace, Name: mirrorName}); got != want {
+					t.Errorf("Reported stat for %v, want: %v", got, want)
+				}
+			default:
+				t.Error("No stat was reported for the mirror revision")
+			}
+			if got := len(h.inFlight); got != 0 {
+				t.Errorf("Copies in flight = %d, want: 0", got)
+			}
+
+			if test.throttler.err == nil {
+				if mirrored == nil {
+					t.Fatal("The request was not sent to the mirror revision")
+				}
+				if got, want := mirrored.URL.String(), "http://10.10.10.10:1234/path"; got != want {
+					t.Errorf("Mirrored URL = %s, want: %s", got, want)
+				}
+				if got, want := mirrored.Header.Get(network.OriginalHostHeader), "mirrored.example.com"; got != want {
+					t.Errorf("Mirrored header %s = %s, want: %s", network.OriginalHostHeader, got, want)
+				}
+				if mirroredBody != test.body {
+					t.Errorf("Mirrored body = %q, want: %q", mirroredBody, test.body)
+				}
+				for _, hdr := range activator.RevisionHeaders {
+					if got := mirrored.Header.Get(hdr); got != "" {
+						t.Errorf("Mirrored header %s = %q, want none", hdr, got)
+					}
+				}
+				if got, want := mirrored.Header.Get(network.ProxyHeaderName), activator.Name; got != want {
+					t.Errorf("Mirrored header %s = %q, want: %q", network.ProxyHeaderName, got, want)
+				}
+			} else if mirrored != nil {
+				t.Error("The request was sent to the mirror revision without capacity")
+			}
+
+			wantResource := &resource.Resource{
+				Type: "knative_revision",
+				Labels: map[string]string{
+					metricskey.LabelNamespaceName:     testNamespace,
+					metricskey.LabelServiceName:       mirrorRev.Labels[serving.ServiceLabelKey],
+					metricskey.LabelConfigurationName: mirrorRev.Labels[serving.ConfigurationLabelKey],
+					metricskey.LabelRevisionName:      mirrorName,
+				},
+			}
+			wantTags := map[string]string{
+				metricskey.PodName:                testPod,
+				metricskey.ContainerName:          activator.Name,
+				metricskey.LabelResponseCode:      strconv.Itoa(test.wantCode),
+				metricskey.LabelResponseCodeClass: strconv.Itoa(test.wantCode/100) + "xx",
+				"traffic_type":                    ShadowTrafficType,
+			}
+			metricstest.AssertMetric(t, metricstest.IntMetric(requestCountM.Name(), 1, wantTags).WithResource(wantResource))
+			metricstest.AssertMetricExists(t, responseTimeInMsecM.Name())
+		})
+	}
+}
+
+func TestMirrorHandlerConfiguredMirror(t *testing.T) {
+	const mirrorName = "mirror-name"
+
+	tests := []struct {
+		name        string
+		routes      []*v1.Route
+		percent     string
+		wantMirror  bool
+		wantPercent int
+	}{{
+		name:    "no routes",
+		percent: "100",
+	}, {
+		name: "mirror on another target",
+		routes: []*v1.Route{
+			mirrorRoute(v1.TrafficTarget{RevisionName: "other-revision"}, mirrorName, 100),
+		},
+		percent: "100",
+	}, {
+		name: "another mirror on the target",
+		routes: []*v1.Route{
+			mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, "other-mirror", 100),
+		},
+		percent: "100",
+	}, {
+		name: "mirror on the revision",
+		routes: []*v1.Route{
+			mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, mirrorName, 100),
+		},
+		percent:     "100",
+		wantMirror:  true,
+		wantPercent: 100,
+	}, {
+		name: "mirror on the configuration",
+		routes: []*v1.Route{
+			mirrorRoute(v1.TrafficTarget{ConfigurationName: "config-" + testRevName}, mirrorName, 100),
+		},
+		percent:     "100",
+		wantMirror:  true,
+		wantPercent: 100,
+	}, {
+		name: "percent above the configured one",
+		routes: []*v1.Route{
+			mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, mirrorName, 5),
+		},
+		percent:     "100",
+		wantMirror:  true,
+		wantPercent: 5,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+			defer cancel()
+			revisionInformer(ctx, revision(testNamespace, mirrorName))
+
+			h := NewMirrorHandler(ctx, "testPod", fakeThrottler{},
+				NewConcurrencyReporter(ctx, "testPod", make(chan []asmetrics.StatMessage, 1)), nil, nil).(*mirrorHandler)
+			indexRoutes(h, test.routes...)
+			sampled := -1
+			h.sample = func(percent int) bool {
+				sampled = percent
+				return true
+			}
+
+			// E.g. a client setting the mirror headers itself.
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header.Set(activator.MirrorRevisionHeaderName, mirrorName)
+			req.Header.Set(activator.MirrorPercentHeaderName, test.percent)
+			req = req.WithContext(mirrorContext())
+			mirror := h.mirrorRequest(req)
+			if (mirror != nil) != test.wantMirror {
+				t.Fatalf("Mirrored = %v, want: %v", mirror != nil, test.wantMirror)
+			}
+			if mirror == nil {
+				if sampled != -1 {
+					t.Errorf("Sampled percent = %d, want: not sampled", sampled)
+				}
+				return
+			}
+			<-h.inFlight
+			if sampled != test.wantPercent {
+				t.Errorf("Sampled percent = %d, want: %d", sampled, test.wantPercent)
+			}
+		})
+	}
+}
+
+func TestMirrorHandlerServeHTTP(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	revisionInformer(ctx, revision(testNamespace, "mirror-name"))
+
+	mirrored := make(chan struct{})
+	rt := pkgnet.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		close(mirrored)
+		return nil, errors.New("connection refused")
+	})
+	served := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+	h := NewMirrorHandler(ctx, "testPod", fakeThrottler{},
+		NewConcurrencyReporter(ctx, "testPod", make(chan []asmetrics.StatMessage, 1)), rt, next).(*mirrorHandler)
+	indexRoutes(h, mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, "mirror-name", 100))
+	h.sample = func(int) bool { return true }
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set(activator.MirrorRevisionHeaderName, "mirror-name")
+	req.Header.Set(activator.MirrorPercentHeaderName, "100")
+	req = req.WithContext(mirrorContext())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !served {
+		t.Error("The request was not served")
+	}
+	// The copy is sent in the background.
+	<-mirrored
+}
+
+func TestMirrorHandlerInFlight(t *testing.T) {
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t)
+	defer cancel()
+	revisionInformer(ctx, revision(testNamespace, "mirror-name"))
+
+	h := NewMirrorHandler(ctx, "testPod", fakeThrottler{},
+		NewConcurrencyReporter(ctx, "testPod", make(chan []asmetrics.StatMessage, 1)), nil, nil).(*mirrorHandler)
+	indexRoutes(h, mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, "mirror-name", 100))
+	h.sample = func(int) bool { return true }
+	for i := 0; i < maxInFlightMirrors; i++ {
+		h.inFlight <- struct{}{}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(wantBody))
+	req.Header.Set(activator.MirrorRevisionHeaderName, "mirror-name")
+	req.Header.Set(activator.MirrorPercentHeaderName, "100")
+	req = req.WithContext(mirrorContext())
+	if h.mirrorRequest(req) != nil {
+		t.Error("The request was mirrored with too many copies in flight")
+	}
+
+	<-h.inFlight
+	if h.mirrorRequest(req) == nil {
+		t.Error("The request was not mirrored")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// mirrorKey identifies the mirror to a revision configured on the traffic
+// targets of a revision or configuration.
+type mirrorKey struct {
+	namespace string
+	// Either revision or configuration is set.
+	revision      string
+	configuration string
+	mirror        string
+}
+
+// mirrorIndex indexes the mirrors configured on the traffic targets of the
+// Routes by the revision or configuration of the target and the mirror
+// revision, so that the mirror of a request is looked up in constant time.
+type mirrorIndex struct {
+	logger *zap.SugaredLogger
+
+	mu sync.RWMutex
+	// percents holds the percent of each mirror per Route configuring it.
+	percents map[mirrorKey]map[string]int64
+	// keys holds the keys of the mirrors of each Route.
+	keys map[types.NamespacedName][]mirrorKey
+}
+
+func newMirrorIndex(logger *zap.SugaredLogger) *mirrorIndex {
+	return &mirrorIndex{
+		logger:   logger,
+		percents: make(map[mirrorKey]map[string]int64),
+		keys:     make(map[types.NamespacedName][]mirrorKey),
+	}
+}
+
+// handler returns the event handler of the Route informer updating the index.
+func (m *mirrorIndex) handler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			m.update(obj.(*v1.Route))
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			m.update(newObj.(*v1.Route))
+		},
+		DeleteFunc: func(obj interface{}) {
+			accessor, err := kmeta.DeletionHandlingAccessor(obj)
+			if err != nil {
+				m.logger.Errorw("Error accessing object", zap.Error(err))
+				return
+			}
+			m.delete(types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()})
+		},
+	}
+}
+
+// update replaces the mirrors of the Route in the index.
+func (m *mirrorIndex) update(route *v1.Route) {
+	name := types.NamespacedName{Namespace: route.Namespace, Name: route.Name}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteLocked(name)
+	var keys []mirrorKey
+	for _, t := range route.Spec.Traffic {
+		if t.Mirror == nil {
+			continue
+		}
+		key := mirrorKey{namespace: route.Namespace, mirror: t.Mirror.RevisionName}
+		if t.RevisionName != "" {
+			key.revision = t.RevisionName
+		} else {
+			key.configuration = t.ConfigurationName
+		}
+		percents, ok := m.percents[key]
+		if !ok {
+			percents = make(map[string]int64, 1)
+			m.percents[key] = percents
+		}
+		if t.Mirror.Percent > percents[route.Name] {
+			percents[route.Name] = t.Mirror.Percent
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 {
+		m.keys[name] = keys
+	}
+}
+
+// delete removes the mirrors of the Route from the index.
+func (m *mirrorIndex) delete(name types.NamespacedName) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteLocked(name)
+}
+
+func (m *mirrorIndex) deleteLocked(name types.NamespacedName) {
+	for _, key := range m.keys[name] {
+		delete(m.percents[key], name.Name)
+		if len(m.percents[key]) == 0 {
+			delete(m.percents, key)
+		}
+	}
+	delete(m.keys, name)
+}
+
+// get returns the largest percent of the mirror to the named revision
+// configured on a traffic target routing to the given revision, or false if
+// there is none. A target of the Configuration of the revision routes to it,
+// since a rollout splits the traffic of the target across its revisions.
+func (m *mirrorIndex) get(rev *v1.Revision, mirror string) (int64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var (
+		percent int64
+		found   bool
+	)
+	for _, key := range []mirrorKey{
+		{namespace: rev.Namespace, revision: rev.Name, mirror: mirror},
+		{namespace: rev.Namespace, configuration: rev.Labels[serving.ConfigurationLabelKey], mirror: mirror},
+	} {
+		for _, p := range m.percents[key] {
+			found = true
+			if p > percent {
+				percent = p
+			}
+		}
+	}
+	return percent, found
+}
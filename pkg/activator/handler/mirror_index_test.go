@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/cache"
+
+	ktesting "knative.dev/pkg/logging/testing"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func TestMirrorIndex(t *testing.T) {
+	m := newMirrorIndex(ktesting.TestLogger(t))
+	events := m.handler()
+	rev := revision(testNamespace, testRevName)
+
+	check := func(mirror string, wantPercent int64, wantFound bool) {
+		t.Helper()
+		if percent, found := m.get(rev, mirror); percent != wantPercent || found != wantFound {
+			t.Errorf("get(%s) = %d, %v, want: %d, %v", mirror, percent, found, wantPercent, wantFound)
+		}
+	}
+
+	check("mirror", 0, false)
+
+	route := mirrorRoute(v1.TrafficTarget{RevisionName: testRevName}, "mirror", 10)
+	events.OnAdd(route)
+	check("mirror", 10, true)
+	check("other-mirror", 0, false)
+
+	// A second Route mirroring the Configuration of the revision.
+	other := mirrorRoute(v1.TrafficTarget{ConfigurationName: "config-" + testRevName}, "mirror", 20)
+	other.Name = "other-route"
+	events.OnAdd(other)
+	check("mirror", 20, true)
+
+	// The updated mirror replaces the previous one of the Route.
+	updated := other.DeepCopy()
+	updated.Spec.Traffic[0].Mirror = &v1.TrafficMirror{RevisionName: "other-mirror", Percent: 30}
+	events.OnUpdate(other, updated)
+	check("mirror", 10, true)
+	check("other-mirror", 30, true)
+
+	events.OnDelete(route)
+	check("mirror", 0, false)
+	events.OnDelete(cache.DeletedFinalStateUnknown{Key: testNamespace + "/other-route", Obj: updated})
+	check("other-mirror", 0, false)
+
+	if len(m.percents) != 0 || len(m.keys) != 0 {
+		t.Errorf("The index is not empty: %v, %v", m.percents, m.keys)
+	}
+}
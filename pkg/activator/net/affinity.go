@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// affinityCookiePrefix is the prefix of the name of the session affinity
+// cookie, which is suffixed with the revision name so that the sessions of
+// the revisions splitting the traffic of a route don't override each other.
+const affinityCookiePrefix = "knative-affinity-"
+
+// sessionAffinity pins the requests of a session to the same pod. The
+// session carries an opaque token identifying the pod, which is handed out
+// on the first response of the session and whenever the pinned pod is gone.
+type sessionAffinity struct {
+	// cookie is the name of the cookie carrying the token, if any.
+	cookie string
+	// header is the name of the header carrying the token, if any.
+	header string
+}
+
+// newSessionAffinity returns the session affinity for the given mode, or
+// nil if the mode is unknown.
+func newSessionAffinity(mode, header, revName string) *sessionAffinity {
+	switch mode {
+	case serving.SessionAffinityCookie:
+		return &sessionAffinity{cookie: affinityCookiePrefix + revName}
+	case serving.SessionAffinityHeader:
+		if header != "" {
+			return &sessionAffinity{header: header}
+		}
+	}
+	return nil
+}
+
+// token returns the affinity token carried by the request, if any.
+func (a *sessionAffinity) token(h http.Header) string {
+	if a.header != "" {
+		return h.Get(a.header)
+	}
+	c, err := (&http.Request{Header: h}).Cookie(a.cookie)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// setToken hands the affinity token out on the response.
+func (a *sessionAffinity) setToken(h http.Header, token string) {
+	if a.header != "" {
+		h.Set(a.header, token)
+		return
+	}
+	h.Add("Set-Cookie", (&http.Cookie{
+		Name:     a.cookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+	}).String())
+}
+
+// affinityToken returns the affinity token of the pod with the given
+// address. It does not expose the address itself.
+func affinityToken(dest string) string {
+	return strconv.FormatUint(fnvHash(fnvOffset, dest), 36)
+}
+
+type responseHeaderKey struct{}
+
+// WithResponseHeader attaches the headers of the response to the request
+// being load balanced to the context, so that the session affinity can hand
+// out its token.
+func WithResponseHeader(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, responseHeaderKey{}, h)
+}
+
+// responseHeaderFrom retrieves the response headers from the context, if any.
+func responseHeaderFrom(ctx context.Context) http.Header {
+	h, _ := ctx.Value(responseHeaderKey{}).(http.Header)
+	return h
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+
+	pkgnet "knative.dev/networking/pkg/apis/networking"
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+func TestNewSessionAffinity(t *testing.T) {
+	if got := newSessionAffinity("", "", "rev"); got != nil {
+		t.Errorf("newSessionAffinity(\"\") = %v, want: nil", got)
+	}
+	if got := newSessionAffinity(serving.SessionAffinityHeader, "", "rev"); got != nil {
+		t.Errorf("newSessionAffinity(header) without a header = %v, want: nil", got)
+	}
+	if got, want := newSessionAffinity(serving.SessionAffinityCookie, "", "rev").cookie, "knative-affinity-rev"; got != want {
+		t.Errorf("cookie = %q, want: %q", got, want)
+	}
+}
+
+func TestSessionAffinity(t *testing.T) {
+	const header = "X-Session"
+	cookie := func(token string) http.Header {
+		return http.Header{"Cookie": []string{(&http.Cookie{Name: "knative-affinity-b", Value: token}).String()}}
+	}
+	setCookie := func(token string) string {
+		return (&http.Cookie{Name: "knative-affinity-b", Value: token, Path: "/", HttpOnly: true}).String()
+	}
+
+	tests := []struct {
+		name string
+		mode string
+		req  http.Header
+		// busy is the index of the tracker without capacity, if any.
+		busy     int
+		wantDest string
+		// wantResp is the header the affinity token is handed out on.
+		wantResp http.Header
+	}{{
+		name:     "new cookie session",
+		mode:     serving.SessionAffinityCookie,
+		req:      http.Header{},
+		busy:     -1,
+		wantDest: "0",
+		wantResp: http.Header{"Set-Cookie": []string{setCookie(affinityToken("0"))}},
+	}, {
+		name:     "pinned cookie session",
+		mode:     serving.SessionAffinityCookie,
+		req:      cookie(affinityToken("2")),
+		busy:     -1,
+		wantDest: "2",
+		wantResp: http.Header{},
+	}, {
+		name:     "pinned pod without capacity",
+		mode:     serving.SessionAffinityCookie,
+		req:      cookie(affinityToken("2")),
+		busy:     2,
+		wantDest: "0",
+		wantResp: http.Header{},
+	}, {
+		name:     "pinned pod of another activator",
+		mode:     serving.SessionAffinityCookie,
+		req:      cookie(affinityToken("3")),
+		busy:     -1,
+		wantDest: "3",
+		wantResp: http.Header{},
+	}, {
+		name:     "pinned pod is gone",
+		mode:     serving.SessionAffinityCookie,
+		req:      cookie(affinityToken("42")),
+		busy:     0,
+		wantDest: "1",
+		wantResp: http.Header{"Set-Cookie": []string{setCookie(affinityToken("1"))}},
+	}, {
+		name:     "new header session",
+		mode:     serving.SessionAffinityHeader,
+		req:      http.Header{},
+		busy:     -1,
+		wantDest: "0",
+		wantResp: http.Header{header: []string{affinityToken("0")}},
+	}, {
+		name:     "pinned header session",
+		mode:     serving.SessionAffinityHeader,
+		req:      http.Header{header: []string{affinityToken("1")}},
+		busy:     -1,
+		wantDest: "1",
+		wantResp: http.Header{},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt := newRevisionThrottler(context.Background(), types.NamespacedName{Namespace: "a", Name: "b"}, 1, /*cc*/
+				serving.ActivatorLBPolicyFirstAvailable, "", newSessionAffinity(test.mode, header, "b"),
+				pkgnet.ServicePortNameHTTP1, testBreakerParams, TestLogger(t))
+			// The last pod is assigned to another activator.
+			rt.podTrackers = makeTrackers(4, 1)
+			rt.assignedTrackers = rt.podTrackers[:3]
+			if test.busy >= 0 {
+				cb, ok := rt.assignedTrackers[test.busy].Reserve(context.Background())
+				if !ok {
+					t.Fatal("Failed to reserve the busy tracker")
+				}
+				defer cb()
+			}
+
+			resp := http.Header{}
+			ctx := WithResponseHeader(WithRequestHeader(context.Background(), test.req), resp)
+			cb, tracker := rt.acquireDest(ctx)
+			if tracker == nil {
+				t.Fatal("acquireDest() = nil tracker")
+			}
+			defer cb()
+			if got := tracker.dest; got != test.wantDest {
+				t.Errorf("dest = %s, want: %s", got, test.wantDest)
+			}
+			if !cmp.Equal(resp, test.wantResp) {
+				t.Error("Response header mismatch (-want,+got):", cmp.Diff(test.wantResp, resp))
+			}
+		})
+	}
+}
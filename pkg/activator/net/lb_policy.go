@@ -21,7 +21,12 @@ package net
 import (
 	"context"
 	"math/rand"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
+
+	"knative.dev/serving/pkg/apis/serving"
 )
 
 // lbPolicy is a functor that selects a target pod from the list, or (noop, nil) if
@@ -33,10 +38,8 @@ type lbPolicy func(ctx context.Context, targets []*podTracker) (func(), *podTrac
 
 // randomLBPolicy is a load balancer policy that picks a random target.
 // This approximates the LB policy done by K8s Service (IPTables based).
-//
-//nolint // This is currently unused but kept here for posterity.
 func randomLBPolicy(_ context.Context, targets []*podTracker) (func(), *podTracker) {
-	return noop, targets[rand.Intn(len(targets))]
+	return noop, targets[rand.Intn(len(targets))] //nolint:gosec // We don't need cryptographic randomness here.
 }
 
 // randomChoice2Policy implements the Power of 2 choices LB algorithm
@@ -111,3 +114,156 @@ func newRoundRobinPolicy() lbPolicy {
 		return noop, nil
 	}
 }
+
+// leastOutstandingLBPolicy is a load balancer policy that picks the target
+// with the lowest number of outstanding requests, weighted by the moving
+// average of the target's latency. Targets without latency samples yet are
+// scored with the mean latency of the others, so that a burst of requests to
+// new pods is spread by the outstanding requests. The ties are broken by the
+// outstanding requests and then randomly.
+func leastOutstandingLBPolicy(ctx context.Context, targets []*podTracker) (func(), *podTracker) {
+	latencies := make([]float64, len(targets))
+	weights := make([]int32, len(targets))
+	sum, sampled := 0., 0
+	for i, t := range targets {
+		latencies[i], weights[i] = t.getLatency(), t.getWeight()
+		if latencies[i] > 0 {
+			sum += latencies[i]
+			sampled++
+		}
+	}
+	mean := defaultLatency
+	if sampled > 0 {
+		mean = sum / float64(sampled)
+	}
+	scores := make([]float64, len(targets))
+	for i, l := range latencies {
+		if l == 0 {
+			l = mean
+		}
+		scores[i] = float64(weights[i]+1) * l
+	}
+	order := rand.Perm(len(targets)) //nolint:gosec // We don't need cryptographic randomness here.
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if scores[a] != scores[b] {
+			return scores[a] < scores[b]
+		}
+		return weights[a] < weights[b]
+	})
+	for _, i := range order {
+		t := targets[i]
+		cb, ok := t.Reserve(ctx)
+		if !ok {
+			continue
+		}
+		t.increaseWeight()
+		start := time.Now()
+		return func() {
+			t.observeLatency(time.Since(start))
+			t.decreaseWeight()
+			cb()
+		}, t
+	}
+	return noop, nil
+}
+
+type lbHeaderKey struct{}
+
+// WithRequestHeader attaches the headers of the request being load balanced
+// to the context, so that the header aware policies can consume them.
+func WithRequestHeader(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, lbHeaderKey{}, h)
+}
+
+// requestHeaderFrom retrieves the request headers from the context, if any.
+func requestHeaderFrom(ctx context.Context) http.Header {
+	h, _ := ctx.Value(lbHeaderKey{}).(http.Header)
+	return h
+}
+
+// newConsistentHashPolicy returns a load balancer policy that consistently
+// picks the same target for the same value of the given request header,
+// using rendezvous hashing so that only the keys of the added or removed
+// targets are remapped. If the preferred target has no free capacity the
+// next preferred one is used. Requests without the header are load balanced
+// via the fallback policy.
+func newConsistentHashPolicy(header string, fallback lbPolicy) lbPolicy {
+	return func(ctx context.Context, targets []*podTracker) (func(), *podTracker) {
+		key := requestHeaderFrom(ctx).Get(header)
+		if key == "" || len(targets) == 0 {
+			return fallback(ctx, targets)
+		}
+		seed := fnvHash(fnvOffset, key)
+		scores := make([]uint64, len(targets))
+		order := make([]int, len(targets))
+		for i, t := range targets {
+			scores[i] = fnvHash(seed, t.dest)
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool {
+			return scores[order[i]] > scores[order[j]]
+		})
+		for _, i := range order {
+			if cb, ok := targets[i].Reserve(ctx); ok {
+				return cb, targets[i]
+			}
+		}
+		return noop, nil
+	}
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// fnvHash continues the FNV-1a hash seeded with h over s.
+// This avoids allocating a hasher for every target on the request path.
+func fnvHash(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+// newLBPolicy returns the load balancer policy with the given name along with
+// the name of the policy that is effectively used. Unknown names, and the
+// policies that are unaware of the pod capacity when the container
+// concurrency is limited, yield the default policy for the container
+// concurrency.
+func newLBPolicy(name, hashHeader string, containerConcurrency int) (lbPolicy, string) {
+	switch name {
+	case serving.ActivatorLBPolicyRandom:
+		if containerConcurrency == 0 {
+			return randomLBPolicy, name
+		}
+	case serving.ActivatorLBPolicyRandomChoice2:
+		if containerConcurrency == 0 {
+			return randomChoice2Policy, name
+		}
+	case serving.ActivatorLBPolicyFirstAvailable:
+		return firstAvailableLBPolicy, name
+	case serving.ActivatorLBPolicyRoundRobin:
+		return newRoundRobinPolicy(), name
+	case serving.ActivatorLBPolicyLeastOutstanding:
+		return leastOutstandingLBPolicy, name
+	case serving.ActivatorLBPolicyConsistentHash:
+		if hashHeader != "" {
+			fallback, _ := newLBPolicy("", "", containerConcurrency)
+			return newConsistentHashPolicy(hashHeader, fallback), name
+		}
+	}
+
+	switch {
+	case containerConcurrency == 0:
+		return randomChoice2Policy, serving.ActivatorLBPolicyRandomChoice2
+	case containerConcurrency <= 3:
+		// For very low CC values use first available pod.
+		return firstAvailableLBPolicy, serving.ActivatorLBPolicyFirstAvailable
+	default:
+		// Otherwise RR.
+		return newRoundRobinPolicy(), serving.ActivatorLBPolicyRoundRobin
+	}
+}
@@ -19,9 +19,13 @@ package net
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/queue"
 )
 
@@ -250,6 +254,9 @@ func BenchmarkPolicy(b *testing.B) {
 	}, {
 		name:   "round-robin",
 		policy: newRoundRobinPolicy(),
+	}, {
+		name:   "least-outstanding",
+		policy: leastOutstandingLBPolicy,
 	}} {
 		for _, n := range []int{1, 2, 3, 10, 100} {
 			b.Run(fmt.Sprintf("%s-%d-trackers-sequential", test.name, n), func(b *testing.B) {
@@ -272,3 +279,227 @@ func BenchmarkPolicy(b *testing.B) {
 		}
 	}
 }
+
+func TestLeastOutstanding(t *testing.T) {
+	t.Run("no capacity", func(t *testing.T) {
+		podTrackers := makeTrackers(2, 1)
+		cb1, pt1 := leastOutstandingLBPolicy(context.Background(), podTrackers)
+		t.Cleanup(cb1)
+		cb2, pt2 := leastOutstandingLBPolicy(context.Background(), podTrackers)
+		t.Cleanup(cb2)
+		if pt1 == nil || pt2 == nil || pt1 == pt2 {
+			t.Fatalf("Trackers = %v, %v, want two different trackers", pt1, pt2)
+		}
+		if _, pt := leastOutstandingLBPolicy(context.Background(), podTrackers); pt != nil {
+			t.Fatal("Wanted nil, got:", pt)
+		}
+	})
+	t.Run("outstanding requests", func(t *testing.T) {
+		podTrackers := makeTrackers(3, 0)
+		for _, pt := range podTrackers {
+			pt.observeLatency(100 * time.Millisecond)
+		}
+		// Every pick goes to a different tracker, until they all have
+		// an outstanding request.
+		picked := sets.NewString()
+		for range podTrackers {
+			cb, pt := leastOutstandingLBPolicy(context.Background(), podTrackers)
+			t.Cleanup(cb)
+			if got, want := pt.getWeight(), int32(1); got != want {
+				t.Errorf("pt.weight = %d, want: %d", got, want)
+			}
+			picked.Insert(pt.dest)
+		}
+		if got, want := picked.Len(), len(podTrackers); got != want {
+			t.Errorf("#Picked trackers = %d, want: %d", got, want)
+		}
+	})
+	t.Run("no latency samples", func(t *testing.T) {
+		// A burst of requests to new pods is spread by the outstanding requests.
+		podTrackers := makeTrackers(3, 0)
+		podTrackers[0].observeLatency(100 * time.Millisecond)
+		for i := 0; i < 3*len(podTrackers); i++ {
+			cb, _ := leastOutstandingLBPolicy(context.Background(), podTrackers)
+			t.Cleanup(cb)
+		}
+		for i, pt := range podTrackers {
+			if got, want := pt.getWeight(), int32(3); got != want {
+				t.Errorf("podTrackers[%d].weight = %d, want: %d", i, got, want)
+			}
+		}
+	})
+	t.Run("latency weighted", func(t *testing.T) {
+		podTrackers := makeTrackers(2, 0)
+		podTrackers[0].observeLatency(250 * time.Millisecond)
+		podTrackers[1].observeLatency(100 * time.Millisecond)
+		// 1 outstanding request with 100ms is still cheaper than none with 250ms.
+		for i := 0; i < 2; i++ {
+			cb, pt := leastOutstandingLBPolicy(context.Background(), podTrackers)
+			t.Cleanup(cb)
+			if got, want := pt, podTrackers[1]; got != want {
+				t.Fatalf("Tracker = %v, want: %v", got, want)
+			}
+		}
+		// 2 outstanding requests with 100ms are not.
+		cb, pt := leastOutstandingLBPolicy(context.Background(), podTrackers)
+		t.Cleanup(cb)
+		if got, want := pt, podTrackers[0]; got != want {
+			t.Fatalf("Tracker = %v, want: %v", got, want)
+		}
+	})
+	t.Run("latency observed", func(t *testing.T) {
+		podTrackers := makeTrackers(1, 0)
+		cb, pt := leastOutstandingLBPolicy(context.Background(), podTrackers)
+		cb()
+		if pt.getWeight() != 0 {
+			t.Errorf("pt.weight = %d, want: 0", pt.getWeight())
+		}
+		if pt.getLatency() <= 0 {
+			t.Errorf("pt.latency = %v, want > 0", pt.getLatency())
+		}
+	})
+}
+
+func TestObserveLatency(t *testing.T) {
+	pt := newPodTracker("a", nil)
+	pt.observeLatency(time.Second)
+	if got, want := pt.getLatency(), 1.; got != want {
+		t.Errorf("latency = %v, want: %v", got, want)
+	}
+	pt.observeLatency(2 * time.Second)
+	if got, want := pt.getLatency(), 1.3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("latency = %v, want: %v", got, want)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	const header = "X-Session-Id"
+	withKey := func(key string) context.Context {
+		h := http.Header{}
+		h.Set(header, key)
+		return WithRequestHeader(context.Background(), h)
+	}
+
+	t.Run("same key same pod", func(t *testing.T) {
+		podTrackers := makeTrackers(10, 0)
+		chp := newConsistentHashPolicy(header, firstAvailableLBPolicy)
+		_, want := chp(withKey("session-1"), podTrackers)
+		for i := 0; i < 10; i++ {
+			if _, got := chp(withKey("session-1"), podTrackers); got != want {
+				t.Fatalf("Tracker = %v, want: %v", got, want)
+			}
+		}
+		// Removing another pod does not remap the key.
+		var rest []*podTracker
+		for _, pt := range podTrackers {
+			if pt != want && len(rest) < 8 {
+				rest = append(rest, pt)
+			}
+		}
+		rest = append(rest, want)
+		if _, got := chp(withKey("session-1"), rest); got != want {
+			t.Fatalf("Tracker = %v, want: %v", got, want)
+		}
+	})
+	t.Run("keys spread", func(t *testing.T) {
+		podTrackers := makeTrackers(3, 0)
+		chp := newConsistentHashPolicy(header, firstAvailableLBPolicy)
+		picked := map[*podTracker]bool{}
+		for i := 0; i < 100; i++ {
+			_, pt := chp(withKey(fmt.Sprint("session-", i)), podTrackers)
+			picked[pt] = true
+		}
+		if got, want := len(picked), 3; got != want {
+			t.Errorf("Picked %d trackers, want: %d", got, want)
+		}
+	})
+	t.Run("next pod when full", func(t *testing.T) {
+		podTrackers := makeTrackers(2, 1)
+		chp := newConsistentHashPolicy(header, firstAvailableLBPolicy)
+		cb1, pt1 := chp(withKey("session-1"), podTrackers)
+		t.Cleanup(cb1)
+		cb2, pt2 := chp(withKey("session-1"), podTrackers)
+		t.Cleanup(cb2)
+		if pt1 == nil || pt2 == nil || pt1 == pt2 {
+			t.Fatalf("Trackers = %v, %v, want two different trackers", pt1, pt2)
+		}
+		if _, pt := chp(withKey("session-1"), podTrackers); pt != nil {
+			t.Fatal("Wanted nil, got:", pt)
+		}
+	})
+	t.Run("fallback without header", func(t *testing.T) {
+		podTrackers := makeTrackers(3, 0)
+		chp := newConsistentHashPolicy(header, firstAvailableLBPolicy)
+		if _, got := chp(context.Background(), podTrackers); got != podTrackers[0] {
+			t.Fatalf("Tracker = %v, want: %v", got, podTrackers[0])
+		}
+	})
+}
+
+func TestNewLBPolicy(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		policy     string
+		hashHeader string
+		cc         int
+		want       string
+	}{{
+		name: "default cc=0",
+		want: serving.ActivatorLBPolicyRandomChoice2,
+	}, {
+		name: "default cc=3",
+		cc:   3,
+		want: serving.ActivatorLBPolicyFirstAvailable,
+	}, {
+		name: "default cc=10",
+		cc:   10,
+		want: serving.ActivatorLBPolicyRoundRobin,
+	}, {
+		name:   "random cc=0",
+		policy: serving.ActivatorLBPolicyRandom,
+		want:   serving.ActivatorLBPolicyRandom,
+	}, {
+		name:   "random cc=10",
+		policy: serving.ActivatorLBPolicyRandom,
+		cc:     10,
+		want:   serving.ActivatorLBPolicyRoundRobin,
+	}, {
+		name:   "random choice 2 cc=1",
+		policy: serving.ActivatorLBPolicyRandomChoice2,
+		cc:     1,
+		want:   serving.ActivatorLBPolicyFirstAvailable,
+	}, {
+		name:   "round robin cc=0",
+		policy: serving.ActivatorLBPolicyRoundRobin,
+		want:   serving.ActivatorLBPolicyRoundRobin,
+	}, {
+		name:   "least outstanding",
+		policy: serving.ActivatorLBPolicyLeastOutstanding,
+		cc:     10,
+		want:   serving.ActivatorLBPolicyLeastOutstanding,
+	}, {
+		name:       "consistent hash",
+		policy:     serving.ActivatorLBPolicyConsistentHash,
+		hashHeader: "X-Session-Id",
+		want:       serving.ActivatorLBPolicyConsistentHash,
+	}, {
+		name:   "consistent hash without header",
+		policy: serving.ActivatorLBPolicyConsistentHash,
+		cc:     10,
+		want:   serving.ActivatorLBPolicyRoundRobin,
+	}, {
+		name:   "unknown",
+		policy: "fastest",
+		want:   serving.ActivatorLBPolicyRandomChoice2,
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			lbp, got := newLBPolicy(test.policy, test.hashHeader, test.cc)
+			if lbp == nil {
+				t.Fatal("newLBPolicy returned nil policy")
+			}
+			if got != test.want {
+				t.Errorf("Policy = %s, want: %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package net
+
+import (
+	pkgmetrics "knative.dev/pkg/metrics"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	lbPickIndexM = stats.Int64(
+		"lb_pick_index",
+		"The position of the picked pod among the pods assigned to the Activator",
+		stats.UnitDimensionless)
+
+	lbPolicyTagKey = tag.MustNewKey("lb_policy")
+
+	// lbPickIndexDistribution buckets the picks by the position of the pod in
+	// the (address sorted) list of pods assigned to the Activator, rather than
+	// by the pod address, to keep the number of time series bounded while
+	// pods come and go.
+	lbPickIndexDistribution = view.Distribution(1, 2, 3, 4, 6, 8, 12, 16, 24, 32, 64, 128)
+)
+
+func init() {
+	register()
+}
+
+func register() {
+	// Create views to see our measurements. This can return an error if
+	// a previously-registered view has the same name with a different value.
+	// View name defaults to the measure name if unspecified.
+	if err := pkgmetrics.RegisterResourceView(
+		&view.View{
+			Description: "The position of the picked pod among the pods assigned to the Activator",
+			Measure:     lbPickIndexM,
+			Aggregation: lbPickIndexDistribution,
+			TagKeys:     []tag.Key{lbPolicyTagKey},
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -23,7 +23,9 @@ import (
 	"net/http"
 	"sort"
 	"sync"
+	"time"
 
+	"go.opencensus.io/tag"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
@@ -32,16 +34,20 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 
+	network "knative.dev/networking/pkg"
 	pkgnet "knative.dev/networking/pkg/apis/networking"
 	endpointsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/logging/logkey"
+	pkgmetrics "knative.dev/pkg/metrics"
 	"knative.dev/pkg/reconciler"
 	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
 	revisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision"
 	servinglisters "knative.dev/serving/pkg/client/listers/serving/v1"
+	"knative.dev/serving/pkg/metrics"
 	"knative.dev/serving/pkg/networking"
 	"knative.dev/serving/pkg/queue"
 )
@@ -58,12 +64,21 @@ const (
 	// requires an explicit buffer size (it's backed by a chan struct{}), but
 	// queue.MaxBreakerCapacity is math.MaxInt32.
 	revisionMaxConcurrency = queue.MaxBreakerCapacity
+
+	// latencyEWMAWeight is the weight of the newest sample in the moving
+	// average of the pod latency.
+	latencyEWMAWeight = 0.3
+
+	// defaultLatency is the latency in seconds assumed for the pods without
+	// latency samples, when none of the pods of the revision has any.
+	defaultLatency = 0.1
 )
 
 func newPodTracker(dest string, b breaker) *podTracker {
 	tracker := &podTracker{
-		dest: dest,
-		b:    b,
+		dest:  dest,
+		token: affinityToken(dest),
+		b:     b,
 	}
 	tracker.decreaseWeight = func() { tracker.weight.Add(-1) }
 
@@ -72,12 +87,17 @@ func newPodTracker(dest string, b breaker) *podTracker {
 
 type podTracker struct {
 	dest string
-	b    breaker
+	// token identifies the pod for the session affinity.
+	token string
+	b     breaker
 
 	// weight is used for LB policy implementations.
 	weight atomic.Int32
 	// decreaseWeight is an allocation optimization for the randomChoice2 policy.
 	decreaseWeight func()
+	// latency is the exponentially weighted moving average of the request
+	// latency in seconds, used by the leastOutstanding policy.
+	latency atomic.Float64
 }
 
 func (p *podTracker) increaseWeight() {
@@ -88,6 +108,25 @@ func (p *podTracker) getWeight() int32 {
 	return p.weight.Load()
 }
 
+func (p *podTracker) getLatency() float64 {
+	return p.latency.Load()
+}
+
+// observeLatency folds the latency of a finished request into the moving average.
+func (p *podTracker) observeLatency(d time.Duration) {
+	sample := d.Seconds()
+	for {
+		old := p.latency.Load()
+		n := sample
+		if old > 0 {
+			n = latencyEWMAWeight*sample + (1-latencyEWMAWeight)*old
+		}
+		if p.latency.CAS(old, n) {
+			return
+		}
+	}
+}
+
 func (p *podTracker) String() string {
 	return p.dest
 }
@@ -128,6 +167,14 @@ type revisionThrottler struct {
 	revID                types.NamespacedName
 	containerConcurrency int
 	lbPolicy             lbPolicy
+	// affinity is nil if the revision does not use session affinity.
+	affinity *sessionAffinity
+	// rateLimiter is nil if the revision has no rate limits.
+	rateLimiter *queue.RateLimiter
+
+	// reporterCtx carries the revision and the load balancing policy tags
+	// for the pick metrics.
+	reporterCtx context.Context
 
 	// These are used in slicing to infer which pods to assign
 	// to this activator.
@@ -164,27 +211,28 @@ type revisionThrottler struct {
 	logger *zap.SugaredLogger
 }
 
-func newRevisionThrottler(revID types.NamespacedName,
-	containerConcurrency int, proto string,
+func newRevisionThrottler(reporterCtx context.Context, revID types.NamespacedName,
+	containerConcurrency int, lbPolicyName, hashHeader string,
+	affinity *sessionAffinity, proto string,
 	breakerParams queue.BreakerParams,
 	logger *zap.SugaredLogger) *revisionThrottler {
 	logger = logger.With(zap.String(logkey.Key, revID.String()))
-	var (
-		revBreaker breaker
-		lbp        lbPolicy
-	)
-	switch {
-	case containerConcurrency == 0:
-		revBreaker = newInfiniteBreaker(logger)
-		lbp = randomChoice2Policy
-	case containerConcurrency <= 3:
-		// For very low CC values use first available pod.
-		revBreaker = queue.NewBreaker(breakerParams)
-		lbp = firstAvailableLBPolicy
-	default:
-		// Otherwise RR.
+	var revBreaker breaker
+	if containerConcurrency == 0 {
+		ib := newInfiniteBreaker(logger)
+		ib.maxQueueWait = breakerParams.MaxQueueWait
+		revBreaker = ib
+	} else {
 		revBreaker = queue.NewBreaker(breakerParams)
-		lbp = newRoundRobinPolicy()
+	}
+	lbp, name := newLBPolicy(lbPolicyName, hashHeader, containerConcurrency)
+	if lbPolicyName != "" && lbPolicyName != name {
+		logger.Warnf("Load balancing policy %q is not applicable with container concurrency %d, using %q",
+			lbPolicyName, containerConcurrency, name)
+	}
+	reporterCtx, err := tag.New(reporterCtx, tag.Upsert(lbPolicyTagKey, name))
+	if err != nil {
+		logger.Errorw("Failed to create the load balancing metrics context", zap.Error(err))
 	}
 	return &revisionThrottler{
 		revID:                revID,
@@ -194,6 +242,8 @@ func newRevisionThrottler(revID types.NamespacedName,
 		protocol:             proto,
 		activatorIndex:       *atomic.NewInt32(-1), // Start with unknown.
 		lbPolicy:             lbp,
+		affinity:             affinity,
+		reporterCtx:          reporterCtx,
 	}
 }
 
@@ -205,13 +255,73 @@ func (rt *revisionThrottler) acquireDest(ctx context.Context) (func(), *podTrack
 	rt.mux.RLock()
 	defer rt.mux.RUnlock()
 
+	// When the pods are not addressable the requests go through the
+	// clusterIP, and the sessions of a revision with session affinity
+	// cannot be pinned to pods, so they are balanced by the service.
 	if rt.clusterIPTracker != nil {
 		return noop, rt.clusterIPTracker
 	}
-	return rt.lbPolicy(ctx, rt.assignedTrackers)
+	cb, tracker, pinned := rt.acquireAffinityDest(ctx)
+	if tracker == nil {
+		cb, tracker = rt.lbPolicy(ctx, rt.assignedTrackers)
+	}
+	if tracker != nil {
+		rt.recordPick(tracker)
+		if rt.affinity != nil && !pinned {
+			// The session is new or its pod is gone, so pin it to this pod.
+			if h := responseHeaderFrom(ctx); h != nil {
+				rt.affinity.setToken(h, tracker.token)
+			}
+		}
+	}
+	return cb, tracker
+}
+
+// acquireAffinityDest returns the pod the session of the request is pinned
+// to, if it has capacity. All the pods of the revision are searched, since
+// with several activators the session might have been pinned by another one;
+// such a pod is used even if it is assigned to another activator, in which
+// case its capacity is shared between them. pinned is true if the pod still
+// exists, in which case the session keeps its pod even when the request has
+// to go elsewhere. Must be called with the mux held.
+func (rt *revisionThrottler) acquireAffinityDest(ctx context.Context) (cb func(), tracker *podTracker, pinned bool) {
+	if rt.affinity == nil {
+		return noop, nil, false
+	}
+	token := rt.affinity.token(requestHeaderFrom(ctx))
+	if token == "" {
+		return noop, nil, false
+	}
+	for _, t := range rt.podTrackers {
+		if t.token != token {
+			continue
+		}
+		if cb, ok := t.Reserve(ctx); ok {
+			return cb, t, true
+		}
+		return noop, nil, true
+	}
+	return noop, nil, false
+}
+
+// recordPick reports the position of the picked tracker among the
+// assigned trackers. Must be called with the mux held.
+func (rt *revisionThrottler) recordPick(tracker *podTracker) {
+	for i, t := range rt.assignedTrackers {
+		if t == tracker {
+			pkgmetrics.Record(rt.reporterCtx, lbPickIndexM.M(int64(i)))
+			return
+		}
+	}
 }
 
 func (rt *revisionThrottler) try(ctx context.Context, function func(string) error) error {
+	if rt.rateLimiter != nil {
+		if err := rt.rateLimiter.Allow(requestHeaderFrom(ctx).Get(network.TagHeaderName)); err != nil {
+			return err
+		}
+	}
+
 	var ret error
 
 	// Retrying infinitely as long as we receive no dest. Outer semaphore and inner
@@ -286,19 +396,22 @@ func (rt *revisionThrottler) updateCapacity(backendCount int) {
 			return 0
 		}
 
-		// Sort, so we get more or less stable results.
-		sort.Slice(rt.podTrackers, func(i, j int) bool {
-			return rt.podTrackers[i].dest < rt.podTrackers[j].dest
+		// Sort, so we get more or less stable results. The pod trackers are
+		// read by the serving thread, so sort a copy.
+		sorted := append(rt.podTrackers[:0:0], rt.podTrackers...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].dest < sorted[j].dest
 		})
-		assigned := rt.podTrackers
+		assigned := sorted
 		if rt.containerConcurrency > 0 {
 			rt.resetTrackers()
-			assigned = assignSlice(rt.podTrackers, ai, ac, rt.containerConcurrency)
+			assigned = assignSlice(sorted, ai, ac, rt.containerConcurrency)
 		}
 		rt.logger.Debugf("Trackers %d/%d: assignment: %v", ai, ac, assigned)
-		// The actual write out of the assigned trackers has to be under lock.
+		// The actual write out of the trackers has to be under lock.
 		rt.mux.Lock()
 		defer rt.mux.Unlock()
+		rt.podTrackers = sorted
 		rt.assignedTrackers = assigned
 		return len(assigned)
 	}()
@@ -541,17 +654,47 @@ func (t *Throttler) getOrCreateRevisionThrottler(revID types.NamespacedName) (*r
 			return nil, err
 		}
 		revThrottler = newRevisionThrottler(
+			metrics.RevisionContext(revID.Namespace, rev.Labels[serving.ServiceLabelKey],
+				rev.Labels[serving.ConfigurationLabelKey], revID.Name),
 			revID,
 			int(rev.Spec.GetContainerConcurrency()),
+			rev.Annotations[serving.ActivatorLBPolicyAnnotationKey],
+			rev.Annotations[serving.ActivatorLBHashHeaderAnnotationKey],
+			newSessionAffinity(rev.Annotations[serving.SessionAffinityAnnotationKey],
+				rev.Annotations[serving.SessionAffinityHeaderAnnotationKey], revID.Name),
 			pkgnet.ServicePortName(rev.GetProtocol()),
-			queue.BreakerParams{QueueDepth: breakerQueueDepth, MaxConcurrency: revisionMaxConcurrency},
+			queue.BreakerParams{QueueDepth: breakerQueueDepth, MaxConcurrency: revisionMaxConcurrency,
+				MaxQueueWait: maxQueueWait(rev)},
 			t.logger,
 		)
+		revThrottler.rateLimiter = rateLimiter(rev, t.logger)
 		t.revisionThrottlers[revID] = revThrottler
 	}
 	return revThrottler, nil
 }
 
+// maxQueueWait returns the maximum time the requests to the revision wait
+// for capacity, or zero if they wait up to the revision timeout.
+func maxQueueWait(rev *v1.Revision) time.Duration {
+	// The annotation is validated by the webhook, an invalid value is ignored.
+	if d, err := time.ParseDuration(rev.Annotations[serving.MaxQueueWaitAnnotationKey]); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// rateLimiter returns the rate limiter of the revision, or nil if it has no
+// rate limits.
+func rateLimiter(rev *v1.Revision, logger *zap.SugaredLogger) *queue.RateLimiter {
+	limits, err := serving.ParseRateLimits(rev.Annotations[serving.RateLimitAnnotationKey])
+	if err != nil {
+		// The annotation is validated by the webhook, so this should not happen.
+		logger.Errorw("Failed to parse the rate limits, ignoring them", zap.Error(err))
+		return nil
+	}
+	return queue.NewRateLimiter(limits)
+}
+
 // revisionUpdated is used to ensure we have a backlog set up for a revision as soon as it is created
 // rather than erroring with revision not found until a networking probe succeeds
 func (t *Throttler) revisionUpdated(obj interface{}) {
@@ -636,6 +779,10 @@ func (rt *revisionThrottler) handlePubEpsUpdate(eps *corev1.Endpoints, selfIP st
 
 	rt.numActivators.Store(newNA)
 	rt.activatorIndex.Store(newAI)
+	if rt.rateLimiter != nil {
+		// The activators in the path split the requests, so they split the limits too.
+		rt.rateLimiter.UpdateShare(asmetrics.ReadyPods{Count: int(newNA), Index: int(newAI)})
+	}
 	rt.logger.Infof("This activator index is %d/%d was %d/%d",
 		rt.activatorIndex, rt.numActivators, newAI, newNA)
 	rt.updateCapacity(rt.backendCount)
@@ -697,6 +844,9 @@ type infiniteBreaker struct {
 	// immediately or wait for capacity to appear.
 	concurrency atomic.Int32
 
+	// maxQueueWait is the maximum time Maybe waits for capacity, if any.
+	maxQueueWait time.Duration
+
 	logger *zap.SugaredLogger
 }
 
@@ -755,11 +905,22 @@ func (ib *infiniteBreaker) Maybe(ctx context.Context, thunk func()) error {
 	ib.mu.RLock()
 	ch = ib.broadcast
 	ib.mu.RUnlock()
+
+	var timeout <-chan time.Time
+	if ib.maxQueueWait > 0 {
+		t := time.NewTimer(ib.maxQueueWait)
+		defer t.Stop()
+		timeout = t.C
+	}
 	select {
 	case <-ch:
 		// Scaled up.
 		thunk()
 		return nil
+	case <-timeout:
+		// Without capacity there is no service time to estimate the wait
+		// from, so the request is retried after another maximum queue wait.
+		return &queue.ShedError{Err: queue.ErrQueueWaitExceeded, RetryAfter: ib.maxQueueWait}
 	case <-ctx.Done():
 		ib.logger.Info("Context is closed: ", ctx.Err())
 		return ctx.Err()
@@ -39,6 +39,8 @@ import (
 	fakeendpointsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/endpoints/fake"
 	"knative.dev/pkg/controller"
 	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
 	rtesting "knative.dev/pkg/reconciler/testing"
 	_ "knative.dev/pkg/system/testing"
 	"knative.dev/serving/pkg/apis/serving"
@@ -538,7 +540,7 @@ func TestPodAssignmentFinite(t *testing.T) {
 	defer cancel()
 
 	throttler := newTestThrottler(ctx)
-	rt := newRevisionThrottler(revName, 42 /*cc*/, pkgnet.ServicePortNameHTTP1, testBreakerParams, logger)
+	rt := newRevisionThrottler(context.Background(), revName, 42 /*cc*/, "", "", nil, pkgnet.ServicePortNameHTTP1, testBreakerParams, logger)
 	rt.numActivators.Store(4)
 	rt.activatorIndex.Store(0)
 	throttler.revisionThrottlers[revName] = rt
@@ -592,7 +594,7 @@ func TestPodAssignmentInfinite(t *testing.T) {
 	defer cancel()
 
 	throttler := newTestThrottler(ctx)
-	rt := newRevisionThrottler(revName, 0 /*cc*/, pkgnet.ServicePortNameHTTP1, testBreakerParams, logger)
+	rt := newRevisionThrottler(context.Background(), revName, 0 /*cc*/, "", "", nil, pkgnet.ServicePortNameHTTP1, testBreakerParams, logger)
 	throttler.revisionThrottlers[revName] = rt
 
 	update := revisionDestsUpdate{
@@ -822,13 +824,29 @@ func TestMultipleActivators(t *testing.T) {
 
 func TestInfiniteBreakerCreation(t *testing.T) {
 	// This test verifies that we use infiniteBreaker when CC==0.
-	tttl := newRevisionThrottler(types.NamespacedName{Namespace: "a", Name: "b"}, 0, /*cc*/
-		pkgnet.ServicePortNameHTTP1, queue.BreakerParams{}, TestLogger(t))
+	tttl := newRevisionThrottler(context.Background(), types.NamespacedName{Namespace: "a", Name: "b"}, 0, /*cc*/
+		"", "", nil, pkgnet.ServicePortNameHTTP1, queue.BreakerParams{}, TestLogger(t))
 	if _, ok := tttl.breaker.(*infiniteBreaker); !ok {
 		t.Errorf("The type of revisionBreaker = %T, want %T", tttl, (*infiniteBreaker)(nil))
 	}
 }
 
+func TestThrottlerPickMetrics(t *testing.T) {
+	// Drop the picks recorded by the other tests.
+	metricstest.Unregister(lbPickIndexM.Name())
+	register()
+	defer metricstest.Unregister(lbPickIndexM.Name())
+	rt := newRevisionThrottler(context.Background(), types.NamespacedName{Namespace: "a", Name: "b"}, 0, /*cc*/
+		serving.ActivatorLBPolicyFirstAvailable, "", nil, pkgnet.ServicePortNameHTTP1, queue.BreakerParams{}, TestLogger(t))
+	rt.assignedTrackers = makeTrackers(3, 0)
+	for i := 0; i < 3; i++ {
+		cb, _ := rt.acquireDest(context.Background())
+		cb()
+	}
+	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric(lbPickIndexM.Name(), 3,
+		map[string]string{lbPolicyTagKey.Name(): serving.ActivatorLBPolicyFirstAvailable}))
+}
+
 func (t *Throttler) try(ctx context.Context, requests int, try func(string) error) chan tryResult {
 	resultChan := make(chan tryResult)
 
@@ -919,6 +937,76 @@ func TestInfiniteBreaker(t *testing.T) {
 	}
 }
 
+func TestInfiniteBreakerMaxQueueWait(t *testing.T) {
+	b := newInfiniteBreaker(TestLogger(t))
+	b.maxQueueWait = 10 * time.Millisecond
+
+	err := b.Maybe(context.Background(), func() { t.Error("Shed request was executed") })
+	var shed *queue.ShedError
+	if !errors.As(err, &shed) || !errors.Is(err, queue.ErrQueueWaitExceeded) {
+		t.Fatalf("Maybe() = %v, want: a ShedError for ErrQueueWaitExceeded", err)
+	}
+	if got, want := shed.RetryAfter, b.maxQueueWait; got != want {
+		t.Errorf("RetryAfter = %v, want: %v", got, want)
+	}
+}
+
+func TestMaxQueueWait(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       time.Duration
+	}{{
+		name: "unset",
+	}, {
+		name:       "valid",
+		annotation: "1500ms",
+		want:       1500 * time.Millisecond,
+	}, {
+		name:       "invalid",
+		annotation: "soon",
+	}, {
+		name:       "negative",
+		annotation: "-1s",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rev := &v1.Revision{}
+			if test.annotation != "" {
+				rev.Annotations = map[string]string{serving.MaxQueueWaitAnnotationKey: test.annotation}
+			}
+			if got := maxQueueWait(rev); got != test.want {
+				t.Errorf("maxQueueWait() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestThrottlerRateLimit(t *testing.T) {
+	rt := newRevisionThrottler(context.Background(), types.NamespacedName{Namespace: "a", Name: "b"}, 1, /*cc*/
+		"", "", nil, pkgnet.ServicePortNameHTTP1, testBreakerParams, TestLogger(t))
+	rt.rateLimiter = rateLimiter(&v1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{serving.RateLimitAnnotationKey: "0.01/1"},
+		},
+	}, TestLogger(t))
+	rt.assignedTrackers = makeTrackers(1, 1)
+	rt.breaker.UpdateConcurrency(1)
+
+	if err := rt.try(context.Background(), func(string) error { return nil }); err != nil {
+		t.Fatal("try() =", err)
+	}
+	err := rt.try(context.Background(), func(string) error {
+		t.Error("Rate limited request was proxied")
+		return nil
+	})
+	var rle *queue.RateLimitError
+	if !errors.As(err, &rle) {
+		t.Errorf("try() = %v, want: a RateLimitError", err)
+	}
+}
+
 func TestInferIndex(t *testing.T) {
 	const myIP = "10.10.10.3"
 	tests := []struct {
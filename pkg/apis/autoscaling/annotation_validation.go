@@ -21,12 +21,15 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
 	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+	"knative.dev/serving/pkg/autoscaler/schedule"
 )
 
 func getIntGE0(m map[string]string, k string) (int32, *apis.FieldError) {
@@ -58,7 +61,12 @@ func ValidateAnnotations(ctx context.Context, config *autoscalerconfig.Config, a
 		Also(validateLastPodRetention(anns)).
 		Also(validateScaleDownDelay(anns)).
 		Also(validateMetric(anns)).
+		Also(validateCustomMetric(config, anns)).
+		Also(validateHPAMetric(anns)).
 		Also(validateAlgorithm(anns)).
+		Also(validateScalingMode(anns)).
+		Also(validateScaleWindows(ctx, config, anns)).
+		Also(validateWarmPoolSize(config, anns)).
 		Also(validateInitialScale(config, anns))
 }
 
@@ -87,6 +95,57 @@ func validateAlgorithm(annotations map[string]string) *apis.FieldError {
 	return nil
 }
 
+func validateScalingMode(annotations map[string]string) *apis.FieldError {
+	// Not a KPA? Don't validate, custom autoscalers might have custom values.
+	if c, ok := annotations[ClassAnnotationKey]; ok && c != KPA {
+		return nil
+	}
+	if m, ok := annotations[ScalingModeAnnotationKey]; ok {
+		switch m {
+		case ScalingModeReactive, ScalingModePredictive:
+			return nil
+		default:
+			return apis.ErrInvalidValue(m, ScalingModeAnnotationKey)
+		}
+	}
+	return nil
+}
+
+func validateScaleWindows(ctx context.Context, config *autoscalerconfig.Config, annotations map[string]string) *apis.FieldError {
+	v, ok := annotations[ScaleWindowsAnnotationKey]
+	if !ok {
+		return nil
+	}
+	switch c, ok := annotations[ClassAnnotationKey]; {
+	case c == HPA:
+		return apis.ErrInvalidKeyName(ScaleWindowsAnnotationKey, apis.CurrentField, HPA)
+	case ok && c != KPA:
+		// Not a KPA? Don't validate, custom autoscalers might have custom values.
+		return nil
+	}
+	windows, err := schedule.ParseWindows(v)
+	if err != nil {
+		return &apis.FieldError{
+			Message: "invalid value: " + v,
+			Paths:   []string{ScaleWindowsAnnotationKey},
+			Details: err.Error(),
+		}
+	}
+	if !apis.IsInCreate(ctx) || config.MaxScaleLimit == 0 {
+		return nil
+	}
+	var errs *apis.FieldError
+	for _, w := range windows {
+		if w.MaxScale != nil && (*w.MaxScale == 0 || *w.MaxScale > config.MaxScaleLimit) {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("window %q: maxScale=%d, must be within [1, %d]", w.Name, *w.MaxScale, config.MaxScaleLimit),
+				Paths:   []string{ScaleWindowsAnnotationKey},
+			})
+		}
+	}
+	return errs
+}
+
 func validateFloats(annotations map[string]string) (errs *apis.FieldError) {
 	if v, ok := annotations[PanicWindowPercentageAnnotationKey]; ok {
 		if fv, err := strconv.ParseFloat(v, 64); err != nil {
@@ -124,6 +183,12 @@ func validateFloats(annotations map[string]string) (errs *apis.FieldError) {
 			errs = errs.Also(apis.ErrInvalidValue(v, TargetBurstCapacityKey))
 		}
 	}
+
+	if v, ok := annotations[QuotaWeightAnnotationKey]; ok {
+		if fv, err := strconv.ParseFloat(v, 64); err != nil || fv <= 0 || math.IsInf(fv, 0) {
+			errs = errs.Also(apis.ErrInvalidValue(v, QuotaWeightAnnotationKey))
+		}
+	}
 	return errs
 }
 
@@ -177,6 +242,8 @@ func validateMinMaxScale(ctx context.Context, config *autoscalerconfig.Config, a
 	min, errs := getIntGE0(annotations, MinScaleAnnotationKey)
 	max, err := getIntGE0(annotations, MaxScaleAnnotationKey)
 	errs = errs.Also(err)
+	_, err = getIntGE0(annotations, RolloutMinScaleAnnotationKey)
+	errs = errs.Also(err)
 
 	if max != 0 && max < min {
 		errs = errs.Also(&apis.FieldError{
@@ -211,12 +278,12 @@ func validateMetric(annotations map[string]string) *apis.FieldError {
 		switch classValue {
 		case KPA:
 			switch metric {
-			case Concurrency, RPS:
+			case Concurrency, RPS, Custom:
 				return nil
 			}
 		case HPA:
 			switch metric {
-			case CPU:
+			case CPU, Memory, Custom, External:
 				return nil
 			}
 		default:
@@ -228,6 +295,96 @@ func validateMetric(annotations map[string]string) *apis.FieldError {
 	return nil
 }
 
+// metricNameRegexp matches the valid Prometheus metric names.
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// validateCustomMetric validates the application-defined metric of the KPA.
+// Such a metric is only reported by the queue-proxies of the revision, so
+// there is no signal to scale up from zero pods on; hence the revision must
+// keep at least one pod, unless scale to zero is disabled cluster-wide.
+func validateCustomMetric(config *autoscalerconfig.Config, annotations map[string]string) *apis.FieldError {
+	if annotations[MetricAnnotationKey] != Custom {
+		return nil
+	}
+	// Not a KPA? Don't validate, custom autoscalers might have custom values.
+	if c, ok := annotations[ClassAnnotationKey]; ok && c != KPA {
+		return nil
+	}
+	var errs *apis.FieldError
+	if name, ok := annotations[MetricNameAnnotationKey]; !ok {
+		errs = errs.Also(apis.ErrMissingField(MetricNameAnnotationKey))
+	} else if !metricNameRegexp.MatchString(name) {
+		errs = errs.Also(apis.ErrInvalidValue(name, MetricNameAnnotationKey))
+	}
+	if path, ok := annotations[MetricPathAnnotationKey]; ok && !strings.HasPrefix(path, "/") {
+		errs = errs.Also(apis.ErrInvalidValue(path, MetricPathAnnotationKey))
+	}
+	// There's no sensible default target for an application-defined metric.
+	if _, ok := annotations[TargetAnnotationKey]; !ok {
+		errs = errs.Also(apis.ErrMissingField(TargetAnnotationKey))
+	}
+	// Errors of the min scale value itself are reported by validateMinMaxScale.
+	if min, err := getIntGE0(annotations, MinScaleAnnotationKey); err == nil && min < 1 && config.EnableScaleToZero {
+		errs = errs.Also(&apis.FieldError{
+			Message: fmt.Sprintf("minScale=%d, must be at least 1 to scale on a custom metric", min),
+			Paths:   []string{MinScaleAnnotationKey},
+		})
+	}
+	return errs
+}
+
+func validateHPAMetric(annotations map[string]string) *apis.FieldError {
+	if annotations[ClassAnnotationKey] != HPA {
+		return nil
+	}
+	var errs *apis.FieldError
+	_, hasTarget := annotations[TargetAnnotationKey]
+	metric := annotations[MetricAnnotationKey]
+	switch metric {
+	case Memory:
+		if _, hasUtilization := annotations[TargetUtilizationPercentageKey]; hasTarget && hasUtilization {
+			errs = errs.Also(apis.ErrMultipleOneOf(TargetAnnotationKey, TargetUtilizationPercentageKey))
+		} else if !hasTarget && !hasUtilization {
+			errs = errs.Also(apis.ErrMissingOneOf(TargetAnnotationKey, TargetUtilizationPercentageKey))
+		}
+	case Custom, External:
+		if annotations[MetricNameAnnotationKey] == "" {
+			errs = errs.Also(apis.ErrMissingField(MetricNameAnnotationKey))
+		}
+		if !hasTarget {
+			errs = errs.Also(apis.ErrMissingField(TargetAnnotationKey))
+		}
+	}
+
+	if sel, ok := annotations[MetricSelectorAnnotationKey]; ok {
+		if metric != Custom && metric != External {
+			errs = errs.Also(apis.ErrInvalidKeyName(MetricSelectorAnnotationKey, apis.CurrentField,
+				fmt.Sprintf("%s for %s %s", HPA, MetricAnnotationKey, metric)))
+		} else if _, err := metav1.ParseToLabelSelector(sel); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(sel, MetricSelectorAnnotationKey))
+		}
+	}
+	return errs
+}
+
+func validateWarmPoolSize(config *autoscalerconfig.Config, annotations map[string]string) *apis.FieldError {
+	if _, ok := annotations[WarmPoolSizeAnnotationKey]; !ok {
+		return nil
+	}
+	// The HPA does not scale to zero, so it has no cold start to shorten.
+	if annotations[ClassAnnotationKey] == HPA {
+		return apis.ErrInvalidKeyName(WarmPoolSizeAnnotationKey, apis.CurrentField, HPA)
+	}
+	size, err := getIntGE0(annotations, WarmPoolSizeAnnotationKey)
+	if err != nil {
+		return err
+	}
+	if config.MaxScaleLimit > 0 && size > config.MaxScaleLimit {
+		return apis.ErrOutOfBoundsValue(size, 0, config.MaxScaleLimit, WarmPoolSizeAnnotationKey)
+	}
+	return nil
+}
+
 func validateInitialScale(config *autoscalerconfig.Config, annotations map[string]string) *apis.FieldError {
 	if initialScale, ok := annotations[InitialScaleAnnotationKey]; ok {
 		initScaleInt, err := strconv.Atoi(initialScale)
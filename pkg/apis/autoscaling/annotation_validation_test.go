@@ -176,6 +176,98 @@ func TestValidateAnnotations(t *testing.T) {
 			MetricAggregationAlgorithmKey: "random-selection",
 			ClassAnnotationKey:            "of-keys",
 		},
+	}, {
+		name: "predictive scaling mode on KPA",
+		annotations: map[string]string{
+			ScalingModeAnnotationKey: ScalingModePredictive,
+			ClassAnnotationKey:       KPA,
+		},
+	}, {
+		name:        "reactive scaling mode on default class",
+		annotations: map[string]string{ScalingModeAnnotationKey: ScalingModeReactive},
+	}, {
+		name:        "invalid scaling mode on default class",
+		annotations: map[string]string{ScalingModeAnnotationKey: "clairvoyant"},
+		expectErr:   "invalid value: clairvoyant: " + ScalingModeAnnotationKey,
+	}, {
+		name: "scale windows",
+		annotations: map[string]string{ScaleWindowsAnnotationKey: `
+- name: business-hours
+  schedule: "0 8 * * 1-5"
+  duration: 10h
+  timeZone: Europe/Berlin
+  minScale: 5
+  maxScale: 10`},
+	}, {
+		name:        "invalid scale windows",
+		annotations: map[string]string{ScaleWindowsAnnotationKey: `[{"name": "a", "schedule": "0 8 * *", "duration": "1h", "minScale": 1}]`},
+		expectErr: "invalid value: [{\"name\": \"a\", \"schedule\": \"0 8 * *\", \"duration\": \"1h\", \"minScale\": 1}]: " +
+			ScaleWindowsAnnotationKey + "\n" + `window "a": invalid schedule: expected 5 fields, got 4 in "0 8 * *"`,
+	}, {
+		name: "scale windows on HPA",
+		annotations: map[string]string{
+			ClassAnnotationKey:        HPA,
+			ScaleWindowsAnnotationKey: "[]",
+		},
+		expectErr: "invalid key name \"" + ScaleWindowsAnnotationKey + "\": \n" + HPA,
+	}, {
+		name: "scale windows on custom class",
+		annotations: map[string]string{
+			ClassAnnotationKey:        "of-keys",
+			ScaleWindowsAnnotationKey: "not windows",
+		},
+	}, {
+		name:       "scale windows max above limit",
+		isInCreate: true,
+		configMutator: func(config *autoscalerconfig.Config) {
+			config.MaxScaleLimit = 10
+		},
+		annotations: map[string]string{
+			MaxScaleAnnotationKey:     "10",
+			ScaleWindowsAnnotationKey: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "maxScale": 11}]`,
+		},
+		expectErr: `window "a": maxScale=11, must be within [1, 10]: ` + ScaleWindowsAnnotationKey,
+	}, {
+		name: "invalid scaling mode on non KPA",
+		annotations: map[string]string{
+			ScalingModeAnnotationKey: "clairvoyant",
+			ClassAnnotationKey:       "of-keys",
+		},
+	}, {
+		name:        "quota weight",
+		annotations: map[string]string{QuotaWeightAnnotationKey: "2.5"},
+	}, {
+		name:        "quota weight zero",
+		annotations: map[string]string{QuotaWeightAnnotationKey: "0"},
+		expectErr:   "invalid value: 0: " + QuotaWeightAnnotationKey,
+	}, {
+		name:        "quota weight not a number",
+		annotations: map[string]string{QuotaWeightAnnotationKey: "heavy"},
+		expectErr:   "invalid value: heavy: " + QuotaWeightAnnotationKey,
+	}, {
+		name:        "warm pool size",
+		annotations: map[string]string{WarmPoolSizeAnnotationKey: "2"},
+	}, {
+		name:        "warm pool size negative",
+		annotations: map[string]string{WarmPoolSizeAnnotationKey: "-2"},
+		expectErr:   "expected 0 <= -2 <= 2147483647: " + WarmPoolSizeAnnotationKey,
+	}, {
+		name: "warm pool size above the max scale limit",
+		configMutator: func(config *autoscalerconfig.Config) {
+			config.MaxScaleLimit = 10
+		},
+		annotations: map[string]string{
+			MaxScaleAnnotationKey:     "10",
+			WarmPoolSizeAnnotationKey: "11",
+		},
+		expectErr: "expected 0 <= 11 <= 10: " + WarmPoolSizeAnnotationKey,
+	}, {
+		name: "warm pool size on HPA",
+		annotations: map[string]string{
+			ClassAnnotationKey:        HPA,
+			WarmPoolSizeAnnotationKey: "2",
+		},
+		expectErr: "invalid key name \"" + WarmPoolSizeAnnotationKey + "\": \n" + HPA,
 	}, {
 		name:        "panic window percentage bad",
 		annotations: map[string]string{PanicWindowPercentageAnnotationKey: "-1"},
@@ -361,6 +453,120 @@ func TestValidateAnnotations(t *testing.T) {
 	}, {
 		name:        "other than HPA and KPA class",
 		annotations: map[string]string{ClassAnnotationKey: "other", MetricAnnotationKey: RPS},
+	}, {
+		name: "valid class KPA with custom metric",
+		annotations: map[string]string{
+			MetricAnnotationKey:     Custom,
+			MetricNameAnnotationKey: "queue_depth",
+			MetricPathAnnotationKey: "/stats",
+			TargetAnnotationKey:     "10",
+		},
+	}, {
+		name: "custom metric with scale to zero",
+		annotations: map[string]string{
+			MetricAnnotationKey:     Custom,
+			MetricNameAnnotationKey: "queue_depth",
+			TargetAnnotationKey:     "10",
+		},
+		configMutator: func(config *autoscalerconfig.Config) {
+			config.EnableScaleToZero = true
+		},
+		expectErr: "minScale=0, must be at least 1 to scale on a custom metric: " + MinScaleAnnotationKey,
+	}, {
+		name: "custom metric with min scale and scale to zero",
+		annotations: map[string]string{
+			MetricAnnotationKey:     Custom,
+			MetricNameAnnotationKey: "queue_depth",
+			TargetAnnotationKey:     "10",
+			MinScaleAnnotationKey:   "1",
+		},
+		configMutator: func(config *autoscalerconfig.Config) {
+			config.EnableScaleToZero = true
+		},
+	}, {
+		name:        "custom metric without name and target",
+		annotations: map[string]string{MetricAnnotationKey: Custom},
+		expectErr:   "missing field(s): " + MetricNameAnnotationKey + ", " + TargetAnnotationKey,
+	}, {
+		name: "custom metric with invalid name and path",
+		annotations: map[string]string{
+			MetricAnnotationKey:     Custom,
+			MetricNameAnnotationKey: "queue-depth",
+			MetricPathAnnotationKey: "stats",
+			TargetAnnotationKey:     "10",
+		},
+		expectErr: "invalid value: queue-depth: " + MetricNameAnnotationKey + "\ninvalid value: stats: " + MetricPathAnnotationKey,
+	}, {
+		name:        "custom metric for HPA class without name and target",
+		annotations: map[string]string{ClassAnnotationKey: HPA, MetricAnnotationKey: Custom},
+		expectErr:   "missing field(s): " + MetricNameAnnotationKey + ", " + TargetAnnotationKey,
+	}, {
+		name: "valid class HPA with custom metric",
+		annotations: map[string]string{
+			ClassAnnotationKey:          HPA,
+			MetricAnnotationKey:         Custom,
+			MetricNameAnnotationKey:     "http-requests",
+			MetricSelectorAnnotationKey: "verb=GET",
+			TargetAnnotationKey:         "10",
+		},
+	}, {
+		name: "valid class HPA with external metric",
+		annotations: map[string]string{
+			ClassAnnotationKey:          HPA,
+			MetricAnnotationKey:         External,
+			MetricNameAnnotationKey:     "queue_messages_ready",
+			MetricSelectorAnnotationKey: "queue in (orders, invoices)",
+			TargetAnnotationKey:         "30",
+		},
+	}, {
+		name: "external metric with invalid selector",
+		annotations: map[string]string{
+			ClassAnnotationKey:          HPA,
+			MetricAnnotationKey:         External,
+			MetricNameAnnotationKey:     "queue_messages_ready",
+			MetricSelectorAnnotationKey: "queue in orders",
+			TargetAnnotationKey:         "30",
+		},
+		expectErr: "invalid value: queue in orders: " + MetricSelectorAnnotationKey,
+	}, {
+		name:        "external metric for KPA class",
+		annotations: map[string]string{MetricAnnotationKey: External},
+		expectErr:   "invalid value: external: " + MetricAnnotationKey,
+	}, {
+		name: "metric selector for HPA class and metric CPU",
+		annotations: map[string]string{
+			ClassAnnotationKey:          HPA,
+			MetricAnnotationKey:         CPU,
+			MetricSelectorAnnotationKey: "verb=GET",
+		},
+		expectErr: fmt.Sprintf("invalid key name %q: \n%s for %s %s", MetricSelectorAnnotationKey, HPA, MetricAnnotationKey, CPU),
+	}, {
+		name: "valid class HPA with metric memory and target",
+		annotations: map[string]string{
+			ClassAnnotationKey:  HPA,
+			MetricAnnotationKey: Memory,
+			TargetAnnotationKey: "128",
+		},
+	}, {
+		name: "valid class HPA with metric memory and target utilization",
+		annotations: map[string]string{
+			ClassAnnotationKey:             HPA,
+			MetricAnnotationKey:            Memory,
+			TargetUtilizationPercentageKey: "75",
+		},
+	}, {
+		name:        "memory metric without target",
+		annotations: map[string]string{ClassAnnotationKey: HPA, MetricAnnotationKey: Memory},
+		expectErr:   "expected exactly one, got neither: " + TargetAnnotationKey + ", " + TargetUtilizationPercentageKey,
+	}, {
+		name: "memory metric with both targets",
+		annotations: map[string]string{
+			ClassAnnotationKey:             HPA,
+			MetricAnnotationKey:            Memory,
+			TargetAnnotationKey:            "128",
+			TargetUtilizationPercentageKey: "75",
+		},
+		expectErr: "expected exactly one, got both: " + TargetAnnotationKey + ", " + TargetUtilizationPercentageKey,
 	}, {
 		name:        "initial scale is zero but cluster doesn't allow",
 		annotations: map[string]string{InitialScaleAnnotationKey: "0"},
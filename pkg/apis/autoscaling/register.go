@@ -45,6 +45,51 @@ const (
 	// the PodAutoscaler should provision. For example,
 	//   autoscaling.knative.dev/maxScale: "10"
 	MaxScaleAnnotationKey = GroupName + "/maxScale"
+	// RolloutMinScaleAnnotationKey is the annotation set on a PodAutoscaler by
+	// the Route pre-warming its revision during a rollout. It raises the
+	// minimum number of Pods to the scale of the revisions being replaced,
+	// up to the maximum scale, until the rollout is done. For example,
+	//   autoscaling.knative.dev/rolloutMinScale: "5"
+	RolloutMinScaleAnnotationKey = GroupName + "/rolloutMinScale"
+	// RolloutMinScaleOwnerAnnotationKey is the annotation set on a PodAutoscaler
+	// along with RolloutMinScaleAnnotationKey to the name of the Route which set
+	// it. Only that Route changes or releases the floor, unless it is deleted.
+	RolloutMinScaleOwnerAnnotationKey = GroupName + "/rolloutMinScaleOwner"
+	// ScaleWindowsAnnotationKey is the annotation to specify recurring windows
+	// of time, as cron expressions with a duration and a time zone, within
+	// which the minimum and maximum number of Pods are overridden. The first
+	// active window applies. For example, to keep 5 Pods on business hours:
+	//   autoscaling.knative.dev/scaleWindows: |
+	//     - name: business-hours
+	//       schedule: "0 8 * * 1-5"
+	//       duration: 10h
+	//       timeZone: Europe/Berlin
+	//       minScale: 5
+	// Only the kpa.autoscaling.knative.dev class autoscaler supports it.
+	ScaleWindowsAnnotationKey = GroupName + "/scaleWindows"
+	// PodBudgetAnnotationKey is the annotation on a Namespace to specify the
+	// total number of Pods of the revisions in the namespace. When the desired
+	// scales of the revisions exceed it, the budget is shared among them by
+	// their QuotaWeightAnnotationKey. The minimum scale of the revisions still
+	// applies. For example,
+	//   autoscaling.knative.dev/podBudget: "50"
+	// Only the kpa.autoscaling.knative.dev class autoscaler supports it.
+	PodBudgetAnnotationKey = GroupName + "/podBudget"
+	// QuotaWeightAnnotationKey is the annotation to specify the share of a
+	// revision in the pod budget of its namespace, relative to the other
+	// revisions. The default weight is 1. For example,
+	//   autoscaling.knative.dev/quotaWeight: "2.5"
+	QuotaWeightAnnotationKey = GroupName + "/quotaWeight"
+	// WarmPoolSizeAnnotationKey is the annotation to specify the number of
+	// pre-started Pods the revision keeps ready, out of the serving endpoints.
+	// On activation from zero they are promoted to serve until the Pods of the
+	// revision are ready, to shorten the cold start. The last Pod is still
+	// retained for the scale-to-zero-pod-retention-period, which revisions
+	// relying on the warm pool can opt out of by setting their
+	// ScaleToZeroPodRetentionPeriodKey to "0s". For example,
+	//   autoscaling.knative.dev/warmPoolSize: "2"
+	// Only the kpa.autoscaling.knative.dev class autoscaler supports it.
+	WarmPoolSizeAnnotationKey = GroupName + "/warmPoolSize"
 
 	// InitialScaleAnnotationKey is the annotation to specify the initial scale of
 	// a revision when a service is initially deployed. This number can be set to 0 iff
@@ -62,13 +107,55 @@ const (
 	Concurrency = "concurrency"
 	// CPU is the amount of the requested cpu actually being consumed by the Pod.
 	CPU = "cpu"
+	// Memory is the amount of memory being consumed by the Pod.
+	// Only the hpa.autoscaling.knative.dev class autoscaler supports it.
+	Memory = "memory"
 	// RPS is the requests per second reaching the Pod.
 	RPS = "rps"
+	// Custom is an application-defined per-pod metric, whose name is specified
+	// via MetricNameAnnotationKey. The kpa.autoscaling.knative.dev class
+	// autoscaler scrapes it from the user container, while the
+	// hpa.autoscaling.knative.dev class autoscaler reads it from the custom
+	// metrics API.
+	Custom = "custom"
+	// External is a metric not associated with any Kubernetes object, whose
+	// name is specified via MetricNameAnnotationKey, e.g. the length of
+	// a queue of a cloud provider. The target is the desired value per pod.
+	// Only the hpa.autoscaling.knative.dev class autoscaler supports it.
+	External = "external"
+
+	// MetricNameAnnotationKey is the annotation to specify the name of the
+	// application-defined metric the PodAutoscaler should be scaled on.
+	// The user container has to expose the metric as a gauge in the Prometheus
+	// text format, and the target is the desired value of the gauge per pod.
+	// For example,
+	//   autoscaling.knative.dev/metric: custom
+	//   autoscaling.knative.dev/metricName: queue_depth
+	//   autoscaling.knative.dev/target: "10"
+	MetricNameAnnotationKey = GroupName + "/metricName"
+	// MetricPathAnnotationKey is the annotation to specify the HTTP path on
+	// the user container port, where the application-defined metric is exposed.
+	MetricPathAnnotationKey = GroupName + "/metricPath"
+	// MetricPathDefault is the default path where the application-defined
+	// metric is exposed.
+	MetricPathDefault = "/metrics"
+	// MetricSelectorAnnotationKey is the annotation to specify the label
+	// selector, in the kubectl selector syntax, narrowing down the series of
+	// the custom or external metric used by the hpa.autoscaling.knative.dev
+	// class autoscaler. For example,
+	//   autoscaling.knative.dev/metric: external
+	//   autoscaling.knative.dev/metricName: queue_messages_ready
+	//   autoscaling.knative.dev/metricSelector: "queue=orders"
+	//   autoscaling.knative.dev/target: "30"
+	MetricSelectorAnnotationKey = GroupName + "/metricSelector"
 
 	// TargetAnnotationKey is the annotation to specify what metric value the
 	// PodAutoscaler should attempt to maintain. For example,
 	//   autoscaling.knative.dev/metric: cpu
 	//   autoscaling.knative.dev/target: "75"   # target 75% cpu utilization
+	// For the memory metric the target is the average memory usage in MiB;
+	// TargetUtilizationPercentageKey targets a percentage of the requested
+	// memory instead.
 	TargetAnnotationKey = GroupName + "/target"
 	// TargetMin is the minimum allowable target.
 	// This can be less than 1 due to the fact that with small container
@@ -105,6 +192,25 @@ const (
 	// with exponentially decaying weights.
 	MetricAggregationAlgorithmWeightedExponential = "weightedExponential"
 
+	// ScalingModeAnnotationKey is the annotation that can be used to select
+	// the mode in which the KPA decider computes the desired scale.
+	// NB: this is an Alpha feature and can be removed or modified
+	//     at any point.
+	// Possible values for KPA are:
+	// - empty/missing or "reactive" — the scale is computed from the
+	//   observed metric values over the stable and panic windows (default);
+	// - predictive — the trend of the metric over the stable window is
+	//   extrapolated forward and the revision is scaled ahead of the
+	//   forecast, but never below the reactive scale.
+	// The default value is configured via scaling-mode in config-autoscaler.
+	ScalingModeAnnotationKey = GroupName + "/scalingMode"
+	// ScalingModeReactive is the scaling mode which only reacts to the
+	// observed metric values.
+	ScalingModeReactive = "reactive"
+	// ScalingModePredictive is the scaling mode which provisions for the
+	// forecasted metric value.
+	ScalingModePredictive = "predictive"
+
 	// WindowAnnotationKey is the annotation to specify the time
 	// interval over which to calculate the average metric.  Larger
 	// values result in more smoothing. For example,
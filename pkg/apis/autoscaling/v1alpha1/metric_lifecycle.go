@@ -25,6 +25,12 @@ const (
 	// MetricConditionReady is set when the Metric's latest
 	// underlying revision has reported readiness.
 	MetricConditionReady = apis.ConditionReady
+
+	// MetricConditionActive is set when the Metric's underlying revision
+	// received traffic over the stable window. It is only maintained for
+	// the revisions of the hpa.autoscaling.knative.dev class, which have no
+	// decider to take the scale to zero decisions.
+	MetricConditionActive apis.ConditionType = "Active"
 )
 
 var condSet = apis.NewLivingConditionSet(
@@ -67,6 +73,22 @@ func (ms *MetricStatus) MarkMetricFailed(reason, message string) {
 	condSet.Manage(ms).MarkFalse(MetricConditionReady, reason, message)
 }
 
+// MarkActive marks the metric status as active.
+func (ms *MetricStatus) MarkActive() {
+	condSet.Manage(ms).MarkTrue(MetricConditionActive)
+}
+
+// MarkInactive marks the metric status as inactive.
+func (ms *MetricStatus) MarkInactive(reason, message string) {
+	condSet.Manage(ms).MarkFalse(MetricConditionActive, reason, message)
+}
+
+// IsInactive returns true if the underlying revision has not received
+// traffic over the stable window.
+func (ms *MetricStatus) IsInactive() bool {
+	return ms.GetCondition(MetricConditionActive).IsFalse()
+}
+
 // IsReady returns true if the Status condition MetricConditionReady
 // is true and the latest spec has been observed.
 func (m *Metric) IsReady() bool {
@@ -178,6 +178,26 @@ func TestTypicalFlowWithMetricCondition(t *testing.T) {
 	apistest.CheckConditionSucceeded(m, MetricConditionReady, t)
 }
 
+func TestMetricActiveCondition(t *testing.T) {
+	m := &MetricStatus{}
+	m.InitializeConditions()
+	m.MarkMetricReady()
+
+	m.MarkInactive("NoTraffic", "idle")
+	if !m.IsInactive() {
+		t.Error("IsInactive() = false, want: true")
+	}
+	// The Active condition doesn't affect the readiness.
+	apistest.CheckConditionSucceeded(m, MetricConditionReady, t)
+
+	m.MarkActive()
+	if m.IsInactive() {
+		t.Error("IsInactive() = true, want: false")
+	}
+	apistest.CheckConditionSucceeded(m, MetricConditionActive, t)
+	apistest.CheckConditionSucceeded(m, MetricConditionReady, t)
+}
+
 func TestMetricGetGroupVersionKind(t *testing.T) {
 	r := &Metric{}
 	want := schema.GroupVersionKind{
@@ -65,6 +65,10 @@ type MetricSpec struct {
 	PanicWindow time.Duration `json:"panicWindow"`
 	// ScrapeTarget is the K8s service that publishes the metric endpoint.
 	ScrapeTarget string `json:"scrapeTarget"`
+	// MetricName is the name of the application-defined metric to collect,
+	// if the entity is scaled on one.
+	// +optional
+	MetricName string `json:"metricName,omitempty"`
 }
 
 // MetricStatus reflects the status of metric collection for this specific entity.
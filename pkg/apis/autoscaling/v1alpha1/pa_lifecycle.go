@@ -22,11 +22,13 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"knative.dev/pkg/apis"
 	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
+	"knative.dev/serving/pkg/autoscaler/schedule"
 )
 
 var podCondSet = apis.NewLivingConditionSet(
@@ -82,21 +84,69 @@ func (pa *PodAutoscaler) annotationFloat64(key string) (float64, bool) {
 // ScaleBounds returns scale bounds annotations values as a tuple:
 // `(min, max int32)`. The value of 0 for any of min or max means the bound is
 // not set.
+// The bounds set by the scale window active per the status take precedence.
+// The min is raised to the rollout min scale, if any, capped by the max.
 // Note: min will be ignored if the PA is not reachable
 func (pa *PodAutoscaler) ScaleBounds(asConfig *autoscalerconfig.Config) (int32, int32) {
-	var min int32
-	if pa.Spec.Reachability != ReachabilityUnreachable {
-		min, _ = pa.annotationInt32(autoscaling.MinScaleAnnotationKey)
+	var window *schedule.Window
+	if name := pa.Status.ActiveScaleWindow; name != "" {
+		if windows, ok := pa.ScaleWindows(); ok {
+			window = schedule.Find(windows, name)
+		}
 	}
 
 	max := asConfig.MaxScale
 	if paMax, ok := pa.annotationInt32(autoscaling.MaxScaleAnnotationKey); ok {
 		max = paMax
 	}
+	if window != nil && window.MaxScale != nil {
+		max = *window.MaxScale
+	}
+
+	var min int32
+	if pa.Spec.Reachability != ReachabilityUnreachable {
+		min, _ = pa.annotationInt32(autoscaling.MinScaleAnnotationKey)
+		if window != nil && window.MinScale != nil {
+			min = *window.MinScale
+		}
+		if floor, ok := pa.annotationInt32(autoscaling.RolloutMinScaleAnnotationKey); ok && floor > min {
+			if max > 0 && floor > max {
+				floor = max
+			}
+			if floor > min {
+				min = floor
+			}
+		}
+	}
 
 	return min, max
 }
 
+// ScaleWindows returns the scale windows annotation value or false if not
+// present, or invalid.
+func (pa *PodAutoscaler) ScaleWindows() ([]schedule.Window, bool) {
+	if s, ok := pa.Annotations[autoscaling.ScaleWindowsAnnotationKey]; ok {
+		windows, err := schedule.ParseWindows(s)
+		return windows, err == nil
+	}
+	return nil, false
+}
+
+// ActiveScaleWindow returns the name of the scale window active at now, if
+// any, and the time when the active window may change next, or the zero time
+// if it never does.
+func (pa *PodAutoscaler) ActiveScaleWindow(now time.Time) (string, time.Time) {
+	windows, ok := pa.ScaleWindows()
+	if !ok {
+		return "", time.Time{}
+	}
+	window, next := schedule.Active(windows, now)
+	if window == nil {
+		return "", next
+	}
+	return window.Name, next
+}
+
 // Target returns the target annotation value or false if not present, or invalid.
 func (pa *PodAutoscaler) Target() (float64, bool) {
 	return pa.annotationFloat64(autoscaling.TargetAnnotationKey)
@@ -156,6 +206,47 @@ func (pa *PodAutoscaler) PanicThresholdPercentage() (percentage float64, ok bool
 	return pa.annotationFloat64(autoscaling.PanicThresholdPercentageAnnotationKey)
 }
 
+// WarmPoolSize returns the warm pool size annotation value, or false if not present.
+func (pa *PodAutoscaler) WarmPoolSize() (int32, bool) {
+	// The value is validated in the webhook.
+	return pa.annotationInt32(autoscaling.WarmPoolSizeAnnotationKey)
+}
+
+// QuotaWeight returns the share of the PA in the pod budget of its namespace,
+// relative to the other PAs, or 1 if not present.
+func (pa *PodAutoscaler) QuotaWeight() float64 {
+	// The value is validated in the webhook.
+	if w, ok := pa.annotationFloat64(autoscaling.QuotaWeightAnnotationKey); ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// ScalingMode returns the scaling mode annotation value, or false if not present.
+func (pa *PodAutoscaler) ScalingMode() (string, bool) {
+	// The value is validated in the webhook.
+	m, ok := pa.Annotations[autoscaling.ScalingModeAnnotationKey]
+	return m, ok
+}
+
+// MetricName returns the name of the application-defined metric annotation
+// value, or false if not present.
+func (pa *PodAutoscaler) MetricName() (string, bool) {
+	// The value is validated in the webhook.
+	n, ok := pa.Annotations[autoscaling.MetricNameAnnotationKey]
+	return n, ok
+}
+
+// MetricSelector returns the label selector of the custom or external metric,
+// if the corresponding annotation is set and valid.
+func (pa *PodAutoscaler) MetricSelector() (*metav1.LabelSelector, bool) {
+	if s, ok := pa.Annotations[autoscaling.MetricSelectorAnnotationKey]; ok {
+		sel, err := metav1.ParseToLabelSelector(s)
+		return sel, err == nil
+	}
+	return nil, false
+}
+
 // InitialScale returns the initial scale on the revision if present, or false if not present.
 func (pa *PodAutoscaler) InitialScale() (int32, bool) {
 	// The value is validated in the webhook.
@@ -208,6 +299,24 @@ func (pas *PodAutoscalerStatus) MarkSKSNotReady(mes string) {
 	podCondSet.Manage(pas).MarkUnknown(PodAutoscalerConditionSKSReady, "NotReady", mes)
 }
 
+// MarkWithinQuota marks the PA condition denoting that the pod budget of the
+// namespace does not throttle the desired scale.
+func (pas *PodAutoscalerStatus) MarkWithinQuota() {
+	podCondSet.Manage(pas).MarkTrue(PodAutoscalerConditionWithinQuota)
+}
+
+// MarkThrottledByQuota marks the PA condition denoting that the pod budget of
+// the namespace throttles the desired scale.
+func (pas *PodAutoscalerStatus) MarkThrottledByQuota(want, got, budget int32) {
+	podCondSet.Manage(pas).MarkFalse(PodAutoscalerConditionWithinQuota, "ThrottledByQuota",
+		"The desired scale %d is throttled to %d by the pod budget %d of the namespace.", want, got, budget)
+}
+
+// ClearQuota removes the quota condition, when the namespace has no pod budget.
+func (pas *PodAutoscalerStatus) ClearQuota() {
+	podCondSet.Manage(pas).ClearCondition(PodAutoscalerConditionWithinQuota)
+}
+
 // GetCondition gets the condition `t`.
 func (pas *PodAutoscalerStatus) GetCondition(t apis.ConditionType) *apis.Condition {
 	return podCondSet.Manage(pas).GetCondition(t)
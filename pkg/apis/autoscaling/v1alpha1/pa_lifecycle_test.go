@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -537,6 +539,9 @@ func TestScaleBounds(t *testing.T) {
 		name         string
 		min          string
 		max          string
+		floor        string
+		windows      string
+		window       string
 		config       autoscalerconfig.Config
 		reachability ReachabilityType
 		wantMin      int32
@@ -596,6 +601,78 @@ func TestScaleBounds(t *testing.T) {
 		max:     "sandwich",
 		wantMin: 0,
 		wantMax: 0,
+	}, {
+		name:    "rollout min scale",
+		min:     "1",
+		floor:   "5",
+		wantMin: 5,
+		wantMax: 10,
+		config: autoscalerconfig.Config{
+			MaxScale: 10,
+		},
+	}, {
+		name:    "rollout min scale below min",
+		min:     "3",
+		floor:   "2",
+		wantMin: 3,
+	}, {
+		name:    "rollout min scale above max",
+		min:     "1",
+		max:     "4",
+		floor:   "5",
+		wantMin: 4,
+		wantMax: 4,
+	}, {
+		name:         "rollout min scale unreachable",
+		floor:        "5",
+		reachability: ReachabilityUnreachable,
+		wantMin:      0,
+	}, {
+		name:    "scale window",
+		min:     "1",
+		max:     "4",
+		windows: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 5, "maxScale": 10}]`,
+		window:  "a",
+		wantMin: 5,
+		wantMax: 10,
+	}, {
+		name:    "scale window min only",
+		min:     "1",
+		max:     "4",
+		windows: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 3}]`,
+		window:  "a",
+		wantMin: 3,
+		wantMax: 4,
+	}, {
+		name:    "scale window, rollout min scale",
+		min:     "1",
+		floor:   "5",
+		windows: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "maxScale": 3}]`,
+		window:  "a",
+		wantMin: 3,
+		wantMax: 3,
+	}, {
+		name:    "scale window not active",
+		min:     "1",
+		max:     "4",
+		windows: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 5}]`,
+		wantMin: 1,
+		wantMax: 4,
+	}, {
+		name:    "scale window unknown",
+		min:     "1",
+		max:     "4",
+		windows: `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 5}]`,
+		window:  "b",
+		wantMin: 1,
+		wantMax: 4,
+	}, {
+		name:         "scale window unreachable",
+		min:          "1",
+		windows:      `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 5}]`,
+		window:       "a",
+		reachability: ReachabilityUnreachable,
+		wantMin:      0,
 	}}
 
 	for _, tc := range cases {
@@ -607,6 +684,13 @@ func TestScaleBounds(t *testing.T) {
 			if tc.max != "" {
 				pa.Annotations[autoscaling.MaxScaleAnnotationKey] = tc.max
 			}
+			if tc.floor != "" {
+				pa.Annotations[autoscaling.RolloutMinScaleAnnotationKey] = tc.floor
+			}
+			if tc.windows != "" {
+				pa.Annotations[autoscaling.ScaleWindowsAnnotationKey] = tc.windows
+			}
+			pa.Status.ActiveScaleWindow = tc.window
 			pa.Spec.Reachability = tc.reachability
 
 			min, max := pa.ScaleBounds(&tc.config)
@@ -621,6 +705,46 @@ func TestScaleBounds(t *testing.T) {
 	}
 }
 
+func TestActiveScaleWindow(t *testing.T) {
+	now := time.Date(2026, 6, 3, 8, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name       string
+		windows    string
+		wantWindow string
+		wantNext   time.Time
+	}{{
+		name: "absent",
+	}, {
+		name:    "malformed",
+		windows: "sandwich",
+	}, {
+		name:       "active",
+		windows:    `[{"name": "a", "schedule": "0 8 * * *", "duration": "1h", "minScale": 5}]`,
+		wantWindow: "a",
+		wantNext:   time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC),
+	}, {
+		name:     "not active",
+		windows:  `[{"name": "a", "schedule": "0 9 * * *", "duration": "1h", "minScale": 5}]`,
+		wantNext: time.Date(2026, 6, 3, 9, 0, 0, 0, time.UTC),
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pa := pa(map[string]string{})
+			if tc.windows != "" {
+				pa.Annotations[autoscaling.ScaleWindowsAnnotationKey] = tc.windows
+			}
+			window, next := pa.ActiveScaleWindow(now)
+			if window != tc.wantWindow {
+				t.Errorf("ActiveScaleWindow() = %q, want: %q", window, tc.wantWindow)
+			}
+			if !next.Equal(tc.wantNext) {
+				t.Errorf("ActiveScaleWindow() next = %v, want: %v", next, tc.wantNext)
+			}
+		})
+	}
+}
+
 func TestMarkResourceNotOwned(t *testing.T) {
 	pa := pa(map[string]string{})
 	pa.Status.MarkResourceNotOwned("doesn't", "matter")
@@ -1177,6 +1301,203 @@ func TestInitialScale(t *testing.T) {
 	}
 }
 
+func TestScalingMode(t *testing.T) {
+	cases := []struct {
+		name   string
+		pa     *PodAutoscaler
+		want   string
+		wantOK bool
+	}{{
+		name: "nil",
+		pa:   pa(nil),
+	}, {
+		name: "not present",
+		pa:   pa(map[string]string{}),
+	}, {
+		name: "present",
+		pa: pa(map[string]string{
+			autoscaling.ScalingModeAnnotationKey: autoscaling.ScalingModePredictive,
+		}),
+		want:   autoscaling.ScalingModePredictive,
+		wantOK: true,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotOK := tc.pa.ScalingMode()
+			if got != tc.want {
+				t.Errorf("ScalingMode = %q, want: %q", got, tc.want)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("OK = %v, want: %v", gotOK, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestWarmPoolSize(t *testing.T) {
+	cases := []struct {
+		name   string
+		pa     *PodAutoscaler
+		want   int32
+		wantOK bool
+	}{{
+		name: "not present",
+		pa:   pa(map[string]string{}),
+	}, {
+		name: "present",
+		pa: pa(map[string]string{
+			autoscaling.WarmPoolSizeAnnotationKey: "3",
+		}),
+		want:   3,
+		wantOK: true,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotOK := tc.pa.WarmPoolSize()
+			if got != tc.want {
+				t.Errorf("WarmPoolSize = %d, want: %d", got, tc.want)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("OK = %v, want: %v", gotOK, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestQuotaWeight(t *testing.T) {
+	cases := []struct {
+		name string
+		pa   *PodAutoscaler
+		want float64
+	}{{
+		name: "not present",
+		pa:   pa(map[string]string{}),
+		want: 1,
+	}, {
+		name: "present",
+		pa: pa(map[string]string{
+			autoscaling.QuotaWeightAnnotationKey: "2.5",
+		}),
+		want: 2.5,
+	}, {
+		name: "invalid",
+		pa: pa(map[string]string{
+			autoscaling.QuotaWeightAnnotationKey: "-3",
+		}),
+		want: 1,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.pa.QuotaWeight(); got != tc.want {
+				t.Errorf("QuotaWeight = %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuotaCondition(t *testing.T) {
+	pa := pa(map[string]string{})
+	pa.Status.InitializeConditions()
+	pa.Status.MarkActive()
+	pa.Status.MarkScaleTargetInitialized()
+	pa.Status.MarkSKSReady()
+
+	pa.Status.MarkThrottledByQuota(10, 4, 20)
+	cond := pa.Status.GetCondition(PodAutoscalerConditionWithinQuota)
+	if cond.Status != corev1.ConditionFalse {
+		t.Error("WithinQuota status = ", cond.Status)
+	}
+	if cond.Reason != "ThrottledByQuota" {
+		t.Error("WithinQuota reason = ", cond.Reason)
+	}
+	// Throttling does not make the PA not ready.
+	apistest.CheckConditionSucceeded(&pa.Status, PodAutoscalerConditionReady, t)
+
+	pa.Status.MarkWithinQuota()
+	apistest.CheckConditionSucceeded(&pa.Status, PodAutoscalerConditionWithinQuota, t)
+
+	pa.Status.ClearQuota()
+	if cond := pa.Status.GetCondition(PodAutoscalerConditionWithinQuota); cond != nil {
+		t.Error("WithinQuota condition was not cleared:", cond)
+	}
+}
+
+func TestMetricName(t *testing.T) {
+	cases := []struct {
+		name   string
+		pa     *PodAutoscaler
+		want   string
+		wantOK bool
+	}{{
+		name: "nil",
+		pa:   pa(nil),
+	}, {
+		name: "not present",
+		pa:   pa(map[string]string{}),
+	}, {
+		name: "present",
+		pa: pa(map[string]string{
+			autoscaling.MetricNameAnnotationKey: "queue_depth",
+		}),
+		want:   "queue_depth",
+		wantOK: true,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotOK := tc.pa.MetricName()
+			if got != tc.want {
+				t.Errorf("MetricName = %q, want: %q", got, tc.want)
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("OK = %v, want: %v", gotOK, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestMetricSelector(t *testing.T) {
+	cases := []struct {
+		name   string
+		pa     *PodAutoscaler
+		want   *metav1.LabelSelector
+		wantOK bool
+	}{{
+		name: "not present",
+		pa:   pa(map[string]string{}),
+	}, {
+		name: "invalid",
+		pa: pa(map[string]string{
+			autoscaling.MetricSelectorAnnotationKey: "queue in orders",
+		}),
+	}, {
+		name: "present",
+		pa: pa(map[string]string{
+			autoscaling.MetricSelectorAnnotationKey: "queue=orders",
+		}),
+		want: &metav1.LabelSelector{
+			MatchLabels:      map[string]string{"queue": "orders"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{},
+		},
+		wantOK: true,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotOK := tc.pa.MetricSelector()
+			if !cmp.Equal(got, tc.want) {
+				t.Error("MetricSelector mismatch (-want,+got):", cmp.Diff(tc.want, got))
+			}
+			if gotOK != tc.wantOK {
+				t.Errorf("OK = %v, want: %v", gotOK, tc.wantOK)
+			}
+		})
+	}
+}
+
 func TestIsScaleTargetInitialized(t *testing.T) {
 	p := PodAutoscaler{}
 	if got, want := p.Status.IsScaleTargetInitialized(), false; got != want {
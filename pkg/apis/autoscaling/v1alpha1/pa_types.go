@@ -108,6 +108,10 @@ const (
 	PodAutoscalerConditionActive apis.ConditionType = "Active"
 	// PodAutoscalerConditionSKSReady is set when SKS is ready.
 	PodAutoscalerConditionSKSReady = "SKSReady"
+	// PodAutoscalerConditionWithinQuota is set when the namespace of the
+	// PodAutoscaler has a pod budget, and becomes false when the budget
+	// throttles the desired scale.
+	PodAutoscalerConditionWithinQuota apis.ConditionType = "WithinQuota"
 )
 
 // PodAutoscalerStatus communicates the observed state of the PodAutoscaler (from the controller).
@@ -127,6 +131,18 @@ type PodAutoscalerStatus struct {
 
 	// ActualScale shows the actual number of replicas for the revision.
 	ActualScale *int32 `json:"actualScale,omitempty"`
+
+	// DemandedScale is the desired number of replicas for the revision
+	// before the pod budget of its namespace throttled it, when the
+	// namespace has a pod budget. The budget is shared by the demanded
+	// scales of the revisions in the namespace.
+	// +optional
+	DemandedScale *int32 `json:"demandedScale,omitempty"`
+
+	// ActiveScaleWindow is the name of the window of the scale windows
+	// annotation whose scale bounds apply, if any.
+	// +optional
+	ActiveScaleWindow string `json:"activeScaleWindow,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
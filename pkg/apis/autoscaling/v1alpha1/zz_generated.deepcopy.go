@@ -211,6 +211,11 @@ func (in *PodAutoscalerStatus) DeepCopyInto(out *PodAutoscalerStatus) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.DemandedScale != nil {
+		in, out := &in.DemandedScale, &out.DemandedScale
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
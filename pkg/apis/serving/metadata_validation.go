@@ -19,6 +19,7 @@ package serving
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -50,7 +51,15 @@ func ValidateObjectMetadata(ctx context.Context, meta metav1.Object, allowAutosc
 // ValidateRolloutDurationAnnotation validates the rollout duration annotation.
 // This annotation can be set on either service or route objects.
 func ValidateRolloutDurationAnnotation(annos map[string]string) (errs *apis.FieldError) {
-	if v := annos[RolloutDurationKey]; v != "" {
+	if v := annos[RolloutDurationKey]; strings.HasPrefix(v, RolloutBlueGreen) || strings.Contains(v, "%") {
+		// A blue/green or scheduled rollout.
+		if _, err := ParseRolloutStrategy(v); err != nil {
+			return errs.Also(&apis.FieldError{
+				Message: err.Error(),
+				Paths:   []string{RolloutDurationKey},
+			})
+		}
+	} else if v != "" {
 		// Parse as duration.
 		d, err := time.ParseDuration(v)
 		if err != nil {
@@ -75,6 +84,44 @@ func ValidateRolloutDurationAnnotation(annos map[string]string) (errs *apis.Fiel
 	return errs
 }
 
+// ValidateRolloutAnalysisAnnotations validates the rollout analysis annotations.
+// These annotations can be set on either service or route objects.
+func ValidateRolloutAnalysisAnnotations(annos map[string]string) (errs *apis.FieldError) {
+	for _, key := range []string{RolloutMaxErrorRateKey, RolloutMaxLatencyKey, RolloutMinRequestsKey} {
+		if v := annos[key]; v != "" {
+			if _, err := ParseRolloutThresholds(map[string]string{key: v}); err != nil {
+				errs = errs.Also(&apis.FieldError{
+					Message: err.Error(),
+					Paths:   []string{key},
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateRolloutControlAnnotation validates the rollout control annotation.
+// This annotation can be set on either service or route objects.
+func ValidateRolloutControlAnnotation(annos map[string]string) *apis.FieldError {
+	switch v := annos[RolloutControlKey]; v {
+	case "", RolloutControlPaused, RolloutControlPromote, RolloutControlAbort:
+		return nil
+	default:
+		return apis.ErrInvalidValue(v, RolloutControlKey)
+	}
+}
+
+// ValidateRolloutPreWarmAnnotation validates the rollout pre-warm annotation.
+// This annotation can be set on either service or route objects.
+func ValidateRolloutPreWarmAnnotation(annos map[string]string) *apis.FieldError {
+	if v, ok := annos[RolloutPreWarmKey]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return apis.ErrInvalidValue(v, RolloutPreWarmKey)
+		}
+	}
+	return nil
+}
+
 // ValidateHasNoAutoscalingAnnotation validates that the respective entity does not have
 // annotations from the autoscaling group. It's to be used to validate Service and
 // Configuration.
@@ -115,6 +162,33 @@ func validateClusterVisibilityLabel(label, key string) (errs *apis.FieldError) {
 	return errs
 }
 
+// SetRolloutControlModifier records the user changing the rollout control
+// annotation of the resource, and keeps the recorded user otherwise.
+func SetRolloutControlModifier(ctx context.Context, oldAnnotations map[string]string, resource metav1.ObjectMetaAccessor) {
+	meta := resource.GetObjectMeta()
+	ans := meta.GetAnnotations()
+	by, hadBy := oldAnnotations[RolloutControlModifierKey]
+	if ans[RolloutControlKey] != oldAnnotations[RolloutControlKey] {
+		ui := apis.GetUserInfo(ctx)
+		if ui == nil {
+			return
+		}
+		by, hadBy = ui.Username, true
+	}
+	if cur, ok := ans[RolloutControlModifierKey]; ok == hadBy && cur == by {
+		return
+	}
+	if ans == nil {
+		ans = map[string]string{}
+		meta.SetAnnotations(ans)
+	}
+	if hadBy {
+		ans[RolloutControlModifierKey] = by
+	} else {
+		delete(ans, RolloutControlModifierKey)
+	}
+}
+
 // SetUserInfo sets creator and updater annotations
 func SetUserInfo(ctx context.Context, oldSpec, newSpec, resource interface{}) {
 	if ui := apis.GetUserInfo(ctx); ui != nil {
@@ -507,6 +507,23 @@ func TestValidateRolloutDurationAnnotation(t *testing.T) {
 		name:  "too precise",
 		value: "211s44ms",
 		want:  "rolloutDuration=211s44ms is not at second precision: serving.knative.dev/rolloutDuration",
+	}, {
+		name:  "blue/green",
+		value: "blue-green",
+	}, {
+		name:  "blue/green with deadline",
+		value: "blue-green:15m",
+	}, {
+		name:  "blue/green with invalid deadline",
+		value: "blue-green:soon",
+		want:  "rolloutDuration=blue-green:soon must end with a positive cut-over deadline: serving.knative.dev/rolloutDuration",
+	}, {
+		name:  "schedule",
+		value: "1%:30s,5%:1m,25%:2m,100%",
+	}, {
+		name:  "schedule not ending at 100%",
+		value: "1%:30s,50%:1m",
+		want:  "rolloutDuration=1%:30s,50%:1m: the last step must be 100%: serving.knative.dev/rolloutDuration",
 	}}
 
 	for _, tc := range tests {
@@ -520,3 +537,197 @@ func TestValidateRolloutDurationAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRolloutAnalysisAnnotations(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  string
+	}{{
+		name: "empty",
+	}, {
+		name: "valid",
+		annos: map[string]string{
+			RolloutMaxErrorRateKey: "0.05",
+			RolloutMaxLatencyKey:   "p99=500ms,p50=100ms",
+			RolloutMinRequestsKey:  "100",
+		},
+	}, {
+		name:  "error rate out of range",
+		annos: map[string]string{RolloutMaxErrorRateKey: "5"},
+		want:  "serving.knative.dev/rolloutMaxErrorRate=5 must be a number within [0, 1]: serving.knative.dev/rolloutMaxErrorRate",
+	}, {
+		name:  "bad latency",
+		annos: map[string]string{RolloutMaxLatencyKey: "p99=fast"},
+		want:  `serving.knative.dev/rolloutMaxLatency=p99=fast: latency "fast" of "p99" must be a positive duration: serving.knative.dev/rolloutMaxLatency`,
+	}, {
+		name: "bad min requests",
+		annos: map[string]string{
+			RolloutMaxErrorRateKey: "0.05",
+			RolloutMinRequestsKey:  "0",
+		},
+		want: "serving.knative.dev/rolloutMinRequests=0 must be a positive integer: serving.knative.dev/rolloutMinRequests",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateRolloutAnalysisAnnotations(tc.annos)
+			if got, want := err.Error(), tc.want; got != want {
+				t.Errorf("APIErr mismatch, diff(-want,+got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestValidateRolloutControlAnnotation(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{{
+		name: "empty",
+	}, {
+		name:  "paused",
+		value: RolloutControlPaused,
+	}, {
+		name:  "promote",
+		value: RolloutControlPromote,
+	}, {
+		name:  "abort",
+		value: RolloutControlAbort,
+	}, {
+		name:  "unknown",
+		value: "resume",
+		want:  "invalid value: resume: serving.knative.dev/rolloutControl",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateRolloutControlAnnotation(map[string]string{
+				RolloutControlKey: tc.value,
+			})
+			if got, want := err.Error(), tc.want; got != want {
+				t.Errorf("APIErr mismatch, diff(-want,+got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestValidateRolloutPreWarmAnnotation(t *testing.T) {
+	tests := []struct {
+		name  string
+		annos map[string]string
+		want  string
+	}{{
+		name: "missing",
+	}, {
+		name:  "enabled",
+		annos: map[string]string{RolloutPreWarmKey: "true"},
+	}, {
+		name:  "disabled",
+		annos: map[string]string{RolloutPreWarmKey: "false"},
+	}, {
+		name:  "invalid",
+		annos: map[string]string{RolloutPreWarmKey: "sure"},
+		want:  "invalid value: sure: serving.knative.dev/rolloutPreWarm",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateRolloutPreWarmAnnotation(tc.annos)
+			if got, want := err.Error(), tc.want; got != want {
+				t.Errorf("APIErr mismatch, diff(-want,+got):\n%s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestSetRolloutControlModifier(t *testing.T) {
+	const (
+		u1 = "oveja@knative.dev"
+		u2 = "cabra@knative.dev"
+	)
+	tests := []struct {
+		name string
+		user string
+		prev map[string]string
+		this map[string]string
+		want map[string]string
+	}{{
+		name: "no control",
+		user: u1,
+	}, {
+		name: "set control",
+		user: u1,
+		this: map[string]string{RolloutControlKey: RolloutControlPaused},
+		want: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u1,
+		},
+	}, {
+		name: "change control",
+		user: u2,
+		prev: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u1,
+		},
+		this: map[string]string{
+			RolloutControlKey:         RolloutControlPromote,
+			RolloutControlModifierKey: u1,
+		},
+		want: map[string]string{
+			RolloutControlKey:         RolloutControlPromote,
+			RolloutControlModifierKey: u2,
+		},
+	}, {
+		name: "remove control",
+		user: u2,
+		prev: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u1,
+		},
+		this: map[string]string{},
+		want: map[string]string{RolloutControlModifierKey: u2},
+	}, {
+		name: "forged modifier is restored",
+		user: u2,
+		prev: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u1,
+		},
+		this: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u2,
+		},
+		want: map[string]string{
+			RolloutControlKey:         RolloutControlPaused,
+			RolloutControlModifierKey: u1,
+		},
+	}, {
+		name: "forged modifier is dropped",
+		user: u2,
+		this: map[string]string{RolloutControlModifierKey: u2},
+		want: map[string]string{},
+	}, {
+		name: "no user info",
+		this: map[string]string{RolloutControlKey: RolloutControlAbort},
+		want: map[string]string{RolloutControlKey: RolloutControlAbort},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.user != "" {
+				ctx = apis.WithUserInfo(ctx, &authv1.UserInfo{
+					Username: test.user,
+				})
+			}
+			this := &withPod{ObjectMeta: metav1.ObjectMeta{Annotations: test.this}}
+			SetRolloutControlModifier(ctx, test.prev, this)
+			if !cmp.Equal(this.Annotations, test.want) {
+				t.Errorf("Annotations = %v, want: %v, diff (-want, +got):\n%s", this.Annotations, test.want,
+					cmp.Diff(test.want, this.Annotations))
+			}
+		})
+	}
+}
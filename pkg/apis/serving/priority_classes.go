@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// PriorityClass is a priority class of the requests queued by queue-proxy.
+type PriorityClass struct {
+	// Name is matched against the priority header or the route tag of the requests.
+	Name string
+	// QueueDepth is the number of requests of the class that may wait for
+	// a free slot before the class overflows.
+	QueueDepth int
+}
+
+// ParsePriorityClasses parses the value of QueueSideCarPriorityClassesAnnotation.
+func ParsePriorityClasses(s string) ([]PriorityClass, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	classes := make([]PriorityClass, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, part := range parts {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("priority class %q is not of the form name=queueDepth", part)
+		}
+		name := kv[0]
+		if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+			return nil, fmt.Errorf("invalid priority class name %q: %s", name, strings.Join(msgs, ", "))
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate priority class %q", name)
+		}
+		seen[name] = struct{}{}
+		depth, err := strconv.Atoi(kv[1])
+		if err != nil || depth < 1 {
+			return nil, fmt.Errorf("queue depth %q of priority class %q must be a positive integer", kv[1], name)
+		}
+		classes = append(classes, PriorityClass{Name: name, QueueDepth: depth})
+	}
+	return classes, nil
+}
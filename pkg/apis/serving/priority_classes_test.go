@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePriorityClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []PriorityClass
+		wantErr bool
+	}{{
+		name: "empty",
+	}, {
+		name:  "single class",
+		value: "bulk=100",
+		want:  []PriorityClass{{Name: "bulk", QueueDepth: 100}},
+	}, {
+		name:  "ordered classes",
+		value: "interactive=10, bulk=100",
+		want: []PriorityClass{
+			{Name: "interactive", QueueDepth: 10},
+			{Name: "bulk", QueueDepth: 100},
+		},
+	}, {
+		name:    "missing depth",
+		value:   "interactive",
+		wantErr: true,
+	}, {
+		name:    "zero depth",
+		value:   "interactive=0",
+		wantErr: true,
+	}, {
+		name:    "invalid depth",
+		value:   "interactive=ten",
+		wantErr: true,
+	}, {
+		name:    "invalid name",
+		value:   "Interactive=10",
+		wantErr: true,
+	}, {
+		name:    "duplicate name",
+		value:   "bulk=10,bulk=20",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParsePriorityClasses(test.value)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ParsePriorityClasses() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Error("ParsePriorityClasses() mismatch (-want,+got):", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
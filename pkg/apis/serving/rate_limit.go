@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RateLimit is a token bucket rate limit of the requests to a revision.
+type RateLimit struct {
+	// Tag is the route tag of the limited requests. The limit without a tag
+	// applies to the requests not matching the limit of their tag.
+	Tag string
+	// Rate is the number of requests per second.
+	Rate float64
+	// Burst is the number of requests that may be served at once.
+	Burst int
+}
+
+// ParseRateLimits parses the value of RateLimitAnnotationKey.
+func ParseRateLimits(s string) ([]RateLimit, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	limits := make([]RateLimit, 0, len(parts))
+	seen := make(map[string]struct{}, len(parts))
+	for _, part := range parts {
+		var limit RateLimit
+		value := strings.TrimSpace(part)
+		if kv := strings.SplitN(value, "=", 2); len(kv) == 2 {
+			limit.Tag, value = kv[0], kv[1]
+			if msgs := validation.IsDNS1123Label(limit.Tag); len(msgs) > 0 {
+				return nil, fmt.Errorf("invalid route tag %q: %s", limit.Tag, strings.Join(msgs, ", "))
+			}
+		}
+		if _, ok := seen[limit.Tag]; ok {
+			return nil, fmt.Errorf("duplicate rate limit for route tag %q", limit.Tag)
+		}
+		seen[limit.Tag] = struct{}{}
+
+		rb := strings.SplitN(value, "/", 2)
+		rate, err := strconv.ParseFloat(rb[0], 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate %q of rate limit %q must be a positive number", rb[0], part)
+		}
+		limit.Rate = rate
+		limit.Burst = int(math.Ceil(rate))
+		if len(rb) == 2 {
+			if limit.Burst, err = strconv.Atoi(rb[1]); err != nil || limit.Burst < 1 {
+				return nil, fmt.Errorf("burst %q of rate limit %q must be a positive integer", rb[1], part)
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []RateLimit
+		wantErr bool
+	}{{
+		name: "empty",
+	}, {
+		name:  "rate",
+		value: "100",
+		want:  []RateLimit{{Rate: 100, Burst: 100}},
+	}, {
+		name:  "fractional rate",
+		value: "0.5",
+		want:  []RateLimit{{Rate: 0.5, Burst: 1}},
+	}, {
+		name:  "rate and burst",
+		value: "100/20",
+		want:  []RateLimit{{Rate: 100, Burst: 20}},
+	}, {
+		name:  "route tags",
+		value: "100/20, canary=5",
+		want: []RateLimit{
+			{Rate: 100, Burst: 20},
+			{Tag: "canary", Rate: 5, Burst: 5},
+		},
+	}, {
+		name:    "zero rate",
+		value:   "0",
+		wantErr: true,
+	}, {
+		name:    "invalid rate",
+		value:   "fast",
+		wantErr: true,
+	}, {
+		name:    "zero burst",
+		value:   "10/0",
+		wantErr: true,
+	}, {
+		name:    "invalid tag",
+		value:   "Canary=10",
+		wantErr: true,
+	}, {
+		name:    "duplicate tag",
+		value:   "canary=10,canary=20",
+		wantErr: true,
+	}, {
+		name:    "duplicate untagged limit",
+		value:   "10,20",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseRateLimits(test.value)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ParseRateLimits() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Error("ParseRateLimits() mismatch (-want,+got):", cmp.Diff(test.want, got))
+			}
+		})
+	}
+}
@@ -37,6 +37,10 @@ const (
 	// from automatically deleting the revision.
 	RevisionPreservedAnnotationKey = GroupName + "/no-gc"
 
+	// RestoreRevisionAnnotationKey is the annotation key attached to a Configuration
+	// to restore the named Revision from the garbage collector's archive.
+	RestoreRevisionAnnotationKey = GroupName + "/restoreRevision"
+
 	// RouteLabelKey is the label key attached to a Configuration indicating by
 	// which Route it is configured as traffic target.
 	// The key is also attached to Revision resources to indicate they are directly
@@ -55,8 +59,50 @@ const (
 	// of the rollout of the latest revision. The value must be a valid positive
 	// Golang time.Duration value serialized to string.
 	// The value can be specified with at most with a second precision.
+	// Alternatively the value may be "blue-green", optionally with a cut-over
+	// deadline, e.g. "blue-green:15m", or an explicit schedule of steps,
+	// e.g. "1%:30s,5%:1m,25%:2m,100%", see ParseRolloutStrategy.
 	RolloutDurationKey = GroupName + "/rolloutDuration"
 
+	// RolloutMaxErrorRateKey is an annotation attached to a Route to indicate the
+	// maximum ratio of the requests to the latest revision which may fail with
+	// a 5xx response during its rollout. The value must be within [0, 1].
+	// The rollout is reverted to the previous revision if the ratio is exceeded.
+	RolloutMaxErrorRateKey = GroupName + "/rolloutMaxErrorRate"
+
+	// RolloutMaxLatencyKey is an annotation attached to a Route to indicate the
+	// maximum latencies of the requests to the latest revision during its rollout,
+	// as a comma separated list of percentile=duration pairs, e.g. "p99=500ms,p50=100ms".
+	// The rollout is reverted to the previous revision if a latency is exceeded.
+	RolloutMaxLatencyKey = GroupName + "/rolloutMaxLatency"
+
+	// RolloutMinRequestsKey is an annotation attached to a Route to indicate the
+	// number of requests the latest revision must serve during a rollout step
+	// for its metrics to be conclusive. The rollout pauses until they are.
+	RolloutMinRequestsKey = GroupName + "/rolloutMinRequests"
+
+	// RolloutControlKey is an annotation attached to a Route to control its
+	// gradual rollouts manually. "paused" freezes the rollouts at their current
+	// traffic split until the annotation is removed, while "promote" completes
+	// and "abort" reverts the rollouts in progress when the annotation is set.
+	RolloutControlKey = GroupName + "/rolloutControl"
+
+	// RolloutControlModifierKey is the annotation key to describe the user that
+	// last changed the RolloutControlKey annotation.
+	RolloutControlModifierKey = GroupName + "/rolloutControlModifier"
+
+	// RolloutPreWarmKey is an annotation attached to a Route to indicate
+	// whether the new revisions are pre-warmed to the scale of the revisions
+	// they replace for the duration of the rollouts, e.g. "true".
+	RolloutPreWarmKey = GroupName + "/rolloutPreWarm"
+
+	// RolloutControlPaused is the value of RolloutControlKey freezing the rollouts.
+	RolloutControlPaused = "paused"
+	// RolloutControlPromote is the value of RolloutControlKey completing the rollouts.
+	RolloutControlPromote = "promote"
+	// RolloutControlAbort is the value of RolloutControlKey reverting the rollouts.
+	RolloutControlAbort = "abort"
+
 	// RoutingStateLabelKey is the label attached to a Revision indicating
 	// its state in relation to serving a Route.
 	RoutingStateLabelKey = GroupName + "/routingState"
@@ -77,6 +123,15 @@ const (
 	// its unique identifier
 	RevisionUID = GroupName + "/revisionUID"
 
+	// WarmPoolLabelKey is the label key attached to the pods of the warm pool
+	// of a revision, instead of RevisionLabelKey and RevisionUID, to reference
+	// the revision by its unique UID.
+	WarmPoolLabelKey = GroupName + "/warmPool"
+
+	// WarmPoolPromotedLabelKey is the label key attached to the pods of the
+	// warm pool of a revision promoted to serve on activation.
+	WarmPoolPromotedLabelKey = GroupName + "/warmPoolPromoted"
+
 	// ConfigurationUIDLabelKey is the label key attached to a pod to reference its
 	// Knative Configuration by its unique UID
 	ConfigurationUIDLabelKey = GroupName + "/configurationUID"
@@ -98,6 +153,11 @@ const (
 	// DomainMapping was created in.
 	DomainMappingNamespaceLabelKey = GroupName + "/domainMappingNamespace"
 
+	// DomainMappingTLSLabelKey is the label key the Secrets holding the
+	// certificates provided for DomainMappings must carry, so that the
+	// DomainMapping controller watches them instead of all the Secrets.
+	DomainMappingTLSLabelKey = GroupName + "/domainMappingTLS"
+
 	// ConfigurationGenerationLabelKey is the label key attached to a Revision indicating the
 	// metadata generation of the Configuration that created this revision
 	ConfigurationGenerationLabelKey = GroupName + "/configurationGeneration"
@@ -113,6 +173,72 @@ const (
 	// It has to be in [0.1,100]
 	QueueSideCarResourcePercentageAnnotation = "queue.sidecar." + GroupName + "/resourcePercentage"
 
+	// QueueSideCarPriorityClassesAnnotation lists the priority classes of the requests
+	// queued by queue-proxy, from the highest priority to the lowest, as comma separated
+	// name=queueDepth pairs, e.g. "interactive=10,bulk=100". It only applies to the
+	// revisions with a container concurrency limit.
+	QueueSideCarPriorityClassesAnnotation = "queue.sidecar." + GroupName + "/priorityClasses"
+
+	// ActivatorLBPolicyAnnotationKey is the annotation key used to pick the
+	// policy the activator uses to load balance the requests across the pods
+	// of the revision. If unset, the policy is picked based on the container
+	// concurrency of the revision.
+	ActivatorLBPolicyAnnotationKey = GroupName + "/activator-lb-policy"
+
+	// ActivatorLBHashHeaderAnnotationKey is the annotation key used to name the
+	// request header whose value is hashed by the consistent-hash policy.
+	ActivatorLBHashHeaderAnnotationKey = GroupName + "/activator-lb-hash-header"
+
+	// ActivatorLBPolicyRandom picks a random pod. It is only used for the
+	// revisions without a container concurrency limit.
+	ActivatorLBPolicyRandom = "random"
+	// ActivatorLBPolicyRandomChoice2 picks the least loaded of two random pods.
+	// It is only used for the revisions without a container concurrency limit.
+	ActivatorLBPolicyRandomChoice2 = "random-choice-2"
+	// ActivatorLBPolicyFirstAvailable picks the first pod with free capacity.
+	ActivatorLBPolicyFirstAvailable = "first-available"
+	// ActivatorLBPolicyRoundRobin picks the pods with free capacity in turn.
+	ActivatorLBPolicyRoundRobin = "round-robin"
+	// ActivatorLBPolicyLeastOutstanding picks the pod with the fewest
+	// outstanding requests, weighted by the moving average of its latency.
+	ActivatorLBPolicyLeastOutstanding = "least-outstanding"
+	// ActivatorLBPolicyConsistentHash consistently picks the same pod for the
+	// same value of the ActivatorLBHashHeaderAnnotationKey header.
+	ActivatorLBPolicyConsistentHash = "consistent-hash"
+
+	// SessionAffinityAnnotationKey is the annotation key used to make the
+	// activator route the requests of a session to the same pod of the
+	// revision while it has capacity. The session is identified by a cookie
+	// or by the SessionAffinityHeaderAnnotationKey header, which the activator
+	// sets on the first response of the session. The activator is kept in
+	// the request path of the revisions using session affinity.
+	// Sessions cannot be pinned when the activator cannot address the pods
+	// directly, e.g. with a service mesh, and reach them via the clusterIP.
+	SessionAffinityAnnotationKey = GroupName + "/session-affinity"
+
+	// SessionAffinityHeaderAnnotationKey is the annotation key used to name
+	// the header carrying the session for the header session affinity.
+	SessionAffinityHeaderAnnotationKey = GroupName + "/session-affinity-header"
+
+	// SessionAffinityCookie identifies the session by a cookie.
+	SessionAffinityCookie = "cookie"
+	// SessionAffinityHeader identifies the session by a request header.
+	SessionAffinityHeader = "header"
+
+	// MaxQueueWaitAnnotationKey is the annotation key used to bound the time a
+	// request waits for capacity in the queue-proxy and the activator, as a
+	// duration, e.g. "2s". The requests waiting longer are shed with a 503 and
+	// a Retry-After header. If unset, the requests wait up to the revision timeout.
+	MaxQueueWaitAnnotationKey = GroupName + "/max-queue-wait"
+
+	// RateLimitAnnotationKey is the annotation key used to cap the requests per
+	// second to the revision, enforced by the queue-proxy and the activator.
+	// It lists token bucket limits as comma separated [tag=]rate[/burst] entries,
+	// e.g. "100/20,canary=5". The limits of a route tag apply to the requests
+	// routed through the tag, the untagged limit to the other requests. The
+	// burst defaults to the rate.
+	RateLimitAnnotationKey = GroupName + "/rate-limit"
+
 	// VisibilityClusterLocal is the label value for VisibilityLabelKey
 	// that will result to the Route/KService getting a cluster local
 	// domain suffix.
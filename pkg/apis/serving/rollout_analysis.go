@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRolloutMinRequests is the number of requests the latest revision
+// must serve during a rollout step for its metrics to be conclusive, if
+// RolloutMinRequestsKey is not set.
+const DefaultRolloutMinRequests = 10
+
+// LatencyThreshold is the maximum latency of a percentile of the requests.
+type LatencyThreshold struct {
+	// Percentile is the percentile of the requests, within (0, 100).
+	Percentile float64
+	// Max is the maximum latency of the percentile.
+	Max time.Duration
+}
+
+// String returns the percentile in the format of RolloutMaxLatencyKey.
+func (l LatencyThreshold) String() string {
+	return "p" + strconv.FormatFloat(l.Percentile, 'f', -1, 64)
+}
+
+// RolloutThresholds are the thresholds the metrics of the latest revision
+// must stay within for its rollout to progress.
+type RolloutThresholds struct {
+	// MaxErrorRate is the maximum ratio of the failed requests, or a
+	// negative value if the error rate is not analyzed.
+	MaxErrorRate float64
+	// MaxLatencies are the maximum latencies of the percentiles of the
+	// requests, sorted by percentile.
+	MaxLatencies []LatencyThreshold
+	// MinRequests is the number of requests needed for a conclusive analysis.
+	MinRequests int
+}
+
+// IsEmpty returns true if there is nothing to analyze.
+func (t RolloutThresholds) IsEmpty() bool {
+	return t.MaxErrorRate < 0 && len(t.MaxLatencies) == 0
+}
+
+// ParseRolloutThresholds parses the rollout analysis annotations.
+func ParseRolloutThresholds(annos map[string]string) (RolloutThresholds, error) {
+	t := RolloutThresholds{
+		MaxErrorRate: -1,
+		MinRequests:  DefaultRolloutMinRequests,
+	}
+	if v := annos[RolloutMaxErrorRateKey]; v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return t, fmt.Errorf("%s=%s must be a number within [0, 1]", RolloutMaxErrorRateKey, v)
+		}
+		t.MaxErrorRate = rate
+	}
+	if v := annos[RolloutMaxLatencyKey]; v != "" {
+		latencies, err := parseLatencyThresholds(v)
+		if err != nil {
+			return t, fmt.Errorf("%s=%s: %w", RolloutMaxLatencyKey, v, err)
+		}
+		t.MaxLatencies = latencies
+	}
+	if v := annos[RolloutMinRequestsKey]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return t, fmt.Errorf("%s=%s must be a positive integer", RolloutMinRequestsKey, v)
+		}
+		t.MinRequests = n
+	}
+	return t, nil
+}
+
+func parseLatencyThresholds(s string) ([]LatencyThreshold, error) {
+	parts := strings.Split(s, ",")
+	latencies := make([]LatencyThreshold, 0, len(parts))
+	seen := make(map[float64]struct{}, len(parts))
+	for _, part := range parts {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], "p") {
+			return nil, fmt.Errorf("%q is not a percentile=duration pair", part)
+		}
+		p, err := strconv.ParseFloat(kv[0][1:], 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("percentile %q must be within (p0, p100)", kv[0])
+		}
+		if _, ok := seen[p]; ok {
+			return nil, fmt.Errorf("duplicate percentile %q", kv[0])
+		}
+		seen[p] = struct{}{}
+		d, err := time.ParseDuration(kv[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("latency %q of %q must be a positive duration", kv[1], kv[0])
+		}
+		latencies = append(latencies, LatencyThreshold{Percentile: p, Max: d})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i].Percentile < latencies[j].Percentile
+	})
+	return latencies, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRolloutThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		annos   map[string]string
+		want    RolloutThresholds
+		wantErr bool
+	}{{
+		name: "empty",
+		want: RolloutThresholds{MaxErrorRate: -1, MinRequests: DefaultRolloutMinRequests},
+	}, {
+		name: "all",
+		annos: map[string]string{
+			RolloutMaxErrorRateKey: "0.05",
+			RolloutMaxLatencyKey:   "p99.9=1s, p50=100ms",
+			RolloutMinRequestsKey:  "50",
+		},
+		want: RolloutThresholds{
+			MaxErrorRate: 0.05,
+			MaxLatencies: []LatencyThreshold{
+				{Percentile: 50, Max: 100 * time.Millisecond},
+				{Percentile: 99.9, Max: time.Second},
+			},
+			MinRequests: 50,
+		},
+	}, {
+		name:  "zero error rate",
+		annos: map[string]string{RolloutMaxErrorRateKey: "0"},
+		want:  RolloutThresholds{MinRequests: DefaultRolloutMinRequests},
+	}, {
+		name:    "negative error rate",
+		annos:   map[string]string{RolloutMaxErrorRateKey: "-0.1"},
+		wantErr: true,
+	}, {
+		name:    "not a percentile",
+		annos:   map[string]string{RolloutMaxLatencyKey: "max=1s"},
+		wantErr: true,
+	}, {
+		name:    "percentile out of range",
+		annos:   map[string]string{RolloutMaxLatencyKey: "p100=1s"},
+		wantErr: true,
+	}, {
+		name:    "duplicate percentile",
+		annos:   map[string]string{RolloutMaxLatencyKey: "p99=1s,p99.0=2s"},
+		wantErr: true,
+	}, {
+		name:    "missing latency",
+		annos:   map[string]string{RolloutMaxLatencyKey: "p99"},
+		wantErr: true,
+	}, {
+		name:    "non-positive latency",
+		annos:   map[string]string{RolloutMaxLatencyKey: "p99=0s"},
+		wantErr: true,
+	}, {
+		name:    "invalid min requests",
+		annos:   map[string]string{RolloutMinRequestsKey: "some"},
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseRolloutThresholds(tc.annos)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseRolloutThresholds() = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error("ParseRolloutThresholds (-want, +got):", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RolloutStrategyType is the way the traffic is shifted to a new revision.
+type RolloutStrategyType string
+
+const (
+	// RolloutStrategyLinear shifts the traffic in equal steps over the
+	// rollout duration.
+	RolloutStrategyLinear RolloutStrategyType = "linear"
+	// RolloutStrategySchedule shifts the traffic following an explicit
+	// schedule of steps.
+	RolloutStrategySchedule RolloutStrategyType = "schedule"
+	// RolloutStrategyBlueGreen keeps the new revision at 0% of the traffic
+	// until it is warm at the scale of the revisions it replaces, and then
+	// shifts all the traffic to it at once. The rollout is aborted if the
+	// new revision is not warm by the cut-over deadline.
+	RolloutStrategyBlueGreen RolloutStrategyType = "blueGreen"
+)
+
+const (
+	// RolloutBlueGreen is the value of RolloutDurationKey selecting the
+	// blue/green strategy. It may be followed by a colon and the cut-over
+	// deadline, e.g. "blue-green:15m".
+	RolloutBlueGreen = "blue-green"
+
+	// DefaultBlueGreenDeadline is the cut-over deadline of the blue/green
+	// rollouts which don't specify one.
+	DefaultBlueGreenDeadline = 10 * time.Minute
+)
+
+// RolloutStep is a step of a scheduled rollout.
+type RolloutStep struct {
+	// Percent is the share of the traffic of the configuration routed to
+	// the new revision at this step, within [1, 100].
+	Percent int
+	// Hold is how long the step is held before the next one.
+	Hold time.Duration
+}
+
+// RolloutStrategy describes how the traffic is shifted to a new revision.
+type RolloutStrategy struct {
+	// Type of the strategy.
+	Type RolloutStrategyType
+	// Duration of a linear rollout, 0 if it is not specified, or the
+	// cut-over deadline of a blue/green rollout.
+	Duration time.Duration
+	// Steps of a scheduled rollout, in increasing order of percent. The last
+	// step is always 100%.
+	Steps []RolloutStep
+}
+
+// ParseRolloutStrategy parses the value of the RolloutDurationKey annotation,
+// which is either a duration, RolloutBlueGreen with an optional deadline or a
+// schedule of steps, as a comma separated list of percent:hold pairs ending
+// with 100%, e.g. "1%:30s,5%:1m,25%:2m,100%".
+func ParseRolloutStrategy(v string) (RolloutStrategy, error) {
+	switch {
+	case v == "":
+		return RolloutStrategy{Type: RolloutStrategyLinear}, nil
+	case v == RolloutBlueGreen:
+		return RolloutStrategy{Type: RolloutStrategyBlueGreen, Duration: DefaultBlueGreenDeadline}, nil
+	case strings.HasPrefix(v, RolloutBlueGreen+":"):
+		d, err := time.ParseDuration(strings.TrimPrefix(v, RolloutBlueGreen+":"))
+		if err != nil || d <= 0 {
+			return RolloutStrategy{}, fmt.Errorf("rolloutDuration=%s must end with a positive cut-over deadline", v)
+		}
+		return RolloutStrategy{Type: RolloutStrategyBlueGreen, Duration: d}, nil
+	case strings.Contains(v, "%"):
+		steps, err := parseRolloutSteps(v)
+		if err != nil {
+			return RolloutStrategy{}, fmt.Errorf("rolloutDuration=%s: %w", v, err)
+		}
+		return RolloutStrategy{Type: RolloutStrategySchedule, Steps: steps}, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return RolloutStrategy{}, err
+	}
+	// Even if tempting %v won't work here, since it might output the value spelled differently.
+	if d.Round(time.Second) != d {
+		return RolloutStrategy{}, fmt.Errorf("rolloutDuration=%s is not at second precision", v)
+	}
+	if d < 0 {
+		return RolloutStrategy{}, fmt.Errorf("rolloutDuration=%s must be positive", v)
+	}
+	return RolloutStrategy{Type: RolloutStrategyLinear, Duration: d}, nil
+}
+
+func parseRolloutSteps(s string) ([]RolloutStep, error) {
+	parts := strings.Split(s, ",")
+	steps := make([]RolloutStep, 0, len(parts))
+	for i, part := range parts {
+		kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+		if !strings.HasSuffix(kv[0], "%") {
+			return nil, fmt.Errorf("%q is not a percent:hold pair", part)
+		}
+		p, err := strconv.Atoi(strings.TrimSuffix(kv[0], "%"))
+		if err != nil || p < 1 || p > 100 {
+			return nil, fmt.Errorf("percent %q must be within [1%%, 100%%]", kv[0])
+		}
+		if i > 0 && p <= steps[i-1].Percent {
+			return nil, fmt.Errorf("percent %q must be larger than the one of the previous step", kv[0])
+		}
+		step := RolloutStep{Percent: p}
+		if p == 100 {
+			if len(kv) == 2 {
+				return nil, errors.New("the 100% step cannot be held")
+			}
+			if i != len(parts)-1 {
+				return nil, errors.New("the 100% step must be the last one")
+			}
+		} else {
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("step %q must have a hold duration", part)
+			}
+			d, err := time.ParseDuration(kv[1])
+			if err != nil || d <= 0 || d.Round(time.Second) != d {
+				return nil, fmt.Errorf("hold %q of %q must be a positive duration at second precision", kv[1], kv[0])
+			}
+			step.Hold = d
+		}
+		steps = append(steps, step)
+	}
+	if steps[len(steps)-1].Percent != 100 {
+		return nil, errors.New("the last step must be 100%")
+	}
+	return steps, nil
+}
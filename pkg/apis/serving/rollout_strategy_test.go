@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRolloutStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    RolloutStrategy
+		wantErr bool
+	}{{
+		name: "empty",
+		want: RolloutStrategy{Type: RolloutStrategyLinear},
+	}, {
+		name:  "duration",
+		value: "3m20s",
+		want:  RolloutStrategy{Type: RolloutStrategyLinear, Duration: 200 * time.Second},
+	}, {
+		name:    "invalid duration",
+		value:   "200",
+		wantErr: true,
+	}, {
+		name:    "negative duration",
+		value:   "-200s",
+		wantErr: true,
+	}, {
+		name:  "blue/green",
+		value: "blue-green",
+		want:  RolloutStrategy{Type: RolloutStrategyBlueGreen, Duration: DefaultBlueGreenDeadline},
+	}, {
+		name:  "blue/green with deadline",
+		value: "blue-green:15m",
+		want:  RolloutStrategy{Type: RolloutStrategyBlueGreen, Duration: 15 * time.Minute},
+	}, {
+		name:    "blue/green with invalid deadline",
+		value:   "blue-green:soon",
+		wantErr: true,
+	}, {
+		name:    "blue/green with negative deadline",
+		value:   "blue-green:-1m",
+		wantErr: true,
+	}, {
+		name:  "schedule",
+		value: "1%:30s, 5%:1m,25%:2m,50%:2m,100%",
+		want: RolloutStrategy{
+			Type: RolloutStrategySchedule,
+			Steps: []RolloutStep{
+				{Percent: 1, Hold: 30 * time.Second},
+				{Percent: 5, Hold: time.Minute},
+				{Percent: 25, Hold: 2 * time.Minute},
+				{Percent: 50, Hold: 2 * time.Minute},
+				{Percent: 100},
+			},
+		},
+	}, {
+		name:  "immediate",
+		value: "100%",
+		want: RolloutStrategy{
+			Type:  RolloutStrategySchedule,
+			Steps: []RolloutStep{{Percent: 100}},
+		},
+	}, {
+		name:    "not a percent",
+		value:   "1:30s,100%",
+		wantErr: true,
+	}, {
+		name:    "percent out of range",
+		value:   "0%:30s,100%",
+		wantErr: true,
+	}, {
+		name:    "decreasing percent",
+		value:   "10%:30s,5%:30s,100%",
+		wantErr: true,
+	}, {
+		name:    "missing hold",
+		value:   "10%,100%",
+		wantErr: true,
+	}, {
+		name:    "invalid hold",
+		value:   "10%:1500ms,100%",
+		wantErr: true,
+	}, {
+		name:    "held last step",
+		value:   "10%:30s,100%:30s",
+		wantErr: true,
+	}, {
+		name:    "step after 100%",
+		value:   "100%,100%",
+		wantErr: true,
+	}, {
+		name:    "not ending at 100%",
+		value:   "10%:30s,50%:30s",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseRolloutStrategy(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseRolloutStrategy() = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error("ParseRolloutStrategy (-want, +got):", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
@@ -98,6 +98,20 @@ type ConfigurationStatus struct {
 	duckv1.Status `json:",inline"`
 
 	ConfigurationStatusFields `json:",inline"`
+
+	// PendingRevisionGC lists the Revisions the garbage collector would
+	// delete, if its policy for the Configuration was not a dry run.
+	// +optional
+	PendingRevisionGC []PendingRevisionGC `json:"pendingRevisionGC,omitempty"`
+}
+
+// PendingRevisionGC is a Revision the garbage collector would delete.
+type PendingRevisionGC struct {
+	// RevisionName is the name of the Revision.
+	RevisionName string `json:"revisionName"`
+
+	// Reason is why the Revision would be deleted.
+	Reason string `json:"reason"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
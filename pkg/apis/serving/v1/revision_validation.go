@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/validation"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmp"
 	"knative.dev/serving/pkg/apis/autoscaling"
@@ -68,6 +70,11 @@ func (rts *RevisionTemplateSpec) Validate(ctx context.Context) *apis.FieldError
 	// it follows the requirements on the name.
 	errs = errs.Also(validateRevisionName(ctx, rts.Name, rts.GenerateName))
 	errs = errs.Also(validateQueueSidecarAnnotation(rts.Annotations).ViaField("metadata.annotations"))
+	errs = errs.Also(validatePriorityClassesAnnotation(rts.Annotations).ViaField("metadata.annotations"))
+	errs = errs.Also(validateActivatorLBAnnotations(rts.Annotations).ViaField("metadata.annotations"))
+	errs = errs.Also(validateSessionAffinityAnnotations(rts.Annotations).ViaField("metadata.annotations"))
+	errs = errs.Also(validateMaxQueueWaitAnnotation(rts.Annotations).ViaField("metadata.annotations"))
+	errs = errs.Also(validateRateLimitAnnotation(rts.Annotations).ViaField("metadata.annotations"))
 	return errs
 }
 
@@ -197,3 +204,108 @@ func validateQueueSidecarAnnotation(annotations map[string]string) *apis.FieldEr
 	}
 	return nil
 }
+
+// validatePriorityClassesAnnotation validates QueueSideCarPriorityClassesAnnotation.
+func validatePriorityClassesAnnotation(annotations map[string]string) *apis.FieldError {
+	v, ok := annotations[serving.QueueSideCarPriorityClassesAnnotation]
+	if !ok {
+		return nil
+	}
+	if _, err := serving.ParsePriorityClasses(v); err != nil {
+		fe := apis.ErrInvalidValue(v, apis.CurrentField)
+		fe.Details = err.Error()
+		return fe.ViaKey(serving.QueueSideCarPriorityClassesAnnotation)
+	}
+	return nil
+}
+
+// validateActivatorLBAnnotations validates ActivatorLBPolicyAnnotationKey and
+// ActivatorLBHashHeaderAnnotationKey.
+func validateActivatorLBAnnotations(annotations map[string]string) *apis.FieldError {
+	if len(annotations) == 0 {
+		return nil
+	}
+	var errs *apis.FieldError
+	policy, ok := annotations[serving.ActivatorLBPolicyAnnotationKey]
+	if ok {
+		switch policy {
+		case serving.ActivatorLBPolicyRandom, serving.ActivatorLBPolicyRandomChoice2,
+			serving.ActivatorLBPolicyFirstAvailable, serving.ActivatorLBPolicyRoundRobin,
+			serving.ActivatorLBPolicyLeastOutstanding, serving.ActivatorLBPolicyConsistentHash:
+		default:
+			errs = apis.ErrInvalidValue(policy, apis.CurrentField).
+				ViaKey(serving.ActivatorLBPolicyAnnotationKey)
+		}
+	}
+
+	header, ok := annotations[serving.ActivatorLBHashHeaderAnnotationKey]
+	switch {
+	case policy == serving.ActivatorLBPolicyConsistentHash && !ok:
+		errs = errs.Also(apis.ErrMissingField(serving.ActivatorLBHashHeaderAnnotationKey))
+	case ok && policy != serving.ActivatorLBPolicyConsistentHash:
+		errs = errs.Also(apis.ErrInvalidKeyName(serving.ActivatorLBHashHeaderAnnotationKey, apis.CurrentField,
+			fmt.Sprintf("only valid for the %s policy", serving.ActivatorLBPolicyConsistentHash)))
+	case ok:
+		if msgs := utilvalidation.IsHTTPHeaderName(header); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(header, apis.CurrentField).
+				ViaKey(serving.ActivatorLBHashHeaderAnnotationKey))
+		}
+	}
+	return errs
+}
+
+// validateSessionAffinityAnnotations validates SessionAffinityAnnotationKey and
+// SessionAffinityHeaderAnnotationKey.
+func validateSessionAffinityAnnotations(annotations map[string]string) *apis.FieldError {
+	if len(annotations) == 0 {
+		return nil
+	}
+	var errs *apis.FieldError
+	mode, ok := annotations[serving.SessionAffinityAnnotationKey]
+	if ok && mode != serving.SessionAffinityCookie && mode != serving.SessionAffinityHeader {
+		errs = apis.ErrInvalidValue(mode, apis.CurrentField).
+			ViaKey(serving.SessionAffinityAnnotationKey)
+	}
+
+	header, ok := annotations[serving.SessionAffinityHeaderAnnotationKey]
+	switch {
+	case mode == serving.SessionAffinityHeader && !ok:
+		errs = errs.Also(apis.ErrMissingField(serving.SessionAffinityHeaderAnnotationKey))
+	case ok && mode != serving.SessionAffinityHeader:
+		errs = errs.Also(apis.ErrInvalidKeyName(serving.SessionAffinityHeaderAnnotationKey, apis.CurrentField,
+			fmt.Sprintf("only valid for the %s session affinity", serving.SessionAffinityHeader)))
+	case ok:
+		if msgs := utilvalidation.IsHTTPHeaderName(header); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(header, apis.CurrentField).
+				ViaKey(serving.SessionAffinityHeaderAnnotationKey))
+		}
+	}
+	return errs
+}
+
+// validateMaxQueueWaitAnnotation validates MaxQueueWaitAnnotationKey.
+func validateMaxQueueWaitAnnotation(annotations map[string]string) *apis.FieldError {
+	v, ok := annotations[serving.MaxQueueWaitAnnotationKey]
+	if !ok {
+		return nil
+	}
+	if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+		return apis.ErrInvalidValue(v, apis.CurrentField).
+			ViaKey(serving.MaxQueueWaitAnnotationKey)
+	}
+	return nil
+}
+
+// validateRateLimitAnnotation validates RateLimitAnnotationKey.
+func validateRateLimitAnnotation(annotations map[string]string) *apis.FieldError {
+	v, ok := annotations[serving.RateLimitAnnotationKey]
+	if !ok {
+		return nil
+	}
+	if _, err := serving.ParseRateLimits(v); err != nil {
+		fe := apis.ErrInvalidValue(v, apis.CurrentField)
+		fe.Details = err.Error()
+		return fe.ViaKey(serving.RateLimitAnnotationKey)
+	}
+	return nil
+}
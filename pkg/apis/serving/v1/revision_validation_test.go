@@ -1054,6 +1054,187 @@ func TestValidateQueueSidecarAnnotation(t *testing.T) {
 	}
 }
 
+func TestValidatePriorityClassesAnnotation(t *testing.T) {
+	if err := validatePriorityClassesAnnotation(map[string]string{
+		serving.QueueSideCarPriorityClassesAnnotation: "interactive=10,bulk=100",
+	}); err != nil {
+		t.Error("validatePriorityClassesAnnotation() =", err)
+	}
+
+	err := validatePriorityClassesAnnotation(map[string]string{
+		serving.QueueSideCarPriorityClassesAnnotation: "interactive=0",
+	})
+	want := apis.ErrInvalidValue("interactive=0", apis.CurrentField)
+	want.Details = `queue depth "0" of priority class "interactive" must be a positive integer`
+	if got, want := err.Error(), want.ViaKey(serving.QueueSideCarPriorityClassesAnnotation).Error(); got != want {
+		t.Errorf("Got: %q want: %q", got, want)
+	}
+}
+
+func TestValidateRateLimitAnnotation(t *testing.T) {
+	if err := validateRateLimitAnnotation(map[string]string{
+		serving.RateLimitAnnotationKey: "100/20,canary=5",
+	}); err != nil {
+		t.Error("validateRateLimitAnnotation() =", err)
+	}
+
+	err := validateRateLimitAnnotation(map[string]string{
+		serving.RateLimitAnnotationKey: "canary=0",
+	})
+	want := apis.ErrInvalidValue("canary=0", apis.CurrentField)
+	want.Details = `rate "0" of rate limit "canary=0" must be a positive number`
+	if got, want := err.Error(), want.ViaKey(serving.RateLimitAnnotationKey).Error(); got != want {
+		t.Errorf("Got: %q want: %q", got, want)
+	}
+}
+
+func TestValidateActivatorLBAnnotations(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation map[string]string
+		expectErr  *apis.FieldError
+	}{{
+		name: "valid policy",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey: serving.ActivatorLBPolicyLeastOutstanding,
+		},
+	}, {
+		name: "valid consistent hash",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey:     serving.ActivatorLBPolicyConsistentHash,
+			serving.ActivatorLBHashHeaderAnnotationKey: "X-Session-Id",
+		},
+	}, {
+		name: "unknown policy",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey: "fastest",
+		},
+		expectErr: apis.ErrInvalidValue("fastest", apis.CurrentField).
+			ViaKey(serving.ActivatorLBPolicyAnnotationKey),
+	}, {
+		name: "consistent hash without header",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey: serving.ActivatorLBPolicyConsistentHash,
+		},
+		expectErr: apis.ErrMissingField(serving.ActivatorLBHashHeaderAnnotationKey),
+	}, {
+		name: "header without consistent hash",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey:     serving.ActivatorLBPolicyRoundRobin,
+			serving.ActivatorLBHashHeaderAnnotationKey: "X-Session-Id",
+		},
+		expectErr: apis.ErrInvalidKeyName(serving.ActivatorLBHashHeaderAnnotationKey, apis.CurrentField,
+			"only valid for the consistent-hash policy"),
+	}, {
+		name: "invalid header",
+		annotation: map[string]string{
+			serving.ActivatorLBPolicyAnnotationKey:     serving.ActivatorLBPolicyConsistentHash,
+			serving.ActivatorLBHashHeaderAnnotationKey: "X Session",
+		},
+		expectErr: apis.ErrInvalidValue("X Session", apis.CurrentField).
+			ViaKey(serving.ActivatorLBHashHeaderAnnotationKey),
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateActivatorLBAnnotations(c.annotation)
+			if got, want := err.Error(), c.expectErr.Error(); got != want {
+				t.Errorf("Got: %q want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateSessionAffinityAnnotations(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation map[string]string
+		expectErr  *apis.FieldError
+	}{{
+		name: "cookie",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey: serving.SessionAffinityCookie,
+		},
+	}, {
+		name: "header",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey:       serving.SessionAffinityHeader,
+			serving.SessionAffinityHeaderAnnotationKey: "X-Session-Id",
+		},
+	}, {
+		name: "unknown mode",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey: "ip",
+		},
+		expectErr: apis.ErrInvalidValue("ip", apis.CurrentField).
+			ViaKey(serving.SessionAffinityAnnotationKey),
+	}, {
+		name: "header mode without header",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey: serving.SessionAffinityHeader,
+		},
+		expectErr: apis.ErrMissingField(serving.SessionAffinityHeaderAnnotationKey),
+	}, {
+		name: "header with cookie mode",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey:       serving.SessionAffinityCookie,
+			serving.SessionAffinityHeaderAnnotationKey: "X-Session-Id",
+		},
+		expectErr: apis.ErrInvalidKeyName(serving.SessionAffinityHeaderAnnotationKey, apis.CurrentField,
+			"only valid for the header session affinity"),
+	}, {
+		name: "invalid header",
+		annotation: map[string]string{
+			serving.SessionAffinityAnnotationKey:       serving.SessionAffinityHeader,
+			serving.SessionAffinityHeaderAnnotationKey: "X Session",
+		},
+		expectErr: apis.ErrInvalidValue("X Session", apis.CurrentField).
+			ViaKey(serving.SessionAffinityHeaderAnnotationKey),
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateSessionAffinityAnnotations(c.annotation)
+			if got, want := err.Error(), c.expectErr.Error(); got != want {
+				t.Errorf("Got: %q want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateMaxQueueWaitAnnotation(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation map[string]string
+		expectErr  *apis.FieldError
+	}{{
+		name:       "unset",
+		annotation: map[string]string{},
+	}, {
+		name:       "valid",
+		annotation: map[string]string{serving.MaxQueueWaitAnnotationKey: "1500ms"},
+	}, {
+		name:       "not a duration",
+		annotation: map[string]string{serving.MaxQueueWaitAnnotationKey: "soon"},
+		expectErr: apis.ErrInvalidValue("soon", apis.CurrentField).
+			ViaKey(serving.MaxQueueWaitAnnotationKey),
+	}, {
+		name:       "zero",
+		annotation: map[string]string{serving.MaxQueueWaitAnnotationKey: "0s"},
+		expectErr: apis.ErrInvalidValue("0s", apis.CurrentField).
+			ViaKey(serving.MaxQueueWaitAnnotationKey),
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateMaxQueueWaitAnnotation(c.annotation)
+			if got, want := err.Error(), c.expectErr.Error(); got != want {
+				t.Errorf("Got: %q want: %q", got, want)
+			}
+		})
+	}
+}
+
 func TestValidateTimeoutSecond(t *testing.T) {
 	cases := []struct {
 		name      string
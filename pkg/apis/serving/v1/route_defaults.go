@@ -29,9 +29,12 @@ func (r *Route) SetDefaults(ctx context.Context) {
 	r.Spec.SetDefaults(apis.WithinSpec(ctx))
 	if r.GetOwnerReferences() == nil {
 		if apis.IsInUpdate(ctx) {
-			serving.SetUserInfo(ctx, apis.GetBaseline(ctx).(*Route).Spec, r.Spec, r)
+			base := apis.GetBaseline(ctx).(*Route)
+			serving.SetUserInfo(ctx, base.Spec, r.Spec, r)
+			serving.SetRolloutControlModifier(ctx, base.Annotations, r)
 		} else {
 			serving.SetUserInfo(ctx, nil, r.Spec, r)
+			serving.SetRolloutControlModifier(ctx, nil, r)
 		}
 	}
 }
@@ -18,6 +18,7 @@ package v1
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -73,6 +74,37 @@ func (r *Route) RolloutDuration() time.Duration {
 	return 0
 }
 
+// RolloutStrategy returns the rollout strategy specified by the rollout
+// duration annotation.
+// A linear strategy without duration is returned if missing or cannot be parsed.
+func (r *Route) RolloutStrategy() serving.RolloutStrategy {
+	s, err := serving.ParseRolloutStrategy(r.Annotations[serving.RolloutDurationKey])
+	if err != nil {
+		// WH should've declined all the invalid values for this annotation.
+		return serving.RolloutStrategy{Type: serving.RolloutStrategyLinear}
+	}
+	return s
+}
+
+// RolloutThresholds returns the rollout analysis thresholds specified as
+// annotations. Nothing is analyzed if they cannot be parsed.
+func (r *Route) RolloutThresholds() serving.RolloutThresholds {
+	t, err := serving.ParseRolloutThresholds(r.Annotations)
+	if err != nil {
+		// WH should've declined all the invalid values for these annotations.
+		return serving.RolloutThresholds{MaxErrorRate: -1}
+	}
+	return t
+}
+
+// RolloutPreWarm returns whether the new revisions are to be pre-warmed
+// during the rollouts, as specified by the rollout pre-warm annotation.
+// false is returned if missing or cannot be parsed.
+func (r *Route) RolloutPreWarm() bool {
+	b, _ := strconv.ParseBool(r.Annotations[serving.RolloutPreWarmKey])
+	return b
+}
+
 // InitializeConditions sets the initial values to the conditions.
 func (rs *RouteStatus) InitializeConditions() {
 	routeCondSet.Manage(rs).InitializeConditions()
@@ -92,6 +124,16 @@ func (rs *RouteStatus) MarkIngressRolloutInProgress() {
 		"RolloutInProgress", "A gradual rollout of the latest revision(s) is in progress.")
 }
 
+// MarkIngressRolloutPaused changes the IngressReady condition to be unknown to reflect
+// that a gradual rollout is paused manually at its current traffic split.
+func (rs *RouteStatus) MarkIngressRolloutPaused(by string) {
+	if by == "" {
+		by = "an unknown user"
+	}
+	routeCondSet.Manage(rs).MarkUnknown(RouteConditionIngressReady,
+		"RolloutPaused", "The gradual rollout of the latest revision(s) was paused by %s.", by)
+}
+
 // MarkIngressNotConfigured changes the IngressReady condition to be unknown to reflect
 // that the Ingress does not yet have a Status
 func (rs *RouteStatus) MarkIngressNotConfigured() {
@@ -182,6 +224,65 @@ func (rs *RouteStatus) MarkCertificateNotOwned(name string) {
 		"There is an existing certificate %s that we don't own.", name)
 }
 
+// MarkRolloutAnalysisPassed marks the RouteConditionRolloutAnalysis condition
+// to indicate the metrics of the revision being rolled out are within the thresholds.
+func (rs *RouteStatus) MarkRolloutAnalysisPassed(name string) {
+	routeCondSet.Manage(rs).MarkTrueWithReason(RouteConditionRolloutAnalysis,
+		"AnalysisPassed",
+		"Revision %q is within the rollout thresholds.", name)
+}
+
+// MarkRolloutAnalysisInconclusive marks the RouteConditionRolloutAnalysis
+// condition to indicate the rollout of the revision is paused until its
+// metrics are conclusive.
+func (rs *RouteStatus) MarkRolloutAnalysisInconclusive(name, msg string) {
+	routeCondSet.Manage(rs).MarkUnknown(RouteConditionRolloutAnalysis,
+		"AnalysisInconclusive",
+		"The rollout of revision %q is paused: %s", name, msg)
+}
+
+// MarkRolloutRolledBack marks the RouteConditionRolloutAnalysis condition to
+// indicate the traffic was reverted to the previous revision because the
+// revision being rolled out breached the thresholds.
+func (rs *RouteStatus) MarkRolloutRolledBack(name, previous, msg string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionRolloutAnalysis,
+		"RolledBack",
+		"The traffic was reverted from revision %q to %q: %s", name, previous, msg)
+}
+
+// ClearRolloutAnalysis removes the RouteConditionRolloutAnalysis condition
+// when no rollout is analyzed.
+func (rs *RouteStatus) ClearRolloutAnalysis() {
+	routeCondSet.Manage(rs).ClearCondition(RouteConditionRolloutAnalysis)
+}
+
+// MarkTrafficObserved marks the RouteConditionTrafficObserved condition to
+// indicate the observed split of the traffic is within the tolerance.
+func (rs *RouteStatus) MarkTrafficObserved() {
+	routeCondSet.Manage(rs).MarkTrue(RouteConditionTrafficObserved)
+}
+
+// MarkTrafficDeviation marks the RouteConditionTrafficObserved condition to
+// indicate the observed share of the revision deviates from the configured one.
+func (rs *RouteStatus) MarkTrafficDeviation(name string, observed, configured int64) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionTrafficObserved,
+		"TrafficDeviation",
+		"Revision %q serves %d%% of the requests instead of %d%%.", name, observed, configured)
+}
+
+// MarkTrafficObservationFailed marks the RouteConditionTrafficObserved
+// condition to indicate the traffic could not be observed.
+func (rs *RouteStatus) MarkTrafficObservationFailed(msg string) {
+	routeCondSet.Manage(rs).MarkUnknown(RouteConditionTrafficObserved,
+		"ObservationFailed", msg)
+}
+
+// ClearTrafficObserved removes the RouteConditionTrafficObserved condition
+// when the traffic is not observed.
+func (rs *RouteStatus) ClearTrafficObserved() {
+	routeCondSet.Manage(rs).ClearCondition(RouteConditionTrafficObserved)
+}
+
 const (
 	// AutoTLSNotEnabledMessage is the message which is set on the
 	// RouteConditionCertificateProvisioned condition when it is set to True
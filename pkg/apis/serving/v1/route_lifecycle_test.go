@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -491,6 +493,21 @@ func TestMarkInRollout(t *testing.T) {
 	apistest.CheckConditionOngoing(r, RouteConditionIngressReady, t)
 }
 
+func TestMarkInRolloutPaused(t *testing.T) {
+	r := &RouteStatus{}
+	r.InitializeConditions()
+	r.MarkIngressRolloutPaused("oveja@knative.dev")
+
+	apistest.CheckConditionOngoing(r, RouteConditionIngressReady, t)
+	c := r.GetCondition(RouteConditionIngressReady)
+	if got, want := c.Reason, "RolloutPaused"; got != want {
+		t.Errorf("Reason = %s, want: %s", got, want)
+	}
+	if got, want := c.Message, "The gradual rollout of the latest revision(s) was paused by oveja@knative.dev."; got != want {
+		t.Errorf("Message = %s, want: %s", got, want)
+	}
+}
+
 func TestRolloutDuration(t *testing.T) {
 	tests := []struct {
 		name string
@@ -524,3 +541,118 @@ func TestRolloutDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestRolloutStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		val  string
+		want serving.RolloutStrategy
+	}{{
+		name: "empty",
+		want: serving.RolloutStrategy{Type: serving.RolloutStrategyLinear},
+	}, {
+		name: "invalid",
+		val:  "not-a-duration",
+		want: serving.RolloutStrategy{Type: serving.RolloutStrategyLinear},
+	}, {
+		name: "duration",
+		val:  "120s",
+		want: serving.RolloutStrategy{Type: serving.RolloutStrategyLinear, Duration: 2 * time.Minute},
+	}, {
+		name: "blue/green",
+		val:  serving.RolloutBlueGreen,
+		want: serving.RolloutStrategy{Type: serving.RolloutStrategyBlueGreen, Duration: serving.DefaultBlueGreenDeadline},
+	}, {
+		name: "schedule",
+		val:  "10%:1m,100%",
+		want: serving.RolloutStrategy{
+			Type: serving.RolloutStrategySchedule,
+			Steps: []serving.RolloutStep{
+				{Percent: 10, Hold: time.Minute},
+				{Percent: 100},
+			},
+		},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						serving.RolloutDurationKey: tc.val,
+					},
+				},
+			}
+			if got, want := r.RolloutStrategy(), tc.want; !cmp.Equal(got, want) {
+				t.Error("RolloutStrategy (-want, +got):", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestRolloutPreWarm(t *testing.T) {
+	r := &Route{}
+	if r.RolloutPreWarm() {
+		t.Error("RolloutPreWarm = true without annotation")
+	}
+	r.Annotations = map[string]string{serving.RolloutPreWarmKey: "true"}
+	if !r.RolloutPreWarm() {
+		t.Error("RolloutPreWarm = false, want: true")
+	}
+	r.Annotations[serving.RolloutPreWarmKey] = "sure"
+	if r.RolloutPreWarm() {
+		t.Error("RolloutPreWarm = true for an invalid value")
+	}
+}
+
+func TestRolloutThresholds(t *testing.T) {
+	r := &Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				serving.RolloutMaxErrorRateKey: "0.1",
+			},
+		},
+	}
+	if got, want := r.RolloutThresholds().MaxErrorRate, 0.1; got != want {
+		t.Errorf("MaxErrorRate = %v, want: %v", got, want)
+	}
+
+	r.Annotations[serving.RolloutMaxErrorRateKey] = "lots"
+	if got := r.RolloutThresholds(); !got.IsEmpty() {
+		t.Errorf("RolloutThresholds = %#v, want empty", got)
+	}
+}
+
+func TestRolloutAnalysisFlow(t *testing.T) {
+	r := &RouteStatus{}
+	r.InitializeConditions()
+	r.MarkTrafficAssigned()
+	r.MarkTLSNotEnabled(AutoTLSNotEnabledMessage)
+	r.PropagateIngressStatus(netv1alpha1.IngressStatus{
+		Status: duckv1.Status{
+			Conditions: duckv1.Conditions{{
+				Type:   netv1alpha1.IngressConditionReady,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+	})
+
+	r.MarkRolloutAnalysisInconclusive("rev-2", "not enough requests")
+	apistest.CheckConditionOngoing(r, RouteConditionRolloutAnalysis, t)
+	apistest.CheckConditionSucceeded(r, RouteConditionReady, t)
+
+	r.MarkRolloutAnalysisPassed("rev-2")
+	apistest.CheckConditionSucceeded(r, RouteConditionRolloutAnalysis, t)
+
+	// The rollback does not make the Route fail, it still serves the traffic.
+	r.MarkRolloutRolledBack("rev-2", "rev-1", "error rate 0.5 exceeds 0.1")
+	apistest.CheckConditionFailed(r, RouteConditionRolloutAnalysis, t)
+	apistest.CheckConditionSucceeded(r, RouteConditionReady, t)
+	if got, want := r.GetCondition(RouteConditionRolloutAnalysis).Reason, "RolledBack"; got != want {
+		t.Errorf("Reason = %q, want: %q", got, want)
+	}
+
+	r.ClearRolloutAnalysis()
+	if c := r.GetCondition(RouteConditionRolloutAnalysis); c != nil {
+		t.Errorf("RolloutAnalysis condition = %#v, want nil", c)
+	}
+}
@@ -108,6 +108,58 @@ type TrafficTarget struct {
 	// a hostname, but may not contain anything else (e.g. basic auth, url path, etc.)
 	// +optional
 	URL *apis.URL `json:"url,omitempty"`
+
+	// ObservedPercent is the percentage of the requests recently observed
+	// to be served by the revision of this traffic target. ObservedPercent is
+	// displayed in status, and is disallowed on spec. The requests are
+	// observed in the metrics of the Prometheus server configured by the
+	// prometheus-url of the config-rollout-analysis ConfigMap, so
+	// ObservedPercent is only set when the cluster runs one scraping
+	// queue-proxy and traffic-observation-interval is set.
+	// +optional
+	ObservedPercent *int64 `json:"observedPercent,omitempty"`
+
+	// Match routes the requests to the main Route URL matching any of the
+	// rules to this target, ahead of the percentage based split, as if they
+	// were sent to the URL of its tag. Match requires a Tag, and is
+	// disallowed in status.
+	// +optional
+	Match []TrafficMatch `json:"match,omitempty"`
+
+	// Mirror sends a copy of a sample of the requests routed to this target
+	// to another revision, whose responses are discarded. All the requests
+	// routed to this target then go through the activator, which sends the
+	// copies, so the activator has to be scaled to carry its traffic. Copies
+	// are dropped rather than delaying the requests. Mirror is disallowed
+	// in status.
+	// +optional
+	Mirror *TrafficMirror `json:"mirror,omitempty"`
+}
+
+// TrafficMirror describes the revision receiving the copies of the requests
+// routed to a traffic target.
+type TrafficMirror struct {
+	// RevisionName of the revision receiving the copies of the requests.
+	RevisionName string `json:"revisionName"`
+
+	// Percent of the requests routed to the traffic target which are
+	// copied, between 1 and 100.
+	Percent int64 `json:"percent"`
+}
+
+// TrafficMatch is a rule matching requests by their attributes.
+// A request matches the rule when it matches all of its conditions.
+// Only exact header matches are supported, since the Ingress can't express
+// regex, cookie or query parameter matches.
+type TrafficMatch struct {
+	// Headers the request must carry, keyed by header name.
+	Headers map[string]HeaderMatch `json:"headers,omitempty"`
+}
+
+// HeaderMatch describes the value a request header must have.
+type HeaderMatch struct {
+	// Exact is the value the header must be equal to.
+	Exact string `json:"exact,omitempty"`
 }
 
 // RouteSpec holds the desired state of the Route (from the client).
@@ -135,6 +187,17 @@ const (
 	// RouteConditionCertificateProvisioned is set to False when the
 	// Knative Certificates fail to be provisioned for the Route.
 	RouteConditionCertificateProvisioned apis.ConditionType = "CertificateProvisioned"
+
+	// RouteConditionRolloutAnalysis reflects the verdict of the analysis of the
+	// metrics of the revision being rolled out. It is set to False when the
+	// rollout was reverted to the previous revision.
+	RouteConditionRolloutAnalysis apis.ConditionType = "RolloutAnalysis"
+
+	// RouteConditionTrafficObserved is set to False when the observed split
+	// of the traffic deviates from the configured one beyond the tolerance.
+	// It is not set when the traffic is not observed, which requires the
+	// Prometheus server of the config-rollout-analysis ConfigMap.
+	RouteConditionTrafficObserved apis.ConditionType = "TrafficObserved"
 )
 
 // IsRouteCondition returns true if the ConditionType is a route condition type
@@ -144,7 +207,9 @@ func IsRouteCondition(t apis.ConditionType) bool {
 		RouteConditionReady,
 		RouteConditionAllTrafficAssigned,
 		RouteConditionIngressReady,
-		RouteConditionCertificateProvisioned:
+		RouteConditionCertificateProvisioned,
+		RouteConditionRolloutAnalysis,
+		RouteConditionTrafficObserved:
 		return true
 	}
 	return false
@@ -19,6 +19,7 @@ package v1
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"k8s.io/apimachinery/pkg/util/validation"
 	network "knative.dev/networking/pkg"
@@ -32,6 +33,12 @@ func (r *Route) Validate(ctx context.Context) *apis.FieldError {
 		r.validateLabels().ViaField("labels"))
 	errs = errs.Also(serving.ValidateRolloutDurationAnnotation(
 		r.GetAnnotations()).ViaField("annotations"))
+	errs = errs.Also(serving.ValidateRolloutAnalysisAnnotations(
+		r.GetAnnotations()).ViaField("annotations"))
+	errs = errs.Also(serving.ValidateRolloutControlAnnotation(
+		r.GetAnnotations()).ViaField("annotations"))
+	errs = errs.Also(serving.ValidateRolloutPreWarmAnnotation(
+		r.GetAnnotations()).ViaField("annotations"))
 	errs = errs.ViaField("metadata")
 	errs = errs.Also(r.Spec.Validate(apis.WithinSpec(ctx)).ViaField("spec"))
 
@@ -53,9 +60,15 @@ func validateTrafficList(ctx context.Context, traffic []TrafficTarget) *apis.Fie
 	// Track the targets of named TrafficTarget entries (to detect duplicates).
 	trafficMap := make(map[string]int)
 
+	// Track the match rules of the TrafficTarget entries (to detect ambiguities).
+	var rules []trafficRule
+
 	sum := int64(0)
 	for i, tt := range traffic {
 		errs = errs.Also(tt.Validate(ctx).ViaIndex(i))
+		for j := range tt.Match {
+			rules = append(rules, trafficRule{target: i, index: j, match: &tt.Match[j]})
+		}
 
 		if tt.Percent != nil {
 			sum += *tt.Percent
@@ -90,9 +103,50 @@ func validateTrafficList(ctx context.Context, traffic []TrafficTarget) *apis.Fie
 			Paths:   []string{apis.CurrentField},
 		})
 	}
+	return errs.Also(validateMatchAmbiguity(traffic, rules))
+}
+
+// trafficRule is a match rule of a TrafficTarget in a traffic list.
+type trafficRule struct {
+	target, index int
+	match         *TrafficMatch
+}
+
+func (tr trafficRule) path() string {
+	return fmt.Sprintf("[%d].match[%d]", tr.target, tr.index)
+}
+
+// validateMatchAmbiguity validates that no request can match the rules of
+// two different traffic targets, i.e. that any two such rules require a
+// different value of some header.
+func validateMatchAmbiguity(traffic []TrafficTarget, rules []trafficRule) (errs *apis.FieldError) {
+	for i, a := range rules {
+		for _, b := range rules[i+1:] {
+			if a.target == b.target || a.match.excludes(b.match) {
+				continue
+			}
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Ambiguous match rules: a request may match both %q and %q",
+					traffic[a.target].Tag, traffic[b.target].Tag),
+				Paths: []string{a.path(), b.path()},
+			})
+		}
+	}
 	return errs
 }
 
+// excludes returns true if no request can match both rules.
+func (m *TrafficMatch) excludes(other *TrafficMatch) bool {
+	for name, h := range m.Headers {
+		for otherName, otherH := range other.Headers {
+			if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(otherName) && h.Exact != otherH.Exact {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Validate implements apis.Validatable
 func (rs *RouteSpec) Validate(ctx context.Context) *apis.FieldError {
 	return validateTrafficList(ctx, rs.Traffic).ViaField("traffic")
@@ -103,9 +157,26 @@ func (tt *TrafficTarget) Validate(ctx context.Context) *apis.FieldError {
 	errs := tt.validateLatestRevision(ctx)
 	errs = tt.validateRevisionAndConfiguration(ctx, errs)
 	errs = tt.validateTrafficPercentage(errs)
+	errs = tt.validateMatch(ctx, errs)
+	errs = tt.validateMirror(ctx, errs)
+	errs = tt.validateObservedPercent(ctx, errs)
 	return tt.validateURL(ctx, errs)
 }
 
+func (tt *TrafficTarget) validateObservedPercent(ctx context.Context, errs *apis.FieldError) *apis.FieldError {
+	if tt.ObservedPercent == nil {
+		return errs
+	}
+	// ObservedPercent is not allowed in traffic under spec.
+	if apis.IsInSpec(ctx) {
+		return errs.Also(apis.ErrDisallowedFields("observedPercent"))
+	}
+	if p := *tt.ObservedPercent; p < 0 || p > 100 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(p, 0, 100, "observedPercent"))
+	}
+	return errs
+}
+
 func (tt *TrafficTarget) validateRevisionAndConfiguration(ctx context.Context, errs *apis.FieldError) *apis.FieldError {
 	// We only validate the sense of latestRevision in the context of a Spec,
 	// and only when it is specified.
@@ -193,6 +264,71 @@ func (tt *TrafficTarget) validateURL(ctx context.Context, errs *apis.FieldError)
 	return errs
 }
 
+func (tt *TrafficTarget) validateMatch(ctx context.Context, errs *apis.FieldError) *apis.FieldError {
+	if len(tt.Match) == 0 {
+		return errs
+	}
+	// Match is not allowed in traffic under status.
+	if apis.IsInStatus(ctx) {
+		return errs.Also(apis.ErrDisallowedFields("match"))
+	}
+	// The matching requests are routed as if they were sent to the URL of
+	// the tag, so there must be one.
+	if tt.Tag == "" {
+		errs = errs.Also(apis.ErrGeneric("match rules require a tag", "tag"))
+	}
+	for i := range tt.Match {
+		errs = errs.Also(tt.Match[i].validate().ViaFieldIndex("match", i))
+	}
+	return errs
+}
+
+func (m *TrafficMatch) validate() (errs *apis.FieldError) {
+	if len(m.Headers) == 0 {
+		return apis.ErrMissingField("headers")
+	}
+	// Header names are case insensitive.
+	canonical := make(map[string]string, len(m.Headers))
+	for name, h := range m.Headers {
+		if msgs := validation.IsHTTPHeaderName(name); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidKeyName(name, "headers", msgs...))
+		}
+		if h.Exact == "" {
+			errs = errs.Also(apis.ErrMissingField("exact").ViaFieldKey("headers", name))
+		}
+		cn := http.CanonicalHeaderKey(name)
+		if other, ok := canonical[cn]; ok {
+			errs = errs.Also(apis.ErrMultipleOneOf(
+				"headers["+name+"]", "headers["+other+"]"))
+		}
+		canonical[cn] = name
+	}
+	return errs
+}
+
+func (tt *TrafficTarget) validateMirror(ctx context.Context, errs *apis.FieldError) *apis.FieldError {
+	if tt.Mirror == nil {
+		return errs
+	}
+	// Mirror is not allowed in traffic under status.
+	if apis.IsInStatus(ctx) {
+		return errs.Also(apis.ErrDisallowedFields("mirror"))
+	}
+	return errs.Also(tt.Mirror.validate().ViaField("mirror"))
+}
+
+func (m *TrafficMirror) validate() (errs *apis.FieldError) {
+	if m.RevisionName == "" {
+		errs = errs.Also(apis.ErrMissingField("revisionName"))
+	} else if el := validation.IsQualifiedName(m.RevisionName); len(el) > 0 {
+		errs = errs.Also(apis.ErrInvalidKeyName(m.RevisionName, "revisionName", el...))
+	}
+	if m.Percent < 1 || m.Percent > 100 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(m.Percent, 1, 100, "percent"))
+	}
+	return errs
+}
+
 func validateClusterVisibilityLabel(label string) *apis.FieldError {
 	if label != serving.VisibilityClusterLocal {
 		return apis.ErrInvalidValue(label, network.VisibilityLabelKey)
@@ -255,6 +255,111 @@ func TestTrafficTargetValidation(t *testing.T) {
 		},
 		wc:   apis.WithinSpec,
 		want: apis.ErrDisallowedFields("url"),
+	}, {
+		name: "valid match",
+		tt: &TrafficTarget{
+			Tag:          "beta",
+			RevisionName: "bar",
+			Percent:      ptr.Int64(0),
+			Match: []TrafficMatch{{
+				Headers: map[string]HeaderMatch{"X-User-Group": {Exact: "beta"}},
+			}},
+		},
+		wc: apis.WithinSpec,
+	}, {
+		name: "match without tag",
+		tt: &TrafficTarget{
+			RevisionName: "bar",
+			Percent:      ptr.Int64(0),
+			Match: []TrafficMatch{{
+				Headers: map[string]HeaderMatch{"X-User-Group": {Exact: "beta"}},
+			}},
+		},
+		wc:   apis.WithinSpec,
+		want: apis.ErrGeneric("match rules require a tag", "tag"),
+	}, {
+		name: "invalid match rules",
+		tt: &TrafficTarget{
+			Tag:          "beta",
+			RevisionName: "bar",
+			Percent:      ptr.Int64(0),
+			Match: []TrafficMatch{{}, {
+				Headers: map[string]HeaderMatch{
+					"X User": {Exact: "beta"},
+					"x-zone": {},
+				},
+			}, {
+				Headers: map[string]HeaderMatch{
+					"x-region": {Exact: "eu"},
+					"X-Region": {Exact: "us"},
+				},
+			}},
+		},
+		wc: apis.WithinSpec,
+		want: apis.ErrMissingField("match[0].headers").Also(
+			apis.ErrInvalidKeyName("X User", "match[1].headers",
+				"a valid HTTP header must consist of alphanumeric characters or '-' (e.g. 'X-Header-Name', regex used for validation is '[-A-Za-z0-9]+')"),
+			apis.ErrMissingField("match[1].headers[x-zone].exact"),
+			apis.ErrMultipleOneOf("match[2].headers[X-Region]", "match[2].headers[x-region]")),
+	}, {
+		name: "disallowed match in status",
+		tt: &TrafficTarget{
+			Tag:          "beta",
+			RevisionName: "bar",
+			Percent:      ptr.Int64(0),
+			URL: &apis.URL{
+				Scheme: "http",
+				Host:   "beta.blah.com",
+			},
+			Match: []TrafficMatch{{
+				Headers: map[string]HeaderMatch{"X-User-Group": {Exact: "beta"}},
+			}},
+		},
+		wc:   apis.WithinStatus,
+		want: apis.ErrDisallowedFields("match"),
+	}, {
+		name: "valid mirror",
+		tt: &TrafficTarget{
+			RevisionName: "bar",
+			Percent:      ptr.Int64(100),
+			Mirror: &TrafficMirror{
+				RevisionName: "baz",
+				Percent:      10,
+			},
+		},
+	}, {
+		name: "mirror without revision",
+		tt: &TrafficTarget{
+			RevisionName: "bar",
+			Percent:      ptr.Int64(100),
+			Mirror: &TrafficMirror{
+				Percent: 10,
+			},
+		},
+		want: apis.ErrMissingField("mirror.revisionName"),
+	}, {
+		name: "mirror percent out of bounds",
+		tt: &TrafficTarget{
+			RevisionName: "bar",
+			Percent:      ptr.Int64(100),
+			Mirror: &TrafficMirror{
+				RevisionName: "baz",
+				Percent:      101,
+			},
+		},
+		want: apis.ErrOutOfBoundsValue(101, 1, 100, "mirror.percent"),
+	}, {
+		name: "disallowed mirror in status",
+		tt: &TrafficTarget{
+			RevisionName: "bar",
+			Percent:      ptr.Int64(100),
+			Mirror: &TrafficMirror{
+				RevisionName: "baz",
+				Percent:      10,
+			},
+		},
+		wc:   apis.WithinStatus,
+		want: apis.ErrDisallowedFields("mirror"),
 	}}
 
 	for _, test := range tests {
@@ -455,6 +560,72 @@ func TestRouteValidation(t *testing.T) {
 			Message: "invalid value: not a DNS 1035 label: [a DNS-1035 label must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character (e.g. 'my-name',  or 'abc-123', regex used for validation is '[a-z]([-a-z0-9]*[a-z0-9])?')]",
 			Paths:   []string{"spec.traffic.tag[0]"},
 		},
+	}, {
+		name: "exclusive match rules",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      ptr.Int64(100),
+				}, {
+					Tag:          "beta",
+					RevisionName: "bar",
+					Percent:      ptr.Int64(0),
+					Match: []TrafficMatch{{
+						Headers: map[string]HeaderMatch{"X-User-Group": {Exact: "beta"}},
+					}, {
+						Headers: map[string]HeaderMatch{"X-Region": {Exact: "eu"}},
+					}},
+				}, {
+					Tag:          "staff",
+					RevisionName: "baz",
+					Percent:      ptr.Int64(0),
+					Match: []TrafficMatch{{
+						Headers: map[string]HeaderMatch{
+							"x-user-group": {Exact: "staff"},
+							"X-Region":     {Exact: "us"},
+						},
+					}},
+				}},
+			},
+		},
+	}, {
+		name: "ambiguous match rules",
+		r: &Route{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "valid",
+			},
+			Spec: RouteSpec{
+				Traffic: []TrafficTarget{{
+					RevisionName: "foo",
+					Percent:      ptr.Int64(100),
+				}, {
+					Tag:          "beta",
+					RevisionName: "bar",
+					Percent:      ptr.Int64(0),
+					Match: []TrafficMatch{{
+						Headers: map[string]HeaderMatch{"X-User-Group": {Exact: "beta"}},
+					}},
+				}, {
+					Tag:          "eu",
+					RevisionName: "baz",
+					Percent:      ptr.Int64(0),
+					Match: []TrafficMatch{{
+						Headers: map[string]HeaderMatch{"X-Region": {Exact: "eu"}},
+					}},
+				}},
+			},
+		},
+		want: &apis.FieldError{
+			Message: `Ambiguous match rules: a request may match both "beta" and "eu"`,
+			Paths: []string{
+				"spec.traffic[1].match[0]",
+				"spec.traffic[2].match[0]",
+			},
+		},
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
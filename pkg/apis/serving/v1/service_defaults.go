@@ -29,9 +29,12 @@ func (s *Service) SetDefaults(ctx context.Context) {
 	s.Spec.SetDefaults(apis.WithinSpec(ctx))
 
 	if apis.IsInUpdate(ctx) {
-		serving.SetUserInfo(ctx, apis.GetBaseline(ctx).(*Service).Spec, s.Spec, s)
+		base := apis.GetBaseline(ctx).(*Service)
+		serving.SetUserInfo(ctx, base.Spec, s.Spec, s)
+		serving.SetRolloutControlModifier(ctx, base.Annotations, s)
 	} else {
 		serving.SetUserInfo(ctx, nil, s.Spec, s)
+		serving.SetRolloutControlModifier(ctx, nil, s)
 	}
 }
 
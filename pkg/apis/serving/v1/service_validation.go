@@ -35,6 +35,12 @@ func (s *Service) Validate(ctx context.Context) (errs *apis.FieldError) {
 		errs = errs.Also(s.validateLabels().ViaField("labels"))
 		errs = errs.Also(serving.ValidateRolloutDurationAnnotation(
 			s.GetAnnotations()).ViaField("annotations"))
+		errs = errs.Also(serving.ValidateRolloutAnalysisAnnotations(
+			s.GetAnnotations()).ViaField("annotations"))
+		errs = errs.Also(serving.ValidateRolloutControlAnnotation(
+			s.GetAnnotations()).ViaField("annotations"))
+		errs = errs.Also(serving.ValidateRolloutPreWarmAnnotation(
+			s.GetAnnotations()).ViaField("annotations"))
 		errs = errs.ViaField("metadata")
 
 		ctx = apis.WithinParent(ctx, s.ObjectMeta)
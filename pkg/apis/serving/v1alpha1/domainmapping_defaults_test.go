@@ -75,6 +75,53 @@ func TestDomainMappingDefaulting(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "empty path ref namespace",
+		in: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "some-namespace",
+			},
+			Spec: DomainMappingSpec{
+				Ref: duckv1.KReference{
+					Namespace: "explicit-namespace",
+				},
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name: "api",
+					},
+				}, {
+					Prefix: "/web",
+					Ref: duckv1.KReference{
+						Name:      "web",
+						Namespace: "explicit-namespace",
+					},
+				}},
+			},
+		},
+		out: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "some-namespace",
+			},
+			Spec: DomainMappingSpec{
+				Ref: duckv1.KReference{
+					Namespace: "explicit-namespace",
+				},
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name:      "api",
+						Namespace: "some-namespace",
+					},
+				}, {
+					Prefix: "/web",
+					Ref: duckv1.KReference{
+						Name:      "web",
+						Namespace: "explicit-namespace",
+					},
+				}},
+			},
+		},
 	}}
 
 	for _, test := range tests {
@@ -112,6 +112,22 @@ func (dms *DomainMappingStatus) MarkHTTPDowngrade(name string) {
 		"Certificate %s is not ready downgrade HTTP.", name)
 }
 
+// MarkCertificateSecretReady marks the
+// DomainMappingConditionCertificateProvisioned condition to indicate that the
+// Secret configured by spec.tls holds a valid certificate.
+func (dms *DomainMappingStatus) MarkCertificateSecretReady(name string) {
+	domainMappingCondSet.Manage(dms).MarkTrue(DomainMappingConditionCertificateProvisioned)
+}
+
+// MarkCertificateSecretInvalid marks the
+// DomainMappingConditionCertificateProvisioned condition to indicate that the
+// Secret configured by spec.tls does not hold a valid certificate.
+func (dms *DomainMappingStatus) MarkCertificateSecretInvalid(name, reason string) {
+	domainMappingCondSet.Manage(dms).MarkFalse(DomainMappingConditionCertificateProvisioned,
+		"CertificateSecretInvalid",
+		"Secret %s does not hold a valid certificate: %s", name, reason)
+}
+
 // MarkIngressNotConfigured changes the IngressReady condition to be unknown to reflect
 // that the Ingress does not yet have a Status.
 func (dms *DomainMappingStatus) MarkIngressNotConfigured() {
@@ -173,6 +173,21 @@ func TestDomainMappingHTTPDowngrade(t *testing.T) {
 	apistest.CheckConditionSucceeded(dms, DomainMappingConditionCertificateProvisioned, t)
 }
 
+func TestDomainMappingCertificateSecret(t *testing.T) {
+	dms := &DomainMappingStatus{}
+	dms.InitializeConditions()
+	dms.MarkCertificateSecretInvalid("my-cert", "expired")
+
+	apistest.CheckConditionFailed(dms, DomainMappingConditionCertificateProvisioned, t)
+	if got, want := dms.GetCondition(DomainMappingConditionCertificateProvisioned).Message,
+		"Secret my-cert does not hold a valid certificate: expired"; got != want {
+		t.Errorf("Message = %q, want: %q", got, want)
+	}
+
+	dms.MarkCertificateSecretReady("my-cert")
+	apistest.CheckConditionSucceeded(dms, DomainMappingConditionCertificateProvisioned, t)
+}
+
 func TestPropagateIngressStatus(t *testing.T) {
 	dms := &DomainMappingStatus{}
 
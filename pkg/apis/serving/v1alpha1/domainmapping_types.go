@@ -74,14 +74,61 @@ type DomainMappingList struct {
 type DomainMappingSpec struct {
 	// Ref specifies the target of the Domain Mapping.
 	//
-	// The object identified by the Ref must be an Addressable with a URL of the
-	// form `{name}.{namespace}.{domain}` where `{domain}` is the cluster domain,
-	// and `{name}` and `{namespace}` are the name and namespace of a Kubernetes
-	// Service.
+	// The object identified by the Ref must be an Addressable whose URL has no
+	// path, such as a Knative Service or Route, a Kubernetes Service, or any
+	// other Addressable resource.
 	//
-	// This contract is satisfied by Knative types such as Knative Services and
-	// Knative Routes, and by Kubernetes Services.
+	// The Ref may be in another namespace if the DomainMappingGrantName
+	// ConfigMap of that namespace permits the namespace of the DomainMapping.
+	//
+	// The requests not matching any of the Paths are sent to Ref.
+	Ref duckv1.KReference `json:"ref"`
+
+	// Tag selects a traffic tag of the Knative Service or Route identified by
+	// Ref. The requests are then sent to the revisions of the tag.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Paths routes the requests whose path starts with one of the prefixes to
+	// other targets. The longest matching prefix wins.
+	// +optional
+	Paths []DomainMappingPath `json:"paths,omitempty"`
+
+	// TLS configures a TLS certificate provided for the domain, which takes
+	// precedence over the certificate auto-TLS would provision.
+	// +optional
+	TLS *DomainMappingTLS `json:"tls,omitempty"`
+}
+
+// DomainMappingTLS configures the TLS certificate of a DomainMapping.
+type DomainMappingTLS struct {
+	// SecretName is the name of the Secret of type kubernetes.io/tls holding
+	// the certificate, in the namespace of the DomainMapping. The certificate
+	// must cover the domain of the DomainMapping, and the Secret must carry
+	// the serving.knative.dev/domainMappingTLS label.
+	SecretName string `json:"secretName"`
+}
+
+// DomainMappingPath routes the requests matching a path prefix to a target.
+type DomainMappingPath struct {
+	// Prefix is the path prefix of the requests routed to the target,
+	// e.g. "/api".
+	Prefix string `json:"prefix"`
+
+	// Ref specifies the target of the requests, with the same contract as
+	// the Ref of the DomainMappingSpec.
 	Ref duckv1.KReference `json:"ref"`
+
+	// Tag selects a traffic tag of the Knative Service or Route identified by
+	// Ref, like the Tag of the DomainMappingSpec.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Rewrite replaces the Prefix of the path of the requests before they
+	// are sent to the target, e.g. "/". The path is rewritten by the
+	// revisions of the target, so it requires a Knative Service or Route.
+	// +optional
+	Rewrite *string `json:"rewrite,omitempty"`
 }
 
 // DomainMappingStatus describes the current state of the DomainMapping.
@@ -95,6 +142,11 @@ type DomainMappingStatus struct {
 	// Address holds the information needed for a DomainMapping to be the target of an event.
 	// +optional
 	Address *duckv1.Addressable `json:"address,omitempty"`
+
+	// CertificateExpiry is the time the certificate configured by spec.tls
+	// expires.
+	// +optional
+	CertificateExpiry *metav1.Time `json:"certificateExpiry,omitempty"`
 }
 
 const (
@@ -119,6 +171,18 @@ const (
 	DomainMappingConditionCertificateProvisioned apis.ConditionType = "CertificateProvisioned"
 )
 
+const (
+	// DomainMappingGrantName is the name of the ConfigMap that permits the
+	// DomainMappings of other namespaces to target the Addressables of its
+	// namespace.
+	DomainMappingGrantName = "domainmapping-grant"
+
+	// DomainMappingGrantNamespacesKey is the key of the DomainMappingGrantName
+	// ConfigMap listing the permitted namespaces, separated by commas.
+	// "*" permits all the namespaces.
+	DomainMappingGrantNamespacesKey = "namespaces"
+)
+
 // GetStatus retrieves the status of the DomainMapping. Implements the KRShaped interface.
 func (dm *DomainMapping) GetStatus() *duckv1.Status {
 	return &dm.Status.Status
@@ -19,11 +19,14 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/network"
 	"knative.dev/serving/pkg/apis/serving"
 )
@@ -69,5 +72,103 @@ func (dm *DomainMapping) validateMetadata(ctx context.Context) (errs *apis.Field
 
 // Validate makes sure the DomainMappingSpec is properly configured.
 func (spec *DomainMappingSpec) Validate(ctx context.Context) *apis.FieldError {
-	return spec.Ref.Validate(ctx).ViaField("ref")
+	// The refs may be in other namespaces, which the reconciler permits
+	// only when granted by the namespace of the target.
+	ctx = apis.AllowDifferentNamespace(ctx)
+	errs := spec.Ref.Validate(ctx).ViaField("ref")
+	errs = errs.Also(validateTag(spec.Tag, &spec.Ref))
+	prefixes := make(map[string]int, len(spec.Paths))
+	for i, p := range spec.Paths {
+		errs = errs.Also(p.Validate(ctx).ViaFieldIndex("paths", i))
+		if j, ok := prefixes[p.Prefix]; ok {
+			errs = errs.Also(&apis.FieldError{
+				Message: fmt.Sprintf("Multiple definitions for prefix %q", p.Prefix),
+				Paths:   []string{fmt.Sprintf("paths[%d].prefix", j), fmt.Sprintf("paths[%d].prefix", i)},
+			})
+		} else {
+			prefixes[p.Prefix] = i
+		}
+	}
+	if spec.TLS != nil {
+		errs = errs.Also(spec.TLS.Validate(ctx).ViaField("tls"))
+	}
+	return errs
+}
+
+// Validate makes sure the DomainMappingTLS is properly configured.
+func (t *DomainMappingTLS) Validate(context.Context) *apis.FieldError {
+	if t.SecretName == "" {
+		return apis.ErrMissingField("secretName")
+	}
+	if msgs := validation.IsDNS1123Subdomain(t.SecretName); len(msgs) > 0 {
+		return apis.ErrInvalidValue(fmt.Sprint("not a DNS 1123 subdomain: ", msgs), "secretName")
+	}
+	return nil
+}
+
+// Validate makes sure the DomainMappingPath is properly configured.
+func (p *DomainMappingPath) Validate(ctx context.Context) *apis.FieldError {
+	errs := validatePath(p.Prefix, "prefix")
+	if p.Prefix == "/" {
+		errs = errs.Also(&apis.FieldError{
+			Message: "invalid value: /",
+			Paths:   []string{"prefix"},
+			Details: "the requests not matching any prefix are sent to spec.ref",
+		})
+	}
+	if p.Rewrite != nil {
+		errs = errs.Also(validatePath(*p.Rewrite, "rewrite"))
+		// The path is rewritten by the queue-proxy of the revisions.
+		if !isServiceOrRoute(&p.Ref) {
+			errs = errs.Also(&apis.FieldError{
+				Message: "invalid value: " + p.Ref.Kind,
+				Paths:   []string{"ref.kind"},
+				Details: "a rewrite requires a Knative Service or Route",
+			})
+		}
+	}
+	errs = errs.Also(validateTag(p.Tag, &p.Ref))
+	return errs.Also(p.Ref.Validate(ctx).ViaField("ref"))
+}
+
+// validateTag validates that the tag is a DNS label, and that the ref is
+// a Knative Service or Route when a tag is set.
+func validateTag(tag string, ref *duckv1.KReference) *apis.FieldError {
+	if tag == "" {
+		return nil
+	}
+	var errs *apis.FieldError
+	if msgs := validation.IsDNS1035Label(tag); len(msgs) > 0 {
+		errs = apis.ErrInvalidValue(fmt.Sprint("not a DNS 1035 label: ", msgs), "tag")
+	}
+	if !isServiceOrRoute(ref) {
+		errs = errs.Also(&apis.FieldError{
+			Message: "invalid value: " + ref.Kind,
+			Paths:   []string{"ref.kind"},
+			Details: "a tag requires a Knative Service or Route",
+		})
+	}
+	return errs
+}
+
+// isServiceOrRoute returns whether the ref is a Knative Service or Route.
+// An invalid APIVersion is reported by the validation of the ref.
+func isServiceOrRoute(ref *duckv1.KReference) bool {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err != nil || (gv.Group == serving.GroupName && (ref.Kind == "Service" || ref.Kind == "Route"))
+}
+
+// validatePath validates that the value is an absolute URL path.
+func validatePath(v, field string) *apis.FieldError {
+	if v == "" {
+		return apis.ErrMissingField(field)
+	}
+	if u, err := url.Parse(v); err != nil || !strings.HasPrefix(v, "/") || u.Path != v {
+		return &apis.FieldError{
+			Message: "invalid value: " + v,
+			Paths:   []string{field},
+			Details: "must be an absolute URL path",
+		}
+	}
+	return nil
 }
@@ -24,9 +24,19 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
 	"knative.dev/serving/pkg/apis/serving"
 )
 
+func ksvcRef(name string) duckv1.KReference {
+	return duckv1.KReference{
+		Name:       name,
+		Namespace:  "ns",
+		Kind:       "Service",
+		APIVersion: "serving.knative.dev/v1",
+	}
+}
+
 func TestDomainMappingValidation(t *testing.T) {
 	tests := []struct {
 		name string
@@ -68,21 +78,16 @@ func TestDomainMappingValidation(t *testing.T) {
 			},
 		},
 	}, {
-		name: "ref in wrong namespace",
-		want: &apis.FieldError{
-			Paths:   []string{"spec.ref.namespace"},
-			Details: `parent namespace: "good-namespace" does not match ref: "bad-namespace"`,
-			Message: `mismatched namespaces`,
-		},
+		name: "ref in another namespace",
 		dm: &DomainMapping{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      "wrong-ref-ns.example.com",
+				Name:      "other-ref-ns.example.com",
 				Namespace: "good-namespace",
 			},
 			Spec: DomainMappingSpec{
 				Ref: duckv1.KReference{
 					Name:       "some-name",
-					Namespace:  "bad-namespace",
+					Namespace:  "other-namespace",
 					APIVersion: "serving.knative.dev/v1",
 					Kind:       "Service",
 				},
@@ -137,6 +142,206 @@ func TestDomainMappingValidation(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "paths",
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "paths.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Paths: []DomainMappingPath{{
+					Prefix:  "/api",
+					Ref:     ksvcRef("api"),
+					Rewrite: ptr.String("/"),
+				}, {
+					Prefix: "/static/",
+					Ref:    ksvcRef("static"),
+				}},
+			},
+		},
+	}, {
+		name: "tags",
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tags.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Tag: "latest",
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name:       "api",
+						Namespace:  "ns",
+						Kind:       "Route",
+						APIVersion: "serving.knative.dev/v1",
+					},
+					Tag: "candidate",
+				}},
+			},
+		},
+	}, {
+		name: "invalid tags",
+		want: apis.ErrInvalidValue("not a DNS 1035 label: [a DNS-1035 label must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character (e.g. 'my-name',  or 'abc-123', regex used for validation is '[a-z]([-a-z0-9]*[a-z0-9])?')]", "spec.tag").Also(&apis.FieldError{
+			Message: "invalid value: Service",
+			Paths:   []string{"spec.paths[0].ref.kind"},
+			Details: "a tag requires a Knative Service or Route",
+		}),
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalid-tags.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Tag: "Latest",
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name:       "api",
+						Namespace:  "ns",
+						Kind:       "Service",
+						APIVersion: "v1",
+					},
+					Tag: "candidate",
+				}},
+			},
+		},
+	}, {
+		name: "rewrite on a Route",
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rewrite.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name:       "api",
+						Namespace:  "ns",
+						Kind:       "Route",
+						APIVersion: "serving.knative.dev/v1",
+					},
+					Rewrite: ptr.String("/"),
+				}},
+			},
+		},
+	}, {
+		name: "rewrite on a K8s Service",
+		want: &apis.FieldError{
+			Message: "invalid value: Service",
+			Paths:   []string{"spec.paths[0].ref.kind"},
+			Details: "a rewrite requires a Knative Service or Route",
+		},
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rewrite.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Paths: []DomainMappingPath{{
+					Prefix: "/api",
+					Ref: duckv1.KReference{
+						Name:       "api",
+						Namespace:  "ns",
+						Kind:       "Service",
+						APIVersion: "v1",
+					},
+					Rewrite: ptr.String("/"),
+				}},
+			},
+		},
+	}, {
+		name: "tls",
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tls.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				TLS: &DomainMappingTLS{SecretName: "tls-example-com"},
+			},
+		},
+	}, {
+		name: "tls missing secretName",
+		want: apis.ErrMissingField("spec.tls.secretName"),
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tls.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				TLS: &DomainMappingTLS{},
+			},
+		},
+	}, {
+		name: "tls invalid secretName",
+		want: apis.ErrInvalidValue("not a DNS 1123 subdomain: [a DNS-1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')]", "spec.tls.secretName"),
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tls.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				TLS: &DomainMappingTLS{SecretName: "Not_A_Secret"},
+			},
+		},
+	}, {
+		name: "invalid paths",
+		want: (&apis.FieldError{
+			Message: "invalid value: api",
+			Paths:   []string{"spec.paths[0].prefix"},
+			Details: "must be an absolute URL path",
+		}).Also(&apis.FieldError{
+			Message: "invalid value: /",
+			Paths:   []string{"spec.paths[1].prefix"},
+			Details: "the requests not matching any prefix are sent to spec.ref",
+		}).Also(&apis.FieldError{
+			Message: "invalid value: /v1?q",
+			Paths:   []string{"spec.paths[2].rewrite"},
+			Details: "must be an absolute URL path",
+		}).Also(apis.ErrMissingField("spec.paths[3].prefix", "spec.paths[4].ref.kind")).Also(&apis.FieldError{
+			Message: `Multiple definitions for prefix "/v1"`,
+			Paths:   []string{"spec.paths[2].prefix", "spec.paths[4].prefix"},
+		}),
+		dm: &DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "invalid-paths.example.com",
+				Namespace: "ns",
+			},
+			Spec: DomainMappingSpec{
+				Ref: ksvcRef("web"),
+				Paths: []DomainMappingPath{{
+					Prefix: "api",
+					Ref:    ksvcRef("api"),
+				}, {
+					Prefix: "/",
+					Ref:    ksvcRef("root"),
+				}, {
+					Prefix:  "/v1",
+					Ref:     ksvcRef("v1"),
+					Rewrite: ptr.String("/v1?q"),
+				}, {
+					Ref: ksvcRef("none"),
+				}, {
+					Prefix: "/v1",
+					Ref: duckv1.KReference{
+						Name:       "v1-again",
+						Namespace:  "ns",
+						APIVersion: "serving.knative.dev/v1",
+					},
+				}},
+			},
+		},
 	}}
 
 	for _, test := range tests {
@@ -47,7 +47,7 @@ func (in *DomainMapping) DeepCopyInto(out *DomainMapping) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -103,10 +103,44 @@ func (in *DomainMappingList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DomainMappingPath) DeepCopyInto(out *DomainMappingPath) {
+	*out = *in
+	out.Ref = in.Ref
+	if in.Rewrite != nil {
+		in, out := &in.Rewrite, &out.Rewrite
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainMappingPath.
+func (in *DomainMappingPath) DeepCopy() *DomainMappingPath {
+	if in == nil {
+		return nil
+	}
+	out := new(DomainMappingPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DomainMappingSpec) DeepCopyInto(out *DomainMappingSpec) {
 	*out = *in
 	out.Ref = in.Ref
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]DomainMappingPath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(DomainMappingTLS)
+		**out = **in
+	}
 	return
 }
 
@@ -134,6 +168,10 @@ func (in *DomainMappingStatus) DeepCopyInto(out *DomainMappingStatus) {
 		*out = new(v1.Addressable)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CertificateExpiry != nil {
+		in, out := &in.CertificateExpiry, &out.CertificateExpiry
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -230,6 +230,101 @@ func (t *TimedFloat64Buckets) WindowAverage(now time.Time) float64 {
 	}
 }
 
+// Forecast fits a linear trend to the bucket values over the window using
+// the least squares method and returns the value of that trend extrapolated
+// `horizon` past `now`.
+//
+// Only the buckets between the first and the last write are used for the fit,
+// in the same way as they are for the WindowAverage. If there is a single
+// such bucket, its value is returned as is. Since the metrics we forecast
+// can't be negative, neither can the result.
+func (t *TimedFloat64Buckets) Forecast(now time.Time, horizon time.Duration) float64 {
+	now = now.Truncate(t.granularity)
+	t.bucketsMutex.RLock()
+	defer t.bucketsMutex.RUnlock()
+	if t.isEmptyLocked(now) {
+		return 0
+	}
+
+	// The number of buckets between now and the last write, which are not
+	// part of the data, but shift the x-coordinates of the data points.
+	gap := int(now.Sub(t.lastWrite) / t.granularity)
+	numB := min(int(t.lastWrite.Sub(t.firstWrite)/t.granularity)+1, len(t.buckets)-max(gap, 0))
+	if numB <= 0 {
+		return 0
+	}
+
+	// x is measured in buckets relative to now, i.e. the data points
+	// lie at non-positive x and the forecast is computed at x = horizon.
+	var sx, sy, sxx, sxy float64
+	lastIdx := t.timeToIndex(t.lastWrite) + len(t.buckets) // To ensure always positive % operation.
+	for i := 0; i < numB; i++ {
+		x := -float64(gap + i)
+		y := t.buckets[(lastIdx-i)%len(t.buckets)]
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	n := float64(numB)
+	if numB == 1 {
+		return roundToNDigits(precision, math.Max(0, sy))
+	}
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	intercept := (sy - slope*sx) / n
+	return roundToNDigits(precision, math.Max(0, intercept+slope*float64(horizon/t.granularity)))
+}
+
+// Snapshot is a serializable copy of the data recorded in the buckets.
+type Snapshot struct {
+	// LastWrite is the time of the last value in Values.
+	LastWrite time.Time `json:"lastWrite"`
+	// Values are the bucket values, oldest first, one per granularity.
+	Values []float64 `json:"values"`
+}
+
+// Snapshot returns a copy of the data recorded within the window as of `now`.
+// An empty snapshot is returned if there is no such data.
+func (t *TimedFloat64Buckets) Snapshot(now time.Time) Snapshot {
+	now = now.Truncate(t.granularity)
+	t.bucketsMutex.RLock()
+	defer t.bucketsMutex.RUnlock()
+	if t.isEmptyLocked(now) {
+		return Snapshot{}
+	}
+
+	numB := min(int(t.lastWrite.Sub(t.firstWrite)/t.granularity)+1, len(t.buckets))
+	values := make([]float64, numB)
+	lastIdx := t.timeToIndex(t.lastWrite) + len(t.buckets) // To ensure always positive % operation.
+	for i := 0; i < numB; i++ {
+		values[numB-1-i] = t.buckets[(lastIdx-i)%len(t.buckets)]
+	}
+	return Snapshot{
+		LastWrite: t.lastWrite,
+		Values:    values,
+	}
+}
+
+// Restore records the data from the snapshot next to the data already
+// present in the buckets, as if it was recorded at the original times.
+// The values falling into the buckets which already hold data are skipped,
+// so that the same samples are not counted twice.
+// The snapshot is expected to be taken from buckets of the same granularity.
+func (t *TimedFloat64Buckets) Restore(s Snapshot) {
+	t.bucketsMutex.Lock()
+	defer t.bucketsMutex.Unlock()
+
+	firstWrite, lastWrite := t.firstWrite, t.lastWrite
+	for i, v := range s.Values {
+		tm := s.LastWrite.Add(-time.Duration(len(s.Values)-1-i) * t.granularity)
+		if bucketTime := tm.Truncate(t.granularity); !firstWrite.IsZero() &&
+			!bucketTime.Before(firstWrite) && !bucketTime.After(lastWrite) {
+			continue
+		}
+		t.recordLocked(tm, v)
+	}
+}
+
 // timeToIndex converts time to an integer that can be used for modulo
 // operations to find the index in the bucket list.
 // bucketMutex needs to be held.
@@ -247,11 +342,14 @@ func (t *TimedFloat64Buckets) timeToIndex(tm time.Time) int {
 // meaning the WindowAverage will be of a partial window until enough data is
 // received to fill it again.
 func (t *TimedFloat64Buckets) Record(now time.Time, value float64) {
-	bucketTime := now.Truncate(t.granularity)
-
 	t.bucketsMutex.Lock()
 	defer t.bucketsMutex.Unlock()
+	t.recordLocked(now, value)
+}
 
+// recordLocked records the value, bucketsMutex needs to be held.
+func (t *TimedFloat64Buckets) recordLocked(now time.Time, value float64) {
+	bucketTime := now.Truncate(t.granularity)
 	writeIdx := t.timeToIndex(now)
 
 	if t.lastWrite != bucketTime {
@@ -303,6 +401,13 @@ func min(a, b int) int {
 	return b
 }
 
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // ResizeWindow implements window resizing for the weighted averaging buckets object.
 func (t *WeightedFloat64Buckets) ResizeWindow(w time.Duration) {
 	t.TimedFloat64Buckets.ResizeWindow(w)
@@ -324,6 +324,121 @@ func TestTimedFloat64BucketsWindowAverage(t *testing.T) {
 	}
 }
 
+func TestTimedFloat64BucketsForecast(t *testing.T) {
+	now := time.Now()
+	buckets := NewTimedFloat64Buckets(5*time.Second, granularity)
+
+	if got, want := buckets.Forecast(now, 3*time.Second), 0.; got != want {
+		t.Errorf("Forecast with no data = %v, want: %v", got, want)
+	}
+
+	// A single data point has no trend.
+	buckets.Record(now, 1)
+	if got, want := buckets.Forecast(now, 3*time.Second), 1.; got != want {
+		t.Errorf("Forecast = %v, want: %v", got, want)
+	}
+	for i := 1; i < 5; i++ {
+		buckets.Record(now.Add(time.Duration(i)*time.Second), float64(i+1))
+	}
+
+	// The values grow by 1 every second.
+	if got, want := buckets.Forecast(now.Add(4*time.Second), 0), 5.; got != want {
+		t.Errorf("Forecast with no horizon = %v, want: %v", got, want)
+	}
+	if got, want := buckets.Forecast(now.Add(4*time.Second), 3*time.Second), 8.; got != want {
+		t.Errorf("Forecast = %v, want: %v", got, want)
+	}
+
+	// Check with short hole: the trend is computed over the buckets still
+	// in the window, but extrapolated from the current time.
+	if got, want := buckets.Forecast(now.Add(6*time.Second), 3*time.Second), 10.; got != want {
+		t.Errorf("Forecast with hole = %v, want: %v", got, want)
+	}
+
+	// Check with a long hole.
+	if got, want := buckets.Forecast(now.Add(10*time.Second), 3*time.Second), 0.; got != want {
+		t.Errorf("Forecast with long hole = %v, want: %v", got, want)
+	}
+
+	// Decreasing trend is never forecasted below 0.
+	now = now.Add(time.Minute)
+	for i := 0; i < 5; i++ {
+		buckets.Record(now.Add(time.Duration(i)*time.Second), float64(10-2*i))
+	}
+	if got, want := buckets.Forecast(now.Add(4*time.Second), time.Second), 0.; got != want {
+		t.Errorf("Forecast with decreasing trend = %v, want: %v", got, want)
+	}
+	if got, want := buckets.Forecast(now.Add(4*time.Second), 0), 2.; got != want {
+		t.Errorf("Forecast with decreasing trend and no horizon = %v, want: %v", got, want)
+	}
+}
+
+func TestTimedFloat64BucketsSnapshotRestore(t *testing.T) {
+	now := time.Now().Truncate(granularity)
+	buckets := NewTimedFloat64Buckets(5*time.Second, granularity)
+
+	if got, want := buckets.Snapshot(now), (Snapshot{}); !cmp.Equal(got, want) {
+		t.Errorf("Snapshot of empty buckets = %v, want: %v", got, want)
+	}
+
+	// Partial window with a hole.
+	buckets.Record(now, 1)
+	buckets.Record(now.Add(time.Second), 2)
+	buckets.Record(now.Add(3*time.Second), 4)
+	want := Snapshot{
+		LastWrite: now.Add(3 * time.Second),
+		Values:    []float64{1, 2, 0, 4},
+	}
+	if got := buckets.Snapshot(now.Add(3 * time.Second)); !cmp.Equal(got, want) {
+		t.Errorf("Snapshot = %v, want: %v", got, want)
+	}
+
+	// Full window, the oldest values are dropped.
+	buckets.Record(now.Add(4*time.Second), 5)
+	buckets.Record(now.Add(5*time.Second), 6)
+	want = Snapshot{
+		LastWrite: now.Add(5 * time.Second),
+		Values:    []float64{2, 0, 4, 5, 6},
+	}
+	snap := buckets.Snapshot(now.Add(6 * time.Second))
+	if !cmp.Equal(snap, want) {
+		t.Errorf("Snapshot = %v, want: %v", snap, want)
+	}
+
+	restored := NewTimedFloat64Buckets(5*time.Second, granularity)
+	restored.Restore(snap)
+	if got, want := restored.WindowAverage(now.Add(5*time.Second)), buckets.WindowAverage(now.Add(5*time.Second)); got != want {
+		t.Errorf("Restored WindowAverage = %v, want: %v", got, want)
+	}
+
+	// Restoring on top of the newer data.
+	restored = NewTimedFloat64Buckets(5*time.Second, granularity)
+	restored.Record(now.Add(6*time.Second), 7)
+	restored.Restore(snap)
+	if got, want := restored.WindowAverage(now.Add(6*time.Second)), (0.+4+5+6+7)/5; got != want {
+		t.Errorf("Restored WindowAverage = %v, want: %v", got, want)
+	}
+
+	// Restoring on top of overlapping data skips the buckets holding data.
+	restored = NewTimedFloat64Buckets(5*time.Second, granularity)
+	restored.Record(now.Add(5*time.Second), 7)
+	restored.Restore(snap)
+	if got, want := restored.WindowAverage(now.Add(5*time.Second)), (2.+0+4+5+7)/5; got != want {
+		t.Errorf("Restored WindowAverage = %v, want: %v", got, want)
+	}
+	restored.Restore(snap)
+	if got, want := restored.WindowAverage(now.Add(5*time.Second)), (2.+0+4+5+7)/5; got != want {
+		t.Errorf("Twice restored WindowAverage = %v, want: %v", got, want)
+	}
+
+	// Restoring a stale snapshot yields no data.
+	restored = NewTimedFloat64Buckets(5*time.Second, granularity)
+	restored.Restore(snap)
+	if got, want := restored.WindowAverage(now.Add(time.Minute)), 0.; got != want {
+		t.Errorf("Stale WindowAverage = %v, want: %v", got, want)
+	}
+}
+
 func TestDescendingRecord(t *testing.T) {
 	now := time.Now()
 	buckets := NewTimedFloat64Buckets(5*time.Second, 1*time.Second)
@@ -23,6 +23,9 @@ import "time"
 type Config struct {
 	// Feature flags.
 	EnableScaleToZero bool
+	// EnableHPAScaleToZero allows the hpa.autoscaling.knative.dev class
+	// revisions to be scaled to zero when idle. Requires EnableScaleToZero.
+	EnableHPAScaleToZero bool
 
 	// Target concurrency knobs for different container concurrency configurations.
 	ContainerConcurrencyTargetFraction float64
@@ -84,5 +87,13 @@ type Config struct {
 	// add an additional delay to the very last pod, if required.
 	ScaleDownDelay time.Duration
 
+	// ScalingMode is the default mode of the KPA decider for the revisions
+	// without an autoscaling.knative.dev/scalingMode annotation.
+	ScalingMode string
+
+	// ForecastHorizon is how far into the future the metric is forecasted
+	// when the decider operates in the predictive scaling mode.
+	ForecastHorizon time.Duration
+
 	PodAutoscalerClass string
 }
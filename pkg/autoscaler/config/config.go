@@ -57,6 +57,8 @@ func defaultConfig() *autoscalerconfig.Config {
 		ScaleToZeroGracePeriod:        30 * time.Second,
 		ScaleToZeroPodRetentionPeriod: 0 * time.Second,
 		ScaleDownDelay:                0 * time.Second,
+		ScalingMode:                   autoscaling.ScalingModeReactive,
+		ForecastHorizon:               30 * time.Second,
 		PodAutoscalerClass:            autoscaling.KPA,
 		AllowZeroInitialScale:         false,
 		InitialScale:                  1,
@@ -71,8 +73,10 @@ func NewConfigFromMap(data map[string]string) (*autoscalerconfig.Config, error)
 
 	if err := cm.Parse(data,
 		cm.AsString("pod-autoscaler-class", &lc.PodAutoscalerClass),
+		cm.AsString("scaling-mode", &lc.ScalingMode),
 
 		cm.AsBool("enable-scale-to-zero", &lc.EnableScaleToZero),
+		cm.AsBool("enable-hpa-scale-to-zero", &lc.EnableHPAScaleToZero),
 		cm.AsBool("allow-zero-initial-scale", &lc.AllowZeroInitialScale),
 
 		cm.AsFloat64("max-scale-up-rate", &lc.MaxScaleUpRate),
@@ -93,6 +97,7 @@ func NewConfigFromMap(data map[string]string) (*autoscalerconfig.Config, error)
 		cm.AsDuration("scale-down-delay", &lc.ScaleDownDelay),
 		cm.AsDuration("scale-to-zero-grace-period", &lc.ScaleToZeroGracePeriod),
 		cm.AsDuration("scale-to-zero-pod-retention-period", &lc.ScaleToZeroPodRetentionPeriod),
+		cm.AsDuration("forecast-horizon", &lc.ForecastHorizon),
 	); err != nil {
 		return nil, fmt.Errorf("failed to parse data: %w", err)
 	}
@@ -183,6 +188,20 @@ func validate(lc *autoscalerconfig.Config) (*autoscalerconfig.Config, error) {
 	if lc.MaxScaleLimit < 0 {
 		return nil, fmt.Errorf("max-scale-limit = %v, must be at least 0", lc.MaxScaleLimit)
 	}
+
+	if lc.ScalingMode != autoscaling.ScalingModeReactive && lc.ScalingMode != autoscaling.ScalingModePredictive {
+		return nil, fmt.Errorf("scaling-mode = %q, must be one of %q or %q", lc.ScalingMode,
+			autoscaling.ScalingModeReactive, autoscaling.ScalingModePredictive)
+	}
+
+	if lc.ForecastHorizon < BucketSize || lc.ForecastHorizon > autoscaling.WindowMax {
+		return nil, fmt.Errorf("forecast-horizon = %v, must be in [%v; %v] range", lc.ForecastHorizon,
+			BucketSize, autoscaling.WindowMax)
+	}
+
+	if lc.ForecastHorizon.Round(time.Second) != lc.ForecastHorizon {
+		return nil, fmt.Errorf("forecast-horizon = %v, must be specified with at most second precision", lc.ForecastHorizon)
+	}
 	return lc, nil
 }
 
@@ -24,6 +24,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	. "knative.dev/pkg/configmap/testing"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
 )
 
@@ -139,6 +140,16 @@ func TestNewConfig(t *testing.T) {
 			c.EnableScaleToZero = false
 			return c
 		}(),
+	}, {
+		name: "with hpa scale to zero",
+		input: map[string]string{
+			"enable-hpa-scale-to-zero": "true",
+		},
+		want: func() *autoscalerconfig.Config {
+			c := defaultConfig()
+			c.EnableHPAScaleToZero = true
+			return c
+		}(),
 	}, {
 		name: "with explicit grace period",
 		input: map[string]string{
@@ -343,6 +354,42 @@ func TestNewConfig(t *testing.T) {
 			"max-scale-limit": "-9",
 		},
 		wantErr: true,
+	}, {
+		name: "with predictive scaling mode",
+		input: map[string]string{
+			"scaling-mode":     "predictive",
+			"forecast-horizon": "45s",
+		},
+		want: func() *autoscalerconfig.Config {
+			c := defaultConfig()
+			c.ScalingMode = autoscaling.ScalingModePredictive
+			c.ForecastHorizon = 45 * time.Second
+			return c
+		}(),
+	}, {
+		name: "with invalid scaling mode",
+		input: map[string]string{
+			"scaling-mode": "clairvoyant",
+		},
+		wantErr: true,
+	}, {
+		name: "forecast horizon too small",
+		input: map[string]string{
+			"forecast-horizon": "0s",
+		},
+		wantErr: true,
+	}, {
+		name: "forecast horizon too big",
+		input: map[string]string{
+			"forecast-horizon": "1h1s",
+		},
+		wantErr: true,
+	}, {
+		name: "forecast horizon not seconds",
+		input: map[string]string{
+			"forecast-horizon": "1500ms",
+		},
+		wantErr: true,
 	}, {
 		name: "with valid default max scale and max scale limit",
 		input: map[string]string{
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package historystore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/serving/pkg/autoscaler/metrics"
+)
+
+const (
+	// historyKey is the key of the ConfigMap data holding the snapshots.
+	historyKey = "history"
+
+	// maxHistorySize is the maximum size of the saved snapshots of a bucket,
+	// which leaves some room under the 1MiB limit of a ConfigMap.
+	maxHistorySize = 1000 * 1000
+)
+
+// configMapStore implements metrics.HistoryStore by storing the snapshots
+// of each bucket in a ConfigMap named after the bucket.
+type configMapStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	// maxSize is the maximum size of the saved snapshots, in bytes.
+	maxSize int
+}
+
+var _ metrics.HistoryStore = (*configMapStore)(nil)
+
+// NewConfigMapStore creates a metrics.HistoryStore backed by ConfigMaps
+// in the given namespace.
+func NewConfigMapStore(kubeClient kubernetes.Interface, namespace string) metrics.HistoryStore {
+	return &configMapStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		maxSize:    maxHistorySize,
+	}
+}
+
+// historyConfigMapName returns the name of the ConfigMap for the given bucket.
+func historyConfigMapName(bkt string) string {
+	return bkt + "-history"
+}
+
+// Load implements metrics.HistoryStore.
+func (s *configMapStore) Load(ctx context.Context, bkt string) (map[types.NamespacedName]metrics.HistorySnapshot, error) {
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(ctx, historyConfigMapName(bkt), metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	raw, ok := cm.Data[historyKey]
+	if !ok {
+		return nil, nil
+	}
+	stored := map[string]metrics.HistorySnapshot{}
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse the history of bucket %s: %w", bkt, err)
+	}
+	ret := make(map[types.NamespacedName]metrics.HistorySnapshot, len(stored))
+	for k, v := range stored {
+		ns, name, err := cache.SplitMetaNamespaceKey(k)
+		if err != nil {
+			return nil, err
+		}
+		ret[types.NamespacedName{Namespace: ns, Name: name}] = v
+	}
+	return ret, nil
+}
+
+// Save implements metrics.HistoryStore. The snapshots that don't fit in the
+// ConfigMap are left out, in the order of their keys so that the same
+// revisions are saved each time.
+func (s *configMapStore) Save(ctx context.Context, bkt string, snapshots map[types.NamespacedName]metrics.HistorySnapshot) error {
+	keys := make([]string, 0, len(snapshots))
+	byKey := make(map[string]metrics.HistorySnapshot, len(snapshots))
+	for k, v := range snapshots {
+		keys = append(keys, k.String())
+		byKey[k.String()] = v
+	}
+	sort.Strings(keys)
+
+	stored := make(map[string]json.RawMessage, len(keys))
+	// Account for the enclosing braces.
+	size := 2
+	for _, k := range keys {
+		v, err := json.Marshal(byKey[k])
+		if err != nil {
+			return err
+		}
+		// The quoted key, the colon and the separating comma.
+		entrySize := len(k) + 4 + len(v)
+		if size+entrySize > s.maxSize {
+			break
+		}
+		size += entrySize
+		stored[k] = v
+	}
+	raw, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := s.write(ctx, bkt, raw); err != nil {
+		return err
+	}
+	if len(stored) < len(keys) {
+		return fmt.Errorf("%w: saved %d of %d revisions of bucket %s",
+			metrics.ErrHistoryTruncated, len(stored), len(keys), bkt)
+	}
+	return nil
+}
+
+// write writes the raw history into the ConfigMap of the given bucket.
+func (s *configMapStore) write(ctx context.Context, bkt string, raw []byte) error {
+	cms := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	name := historyConfigMapName(bkt)
+	cm, err := cms.Get(ctx, name, metav1.GetOptions{})
+	if apierrs.IsNotFound(err) {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.namespace,
+			},
+			Data: map[string]string{historyKey: string(raw)},
+		}, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[historyKey] = string(raw)
+	_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package historystore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	"knative.dev/serving/pkg/autoscaler/metrics"
+)
+
+const testHistoryBucket = "autoscaler-bucket-00-of-01"
+
+func TestConfigMapStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewConfigMapStore(fakek8s.NewSimpleClientset(), "knative-testing")
+
+	if got, err := store.Load(ctx, testHistoryBucket); err != nil || len(got) != 0 {
+		t.Errorf("Load() = %v, %v, want: empty, nil", got, err)
+	}
+
+	now := time.Unix(1000, 0).UTC()
+	want := map[types.NamespacedName]metrics.HistorySnapshot{
+		{Namespace: "ns", Name: "rev1"}: {
+			Timestamp: now,
+		},
+		{Namespace: "ns", Name: "rev2"}: {
+			Timestamp: now,
+		},
+	}
+	// Save twice to exercise both creation and update.
+	for i := 0; i < 2; i++ {
+		if err := store.Save(ctx, testHistoryBucket, want); err != nil {
+			t.Fatal("Save() =", err)
+		}
+		got, err := store.Load(ctx, testHistoryBucket)
+		if err != nil {
+			t.Fatal("Load() =", err)
+		}
+		if !cmp.Equal(got, want) {
+			t.Error("Load() mismatch (-want,+got):", cmp.Diff(want, got))
+		}
+		delete(want, types.NamespacedName{Namespace: "ns", Name: "rev2"})
+	}
+}
+
+func TestConfigMapStoreTruncated(t *testing.T) {
+	ctx := context.Background()
+	store := NewConfigMapStore(fakek8s.NewSimpleClientset(), "knative-testing").(*configMapStore)
+
+	now := time.Unix(1000, 0).UTC()
+	snapshots := map[types.NamespacedName]metrics.HistorySnapshot{}
+	for _, name := range []string{"rev3", "rev1", "rev2"} {
+		snapshots[types.NamespacedName{Namespace: "ns", Name: name}] = metrics.HistorySnapshot{
+			Timestamp: now,
+		}
+	}
+	// Make room for two of the three snapshots only.
+	store.maxSize = 2
+	for _, name := range []string{"rev1", "rev2"} {
+		v, err := json.Marshal(snapshots[types.NamespacedName{Namespace: "ns", Name: name}])
+		if err != nil {
+			t.Fatal("Marshal() =", err)
+		}
+		store.maxSize += len("ns/"+name) + 4 + len(v)
+	}
+
+	if err := store.Save(ctx, testHistoryBucket, snapshots); !errors.Is(err, metrics.ErrHistoryTruncated) {
+		t.Errorf("Save() = %v, want: %v", err, metrics.ErrHistoryTruncated)
+	}
+	got, err := store.Load(ctx, testHistoryBucket)
+	if err != nil {
+		t.Fatal("Load() =", err)
+	}
+	want := map[types.NamespacedName]metrics.HistorySnapshot{
+		{Namespace: "ns", Name: "rev1"}: {Timestamp: now},
+		{Namespace: "ns", Name: "rev2"}: {Timestamp: now},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error("Load() mismatch (-want,+got):", cmp.Diff(want, got))
+	}
+}
@@ -69,6 +69,10 @@ type Collector interface {
 	// Watch registers a singleton function to call when a specific collector's status changes.
 	// The passed name is the namespace/name of the metric owned by the respective collector.
 	Watch(func(types.NamespacedName))
+	// IsIdle returns whether the entity has received no traffic over the
+	// stable window. ok is false if the activity is not tracked for the entity
+	// or is not known yet.
+	IsIdle(key types.NamespacedName) (idle, ok bool)
 }
 
 // MetricClient surfaces the metrics that can be obtained via the collector.
@@ -80,6 +84,26 @@ type MetricClient interface {
 	// StableAndPanicRPS returns both the stable and the panic RPS
 	// for the given replica as of the given time.
 	StableAndPanicRPS(key types.NamespacedName, now time.Time) (float64, float64, error)
+
+	// ForecastConcurrency returns the concurrency forecasted `horizon` ahead
+	// of the given time from the trend over the stable window.
+	ForecastConcurrency(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error)
+
+	// ForecastRPS returns the RPS forecasted `horizon` ahead of the given
+	// time from the trend over the stable window.
+	ForecastRPS(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error)
+
+	// StableAndPanicCustom returns both the stable and the panic value of
+	// the application-defined metric for the given replica as of the given time.
+	StableAndPanicCustom(key types.NamespacedName, now time.Time) (float64, float64, error)
+
+	// ForecastCustom returns the value of the application-defined metric
+	// forecasted `horizon` ahead of the given time from the trend over the stable window.
+	ForecastCustom(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error)
+
+	// HistoryRestored returns true if the metric history for the given replica
+	// was restored from a snapshot taken before the autoscaler (re)started.
+	HistoryRestored(key types.NamespacedName) bool
 }
 
 // MetricCollector manages collection of metrics for many entities.
@@ -91,6 +115,9 @@ type MetricCollector struct {
 
 	collectionsMutex sync.RWMutex
 	collections      map[types.NamespacedName]*collection
+	// pendingHistory holds the restored snapshots for the collections
+	// that have not been created yet. Guarded by collectionsMutex.
+	pendingHistory map[types.NamespacedName]HistorySnapshot
 
 	watcherMutex sync.RWMutex
 	watcher      func(types.NamespacedName)
@@ -104,6 +131,7 @@ func NewMetricCollector(statsScraperFactory StatsScraperFactory, logger *zap.Sug
 	return &MetricCollector{
 		logger:              logger,
 		collections:         make(map[types.NamespacedName]*collection),
+		pendingHistory:      make(map[types.NamespacedName]HistorySnapshot),
 		statsScraperFactory: statsScraperFactory,
 		clock:               clock.RealClock{},
 	}
@@ -132,7 +160,14 @@ func (c *MetricCollector) CreateOrUpdate(metric *autoscalingv1alpha1.Metric) err
 		return collection.lastError()
 	}
 
-	c.collections[key] = newCollection(metric, scraper, c.clock, c.Inform, logger)
+	collection = newCollection(metric, scraper, c.clock, c.Inform, logger)
+	if s, ok := c.pendingHistory[key]; ok {
+		delete(c.pendingHistory, key)
+		if s.isFresh(c.clock.Now()) {
+			collection.restore(s)
+		}
+	}
+	c.collections[key] = collection
 	return nil
 }
 
@@ -179,6 +214,20 @@ func (c *MetricCollector) Inform(event types.NamespacedName) {
 	}
 }
 
+// IsIdle returns whether the entity has received no traffic over the stable window.
+// The activity is only tracked for the metrics of the hpa.autoscaling.knative.dev
+// class revisions, as the KPA decider makes its own scale to zero decisions.
+func (c *MetricCollector) IsIdle(key types.NamespacedName) (idle, ok bool) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists {
+		return false, false
+	}
+	return collection.isIdle()
+}
+
 // StableAndPanicConcurrency returns both the stable and the panic concurrency.
 // It may truncate metric buckets as a side-effect.
 func (c *MetricCollector) StableAndPanicConcurrency(key types.NamespacedName, now time.Time) (float64, float64, error) {
@@ -217,13 +266,97 @@ func (c *MetricCollector) StableAndPanicRPS(key types.NamespacedName, now time.T
 		nil
 }
 
+// ForecastConcurrency returns the concurrency forecasted over the stable window.
+// It may truncate metric buckets as a side-effect.
+func (c *MetricCollector) ForecastConcurrency(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists {
+		return 0, ErrNotCollecting
+	}
+
+	if collection.concurrencyBuckets.IsEmpty(now) && collection.currentMetric().Spec.ScrapeTarget != "" {
+		return 0, ErrNoData
+	}
+	return collection.concurrencyBuckets.Forecast(now, horizon), nil
+}
+
+// ForecastRPS returns the RPS forecasted over the stable window.
+// It may truncate metric buckets as a side-effect.
+func (c *MetricCollector) ForecastRPS(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists {
+		return 0, ErrNotCollecting
+	}
+
+	if collection.rpsBuckets.IsEmpty(now) && collection.currentMetric().Spec.ScrapeTarget != "" {
+		return 0, ErrNoData
+	}
+	return collection.rpsBuckets.Forecast(now, horizon), nil
+}
+
+// StableAndPanicCustom returns both the stable and the panic value of the
+// application-defined metric.
+// It may truncate metric buckets as a side-effect.
+func (c *MetricCollector) StableAndPanicCustom(key types.NamespacedName, now time.Time) (float64, float64, error) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists || collection.customBuckets == nil {
+		return 0, 0, ErrNotCollecting
+	}
+
+	if collection.customBuckets.IsEmpty(now) && collection.currentMetric().Spec.ScrapeTarget != "" {
+		return 0, 0, ErrNoData
+	}
+	return collection.customBuckets.WindowAverage(now),
+		collection.customPanicBuckets.WindowAverage(now),
+		nil
+}
+
+// ForecastCustom returns the value of the application-defined metric
+// forecasted over the stable window.
+// It may truncate metric buckets as a side-effect.
+func (c *MetricCollector) ForecastCustom(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	if !exists || collection.customBuckets == nil {
+		return 0, ErrNotCollecting
+	}
+
+	if collection.customBuckets.IsEmpty(now) && collection.currentMetric().Spec.ScrapeTarget != "" {
+		return 0, ErrNoData
+	}
+	return collection.customBuckets.Forecast(now, horizon), nil
+}
+
+// activity is the traffic state of a collection.
+type activity int
+
+const (
+	activityUnknown activity = iota
+	activityActive
+	activityIdle
+)
+
 type (
 	// windowAverager is the client side abstraction for various bucket types.
 	windowAverager interface {
 		Record(time.Time, float64)
 		ResizeWindow(time.Duration)
 		WindowAverage(time.Time) float64
+		Forecast(time.Time, time.Duration) float64
 		IsEmpty(time.Time) bool
+		Snapshot(time.Time) aggregation.Snapshot
+		Restore(aggregation.Snapshot)
 	}
 
 	// collection represents the collection of metrics for one specific entity.
@@ -238,12 +371,24 @@ type (
 		concurrencyPanicBuckets windowAverager
 		rpsBuckets              windowAverager
 		rpsPanicBuckets         windowAverager
+		// The buckets for the application-defined metric are only
+		// present if the metric spec names one.
+		customBuckets      windowAverager
+		customPanicBuckets windowAverager
+
+		// Fields relevant to activity tracking. The activity is only tracked
+		// if trackActivity is set.
+		trackActivity bool
+		created       time.Time
+		activity      activity
 
 		// Fields relevant for metric scraping specifically.
 		scraper StatsScraper
 		lastErr error
-		grp     sync.WaitGroup
-		stopCh  chan struct{}
+		// historyRestored is set when the buckets were restored from a snapshot.
+		historyRestored bool
+		grp             sync.WaitGroup
+		stopCh          chan struct{}
 	}
 )
 
@@ -287,8 +432,15 @@ func newCollection(metric *autoscalingv1alpha1.Metric, scraper StatsScraper, clo
 			metric.Spec.PanicWindow, config.BucketSize),
 		scraper: scraper,
 
+		trackActivity: metric.Annotations[autoscaling.ClassAnnotationKey] == autoscaling.HPA,
+		created:       clock.Now(),
+
 		stopCh: make(chan struct{}),
 	}
+	if metric.Spec.MetricName != "" {
+		c.customBuckets = bucketCtor(metric.Spec.StableWindow, config.BucketSize)
+		c.customPanicBuckets = bucketCtor(metric.Spec.PanicWindow, config.BucketSize)
+	}
 
 	key := types.NamespacedName{Namespace: metric.Namespace, Name: metric.Name}
 	logger = logger.Named("collector").With(zap.String(logkey.Key, key.String()))
@@ -307,7 +459,9 @@ func newCollection(metric *autoscalingv1alpha1.Metric, scraper StatsScraper, clo
 				scraper := c.getScraper()
 				if scraper == nil {
 					// Don't scrape empty target service.
-					if c.updateLastError(nil) {
+					errChanged := c.updateLastError(nil)
+					actChanged := c.updateActivity(clock.Now())
+					if errChanged || actChanged {
 						callback(key)
 					}
 					continue
@@ -323,6 +477,9 @@ func newCollection(metric *autoscalingv1alpha1.Metric, scraper StatsScraper, clo
 				if stat != emptyStat {
 					c.record(clock.Now(), stat)
 				}
+				if c.updateActivity(clock.Now()) {
+					callback(key)
+				}
 			}
 		}
 	}()
@@ -346,6 +503,10 @@ func (c *collection) updateMetric(metric *autoscalingv1alpha1.Metric) {
 	c.concurrencyPanicBuckets.ResizeWindow(metric.Spec.PanicWindow)
 	c.rpsBuckets.ResizeWindow(metric.Spec.StableWindow)
 	c.rpsPanicBuckets.ResizeWindow(metric.Spec.PanicWindow)
+	if c.customBuckets != nil {
+		c.customBuckets.ResizeWindow(metric.Spec.StableWindow)
+		c.customPanicBuckets.ResizeWindow(metric.Spec.PanicWindow)
+	}
 }
 
 // currentMetric safely returns the current metric stored in the collection.
@@ -369,6 +530,44 @@ func (c *collection) updateLastError(err error) bool {
 	return true
 }
 
+// updateActivity updates the traffic state of the collection and returns true
+// if it changed. The collection is idle if no traffic was recorded over the
+// stable window and it has existed for at least that long.
+func (c *collection) updateActivity(now time.Time) bool {
+	if !c.trackActivity {
+		return false
+	}
+
+	// The buckets guard themselves, so we can read them before taking the lock.
+	busy := c.concurrencyBuckets.WindowAverage(now) > 0 || c.rpsBuckets.WindowAverage(now) > 0
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	next := c.activity
+	switch {
+	case busy:
+		next = activityActive
+	case now.Sub(c.created) >= c.metric.Spec.StableWindow:
+		next = activityIdle
+	}
+	if next == c.activity {
+		return false
+	}
+	c.activity = next
+	return true
+}
+
+func (c *collection) isIdle() (idle, ok bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if c.activity == activityUnknown {
+		return false, false
+	}
+	return c.activity == activityIdle, true
+}
+
 func (c *collection) lastError() error {
 	c.mux.RLock()
 	defer c.mux.RUnlock()
@@ -386,6 +585,10 @@ func (c *collection) record(now time.Time, stat Stat) {
 	rps := stat.RequestCount - stat.ProxiedRequestCount
 	c.rpsBuckets.Record(now, rps)
 	c.rpsPanicBuckets.Record(now, rps)
+	if c.customBuckets != nil {
+		c.customBuckets.Record(now, stat.CustomMetricValue)
+		c.customPanicBuckets.Record(now, stat.CustomMetricValue)
+	}
 }
 
 // add adds the stats from `src` to `dst`.
@@ -394,6 +597,7 @@ func (dst *Stat) add(src Stat) {
 	dst.AverageProxiedConcurrentRequests += src.AverageProxiedConcurrentRequests
 	dst.RequestCount += src.RequestCount
 	dst.ProxiedRequestCount += src.ProxiedRequestCount
+	dst.CustomMetricValue += src.CustomMetricValue
 }
 
 // average reduces the aggregate stat from `sample` pods to an averaged one over
@@ -412,4 +616,5 @@ func (dst *Stat) average(sample, total float64) {
 	dst.AverageProxiedConcurrentRequests = dst.AverageProxiedConcurrentRequests / sample * total
 	dst.RequestCount = dst.RequestCount / sample * total
 	dst.ProxiedRequestCount = dst.ProxiedRequestCount / sample * total
+	dst.CustomMetricValue = dst.CustomMetricValue / sample * total
 }
@@ -492,6 +492,241 @@ func TestMetricCollectorRecord(t *testing.T) {
 	}
 }
 
+func TestMetricCollectorForecast(t *testing.T) {
+	logger := TestLogger(t)
+
+	now := time.Now()
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+	scraper := &testScraper{
+		s: func() (Stat, error) {
+			return emptyStat, nil
+		},
+	}
+	factory := scraperFactory(scraper, nil)
+	coll := NewMetricCollector(factory, logger)
+
+	if _, err := coll.ForecastConcurrency(metricKey, now, time.Minute); !errors.Is(err, ErrNotCollecting) {
+		t.Errorf("ForecastConcurrency() = %v, want %v", err, ErrNotCollecting)
+	}
+
+	coll.CreateOrUpdate(&defaultMetric)
+	if _, err := coll.ForecastConcurrency(metricKey, now, time.Minute); !errors.Is(err, ErrNoData) {
+		t.Errorf("ForecastConcurrency() = %v, want %v", err, ErrNoData)
+	}
+	if _, err := coll.ForecastRPS(metricKey, now, time.Minute); !errors.Is(err, ErrNoData) {
+		t.Errorf("ForecastRPS() = %v, want %v", err, ErrNoData)
+	}
+
+	// Both metrics grow linearly: concurrency by 1 and RPS by 2 every second.
+	for i := 0; i < 10; i++ {
+		coll.Record(metricKey, now.Add(time.Duration(i)*time.Second), Stat{
+			PodName:                   "testPod",
+			AverageConcurrentRequests: float64(i),
+			RequestCount:              float64(2 * i),
+		})
+	}
+	now = now.Add(9 * time.Second)
+
+	const tolerance = 0.001
+	got, err := coll.ForecastConcurrency(metricKey, now, 5*time.Second)
+	if err != nil {
+		t.Fatal("ForecastConcurrency:", err)
+	}
+	if want := 14.; math.Abs(got-want) > tolerance {
+		t.Errorf("ForecastConcurrency() = %v, want %v", got, want)
+	}
+	got, err = coll.ForecastRPS(metricKey, now, 5*time.Second)
+	if err != nil {
+		t.Fatal("ForecastRPS:", err)
+	}
+	if want := 28.; math.Abs(got-want) > tolerance {
+		t.Errorf("ForecastRPS() = %v, want %v", got, want)
+	}
+}
+
+func TestMetricCollectorCustom(t *testing.T) {
+	logger := TestLogger(t)
+
+	now := time.Now()
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+	scraper := &testScraper{
+		s: func() (Stat, error) {
+			return emptyStat, nil
+		},
+	}
+	factory := scraperFactory(scraper, nil)
+	coll := NewMetricCollector(factory, logger)
+
+	// The application-defined metric is not collected unless named in the spec.
+	coll.CreateOrUpdate(&defaultMetric)
+	if _, _, err := coll.StableAndPanicCustom(metricKey, now); !errors.Is(err, ErrNotCollecting) {
+		t.Errorf("StableAndPanicCustom() = %v, want %v", err, ErrNotCollecting)
+	}
+	if _, err := coll.ForecastCustom(metricKey, now, time.Minute); !errors.Is(err, ErrNotCollecting) {
+		t.Errorf("ForecastCustom() = %v, want %v", err, ErrNotCollecting)
+	}
+	coll.Delete(defaultNamespace, defaultName)
+
+	metric := defaultMetric.DeepCopy()
+	metric.Spec.MetricName = "queue_depth"
+	coll.CreateOrUpdate(metric)
+	defer coll.Delete(defaultNamespace, defaultName)
+	if _, _, err := coll.StableAndPanicCustom(metricKey, now); !errors.Is(err, ErrNoData) {
+		t.Errorf("StableAndPanicCustom() = %v, want %v", err, ErrNoData)
+	}
+
+	// The value grows by 1 every second.
+	for i := 0; i < 10; i++ {
+		coll.Record(metricKey, now.Add(time.Duration(i)*time.Second), Stat{
+			PodName:           "testPod",
+			CustomMetricValue: float64(i),
+		})
+	}
+	now = now.Add(9 * time.Second)
+
+	gotStable, gotPanic, err := coll.StableAndPanicCustom(metricKey, now)
+	if err != nil {
+		t.Fatal("StableAndPanicCustom:", err)
+	}
+	// Stable is the average of 0..9, panic the one of 4..9 over the 6s panic window.
+	if gotStable != 4.5 || gotPanic != 6.5 {
+		t.Errorf("StableAndPanicCustom() = %v, %v, want 4.5, 6.5", gotStable, gotPanic)
+	}
+	got, err := coll.ForecastCustom(metricKey, now, 5*time.Second)
+	if err != nil {
+		t.Fatal("ForecastCustom:", err)
+	}
+	if want := 14.; math.Abs(got-want) > 0.001 {
+		t.Errorf("ForecastCustom() = %v, want %v", got, want)
+	}
+}
+
+func TestMetricCollectorIsIdle(t *testing.T) {
+	logger := TestLogger(t)
+
+	mtp := &fake.ManualTickProvider{
+		Channel: make(chan time.Time),
+	}
+	now := time.Now()
+	fc := fake.Clock{
+		FakeClock: clock.NewFakeClock(now),
+		TP:        mtp,
+	}
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+
+	coll := NewMetricCollector(scraperFactory(nil, nil), logger)
+	coll.clock = fc
+	watchCh := make(chan types.NamespacedName)
+	coll.Watch(func(key types.NamespacedName) {
+		watchCh <- key
+	})
+
+	// The activity is not tracked for the KPA class.
+	kpaMetric := defaultMetric
+	kpaMetric.Name = "kpa"
+	coll.CreateOrUpdate(&kpaMetric)
+	defer coll.Delete(kpaMetric.Namespace, kpaMetric.Name)
+	if _, ok := coll.IsIdle(types.NamespacedName{Namespace: defaultNamespace, Name: "kpa"}); ok {
+		t.Error("IsIdle() = _, true for the KPA class, want: false")
+	}
+
+	hpaMetric := defaultMetric
+	hpaMetric.Spec.ScrapeTarget = ""
+	hpaMetric.Annotations = map[string]string{
+		autoscaling.ClassAnnotationKey: autoscaling.HPA,
+	}
+	coll.CreateOrUpdate(&hpaMetric)
+	defer coll.Delete(defaultNamespace, defaultName)
+	if _, ok := coll.IsIdle(metricKey); ok {
+		t.Error("IsIdle() = _, true before the first tick, want: false")
+	}
+
+	tick := func(want bool) {
+		t.Helper()
+		mtp.Channel <- now
+		if got := <-watchCh; got != metricKey {
+			t.Fatalf("Event = %v, want: %v", got, metricKey)
+		}
+		if idle, ok := coll.IsIdle(metricKey); !ok || idle != want {
+			t.Errorf("IsIdle() = %v, %v, want: %v, true", idle, ok, want)
+		}
+	}
+
+	coll.Record(metricKey, now, Stat{AverageConcurrentRequests: 1, RequestCount: 1})
+	tick(false /*idle*/)
+
+	// No traffic for the stable window.
+	now = now.Add(hpaMetric.Spec.StableWindow + time.Second)
+	fc.SetTime(now)
+	tick(true /*idle*/)
+
+	coll.Record(metricKey, now, Stat{RequestCount: 1})
+	tick(false /*idle*/)
+}
+
+func TestMetricCollectorIsIdleWhenErrorClears(t *testing.T) {
+	logger := TestLogger(t)
+
+	mtp := &fake.ManualTickProvider{
+		Channel: make(chan time.Time),
+	}
+	now := time.Now()
+	fc := fake.Clock{
+		FakeClock: clock.NewFakeClock(now),
+		TP:        mtp,
+	}
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+
+	// The scraper fails until the scrape target is gone.
+	scraper := &testScraper{
+		s: func() (Stat, error) {
+			return emptyStat, ErrFailedGetEndpoints
+		},
+	}
+	factory := func(m *autoscalingv1alpha1.Metric, _ *zap.SugaredLogger) (StatsScraper, error) {
+		if m.Spec.ScrapeTarget == "" {
+			return nil, nil
+		}
+		return scraper, nil
+	}
+	coll := NewMetricCollector(factory, logger)
+	coll.clock = fc
+	watchCh := make(chan types.NamespacedName, 2)
+	coll.Watch(func(key types.NamespacedName) {
+		watchCh <- key
+	})
+
+	hpaMetric := defaultMetric
+	hpaMetric.Annotations = map[string]string{
+		autoscaling.ClassAnnotationKey: autoscaling.HPA,
+	}
+	coll.CreateOrUpdate(&hpaMetric)
+	defer coll.Delete(defaultNamespace, defaultName)
+
+	// Both the error and the activity change.
+	coll.Record(metricKey, now, Stat{RequestCount: 1})
+	mtp.Channel <- now
+	<-watchCh
+	<-watchCh
+	if idle, ok := coll.IsIdle(metricKey); !ok || idle {
+		t.Errorf("IsIdle() = %v, %v, want: false, true", idle, ok)
+	}
+
+	// The error clears on the same tick as the revision goes idle.
+	noTarget := hpaMetric
+	noTarget.Spec.ScrapeTarget = ""
+	coll.CreateOrUpdate(&noTarget)
+	now = now.Add(hpaMetric.Spec.StableWindow + time.Second)
+	fc.SetTime(now)
+	mtp.Channel <- now
+	<-watchCh
+	// Synchronize with the end of the tick.
+	mtp.Channel <- now
+	if idle, ok := coll.IsIdle(metricKey); !ok || !idle {
+		t.Errorf("IsIdle() = %v, %v, want: true, true", idle, ok)
+	}
+}
+
 func TestDoubleWatch(t *testing.T) {
 	defer func() {
 		if x := recover(); x == nil {
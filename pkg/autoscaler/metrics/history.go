@@ -0,0 +1,314 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"knative.dev/pkg/hash"
+	pkgmetrics "knative.dev/pkg/metrics"
+	"knative.dev/serving/pkg/autoscaler/aggregation"
+)
+
+const (
+	// historySnapshotInterval is the interval between the snapshots of the
+	// metric history of the owned buckets.
+	historySnapshotInterval = 5 * time.Second
+
+	// maxHistoryAge is the maximum age of a snapshot to be restored. Older
+	// snapshots would leave too large a gap in the data to be trusted.
+	maxHistoryAge = 30 * time.Second
+
+	// historyRefreshInterval is the interval after which an unchanged metric
+	// history is saved again, so that it is still fresh when restored.
+	historyRefreshInterval = maxHistoryAge / 2
+)
+
+// ErrHistoryTruncated is returned by the HistoryStore when only a part of
+// the snapshots could be saved.
+var ErrHistoryTruncated = errors.New("metric history truncated")
+
+var historySaveErrorsM = stats.Int64(
+	"history_save_errors",
+	"The number of failures to save the metric history of a bucket",
+	stats.UnitDimensionless)
+
+func init() {
+	if err := view.Register(
+		&view.View{
+			Description: "The number of failures to save the metric history of a bucket",
+			Measure:     historySaveErrorsM,
+			Aggregation: view.Count(),
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// HistorySnapshot is a snapshot of the metric history of a collection.
+//
+// Only the stable window buckets are stored: the panic window buckets record
+// exactly the same values over a shorter window, so they are rebuilt from
+// the same data on restore.
+type HistorySnapshot struct {
+	// Timestamp is the time when the snapshot was taken.
+	Timestamp time.Time `json:"timestamp"`
+	// Concurrency is the snapshot of the concurrency buckets.
+	Concurrency aggregation.Snapshot `json:"concurrency"`
+	// RPS is the snapshot of the RPS buckets.
+	RPS aggregation.Snapshot `json:"rps"`
+	// Custom is the snapshot of the application-defined metric buckets, if any.
+	Custom *aggregation.Snapshot `json:"custom,omitempty"`
+}
+
+// isEmpty returns true if the snapshot holds no data.
+func (s HistorySnapshot) isEmpty() bool {
+	return len(s.Concurrency.Values) == 0 && len(s.RPS.Values) == 0 &&
+		(s.Custom == nil || len(s.Custom.Values) == 0)
+}
+
+// isFresh returns true if the snapshot is recent enough to be restored.
+func (s HistorySnapshot) isFresh(now time.Time) bool {
+	return now.Sub(s.Timestamp) <= maxHistoryAge
+}
+
+// snapshot returns the snapshot of the collection's metric history.
+func (c *collection) snapshot(now time.Time) HistorySnapshot {
+	s := HistorySnapshot{
+		Timestamp:   now,
+		Concurrency: c.concurrencyBuckets.Snapshot(now),
+		RPS:         c.rpsBuckets.Snapshot(now),
+	}
+	if c.customBuckets != nil {
+		custom := c.customBuckets.Snapshot(now)
+		s.Custom = &custom
+	}
+	return s
+}
+
+// restore rehydrates the collection's buckets from the given snapshot.
+func (c *collection) restore(s HistorySnapshot) {
+	// The buckets guard themselves, the mux only guards the flag.
+	c.concurrencyBuckets.Restore(s.Concurrency)
+	c.concurrencyPanicBuckets.Restore(s.Concurrency)
+	c.rpsBuckets.Restore(s.RPS)
+	c.rpsPanicBuckets.Restore(s.RPS)
+	if c.customBuckets != nil && s.Custom != nil {
+		c.customBuckets.Restore(*s.Custom)
+		c.customPanicBuckets.Restore(*s.Custom)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.historyRestored = true
+}
+
+func (c *collection) isHistoryRestored() bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.historyRestored
+}
+
+// Snapshot returns the snapshots of the metric history of all the collections
+// whose keys pass the given filter.
+func (c *MetricCollector) Snapshot(now time.Time, filter func(types.NamespacedName) bool) map[types.NamespacedName]HistorySnapshot {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	ret := make(map[types.NamespacedName]HistorySnapshot, len(c.collections))
+	for key, collection := range c.collections {
+		if filter(key) {
+			ret[key] = collection.snapshot(now)
+		}
+	}
+	return ret
+}
+
+// Restore rehydrates the metric history from the given snapshots.
+// The snapshots older than maxHistoryAge are ignored. The snapshots for the
+// collections which don't exist yet are kept until they are created.
+func (c *MetricCollector) Restore(snapshots map[types.NamespacedName]HistorySnapshot) {
+	now := c.clock.Now()
+
+	c.collectionsMutex.Lock()
+	defer c.collectionsMutex.Unlock()
+
+	// Drop the pending snapshots that went stale without a collection showing up.
+	for key, s := range c.pendingHistory {
+		if !s.isFresh(now) {
+			delete(c.pendingHistory, key)
+		}
+	}
+
+	for key, s := range snapshots {
+		if !s.isFresh(now) {
+			continue
+		}
+		if collection, exists := c.collections[key]; exists {
+			collection.restore(s)
+		} else {
+			c.pendingHistory[key] = s
+		}
+	}
+}
+
+// HistoryRestored returns true if the metric history for the given key
+// was restored from a snapshot.
+func (c *MetricCollector) HistoryRestored(key types.NamespacedName) bool {
+	c.collectionsMutex.RLock()
+	defer c.collectionsMutex.RUnlock()
+
+	collection, exists := c.collections[key]
+	return exists && collection.isHistoryRestored()
+}
+
+// HistoryStore persists the metric history snapshots per bucket.
+type HistoryStore interface {
+	// Load returns the snapshots last saved for the given bucket.
+	Load(ctx context.Context, bkt string) (map[types.NamespacedName]HistorySnapshot, error)
+	// Save replaces the snapshots saved for the given bucket. If the store
+	// can only hold a part of the snapshots, it saves that part and returns
+	// an error wrapping ErrHistoryTruncated.
+	Save(ctx context.Context, bkt string, snapshots map[types.NamespacedName]HistorySnapshot) error
+}
+
+// savedHistory is the metric history last saved for a bucket.
+type savedHistory struct {
+	at        time.Time
+	snapshots map[types.NamespacedName]HistorySnapshot
+}
+
+// sameHistory returns true if both sets of snapshots hold the same data,
+// regardless of when the snapshots were taken.
+func sameHistory(a, b map[types.NamespacedName]HistorySnapshot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, sa := range a {
+		sb, ok := b[key]
+		if !ok {
+			return false
+		}
+		sa.Timestamp, sb.Timestamp = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(sa, sb) {
+			return false
+		}
+	}
+	return true
+}
+
+// HistoryPersister periodically saves the metric history of the buckets
+// owned by this autoscaler and restores it when a bucket is acquired.
+type HistoryPersister struct {
+	logger    *zap.SugaredLogger
+	collector *MetricCollector
+	store     HistoryStore
+	bs        *hash.BucketSet
+	isOwner   func(bkt string) bool
+	clock     clock.Clock
+
+	// saved is the history last saved per bucket. It is only accessed
+	// by the Run goroutine.
+	saved map[string]savedHistory
+}
+
+// NewHistoryPersister creates a new HistoryPersister. isOwner reports whether
+// this autoscaler currently owns the given bucket of the BucketSet.
+func NewHistoryPersister(collector *MetricCollector, store HistoryStore, bs *hash.BucketSet,
+	isOwner func(bkt string) bool, logger *zap.SugaredLogger) *HistoryPersister {
+	return &HistoryPersister{
+		logger:    logger.Named("history"),
+		collector: collector,
+		store:     store,
+		bs:        bs,
+		isOwner:   isOwner,
+		clock:     clock.RealClock{},
+		saved:     make(map[string]savedHistory, len(bs.BucketList())),
+	}
+}
+
+// Run saves the metric history every historySnapshotInterval until stopCh is closed.
+func (p *HistoryPersister) Run(stopCh <-chan struct{}) {
+	ticker := p.clock.NewTicker(historySnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C():
+			p.save()
+		}
+	}
+}
+
+// save saves the metric history of each owned bucket. The revisions without
+// data are left out, and the buckets whose history did not change since the
+// last save are only saved again once historyRefreshInterval has passed.
+func (p *HistoryPersister) save() {
+	now := p.clock.Now()
+	for _, bkt := range p.bs.BucketList() {
+		if !p.isOwner(bkt) {
+			delete(p.saved, bkt)
+			continue
+		}
+		snapshots := p.collector.Snapshot(now, func(key types.NamespacedName) bool {
+			return p.bs.Owner(key.String()) == bkt
+		})
+		for key, s := range snapshots {
+			if s.isEmpty() {
+				delete(snapshots, key)
+			}
+		}
+		if last, ok := p.saved[bkt]; ok && now.Sub(last.at) < historyRefreshInterval &&
+			sameHistory(last.snapshots, snapshots) {
+			continue
+		}
+
+		err := p.store.Save(context.Background(), bkt, snapshots)
+		switch {
+		case errors.Is(err, ErrHistoryTruncated):
+			p.logger.Warnw("Saved only a part of the metric history of bucket "+bkt, zap.Error(err))
+		case err != nil:
+			p.logger.Errorw("Failed to save the metric history of bucket "+bkt, zap.Error(err))
+			pkgmetrics.Record(context.Background(), historySaveErrorsM.M(1))
+			// Retry on the next tick.
+			delete(p.saved, bkt)
+			continue
+		}
+		p.saved[bkt] = savedHistory{at: now, snapshots: snapshots}
+	}
+}
+
+// Restore loads the metric history of the given bucket and restores it into
+// the collector. It is meant to be called when the bucket is acquired.
+func (p *HistoryPersister) Restore(bkt string) {
+	snapshots, err := p.store.Load(context.Background(), bkt)
+	if err != nil {
+		p.logger.Errorw("Failed to load the metric history of bucket "+bkt, zap.Error(err))
+		return
+	}
+	p.collector.Restore(snapshots)
+	p.logger.Infof("Loaded the metric history of %d revisions for bucket %s", len(snapshots), bkt)
+}
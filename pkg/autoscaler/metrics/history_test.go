@@ -0,0 +1,274 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"knative.dev/pkg/hash"
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/autoscaler/fake"
+)
+
+const testHistoryBucket = "autoscaler-bucket-00-of-01"
+
+func newHistoryTestCollector(t *testing.T, fc fake.Clock) *MetricCollector {
+	scraper := &testScraper{
+		s: func() (Stat, error) {
+			return emptyStat, nil
+		},
+	}
+	coll := NewMetricCollector(scraperFactory(scraper, nil), TestLogger(t))
+	coll.clock = fc
+	return coll
+}
+
+func newHistoryTestClock(now time.Time) fake.Clock {
+	return fake.Clock{
+		FakeClock: clock.NewFakeClock(now),
+		TP:        &fake.ManualTickProvider{Channel: make(chan time.Time)},
+	}
+}
+
+// recordHistory records a few seconds worth of growing load.
+func recordHistory(coll *MetricCollector, key types.NamespacedName, now time.Time) {
+	for i := 5; i >= 0; i-- {
+		coll.Record(key, now.Add(-time.Duration(i)*time.Second), Stat{
+			AverageConcurrentRequests: float64(10 - i),
+			RequestCount:              float64(20 - i),
+		})
+	}
+}
+
+func TestMetricCollectorSnapshotRestore(t *testing.T) {
+	now := time.Now()
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+
+	src := newHistoryTestCollector(t, newHistoryTestClock(now))
+	if err := src.CreateOrUpdate(&defaultMetric); err != nil {
+		t.Fatal("CreateOrUpdate() =", err)
+	}
+	defer src.Delete(defaultNamespace, defaultName)
+	recordHistory(src, metricKey, now)
+
+	snapshots := src.Snapshot(now, func(types.NamespacedName) bool { return true })
+	if got := len(snapshots); got != 1 {
+		t.Fatalf("len(Snapshot()) = %d, want: 1", got)
+	}
+	if got := src.Snapshot(now, func(types.NamespacedName) bool { return false }); len(got) != 0 {
+		t.Errorf("Snapshot() with a rejecting filter = %v, want: empty", got)
+	}
+
+	wantStable, wantPanic, _ := src.StableAndPanicConcurrency(metricKey, now)
+	wantStableRPS, wantPanicRPS, _ := src.StableAndPanicRPS(metricKey, now)
+
+	for _, tc := range []struct {
+		name string
+		// createFirst creates the collection before the history is restored.
+		createFirst bool
+		// age is how old the snapshot is when it's restored.
+		age          time.Duration
+		wantRestored bool
+	}{{
+		name:         "existing collection",
+		createFirst:  true,
+		wantRestored: true,
+	}, {
+		name:         "pending collection",
+		wantRestored: true,
+	}, {
+		name:         "fresh enough",
+		age:          maxHistoryAge,
+		wantRestored: true,
+	}, {
+		name:        "stale existing collection",
+		createFirst: true,
+		age:         maxHistoryAge + time.Second,
+	}, {
+		name: "stale pending collection",
+		age:  maxHistoryAge + time.Second,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			restoreTime := now.Add(tc.age)
+			dst := newHistoryTestCollector(t, newHistoryTestClock(restoreTime))
+			if tc.createFirst {
+				dst.CreateOrUpdate(&defaultMetric)
+			}
+			dst.Restore(snapshots)
+			if !tc.createFirst {
+				dst.CreateOrUpdate(&defaultMetric)
+			}
+			defer dst.Delete(defaultNamespace, defaultName)
+
+			if got := dst.HistoryRestored(metricKey); got != tc.wantRestored {
+				t.Errorf("HistoryRestored() = %v, want: %v", got, tc.wantRestored)
+			}
+			if !tc.wantRestored {
+				return
+			}
+			if s, p, err := dst.StableAndPanicConcurrency(metricKey, now); err != nil {
+				t.Error("StableAndPanicConcurrency() =", err)
+			} else if s != wantStable || p != wantPanic {
+				t.Errorf("StableAndPanicConcurrency() = %v, %v, want: %v, %v", s, p, wantStable, wantPanic)
+			}
+			if s, p, err := dst.StableAndPanicRPS(metricKey, now); err != nil {
+				t.Error("StableAndPanicRPS() =", err)
+			} else if s != wantStableRPS || p != wantPanicRPS {
+				t.Errorf("StableAndPanicRPS() = %v, %v, want: %v, %v", s, p, wantStableRPS, wantPanicRPS)
+			}
+		})
+	}
+}
+
+// memoryHistoryStore is an in-memory HistoryStore.
+type memoryHistoryStore map[string]map[types.NamespacedName]HistorySnapshot
+
+func (s memoryHistoryStore) Load(_ context.Context, bkt string) (map[types.NamespacedName]HistorySnapshot, error) {
+	return s[bkt], nil
+}
+
+func (s memoryHistoryStore) Save(_ context.Context, bkt string, snapshots map[types.NamespacedName]HistorySnapshot) error {
+	s[bkt] = snapshots
+	return nil
+}
+
+func TestHistoryPersister(t *testing.T) {
+	now := time.Now()
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+	store := memoryHistoryStore{}
+	bs := hash.NewBucketSet(sets.NewString(testHistoryBucket))
+	fc := newHistoryTestClock(now)
+
+	src := newHistoryTestCollector(t, fc)
+	src.CreateOrUpdate(&defaultMetric)
+	defer src.Delete(defaultNamespace, defaultName)
+	recordHistory(src, metricKey, now)
+
+	// Nothing is saved for the buckets we don't own.
+	p := NewHistoryPersister(src, store, bs, func(string) bool { return false }, TestLogger(t))
+	p.clock = fc
+	p.save()
+	if got, _ := store.Load(context.Background(), testHistoryBucket); len(got) != 0 {
+		t.Errorf("Load() = %v, want: empty", got)
+	}
+
+	p = NewHistoryPersister(src, store, bs, func(string) bool { return true }, TestLogger(t))
+	p.clock = fc
+	p.save()
+
+	dst := newHistoryTestCollector(t, fc)
+	NewHistoryPersister(dst, store, bs, func(string) bool { return true }, TestLogger(t)).Restore(testHistoryBucket)
+	dst.CreateOrUpdate(&defaultMetric)
+	defer dst.Delete(defaultNamespace, defaultName)
+
+	if !dst.HistoryRestored(metricKey) {
+		t.Error("HistoryRestored() = false, want: true")
+	}
+	wantStable, wantPanic, _ := src.StableAndPanicConcurrency(metricKey, now)
+	if s, p, err := dst.StableAndPanicConcurrency(metricKey, now); err != nil {
+		t.Error("StableAndPanicConcurrency() =", err)
+	} else if s != wantStable || p != wantPanic {
+		t.Errorf("StableAndPanicConcurrency() = %v, %v, want: %v, %v", s, p, wantStable, wantPanic)
+	}
+}
+
+// countingHistoryStore is a HistoryStore counting the saves, which
+// fail with err if set.
+type countingHistoryStore struct {
+	memoryHistoryStore
+	saves int
+	err   error
+}
+
+func (s *countingHistoryStore) Save(ctx context.Context, bkt string, snapshots map[types.NamespacedName]HistorySnapshot) error {
+	s.saves++
+	if s.err != nil {
+		return s.err
+	}
+	return s.memoryHistoryStore.Save(ctx, bkt, snapshots)
+}
+
+func TestHistoryPersisterSkipsUnchanged(t *testing.T) {
+	now := time.Now()
+	metricKey := types.NamespacedName{Namespace: defaultNamespace, Name: defaultName}
+	emptyKey := types.NamespacedName{Namespace: defaultNamespace, Name: "empty"}
+	store := &countingHistoryStore{memoryHistoryStore: memoryHistoryStore{}}
+	bs := hash.NewBucketSet(sets.NewString(testHistoryBucket))
+	fc := newHistoryTestClock(now)
+
+	coll := newHistoryTestCollector(t, fc)
+	coll.CreateOrUpdate(&defaultMetric)
+	defer coll.Delete(defaultNamespace, defaultName)
+	recordHistory(coll, metricKey, now)
+	emptyMetric := defaultMetric.DeepCopy()
+	emptyMetric.Name = emptyKey.Name
+	coll.CreateOrUpdate(emptyMetric)
+	defer coll.Delete(defaultNamespace, emptyKey.Name)
+
+	p := NewHistoryPersister(coll, store, bs, func(string) bool { return true }, TestLogger(t))
+	p.clock = fc
+	p.save()
+	if store.saves != 1 {
+		t.Fatalf("saves = %d, want: 1", store.saves)
+	}
+	got, _ := store.Load(context.Background(), testHistoryBucket)
+	if _, ok := got[emptyKey]; ok {
+		t.Error("The empty snapshot was saved")
+	}
+	if _, ok := got[metricKey]; !ok {
+		t.Error("The snapshot was not saved")
+	}
+
+	// Nothing changed, so nothing is written.
+	fc.Step(historySnapshotInterval)
+	p.save()
+	if store.saves != 1 {
+		t.Errorf("saves = %d, want: 1", store.saves)
+	}
+
+	// An unchanged history is still refreshed.
+	fc.Step(historyRefreshInterval)
+	p.save()
+	if store.saves != 2 {
+		t.Errorf("saves = %d, want: 2", store.saves)
+	}
+
+	// A changed history is saved right away.
+	recordHistory(coll, metricKey, fc.Now())
+	fc.Step(historySnapshotInterval)
+	p.save()
+	if store.saves != 3 {
+		t.Errorf("saves = %d, want: 3", store.saves)
+	}
+
+	// Failed saves are retried on the next tick.
+	store.err = errors.New("injected")
+	fc.Step(historySnapshotInterval)
+	recordHistory(coll, metricKey, fc.Now())
+	p.save()
+	p.save()
+	if store.saves != 5 {
+		t.Errorf("saves = %d, want: 5", store.saves)
+	}
+}
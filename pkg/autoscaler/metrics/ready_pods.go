@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"go.uber.org/zap"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	pkgnet "knative.dev/pkg/network"
+	"knative.dev/serving/pkg/resources"
+)
+
+const (
+	// ReadyPodsPath is the path the autoscaler serves the ReadyPods of the
+	// pods on, next to the stats websocket.
+	ReadyPodsPath = "/readypods"
+
+	// autoscalerPort is the port of the autoscaler service serving ReadyPodsPath.
+	autoscalerPort = 8080
+)
+
+// ReadyPods is the share of a pod among the ready pods of its revision,
+// which the queue-proxy divides the rate limits of the revision by.
+type ReadyPods struct {
+	// Count is the number of ready pods of the revision.
+	Count int `json:"count"`
+	// Index is the index of the pod among the ready pods of the revision
+	// ordered by name, or -1 if the pod is not ready (yet).
+	Index int `json:"index"`
+}
+
+// ReadyPodsURL returns the URL of the ReadyPods of the given pod, served by
+// the autoscaler in the given system namespace.
+func ReadyPodsURL(systemNamespace, namespace, revision, pod string) string {
+	q := url.Values{}
+	q.Set("namespace", namespace)
+	q.Set("revision", revision)
+	q.Set("pod", pod)
+	return fmt.Sprintf("http://autoscaler.%s.svc.%s:%d%s?%s", systemNamespace,
+		pkgnet.GetClusterDomainName(), autoscalerPort, ReadyPodsPath, q.Encode())
+}
+
+// NewReadyPodsHandler returns the handler serving the ReadyPods of the pod
+// given by the namespace, revision and pod query parameters.
+// Any autoscaler replica can serve them, since all of them watch the pods.
+func NewReadyPodsHandler(lister corev1listers.PodLister, logger *zap.SugaredLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		ns, rev, pod := q.Get("namespace"), q.Get("revision"), q.Get("pod")
+		if ns == "" || rev == "" {
+			http.Error(w, "namespace and revision are required", http.StatusBadRequest)
+			return
+		}
+		names, err := resources.NewPodAccessor(lister, ns, rev).ReadyPodNames()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rp := ReadyPods{Count: len(names), Index: -1}
+		if i := sort.SearchStrings(names, pod); i < len(names) && names[i] == pod {
+			rp.Index = i
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rp); err != nil {
+			logger.Errorw("Failed to write the ready pods", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakepodsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod/fake"
+	"knative.dev/pkg/logging"
+	. "knative.dev/pkg/reconciler/testing"
+)
+
+func TestReadyPodsURL(t *testing.T) {
+	got := ReadyPodsURL("knative-serving", "ns", "rev", "rev-pod")
+	want := "http://autoscaler.knative-serving.svc.cluster.local:8080/readypods?namespace=ns&pod=rev-pod&revision=rev"
+	if got != want {
+		t.Errorf("ReadyPodsURL = %s, want: %s", got, want)
+	}
+}
+
+func TestReadyPodsHandler(t *testing.T) {
+	ctx, _ := SetupFakeContext(t)
+	makePods(ctx, "pod-", 3, metav1.Now())
+	h := NewReadyPodsHandler(fakepodsinformer.Get(ctx).Lister(), logging.FromContext(ctx))
+
+	tests := []struct {
+		name     string
+		query    url.Values
+		wantCode int
+		want     ReadyPods
+	}{{
+		name:     "ready pod",
+		query:    url.Values{"namespace": {testNamespace}, "revision": {testRevision}, "pod": {"pod-1"}},
+		wantCode: http.StatusOK,
+		want:     ReadyPods{Count: 3, Index: 1},
+	}, {
+		name:     "unknown pod",
+		query:    url.Values{"namespace": {testNamespace}, "revision": {testRevision}, "pod": {"pod-42"}},
+		wantCode: http.StatusOK,
+		want:     ReadyPods{Count: 3, Index: -1},
+	}, {
+		name:     "unknown revision",
+		query:    url.Values{"namespace": {testNamespace}, "revision": {"other"}, "pod": {"pod-1"}},
+		wantCode: http.StatusOK,
+		want:     ReadyPods{Count: 0, Index: -1},
+	}, {
+		name:     "no revision",
+		query:    url.Values{"namespace": {testNamespace}},
+		wantCode: http.StatusBadRequest,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h(rec, httptest.NewRequest(http.MethodGet, ReadyPodsPath+"?"+test.query.Encode(), nil))
+			if got := rec.Code; got != test.wantCode {
+				t.Fatalf("Code = %d, want: %d", got, test.wantCode)
+			}
+			if test.wantCode != http.StatusOK {
+				return
+			}
+			var got ReadyPods
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal("Failed to decode the response:", err)
+			}
+			if got != test.want {
+				t.Errorf("ReadyPods = %#v, want: %#v", got, test.want)
+			}
+		})
+	}
+}
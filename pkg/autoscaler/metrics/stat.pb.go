@@ -57,6 +57,9 @@ type Stat struct {
 	// Time/date that the stat was generated in seconds since
 	// 1970-01-01 00:00:00.000 UTC.
 	Timestamp int64 `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Value of the application-defined metric exposed by the user container,
+	// if the revision is scaled on one.
+	CustomMetricValue float64 `protobuf:"fixed64,8,opt,name=custom_metric_value,json=customMetricValue,proto3" json:"custom_metric_value,omitempty"`
 }
 
 func (m *Stat) Reset()         { *m = Stat{} }
@@ -141,6 +144,13 @@ func (m *Stat) GetTimestamp() int64 {
 	return 0
 }
 
+func (m *Stat) GetCustomMetricValue() float64 {
+	if m != nil {
+		return m.CustomMetricValue
+	}
+	return 0
+}
+
 // WireStatMessage is a copy of the StatMessage Golang type, exploding the fields of
 // `types.NamespacedName` to make it compatible with protobufs.
 type WireStatMessage struct {
@@ -261,30 +271,31 @@ func init() {
 func init() { proto.RegisterFile("pkg/autoscaler/metrics/stat.proto", fileDescriptor_cf216df9f6fff44c) }
 
 var fileDescriptor_cf216df9f6fff44c = []byte{
-	// 359 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0x4f, 0x4b, 0xc3, 0x30,
-	0x18, 0xc6, 0x97, 0xad, 0xee, 0x4f, 0xe6, 0x54, 0x22, 0x42, 0x86, 0x52, 0xba, 0x0d, 0xa1, 0xa7,
-	0x0d, 0xa6, 0x67, 0x0f, 0xee, 0xe2, 0x65, 0x22, 0x15, 0xf1, 0x58, 0x62, 0xf6, 0x3a, 0x8a, 0xb6,
-	0x89, 0x49, 0x2a, 0x7e, 0x0c, 0x3f, 0x96, 0x27, 0xd9, 0xd1, 0xa3, 0x6c, 0x5f, 0x44, 0x1a, 0xb3,
-	0x4d, 0xc7, 0x4e, 0x0d, 0xcf, 0xfb, 0x7b, 0x9e, 0x17, 0x9e, 0xb7, 0xb8, 0x23, 0x9f, 0xa6, 0x03,
-	0x96, 0x1b, 0xa1, 0x39, 0x7b, 0x06, 0x35, 0x48, 0xc1, 0xa8, 0x84, 0xeb, 0x81, 0x36, 0xcc, 0xf4,
-	0xa5, 0x12, 0x46, 0x90, 0x9a, 0xd3, 0xba, 0x9f, 0x65, 0xec, 0xdd, 0x1a, 0x66, 0x48, 0x1b, 0xd7,
-	0xa5, 0x98, 0xc4, 0x19, 0x4b, 0x81, 0xa2, 0x00, 0x85, 0x8d, 0xa8, 0x26, 0xc5, 0xe4, 0x9a, 0xa5,
-	0x40, 0x2e, 0xf0, 0x31, 0x7b, 0x05, 0xc5, 0xa6, 0x10, 0x73, 0x91, 0xf1, 0x5c, 0x29, 0xc8, 0x4c,
-	0xac, 0xe0, 0x25, 0x07, 0x6d, 0x34, 0x2d, 0x07, 0x28, 0x44, 0x51, 0xdb, 0x21, 0xa3, 0x15, 0x11,
-	0x39, 0x80, 0x8c, 0x71, 0x6f, 0xe9, 0x97, 0x4a, 0xbc, 0x25, 0x30, 0xd9, 0x9a, 0x53, 0xb1, 0x39,
-	0x81, 0x43, 0x6f, 0x7e, 0xc9, 0x2d, 0x71, 0x3d, 0xdc, 0x72, 0x9e, 0x98, 0x8b, 0x3c, 0x33, 0xd4,
-	0xb3, 0xc6, 0x5d, 0x27, 0x8e, 0x0a, 0x8d, 0x0c, 0xf1, 0xd1, 0x72, 0xd7, 0x7f, 0x78, 0xc7, 0xc2,
-	0x87, 0x6e, 0x18, 0xfd, 0xf5, 0x9c, 0xe2, 0x3d, 0xa9, 0x04, 0x07, 0xad, 0xe3, 0x5c, 0x9a, 0x24,
-	0x05, 0x5a, 0xb5, 0x70, 0xcb, 0xa9, 0x77, 0x56, 0x24, 0x27, 0xb8, 0x51, 0x7c, 0xb5, 0x61, 0xa9,
-	0xa4, 0xb5, 0x00, 0x85, 0x95, 0x68, 0x2d, 0x74, 0x1f, 0xf1, 0xfe, 0x7d, 0xa2, 0xa0, 0xe8, 0x74,
-	0x0c, 0x5a, 0xb3, 0xa9, 0x35, 0x14, 0xb5, 0x6a, 0xc9, 0xf8, 0xb2, 0xdb, 0xb5, 0x40, 0x08, 0xf6,
-	0x6c, 0xe9, 0x65, 0x3b, 0xb0, 0x6f, 0xd2, 0xc1, 0x5e, 0x71, 0x2c, 0x5b, 0x49, 0x73, 0xd8, 0xea,
-	0xbb, 0x6b, 0xf5, 0x8b, 0xd4, 0xc8, 0x8e, 0xba, 0x57, 0xf8, 0x60, 0x63, 0x8f, 0x26, 0xe7, 0xb8,
-	0x9e, 0xba, 0x37, 0x45, 0x41, 0x25, 0x6c, 0x0e, 0xe9, 0xca, 0xba, 0x01, 0x47, 0x2b, 0xf2, 0x92,
-	0x7e, 0xcc, 0x7d, 0x34, 0x9b, 0xfb, 0xe8, 0x7b, 0xee, 0xa3, 0xf7, 0x85, 0x5f, 0x9a, 0x2d, 0xfc,
-	0xd2, 0xd7, 0xc2, 0x2f, 0x3d, 0x54, 0xed, 0xcf, 0x72, 0xf6, 0x13, 0x00, 0x00, 0xff, 0xff, 0x6d,
-	0xc3, 0xa5, 0x6a, 0x51, 0x02, 0x00, 0x00,
+	// 381 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0x4f, 0x4f, 0xc2, 0x30,
+	0x00, 0xc5, 0x29, 0x4c, 0xfe, 0x14, 0xf1, 0x4f, 0x89, 0x49, 0x89, 0x66, 0x19, 0x10, 0x93, 0x9d,
+	0x46, 0x82, 0x9e, 0x3d, 0xc8, 0xc5, 0x0b, 0xc6, 0xcc, 0xa8, 0xc7, 0xa5, 0x96, 0x4a, 0x16, 0xd9,
+	0x5a, 0xdb, 0x8e, 0xf8, 0x31, 0xfc, 0x58, 0x1e, 0x39, 0x7a, 0x34, 0xf0, 0x3d, 0x8c, 0x59, 0x29,
+	0xa0, 0x84, 0xd3, 0x9a, 0xf7, 0x7e, 0xef, 0x35, 0xe9, 0x1b, 0x6c, 0x8b, 0xd7, 0x71, 0x8f, 0x64,
+	0x9a, 0x2b, 0x4a, 0x26, 0x4c, 0xf6, 0x12, 0xa6, 0x65, 0x4c, 0x55, 0x4f, 0x69, 0xa2, 0x03, 0x21,
+	0xb9, 0xe6, 0xa8, 0x62, 0xb5, 0xce, 0x4f, 0x11, 0x3a, 0xf7, 0x9a, 0x68, 0xd4, 0x82, 0x55, 0xc1,
+	0x47, 0x51, 0x4a, 0x12, 0x86, 0x81, 0x07, 0xfc, 0x5a, 0x58, 0x11, 0x7c, 0x74, 0x4b, 0x12, 0x86,
+	0xae, 0xe0, 0x29, 0x99, 0x32, 0x49, 0xc6, 0x2c, 0xa2, 0x3c, 0xa5, 0x99, 0x94, 0x2c, 0xd5, 0x91,
+	0x64, 0x6f, 0x19, 0x53, 0x5a, 0xe1, 0xa2, 0x07, 0x7c, 0x10, 0xb6, 0x2c, 0x32, 0x58, 0x13, 0xa1,
+	0x05, 0xd0, 0x10, 0x76, 0x57, 0x79, 0x21, 0xf9, 0x7b, 0xcc, 0x46, 0x3b, 0x7b, 0x4a, 0xa6, 0xc7,
+	0xb3, 0xe8, 0xdd, 0x92, 0xdc, 0x51, 0xd7, 0x85, 0x0d, 0x9b, 0x89, 0x28, 0xcf, 0x52, 0x8d, 0x1d,
+	0x13, 0xdc, 0xb7, 0xe2, 0x20, 0xd7, 0x50, 0x1f, 0x9e, 0xac, 0xee, 0xfa, 0x0f, 0xef, 0x19, 0xb8,
+	0x69, 0xcd, 0xf0, 0x6f, 0xe6, 0x1c, 0x1e, 0x08, 0xc9, 0x29, 0x53, 0x2a, 0xca, 0x84, 0x8e, 0x13,
+	0x86, 0xcb, 0x06, 0x6e, 0x58, 0xf5, 0xc1, 0x88, 0xe8, 0x0c, 0xd6, 0xf2, 0xaf, 0xd2, 0x24, 0x11,
+	0xb8, 0xe2, 0x01, 0xbf, 0x14, 0x6e, 0x04, 0x14, 0xc0, 0x26, 0xcd, 0x94, 0xe6, 0x49, 0xb4, 0x7c,
+	0xe2, 0x68, 0x4a, 0x26, 0x19, 0xc3, 0x55, 0xd3, 0x74, 0xbc, 0xb4, 0x86, 0xc6, 0x79, 0xcc, 0x8d,
+	0xce, 0x0b, 0x3c, 0x7c, 0x8a, 0x25, 0xcb, 0x37, 0x18, 0x32, 0xa5, 0xc8, 0xd8, 0x5c, 0x90, 0xcf,
+	0xa0, 0x04, 0xa1, 0xab, 0x2d, 0x36, 0x02, 0x42, 0xd0, 0x31, 0x23, 0x15, 0x8d, 0x61, 0xce, 0xa8,
+	0x0d, 0x9d, 0x7c, 0x5c, 0xf3, 0x84, 0xf5, 0x7e, 0x23, 0xb0, 0xeb, 0x06, 0x79, 0x6b, 0x68, 0xac,
+	0xce, 0x0d, 0x3c, 0xda, 0xba, 0x47, 0xa1, 0x4b, 0x58, 0x4d, 0xec, 0x19, 0x03, 0xaf, 0xe4, 0xd7,
+	0xfb, 0x78, 0x1d, 0xdd, 0x82, 0xc3, 0x35, 0x79, 0x8d, 0x3f, 0xe7, 0x2e, 0x98, 0xcd, 0x5d, 0xf0,
+	0x3d, 0x77, 0xc1, 0xc7, 0xc2, 0x2d, 0xcc, 0x16, 0x6e, 0xe1, 0x6b, 0xe1, 0x16, 0x9e, 0xcb, 0xe6,
+	0xe7, 0xba, 0xf8, 0x1d, 0x00, 0x9a, 0x61, 0x9e, 0x4e, 0x81, 0x02, 0x00, 0x00,
 }
 
 func (m *Stat) Marshal() (dAtA []byte, err error) {
@@ -307,6 +318,12 @@ func (m *Stat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.CustomMetricValue != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.CustomMetricValue))))
+		i--
+		dAtA[i] = 0x41
+	}
 	if m.Timestamp != 0 {
 		i = encodeVarintStat(dAtA, i, uint64(m.Timestamp))
 		i--
@@ -477,6 +494,9 @@ func (m *Stat) Size() (n int) {
 	if m.Timestamp != 0 {
 		n += 1 + sovStat(uint64(m.Timestamp))
 	}
+	if m.CustomMetricValue != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -657,6 +677,17 @@ func (m *Stat) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 8:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CustomMetricValue", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.CustomMetricValue = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStat(dAtA[iNdEx:])
@@ -55,6 +55,9 @@ type autoscaler struct {
 	// State in panic mode.
 	panicTime    time.Time
 	maxPanicPods int32
+	// startupPanic is true while we're in the panic mode we started in,
+	// as opposed to the one caused by a surge.
+	startupPanic bool
 
 	// delayWindow is used to defer scale-down decisions until a time
 	// window has passed at the reduced concurrency.
@@ -95,7 +98,8 @@ func newAutoscaler(
 	// When Autoscaler restarts we lose metric history, which causes us to
 	// momentarily scale down, and that is not a desired behaviour.
 	// Thus, we're keeping at least the current scale until we
-	// accumulate enough data to make conscious decisions.
+	// accumulate enough data to make conscious decisions, or until
+	// the metric history is restored from a snapshot.
 	curC, err := podCounter.ReadyCount()
 	if err != nil {
 		// This always happens on new revision creation, since decider
@@ -124,6 +128,7 @@ func newAutoscaler(
 
 		panicTime:    pt,
 		maxPanicPods: int32(curC),
+		startupPanic: !pt.IsZero(),
 	}
 }
 
@@ -161,6 +166,8 @@ func (a *autoscaler) Scale(logger *zap.SugaredLogger, now time.Time) ScaleResult
 	switch spec.ScalingMetric {
 	case autoscaling.RPS:
 		observedStableValue, observedPanicValue, err = a.metricClient.StableAndPanicRPS(metricKey, now)
+	case autoscaling.Custom:
+		observedStableValue, observedPanicValue, err = a.metricClient.StableAndPanicCustom(metricKey, now)
 	default:
 		metricName = autoscaling.Concurrency // concurrency is used by default
 		observedStableValue, observedPanicValue, err = a.metricClient.StableAndPanicConcurrency(metricKey, now)
@@ -197,12 +204,46 @@ func (a *autoscaler) Scale(logger *zap.SugaredLogger, now time.Time) ScaleResult
 				dspc, dppc, originalReadyPodsCount, maxScaleUp, maxScaleDown))
 	}
 
+	// In the predictive mode we provision ahead for the forecasted value,
+	// but never below what the currently observed value requires.
+	// The reactive pod count is recorded for comparison either way.
+	reactivePodCount := int32(math.Min(math.Max(dspc, maxScaleDown), maxScaleUp))
+	if spec.ScalingMode == autoscaling.ScalingModePredictive {
+		forecastValue, err := a.forecast(metricKey, metricName, now, spec.ForecastHorizon)
+		if err != nil {
+			// The observed values are available, so we can still make a reactive decision.
+			logger.Warnw("Failed to forecast metrics, scaling reactively", zap.Error(err))
+		} else {
+			fpc := math.Ceil(forecastValue / spec.TargetValue)
+			if debugEnabled {
+				desugared.Debug(
+					fmt.Sprintf("For metric %s forecasted value in %v: %0.3f, Desired ForecastPodCount = %0.0f",
+						metricName, spec.ForecastHorizon, forecastValue, fpc))
+			}
+			dspc = math.Max(dspc, fpc)
+			pkgmetrics.RecordBatch(a.reporterCtx,
+				forecastValueM(metricName).M(forecastValue),
+				forecastPodCountM.M(int64(math.Min(math.Max(fpc, maxScaleDown), maxScaleUp))),
+			)
+		}
+	}
+
 	// We want to keep desired pod count in the  [maxScaleDown, maxScaleUp] range.
 	desiredStablePodCount := int32(math.Min(math.Max(dspc, maxScaleDown), maxScaleUp))
 	desiredPanicPodCount := int32(math.Min(math.Max(dppc, maxScaleDown), maxScaleUp))
 
 	isOverPanicThreshold := dppc/readyPodsCount >= spec.PanicThreshold
 
+	if a.startupPanic && a.metricClient.HistoryRestored(metricKey) {
+		// The history was restored, so the metrics are as good as before
+		// the restart and we don't need to wait for them to accumulate.
+		logger.Info("Metric history restored, leaving the startup panic mode.")
+		a.startupPanic = false
+		a.panicTime = time.Time{}
+		a.maxPanicPods = 0
+		pkgmetrics.Record(a.reporterCtx, panicM.M(0))
+	}
+
 	if a.panicTime.IsZero() && isOverPanicThreshold {
 		// Begin panicking when we cross the threshold in the panic window.
 		logger.Info("PANICKING.")
@@ -211,9 +252,11 @@ func (a *autoscaler) Scale(logger *zap.SugaredLogger, now time.Time) ScaleResult
 	} else if isOverPanicThreshold {
 		// If we're still over panic threshold right now — extend the panic window.
 		a.panicTime = now
+		a.startupPanic = false
 	} else if !a.panicTime.IsZero() && !isOverPanicThreshold && a.panicTime.Add(spec.StableWindow).Before(now) {
 		// Stop panicking after the surge has made its way into the stable metric.
 		logger.Info("Un-panicking.")
+		a.startupPanic = false
 		a.panicTime = time.Time{}
 		a.maxPanicPods = 0
 		pkgmetrics.Record(a.reporterCtx, panicM.M(0))
@@ -301,14 +344,25 @@ func (a *autoscaler) Scale(logger *zap.SugaredLogger, now time.Time) ScaleResult
 		pkgmetrics.RecordBatch(a.reporterCtx,
 			excessBurstCapacityM.M(excessBCF),
 			desiredPodCountM.M(int64(desiredPodCount)),
+			reactivePodCountM.M(int64(reactivePodCount)),
 			stableRPSM.M(observedStableValue),
 			panicRPSM.M(observedStableValue),
 			targetRPSM.M(spec.TargetValue),
 		)
+	case autoscaling.Custom:
+		pkgmetrics.RecordBatch(a.reporterCtx,
+			excessBurstCapacityM.M(excessBCF),
+			desiredPodCountM.M(int64(desiredPodCount)),
+			reactivePodCountM.M(int64(reactivePodCount)),
+			stableCustomMetricM.M(observedStableValue),
+			panicCustomMetricM.M(observedPanicValue),
+			targetCustomMetricM.M(spec.TargetValue),
+		)
 	default:
 		pkgmetrics.RecordBatch(a.reporterCtx,
 			excessBurstCapacityM.M(excessBCF),
 			desiredPodCountM.M(int64(desiredPodCount)),
+			reactivePodCountM.M(int64(reactivePodCount)),
 			stableRequestConcurrencyM.M(observedStableValue),
 			panicRequestConcurrencyM.M(observedPanicValue),
 			targetRequestConcurrencyM.M(spec.TargetValue),
@@ -323,6 +377,18 @@ func (a *autoscaler) Scale(logger *zap.SugaredLogger, now time.Time) ScaleResult
 	}
 }
 
+// forecast returns the value of the given metric forecasted `horizon` ahead of now.
+func (a *autoscaler) forecast(key types.NamespacedName, metricName string, now time.Time, horizon time.Duration) (float64, error) {
+	switch metricName {
+	case autoscaling.RPS:
+		return a.metricClient.ForecastRPS(key, now, horizon)
+	case autoscaling.Custom:
+		return a.metricClient.ForecastCustom(key, now, horizon)
+	default:
+		return a.metricClient.ForecastConcurrency(key, now, horizon)
+	}
+}
+
 func (a *autoscaler) currentSpec() *DeciderSpec {
 	a.specMux.RLock()
 	defer a.specMux.RUnlock()
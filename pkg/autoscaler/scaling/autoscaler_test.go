@@ -32,6 +32,7 @@ import (
 	"knative.dev/pkg/metrics/metricstest"
 
 	logtesting "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/autoscaler/metrics"
 	smetrics "knative.dev/serving/pkg/metrics"
 	"knative.dev/serving/pkg/resources"
@@ -243,6 +244,76 @@ func TestAutoscalerMetricsWithRPS(t *testing.T) {
 	metricstest.AssertMetric(t, wantMetrics...)
 }
 
+func TestAutoscalerMetricsWithCustom(t *testing.T) {
+	defer reset()
+	metrics := &metricClient{StableCustom: 50, PanicCustom: 60}
+	a, _ := newTestAutoscalerWithScalingMetric(10, 100, metrics, autoscaling.Custom, false /*startInPanic*/)
+	ebc := expectedEBC(10, 100, 60, 1)
+	na := expectedNA(a, 1)
+	expectScale(t, a, time.Now(), ScaleResult{6, ebc, na, true})
+	spec := a.currentSpec()
+
+	wantMetrics := []metricstest.Metric{
+		metricstest.FloatMetric(stableCustomMetricM.Name(), 50, nil).WithResource(wantResource),
+		metricstest.FloatMetric(panicCustomMetricM.Name(), 60, nil).WithResource(wantResource),
+		metricstest.IntMetric(desiredPodCountM.Name(), 6, nil).WithResource(wantResource),
+		metricstest.FloatMetric(targetCustomMetricM.Name(), spec.TargetValue, nil).WithResource(wantResource),
+		metricstest.FloatMetric(excessBurstCapacityM.Name(), float64(ebc), nil).WithResource(wantResource),
+		metricstest.IntMetric(panicM.Name(), 1, nil).WithResource(wantResource),
+	}
+	metricstest.AssertMetric(t, wantMetrics...)
+}
+
+func TestAutoscalerPredictiveMetrics(t *testing.T) {
+	defer reset()
+	metrics := &metricClient{StableConcurrency: 50.0, PanicConcurrency: 50.0, ForecastedConcurrency: 80}
+	a := newTestAutoscalerNoPC(10, 100, metrics)
+	a.deciderSpec.ScalingMode = autoscaling.ScalingModePredictive
+	ebc := expectedEBC(10, 100, 50, 1)
+	na := expectedNA(a, 1)
+	expectScale(t, a, time.Now(), ScaleResult{8, ebc, na, true})
+
+	wantMetrics := []metricstest.Metric{
+		metricstest.IntMetric(desiredPodCountM.Name(), 8, nil).WithResource(wantResource),
+		metricstest.IntMetric(reactivePodCountM.Name(), 5, nil).WithResource(wantResource),
+		metricstest.IntMetric(forecastPodCountM.Name(), 8, nil).WithResource(wantResource),
+		metricstest.FloatMetric(forecastRequestConcurrencyM.Name(), 80, nil).WithResource(wantResource),
+	}
+	metricstest.AssertMetric(t, wantMetrics...)
+}
+
+func TestAutoscalerPredictiveMode(t *testing.T) {
+	metrics := &metricClient{StableConcurrency: 50.0, PanicConcurrency: 10, ForecastedConcurrency: 80}
+	a := newTestAutoscalerNoPC(10, 101, metrics)
+	a.deciderSpec.ScalingMode = autoscaling.ScalingModePredictive
+	na := expectedNA(a, 1)
+
+	// Scale ahead of the forecast.
+	expectScale(t, a, time.Now(), ScaleResult{8, expectedEBC(10, 101, 10, 1), na, true})
+
+	// Never scale below the observed value.
+	metrics.ForecastedConcurrency = 20
+	expectScale(t, a, time.Now(), ScaleResult{5, expectedEBC(10, 101, 10, 1), na, true})
+
+	// Fall back to the reactive scale if forecasting fails.
+	metrics.ForecastedConcurrency = 80
+	metrics.ForecastErr = errors.New("crystal ball is cloudy")
+	expectScale(t, a, time.Now(), ScaleResult{5, expectedEBC(10, 101, 10, 1), na, true})
+
+	// The forecast honors the max scale up rate.
+	metrics.ForecastErr = nil
+	metrics.ForecastedConcurrency = 1000
+	expectScale(t, a, time.Now(), ScaleResult{10, expectedEBC(10, 101, 10, 1), na, true})
+}
+
+func TestAutoscalerPredictiveModeWithRPS(t *testing.T) {
+	metrics := &metricClient{StableRPS: 50.0, PanicRPS: 10, ForecastedRPS: 70, ForecastedConcurrency: 1000}
+	a, _ := newTestAutoscalerWithScalingMetric(10, 101, metrics, "rps", false /*startInPanic*/)
+	a.deciderSpec.ScalingMode = autoscaling.ScalingModePredictive
+	na := expectedNA(a, 1)
+	expectScale(t, a, time.Now(), ScaleResult{7, expectedEBC(10, 101, 10, 1), na, true})
+}
+
 func TestAutoscalerStableModeIncreaseWithConcurrencyDefault(t *testing.T) {
 	metrics := &metricClient{StableConcurrency: 50.0, PanicConcurrency: 10}
 	a := newTestAutoscalerNoPC(10, 101, metrics)
@@ -687,6 +758,31 @@ func TestStartInPanicMode(t *testing.T) {
 	}
 }
 
+func TestLeaveStartupPanicModeOnRestoredHistory(t *testing.T) {
+	metrics := &metricClient{StableConcurrency: 10, PanicConcurrency: 10}
+	a, _ := newTestAutoscalerWithScalingMetric(10, 101, metrics, "concurrency", true /*panic*/)
+
+	// Not restored: the startup panic mode keeps the current scale.
+	now := time.Now()
+	expectScale(t, a, now, ScaleResult{2, expectedEBC(10, 101, 10, 2), expectedNA(a, 2), true})
+
+	// Restored: normal decisions are made right away.
+	metrics.Restored = true
+	now = now.Add(tickInterval)
+	expectScale(t, a, now, ScaleResult{1, expectedEBC(10, 101, 10, 2), expectedNA(a, 2), true})
+	if !a.panicTime.IsZero() {
+		t.Error("Autoscaler still in panic mode after the history was restored")
+	}
+
+	// A surge panics as usual, and the restored history doesn't end that panic.
+	metrics.SetStableAndPanicConcurrency(40, 40)
+	now = now.Add(tickInterval)
+	expectScale(t, a, now, ScaleResult{4, expectedEBC(10, 101, 40, 2), expectedNA(a, 2), true})
+	metrics.SetStableAndPanicConcurrency(10, 10)
+	now = now.Add(tickInterval)
+	expectScale(t, a, now, ScaleResult{4, expectedEBC(10, 101, 10, 2), expectedNA(a, 2), true})
+}
+
 func TestNewFail(t *testing.T) {
 	metrics := &staticMetricClient
 	deciderSpec := &DeciderSpec{
@@ -712,7 +808,11 @@ func reset() {
 		panicRequestConcurrencyM.Name(),
 		targetRequestConcurrencyM.Name(),
 		stableRPSM.Name(), panicRPSM.Name(),
-		targetRPSM.Name(), panicM.Name())
+		targetRPSM.Name(), panicM.Name(),
+		reactivePodCountM.Name(), forecastPodCountM.Name(),
+		forecastRequestConcurrencyM.Name(), forecastRPSM.Name(),
+		stableCustomMetricM.Name(), panicCustomMetricM.Name(),
+		targetCustomMetricM.Name(), forecastCustomMetricM.Name())
 	register()
 }
 
@@ -730,7 +830,16 @@ type metricClient struct {
 	PanicConcurrency  float64
 	StableRPS         float64
 	PanicRPS          float64
-	ErrF              func(key types.NamespacedName, now time.Time) error
+	// ForecastedConcurrency and ForecastedRPS are returned by the respective
+	// forecast methods.
+	ForecastedConcurrency float64
+	ForecastedRPS         float64
+	StableCustom          float64
+	PanicCustom           float64
+	ForecastedCustom      float64
+	ErrF                  func(key types.NamespacedName, now time.Time) error
+	ForecastErr           error
+	Restored              bool
 }
 
 // SetStableAndPanicConcurrency sets the stable and panic concurrencies.
@@ -758,6 +867,39 @@ func (mc *metricClient) StableAndPanicRPS(key types.NamespacedName, now time.Tim
 	return mc.StableRPS, mc.PanicRPS, err
 }
 
+// ForecastConcurrency returns the forecasted concurrency stored in the object
+// and ForecastErr as the error.
+func (mc *metricClient) ForecastConcurrency(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	return mc.ForecastedConcurrency, mc.ForecastErr
+}
+
+// ForecastRPS returns the forecasted RPS stored in the object
+// and ForecastErr as the error.
+func (mc *metricClient) ForecastRPS(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	return mc.ForecastedRPS, mc.ForecastErr
+}
+
+// StableAndPanicCustom returns stable/panic custom metric values stored in
+// the object and the result of Errf as the error.
+func (mc *metricClient) StableAndPanicCustom(key types.NamespacedName, now time.Time) (float64, float64, error) {
+	var err error
+	if mc.ErrF != nil {
+		err = mc.ErrF(key, now)
+	}
+	return mc.StableCustom, mc.PanicCustom, err
+}
+
+// ForecastCustom returns the forecasted custom metric value stored in the object
+// and ForecastErr as the error.
+func (mc *metricClient) ForecastCustom(key types.NamespacedName, now time.Time, horizon time.Duration) (float64, error) {
+	return mc.ForecastedCustom, mc.ForecastErr
+}
+
+// HistoryRestored returns Restored stored in the object.
+func (mc *metricClient) HistoryRestored(key types.NamespacedName) bool {
+	return mc.Restored
+}
+
 func BenchmarkAutoscaler(b *testing.B) {
 	metrics := &metricClient{StableConcurrency: 50.0, PanicConcurrency: 10}
 	a := newTestAutoscalerNoPC(10, 101, metrics)
@@ -18,6 +18,7 @@ package scaling
 
 import (
 	pkgmetrics "knative.dev/pkg/metrics"
+	"knative.dev/serving/pkg/apis/autoscaling"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -56,12 +57,56 @@ var (
 		"target_requests_per_second",
 		"The desired requests-per-second for each pod",
 		stats.UnitDimensionless)
+	stableCustomMetricM = stats.Float64(
+		"stable_custom_metric",
+		"Average of the application-defined metric per observed pod over the stable window",
+		stats.UnitDimensionless)
+	panicCustomMetricM = stats.Float64(
+		"panic_custom_metric",
+		"Average of the application-defined metric per observed pod over the panic window",
+		stats.UnitDimensionless)
+	targetCustomMetricM = stats.Float64(
+		"target_custom_metric_per_pod",
+		"The desired value of the application-defined metric for each pod",
+		stats.UnitDimensionless)
+	reactivePodCountM = stats.Int64(
+		"reactive_desired_pods",
+		"Number of pods autoscaler would allocate based on the observed metric values only",
+		stats.UnitDimensionless)
+	forecastPodCountM = stats.Int64(
+		"forecast_desired_pods",
+		"Number of pods autoscaler would allocate based on the forecasted metric values",
+		stats.UnitDimensionless)
+	forecastRequestConcurrencyM = stats.Float64(
+		"forecast_request_concurrency",
+		"Forecasted average of requests count per observed pod",
+		stats.UnitDimensionless)
+	forecastRPSM = stats.Float64(
+		"forecast_requests_per_second",
+		"Forecasted requests-per-second per observed pod",
+		stats.UnitDimensionless)
+	forecastCustomMetricM = stats.Float64(
+		"forecast_custom_metric",
+		"Forecasted value of the application-defined metric per observed pod",
+		stats.UnitDimensionless)
 	panicM = stats.Int64(
 		"panic_mode",
 		"1 if autoscaler is in panic mode, 0 otherwise",
 		stats.UnitDimensionless)
 )
 
+// forecastValueM returns the forecast measure for the given scaling metric.
+func forecastValueM(metric string) *stats.Float64Measure {
+	switch metric {
+	case autoscaling.RPS:
+		return forecastRPSM
+	case autoscaling.Custom:
+		return forecastCustomMetricM
+	default:
+		return forecastRequestConcurrencyM
+	}
+}
+
 func init() {
 	register()
 }
@@ -101,6 +146,26 @@ func register() {
 			Measure:     panicM,
 			Aggregation: view.LastValue(),
 		},
+		&view.View{
+			Description: "Number of pods autoscaler would allocate based on the observed metric values only",
+			Measure:     reactivePodCountM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "Number of pods autoscaler would allocate based on the forecasted metric values",
+			Measure:     forecastPodCountM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "Forecasted average of requests count",
+			Measure:     forecastRequestConcurrencyM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "Forecasted requests-per-second",
+			Measure:     forecastRPSM,
+			Aggregation: view.LastValue(),
+		},
 		&view.View{
 			Description: "Average requests-per-second over the stable window",
 			Measure:     stableRPSM,
@@ -116,6 +181,26 @@ func register() {
 			Measure:     targetRPSM,
 			Aggregation: view.LastValue(),
 		},
+		&view.View{
+			Description: "Average of the application-defined metric over the stable window",
+			Measure:     stableCustomMetricM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "Average of the application-defined metric over the panic window",
+			Measure:     panicCustomMetricM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "The desired value of the application-defined metric for each pod",
+			Measure:     targetCustomMetricM,
+			Aggregation: view.LastValue(),
+		},
+		&view.View{
+			Description: "Forecasted value of the application-defined metric",
+			Measure:     forecastCustomMetricM,
+			Aggregation: view.LastValue(),
+		},
 	); err != nil {
 		panic(err)
 	}
@@ -70,6 +70,12 @@ type DeciderSpec struct {
 	// ScaleDownDelay is the time that must pass at reduced concurrency before a
 	// scale-down decision is applied.
 	ScaleDownDelay time.Duration
+	// ScalingMode is the mode in which the decider computes the desired scale,
+	// i.e. reactive or predictive.
+	ScalingMode string
+	// ForecastHorizon is how far ahead the metric is forecasted in the
+	// predictive scaling mode.
+	ForecastHorizon time.Duration
 	// InitialScale is the calculated initial scale of the revision, taking both
 	// revision initial scale and cluster initial scale into account. Revision initial
 	// scale overrides cluster initial scale.
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule implements the recurring time windows overriding the
+// scale bounds of a PodAutoscaler.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchDays bounds the search of the next activation of a Schedule, so
+// that schedules which never fire, e.g. on February 30th, terminate.
+const maxSearchDays = 5 * 366
+
+// field is the range of the values of a field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	minutes  = field{"minute", 0, 59}
+	hours    = field{"hour", 0, 23}
+	days     = field{"day of month", 1, 31}
+	months   = field{"month", 1, 12}
+	weekdays = field{"day of week", 0, 7} // Both 0 and 7 are Sunday.
+)
+
+// Schedule is a parsed cron expression in the standard five fields format:
+// minute, hour, day of month, month and day of week. The fields support
+// `*`, values, ranges `a-b`, steps `*/n` and `a-b/n`, and lists thereof.
+// As in cron, when both the day of month and the day of week are
+// restricted, a day matching either of them matches. A field starting with
+// `*`, like `*/2`, does not restrict the day.
+type Schedule struct {
+	minute, hour, day, month, weekday uint64
+
+	// anyDay and anyWeekday are whether the day of month and day of week
+	// fields start with `*`.
+	anyDay, anyWeekday bool
+}
+
+// ParseSchedule parses the cron expression.
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d in %q", len(fields), spec)
+	}
+	s := &Schedule{
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}
+	for i, f := range []struct {
+		field
+		bits *uint64
+	}{
+		{minutes, &s.minute},
+		{hours, &s.hour},
+		{days, &s.day},
+		{months, &s.month},
+		{weekdays, &s.weekday},
+	} {
+		bits, err := parseField(fields[i], f.field)
+		if err != nil {
+			return nil, err
+		}
+		*f.bits = bits
+	}
+	// Sunday may be written 7.
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1
+	}
+	return s, nil
+}
+
+// parseField returns the bitset of the values of the field matching expr.
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(expr, ",") {
+		rng, step := term, 1
+		if i := strings.IndexByte(term, '/'); i >= 0 {
+			var err error
+			rng = term[:i]
+			if step, err = strconv.Atoi(term[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s %q", f.name, term)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		switch i := strings.IndexByte(rng, '-'); {
+		case rng == "*":
+		case i >= 0:
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(rng[:i])
+			hi, err2 = strconv.Atoi(rng[i+1:])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range in %s %q", f.name, term)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %s %q", f.name, term)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				// `a/n` means from a to the max by n.
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s %q out of range [%d, %d]", f.name, term, f.min, f.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time strictly after t matched by the schedule, in the
+// location of t, or the zero time if there is none within the next years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	// Start at the next whole minute.
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	for i := 0; i < maxSearchDays; i++ {
+		if s.matchesDay(day) {
+			for h := 0; h < 24; h++ {
+				if s.hour&(1<<uint(h)) == 0 {
+					continue
+				}
+				for min := 0; min < 60; min++ {
+					if s.minute&(1<<uint(min)) == 0 {
+						continue
+					}
+					// Times skipped by a DST transition are normalized past it.
+					if c := time.Date(day.Year(), day.Month(), day.Day(), h, min, 0, 0, loc); !c.Before(t) {
+						return c
+					}
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(day time.Time) bool {
+	if s.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	dom := s.day&(1<<uint(day.Day())) != 0
+	dow := s.weekday&(1<<uint(day.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return dom && dow
+	}
+	return dom || dow
+}
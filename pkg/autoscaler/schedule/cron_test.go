@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+		"*/x * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = nil error, want an error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal("LoadLocation() =", err)
+	}
+	// A Wednesday.
+	wed := time.Date(2026, 6, 3, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{{
+		name: "every minute",
+		spec: "* * * * *",
+		from: wed,
+		want: time.Date(2026, 6, 3, 10, 31, 0, 0, time.UTC),
+	}, {
+		name: "strictly after",
+		spec: "31 10 * * *",
+		from: time.Date(2026, 6, 3, 10, 31, 0, 0, time.UTC),
+		want: time.Date(2026, 6, 4, 10, 31, 0, 0, time.UTC),
+	}, {
+		name: "steps",
+		spec: "*/20 * * * *",
+		from: wed,
+		want: time.Date(2026, 6, 3, 10, 40, 0, 0, time.UTC),
+	}, {
+		name: "range step",
+		spec: "0 1-9/4 * * *",
+		from: wed,
+		want: time.Date(2026, 6, 4, 1, 0, 0, 0, time.UTC),
+	}, {
+		name: "list",
+		spec: "15,45 * * * *",
+		from: wed,
+		want: time.Date(2026, 6, 3, 10, 45, 0, 0, time.UTC),
+	}, {
+		name: "weekdays, from wednesday",
+		spec: "0 8 * * 1-5",
+		from: wed,
+		want: time.Date(2026, 6, 4, 8, 0, 0, 0, time.UTC),
+	}, {
+		name: "weekdays, from friday evening",
+		spec: "0 8 * * 1-5",
+		from: time.Date(2026, 6, 5, 20, 0, 0, 0, time.UTC),
+		want: time.Date(2026, 6, 8, 8, 0, 0, 0, time.UTC),
+	}, {
+		name: "sunday as 7",
+		spec: "0 0 * * 7",
+		from: wed,
+		want: time.Date(2026, 6, 7, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "day of month or day of week",
+		spec: "0 0 5 * 1",
+		from: wed,
+		want: time.Date(2026, 6, 5, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "day of month step and day of week",
+		spec: "0 0 */1 * 1",
+		from: wed,
+		want: time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "day of month and day of week step",
+		spec: "0 0 5 * */2",
+		from: wed,
+		want: time.Date(2026, 7, 5, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "month",
+		spec: "0 0 1 1 *",
+		from: wed,
+		want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "leap day",
+		spec: "0 0 29 2 *",
+		from: wed,
+		want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "never",
+		spec: "0 0 30 2 *",
+		from: wed,
+	}, {
+		name: "time zone",
+		spec: "0 8 * * *",
+		from: wed.In(berlin),
+		want: time.Date(2026, 6, 4, 8, 0, 0, 0, berlin),
+	}, {
+		name: "skipped by DST",
+		spec: "30 2 * * *",
+		from: time.Date(2026, 3, 28, 12, 0, 0, 0, berlin),
+		want: time.Date(2026, 3, 29, 3, 30, 0, 0, berlin),
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseSchedule(tc.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) = %v", tc.spec, err)
+			}
+			if got := s.Next(tc.from); !got.Equal(tc.want) {
+				t.Errorf("Next(%v) = %v, want: %v", tc.from, got, tc.want)
+			}
+		})
+	}
+}
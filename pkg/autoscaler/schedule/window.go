@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	// Embed the time zone database, so that the windows' time zones resolve
+	// regardless of the image the binaries run on.
+	_ "time/tzdata"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Window is a recurring period of time overriding the scale bounds of a
+// PodAutoscaler. For example, the window of the business hours:
+//   - name: business-hours
+//     schedule: "0 8 * * 1-5"
+//     duration: 10h
+//     timeZone: Europe/Berlin
+//     minScale: 5
+type Window struct {
+	// Name identifies the window in the status of the PodAutoscaler.
+	Name string `json:"name"`
+	// Schedule is the cron expression of the starts of the window.
+	Schedule string `json:"schedule"`
+	// Duration is how long the window lasts from each of its starts.
+	Duration string `json:"duration"`
+	// TimeZone is the IANA time zone of the schedule, UTC if empty.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// MinScale overrides the minimum scale within the window.
+	MinScale *int32 `json:"minScale,omitempty"`
+	// MaxScale overrides the maximum scale within the window.
+	MaxScale *int32 `json:"maxScale,omitempty"`
+
+	schedule *Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// ParseWindows parses and validates the YAML or JSON list of windows.
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	if err := yaml.UnmarshalStrict([]byte(s), &windows); err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(windows))
+	for i := range windows {
+		w := &windows[i]
+		if w.Name == "" {
+			return nil, fmt.Errorf("window %d: name must be set", i)
+		}
+		if _, ok := names[w.Name]; ok {
+			return nil, fmt.Errorf("window %d: duplicate name %q", i, w.Name)
+		}
+		names[w.Name] = struct{}{}
+
+		var err error
+		if w.schedule, err = ParseSchedule(w.Schedule); err != nil {
+			return nil, fmt.Errorf("window %q: invalid schedule: %w", w.Name, err)
+		}
+		if w.duration, err = time.ParseDuration(w.Duration); err != nil {
+			return nil, fmt.Errorf("window %q: invalid duration: %w", w.Name, err)
+		}
+		if w.duration < time.Minute {
+			return nil, fmt.Errorf("window %q: duration %v must be at least 1m", w.Name, w.duration)
+		}
+		if w.location, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, fmt.Errorf("window %q: invalid time zone: %w", w.Name, err)
+		}
+
+		switch {
+		case w.MinScale == nil && w.MaxScale == nil:
+			return nil, fmt.Errorf("window %q: minScale or maxScale must be set", w.Name)
+		case w.MinScale != nil && *w.MinScale < 0:
+			return nil, fmt.Errorf("window %q: minScale=%d must be non-negative", w.Name, *w.MinScale)
+		case w.MaxScale != nil && *w.MaxScale < 0:
+			return nil, fmt.Errorf("window %q: maxScale=%d must be non-negative", w.Name, *w.MaxScale)
+		case w.MinScale != nil && w.MaxScale != nil && *w.MaxScale != 0 && *w.MaxScale < *w.MinScale:
+			return nil, fmt.Errorf("window %q: maxScale=%d is less than minScale=%d", w.Name, *w.MaxScale, *w.MinScale)
+		}
+	}
+	return windows, nil
+}
+
+// start returns the start of the occurrence of the window active at now, or
+// the zero time if the window is not active.
+func (w *Window) start(now time.Time) time.Time {
+	// The only occurrence that may be active is the first one starting
+	// after now - duration.
+	start := w.schedule.Next(now.Add(-w.duration).In(w.location))
+	if start.IsZero() || start.After(now) {
+		return time.Time{}
+	}
+	return start
+}
+
+// Active returns the first of the windows active at now, if any, and the
+// time of the next start or end of any of the windows, i.e. when the active
+// window may change. The time is zero if no window ever starts again.
+// The windows are those returned by ParseWindows.
+func Active(windows []Window, now time.Time) (*Window, time.Time) {
+	var (
+		active *Window
+		next   time.Time
+	)
+	earliest := func(t time.Time) {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	for i := range windows {
+		w := &windows[i]
+		if start := w.start(now); !start.IsZero() {
+			if active == nil {
+				active = w
+			}
+			earliest(start.Add(w.duration))
+		}
+		earliest(w.schedule.Next(now.In(w.location)))
+	}
+	return active, next
+}
+
+// Find returns the window with the name, if any.
+func Find(windows []Window, name string) *Window {
+	for i := range windows {
+		if windows[i].Name == name {
+			return &windows[i]
+		}
+	}
+	return nil
+}
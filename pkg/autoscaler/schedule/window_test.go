@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWindowsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows string
+		want    string
+	}{{
+		name:    "not a list",
+		windows: "name: foo",
+		want:    "cannot unmarshal",
+	}, {
+		name:    "unknown field",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h", "minScale": 1, "min": 1}]`,
+		want:    "unknown field",
+	}, {
+		name:    "no name",
+		windows: `[{"schedule": "* * * * *", "duration": "1h", "minScale": 1}]`,
+		want:    "name must be set",
+	}, {
+		name: "duplicate name",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h", "minScale": 1},
+		           {"name": "a", "schedule": "* * * * *", "duration": "1h", "minScale": 1}]`,
+		want: "duplicate name",
+	}, {
+		name:    "bad schedule",
+		windows: `[{"name": "a", "schedule": "* * *", "duration": "1h", "minScale": 1}]`,
+		want:    "invalid schedule",
+	}, {
+		name:    "bad duration",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1 hour", "minScale": 1}]`,
+		want:    "invalid duration",
+	}, {
+		name:    "short duration",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "30s", "minScale": 1}]`,
+		want:    "must be at least 1m",
+	}, {
+		name:    "bad time zone",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h", "timeZone": "Mars/Olympus", "minScale": 1}]`,
+		want:    "invalid time zone",
+	}, {
+		name:    "no bounds",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h"}]`,
+		want:    "minScale or maxScale must be set",
+	}, {
+		name:    "negative min",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h", "minScale": -1}]`,
+		want:    "must be non-negative",
+	}, {
+		name:    "max less than min",
+		windows: `[{"name": "a", "schedule": "* * * * *", "duration": "1h", "minScale": 5, "maxScale": 2}]`,
+		want:    "maxScale=2 is less than minScale=5",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseWindows(tc.windows); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParseWindows() = %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	windows, err := ParseWindows(`
+- name: business-hours
+  schedule: "0 8 * * 1-5"
+  duration: 10h
+  timeZone: Europe/Berlin
+  minScale: 5
+- name: nightly-batch
+  schedule: "0 2 * * *"
+  duration: 8h
+  minScale: 2
+  maxScale: 10
+`)
+	if err != nil {
+		t.Fatal("ParseWindows() =", err)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantWindow string
+		wantNext   time.Time
+	}{{
+		// 07:00 in Berlin (CEST).
+		name:       "before business hours, within nightly batch",
+		now:        time.Date(2026, 6, 3, 5, 0, 0, 0, time.UTC),
+		wantWindow: "nightly-batch",
+		wantNext:   time.Date(2026, 6, 3, 6, 0, 0, 0, time.UTC),
+	}, {
+		name:       "business hours take precedence",
+		now:        time.Date(2026, 6, 3, 7, 0, 0, 0, time.UTC),
+		wantWindow: "business-hours",
+		wantNext:   time.Date(2026, 6, 3, 10, 0, 0, 0, time.UTC),
+	}, {
+		name:       "business hours",
+		now:        time.Date(2026, 6, 3, 12, 0, 0, 0, time.UTC),
+		wantWindow: "business-hours",
+		wantNext:   time.Date(2026, 6, 3, 16, 0, 0, 0, time.UTC),
+	}, {
+		name:     "evening",
+		now:      time.Date(2026, 6, 3, 16, 0, 0, 0, time.UTC),
+		wantNext: time.Date(2026, 6, 4, 2, 0, 0, 0, time.UTC),
+	}, {
+		name:     "saturday morning",
+		now:      time.Date(2026, 6, 6, 11, 0, 0, 0, time.UTC),
+		wantNext: time.Date(2026, 6, 7, 2, 0, 0, 0, time.UTC),
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, next := Active(windows, tc.now)
+			var got string
+			if w != nil {
+				got = w.Name
+			}
+			if got != tc.wantWindow {
+				t.Errorf("Active window = %q, want: %q", got, tc.wantWindow)
+			}
+			if !next.Equal(tc.wantNext) {
+				t.Errorf("Next boundary = %v, want: %v", next, tc.wantNext)
+			}
+		})
+	}
+
+	if got := Find(windows, "nightly-batch"); got == nil || *got.MaxScale != 10 {
+		t.Errorf("Find(nightly-batch) = %v", got)
+	}
+	if got := Find(windows, "nope"); got != nil {
+		t.Errorf("Find(nope) = %v, want nil", got)
+	}
+}
@@ -66,6 +66,11 @@ type Forwarder struct {
 	// processorsLock is the lock for processors.
 	processorsLock sync.RWMutex
 	processors     map[string]bucketProcessor
+	// onAcquired is called when this pod becomes the owner of a bucket.
+	onAcquired func(bkt string)
+	// Used to capture the asynchronous onAcquired calls to be waited
+	// on when shutting down.
+	acquiredWg sync.WaitGroup
 	// Used to capture asynchronous processes for re-enqueuing to be waited
 	// on when shutting down.
 	retryWg sync.WaitGroup
@@ -105,8 +110,29 @@ func (f *Forwarder) getProcessor(bkt string) bucketProcessor {
 
 func (f *Forwarder) setProcessor(bkt string, p bucketProcessor) {
 	f.processorsLock.Lock()
-	defer f.processorsLock.Unlock()
+	_, wasOwned := f.processors[bkt].(*localProcessor)
 	f.processors[bkt] = p
+	onAcquired := f.onAcquired
+	f.processorsLock.Unlock()
+
+	if _, owned := p.(*localProcessor); owned && !wasOwned && onAcquired != nil {
+		// onAcquired might talk to the API server, so don't block the
+		// bucket ownership handling on it.
+		f.acquiredWg.Add(1)
+		go func() {
+			defer f.acquiredWg.Done()
+			onAcquired(bkt)
+		}()
+	}
+}
+
+// OnBucketAcquired registers a function to call asynchronously when this pod
+// becomes the owner of a bucket. It must be called before the processors are
+// set up.
+func (f *Forwarder) OnBucketAcquired(fn func(bkt string)) {
+	f.processorsLock.Lock()
+	defer f.processorsLock.Unlock()
+	f.onAcquired = fn
 }
 
 // Process enqueues the given Stat for processing asynchronously.
@@ -177,6 +203,7 @@ func (f *Forwarder) Cancel() {
 	}
 
 	f.processingWg.Wait()
+	f.acquiredWg.Wait()
 	close(f.statCh)
 }
 
@@ -495,3 +495,29 @@ func TestIsBucketOwner(t *testing.T) {
 		t.Errorf("IsBktOwner(not-in-record) = %v, want true", got)
 	}
 }
+
+func TestOnBucketAcquired(t *testing.T) {
+	f := Forwarder{
+		processors: map[string]bucketProcessor{},
+	}
+	acquiredCh := make(chan string, 10)
+	f.OnBucketAcquired(func(bkt string) {
+		acquiredCh <- bkt
+	})
+
+	f.setProcessor(bucket1, &remoteProcessor{bkt: bucket1})
+	f.setProcessor(bucket2, &localProcessor{bkt: bucket2, accept: noOp})
+	// Replacing the processor of an owned bucket is not an acquisition.
+	f.setProcessor(bucket2, &localProcessor{bkt: bucket2, accept: noOp})
+	f.setProcessor(bucket1, &localProcessor{bkt: bucket1, accept: noOp})
+
+	f.acquiredWg.Wait()
+	close(acquiredCh)
+	acquired := sets.NewString()
+	for bkt := range acquiredCh {
+		acquired.Insert(bkt)
+	}
+	if want := sets.NewString(bucket2, bucket1); !acquired.Equal(want) {
+		t.Error("Acquired buckets mismatch (-want,+got):", cmp.Diff(want, acquired))
+	}
+}
@@ -42,6 +42,7 @@ var isBucketHost = bucket.IsBucketHost
 // Server receives autoscaler statistics over WebSocket and sends them to a channel.
 type Server struct {
 	addr        string
+	mux         *http.ServeMux
 	wsSrv       http.Server
 	servingCh   chan struct{}
 	stopCh      chan struct{}
@@ -63,16 +64,22 @@ func New(statsServerAddr string, statsCh chan<- metrics.StatMessage, logger *zap
 		logger:      logger.Named("stats-websocket-server").With("address", statsServerAddr),
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", svr.Handler)
+	svr.mux = http.NewServeMux()
+	svr.mux.HandleFunc("/", svr.Handler)
 	svr.wsSrv = http.Server{
 		Addr:      statsServerAddr,
-		Handler:   mux,
+		Handler:   svr.mux,
 		ConnState: svr.onConnStateChange,
 	}
 	return &svr
 }
 
+// Handle registers the handler for the given path next to the stats
+// websocket. It must be called before the server is started.
+func (s *Server) Handle(path string, h http.Handler) {
+	s.mux.Handle(path, h)
+}
+
 func (s *Server) onConnStateChange(conn net.Conn, state http.ConnState) {
 	if state == http.StateNew {
 		tcpConn := conn.(*net.TCPConn)
@@ -24,7 +24,13 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	cm "knative.dev/pkg/configmap"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -54,6 +60,68 @@ type Config struct {
 	// regardless of creation or staleness time-bounds.
 	// Set Disabled (-1) to disable/ignore max.
 	MaxNonActiveRevisions int64
+	// DryRun reports the revisions GC would delete instead of deleting them.
+	DryRun bool
+
+	// ArchiveRevisions snapshots the revisions GC deletes into an archive per
+	// Configuration, from which they can be restored.
+	ArchiveRevisions bool
+	// Maximum number of revisions to keep in the archive of a Configuration.
+	// Set Disabled (-1) to disable/ignore max.
+	MaxArchivedRevisions int64
+	// Duration from archiving after which a revision is dropped from the
+	// archive. Set Disabled (-1) to disable/ignore duration.
+	RetainArchivedRevisionsTime time.Duration
+
+	// Policies override the settings above for the Configurations they match.
+	// The first matching policy applies.
+	Policies []Policy
+}
+
+// Policy overrides the settings of the Config for the Configurations it
+// matches.
+type Policy struct {
+	// Namespaces restricts the policy to the Configurations of these
+	// namespaces. All the namespaces match when empty.
+	Namespaces []string
+	// Selector restricts the policy to the Configurations with these labels.
+	// All the Configurations match when empty.
+	Selector map[string]string
+	// Settings are the settings of the matched Configurations. Those the
+	// policy does not set are the cluster-wide ones.
+	Settings Config
+}
+
+// policy is a Policy as written in the "policies" setting.
+type policy struct {
+	Namespaces                []string            `json:"namespaces,omitempty"`
+	Selector                  map[string]string   `json:"selector,omitempty"`
+	RetainSinceCreateTime     string              `json:"retain-since-create-time,omitempty"`
+	RetainSinceLastActiveTime string              `json:"retain-since-last-active-time,omitempty"`
+	MinNonActiveRevisions     *intstr.IntOrString `json:"min-non-active-revisions,omitempty"`
+	MaxNonActiveRevisions     *intstr.IntOrString `json:"max-non-active-revisions,omitempty"`
+	DryRun                    *bool               `json:"dry-run,omitempty"`
+}
+
+// Matches returns true if the policy applies to the Configuration of the
+// namespace with the labels.
+func (p *Policy) Matches(namespace string, lbls map[string]string) bool {
+	if len(p.Namespaces) > 0 && !sets.NewString(p.Namespaces...).Has(namespace) {
+		return false
+	}
+	return labels.SelectorFromSet(p.Selector).Matches(labels.Set(lbls))
+}
+
+// ForConfiguration returns the settings of the Configuration of the
+// namespace with the labels: those of the first matching policy, or else the
+// cluster-wide ones.
+func (c *Config) ForConfiguration(namespace string, lbls map[string]string) *Config {
+	for i := range c.Policies {
+		if c.Policies[i].Matches(namespace, lbls) {
+			return &c.Policies[i].Settings
+		}
+	}
+	return c
 }
 
 func defaultConfig() *Config {
@@ -62,6 +130,9 @@ func defaultConfig() *Config {
 		RetainSinceLastActiveTime: 15 * time.Hour,
 		MinNonActiveRevisions:     20,
 		MaxNonActiveRevisions:     1000,
+
+		MaxArchivedRevisions:        10,
+		RetainArchivedRevisionsTime: 30 * 24 * time.Hour,
 	}
 }
 
@@ -70,12 +141,17 @@ func NewConfigFromConfigMapFunc(ctx context.Context) func(configMap *corev1.Conf
 	return func(configMap *corev1.ConfigMap) (*Config, error) {
 		c := defaultConfig()
 
-		var retainCreate, retainActive, max string
+		var retainCreate, retainActive, max, maxArchived, retainArchived, policies string
 		if err := cm.Parse(configMap.Data,
 			cm.AsString("retain-since-create-time", &retainCreate),
 			cm.AsString("retain-since-last-active-time", &retainActive),
 			cm.AsInt64("min-non-active-revisions", &c.MinNonActiveRevisions),
 			cm.AsString("max-non-active-revisions", &max),
+			cm.AsBool("dry-run", &c.DryRun),
+			cm.AsBool("archive-revisions", &c.ArchiveRevisions),
+			cm.AsString("max-archived-revisions", &maxArchived),
+			cm.AsString("retain-archived-revisions-time", &retainArchived),
+			cm.AsString("policies", &policies),
 		); err != nil {
 			return nil, fmt.Errorf("failed to parse data: %w", err)
 		}
@@ -90,16 +166,84 @@ func NewConfigFromConfigMapFunc(ctx context.Context) func(configMap *corev1.Conf
 		if err := parseDisabledOrInt64(max, &c.MaxNonActiveRevisions); err != nil {
 			return nil, fmt.Errorf("failed to parse max-non-active-revisions: %w", err)
 		}
-		if c.MinNonActiveRevisions < 0 {
-			return nil, fmt.Errorf("min-non-active-revisions must be non-negative, was: %d", c.MinNonActiveRevisions)
+		if err := parseDisabledOrInt64(maxArchived, &c.MaxArchivedRevisions); err != nil {
+			return nil, fmt.Errorf("failed to parse max-archived-revisions: %w", err)
+		}
+		if err := parseDisabledOrDuration(retainArchived, &c.RetainArchivedRevisionsTime); err != nil {
+			return nil, fmt.Errorf("failed to parse retain-archived-revisions-time: %w", err)
 		}
-		if c.MaxNonActiveRevisions >= 0 && c.MinNonActiveRevisions > c.MaxNonActiveRevisions {
-			return nil, fmt.Errorf("min-non-active-revisions(%d) must be <= max-non-active-revisions(%d)", c.MinNonActiveRevisions, c.MaxNonActiveRevisions)
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		if policies != "" {
+			if err := c.parsePolicies(policies); err != nil {
+				return nil, fmt.Errorf("failed to parse policies: %w", err)
+			}
 		}
 		return c, nil
 	}
 }
 
+func (c *Config) validate() error {
+	if c.MinNonActiveRevisions < 0 {
+		return fmt.Errorf("min-non-active-revisions must be non-negative, was: %d", c.MinNonActiveRevisions)
+	}
+	if c.MaxNonActiveRevisions >= 0 && c.MinNonActiveRevisions > c.MaxNonActiveRevisions {
+		return fmt.Errorf("min-non-active-revisions(%d) must be <= max-non-active-revisions(%d)", c.MinNonActiveRevisions, c.MaxNonActiveRevisions)
+	}
+	return nil
+}
+
+// parsePolicies parses the YAML list of policies, whose unset settings are
+// those of the Config.
+func (c *Config) parsePolicies(data string) error {
+	var ps []policy
+	if err := yaml.UnmarshalStrict([]byte(data), &ps); err != nil {
+		return err
+	}
+	c.Policies = make([]Policy, 0, len(ps))
+	for i, p := range ps {
+		if len(p.Namespaces) == 0 && len(p.Selector) == 0 {
+			return fmt.Errorf("policy %d: namespaces or selector must be set", i)
+		}
+		if err := validation.ValidateLabels(p.Selector, field.NewPath("selector")).ToAggregate(); err != nil {
+			return fmt.Errorf("policy %d: %w", i, err)
+		}
+		settings := *c
+		settings.Policies = nil
+		if err := parseDisabledOrDuration(p.RetainSinceCreateTime, &settings.RetainSinceCreateTime); err != nil {
+			return fmt.Errorf("policy %d: failed to parse retain-since-create-time: %w", i, err)
+		}
+		if err := parseDisabledOrDuration(p.RetainSinceLastActiveTime, &settings.RetainSinceLastActiveTime); err != nil {
+			return fmt.Errorf("policy %d: failed to parse retain-since-last-active-time: %w", i, err)
+		}
+		if p.MinNonActiveRevisions != nil {
+			min, err := strconv.ParseInt(p.MinNonActiveRevisions.String(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("policy %d: failed to parse min-non-active-revisions: %w", i, err)
+			}
+			settings.MinNonActiveRevisions = min
+		}
+		if p.MaxNonActiveRevisions != nil {
+			if err := parseDisabledOrInt64(p.MaxNonActiveRevisions.String(), &settings.MaxNonActiveRevisions); err != nil {
+				return fmt.Errorf("policy %d: failed to parse max-non-active-revisions: %w", i, err)
+			}
+		}
+		if p.DryRun != nil {
+			settings.DryRun = *p.DryRun
+		}
+		if err := settings.validate(); err != nil {
+			return fmt.Errorf("policy %d: %w", i, err)
+		}
+		c.Policies = append(c.Policies, Policy{
+			Namespaces: p.Namespaces,
+			Selector:   p.Selector,
+			Settings:   settings,
+		})
+	}
+	return nil
+}
+
 func parseDisabledOrInt64(val string, toSet *int64) error {
 	switch {
 	case val == "":
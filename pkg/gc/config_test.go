@@ -47,10 +47,12 @@ func TestOurConfig(t *testing.T) {
 	}, {
 		name: "with value overrides",
 		want: &Config{
-			RetainSinceCreateTime:     17 * time.Hour,
-			RetainSinceLastActiveTime: 16 * time.Hour,
-			MinNonActiveRevisions:     5,
-			MaxNonActiveRevisions:     500,
+			RetainSinceCreateTime:       17 * time.Hour,
+			RetainSinceLastActiveTime:   16 * time.Hour,
+			MinNonActiveRevisions:       5,
+			MaxNonActiveRevisions:       500,
+			MaxArchivedRevisions:        10,
+			RetainArchivedRevisionsTime: 30 * 24 * time.Hour,
 		},
 		data: map[string]string{
 			"retain-since-create-time":      "17h",
@@ -131,6 +133,116 @@ func TestOurConfig(t *testing.T) {
 		data: map[string]string{
 			"max-non-active-revisions": disabled,
 		},
+	}, {
+		name: "dry-run",
+		want: func() *Config {
+			d := defaultConfig()
+			d.DryRun = true
+			return d
+		}(),
+		data: map[string]string{
+			"dry-run": "true",
+		},
+	}, {
+		name: "archive",
+		want: func() *Config {
+			d := defaultConfig()
+			d.ArchiveRevisions = true
+			d.MaxArchivedRevisions = 3
+			d.RetainArchivedRevisionsTime = time.Duration(Disabled)
+			return d
+		}(),
+		data: map[string]string{
+			"archive-revisions":              "true",
+			"max-archived-revisions":         "3",
+			"retain-archived-revisions-time": disabled,
+		},
+	}, {
+		name: "max-archived unparsable",
+		fail: true,
+		data: map[string]string{
+			"max-archived-revisions": "-3",
+		},
+	}, {
+		name: "archive retention unparsable",
+		fail: true,
+		data: map[string]string{
+			"retain-archived-revisions-time": "forever",
+		},
+	}, {
+		name: "policies",
+		want: func() *Config {
+			d := defaultConfig()
+			d.MinNonActiveRevisions = 2
+			d.Policies = []Policy{{
+				Namespaces: []string{"staging"},
+				Settings: Config{
+					RetainSinceCreateTime:       time.Duration(Disabled),
+					RetainSinceLastActiveTime:   time.Duration(Disabled),
+					MinNonActiveRevisions:       0,
+					MaxNonActiveRevisions:       5,
+					MaxArchivedRevisions:        10,
+					RetainArchivedRevisionsTime: 30 * 24 * time.Hour,
+				},
+			}, {
+				Selector: map[string]string{"tier": "critical"},
+				Settings: Config{
+					RetainSinceCreateTime:     48 * time.Hour,
+					RetainSinceLastActiveTime: 15 * time.Hour,
+					MinNonActiveRevisions:     2,
+					MaxNonActiveRevisions:       Disabled,
+					DryRun:                      true,
+					MaxArchivedRevisions:        10,
+					RetainArchivedRevisionsTime: 30 * 24 * time.Hour,
+				},
+			}}
+			return d
+		}(),
+		data: map[string]string{
+			"min-non-active-revisions": "2",
+			"policies": `
+- namespaces: ["staging"]
+  retain-since-create-time: disabled
+  retain-since-last-active-time: disabled
+  min-non-active-revisions: 0
+  max-non-active-revisions: "5"
+- selector:
+    tier: critical
+  max-non-active-revisions: disabled
+  dry-run: true
+`,
+		},
+	}, {
+		name: "policies unparsable",
+		fail: true,
+		data: map[string]string{
+			"policies": "- namespaces: staging",
+		},
+	}, {
+		name: "policy with unknown setting",
+		fail: true,
+		data: map[string]string{
+			"policies": "- namespaces: [staging]\n  retain: 1h",
+		},
+	}, {
+		name: "policy matching everything",
+		fail: true,
+		data: map[string]string{
+			"policies": "- max-non-active-revisions: 5",
+		},
+	}, {
+		name: "policy with invalid selector",
+		fail: true,
+		data: map[string]string{
+			"policies": "- selector: {\"tier/\": critical}",
+		},
+	}, {
+		name: "policy min greater than cluster-wide max",
+		fail: true,
+		data: map[string]string{
+			"max-non-active-revisions": "10",
+			"policies":                 "- namespaces: [staging]\n  min-non-active-revisions: 11",
+		},
 	}} {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := NewConfigFromConfigMapFunc(logtesting.TestContextWithLogger(t))(
@@ -145,3 +257,55 @@ func TestOurConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestForConfiguration(t *testing.T) {
+	staging := Config{MaxNonActiveRevisions: 5}
+	critical := Config{MaxNonActiveRevisions: Disabled}
+	c := &Config{
+		MaxNonActiveRevisions: 1000,
+		Policies: []Policy{{
+			Namespaces: []string{"staging"},
+			Settings:   staging,
+		}, {
+			Namespaces: []string{"prod"},
+			Selector:   map[string]string{"tier": "critical"},
+			Settings:   critical,
+		}},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		namespace string
+		labels    map[string]string
+		want      *Config
+	}{{
+		name:      "namespace match",
+		namespace: "staging",
+		want:      &staging,
+	}, {
+		name:      "first match wins",
+		namespace: "staging",
+		labels:    map[string]string{"tier": "critical"},
+		want:      &staging,
+	}, {
+		name:      "namespace and selector match",
+		namespace: "prod",
+		labels:    map[string]string{"tier": "critical", "app": "foo"},
+		want:      &critical,
+	}, {
+		name:      "selector mismatch",
+		namespace: "prod",
+		labels:    map[string]string{"tier": "batch"},
+		want:      c,
+	}, {
+		name:      "no match",
+		namespace: "default",
+		want:      c,
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.ForConfiguration(tt.namespace, tt.labels); !cmp.Equal(tt.want, got) {
+				t.Error("ForConfiguration (-want, +got):", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
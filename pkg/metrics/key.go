@@ -32,4 +32,9 @@ var (
 	ResponseCodeKey      = tag.MustNewKey(metricskey.LabelResponseCode)
 	ResponseCodeClassKey = tag.MustNewKey(metricskey.LabelResponseCodeClass)
 	RouteTagKey          = tag.MustNewKey("tag")
+	TrafficTypeKey       = tag.MustNewKey("traffic_type")
 )
+
+// TaggedTrafficType is the TrafficTypeKey value of the requests routed to a
+// revision by a tag of the route, rather than by the traffic split.
+const TaggedTrafficType = "tagged"
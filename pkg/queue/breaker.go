@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"time"
 
 	"go.uber.org/atomic"
 )
@@ -30,8 +31,50 @@ var (
 	ErrRelease = errors.New("semaphore release error: returned tokens must be <= acquired tokens")
 	// ErrRequestQueueFull indicates the breaker queue depth was exceeded.
 	ErrRequestQueueFull = errors.New("pending request queue full")
+	// ErrQueueWaitExceeded indicates a request waited for capacity longer than
+	// the maximum queue wait of the breaker.
+	ErrQueueWaitExceeded = errors.New("maximum queue wait exceeded")
 )
 
+// serviceTimeEWMAWeight is the weight of the latest execution in the moving
+// average of the service time of the breaker.
+const serviceTimeEWMAWeight = 0.1
+
+// ShedError is returned by a Breaker with a maximum queue wait when it sheds
+// an execution, either because its queue is full or because it waited for
+// capacity for too long.
+type ShedError struct {
+	// Err is ErrRequestQueueFull or ErrQueueWaitExceeded.
+	Err error
+	// RetryAfter is the estimated time it takes the breaker to work off
+	// its queue.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *ShedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the reason of the shedding.
+func (e *ShedError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfterSeconds returns RetryAfter in whole seconds, as expected by the
+// Retry-After header. It is at least one second.
+func (e *ShedError) RetryAfterSeconds() int {
+	return retryAfterSeconds(e.RetryAfter)
+}
+
+// retryAfterSeconds rounds d up to whole seconds, at least one.
+func retryAfterSeconds(d time.Duration) int {
+	if secs := int(math.Ceil(d.Seconds())); secs > 1 {
+		return secs
+	}
+	return 1
+}
+
 // MaxBreakerCapacity is the largest valid value for the MaxConcurrency value of BreakerParams.
 // This is limited by the maximum size of a chan struct{} in the current implementation.
 const MaxBreakerCapacity = math.MaxInt32
@@ -41,27 +84,63 @@ type BreakerParams struct {
 	QueueDepth      int
 	MaxConcurrency  int
 	InitialCapacity int
+	// PriorityQueueDepths are the queue depths of the priority classes of
+	// the breaker, from the highest priority to the lowest. If set, they
+	// supersede QueueDepth.
+	PriorityQueueDepths []int
+	// MaxQueueWait is the maximum time an execution waits for capacity before
+	// it is shed. If set, the executions shed because the queue is full are
+	// reported as a ShedError too. Zero means no limit.
+	MaxQueueWait time.Duration
 }
 
-// Breaker is a component that enforces a concurrency limit on the
-// execution of a function. It also maintains a queue of function
-// executions in excess of the concurrency limit. Function call attempts
-// beyond the limit of the queue are failed immediately.
-type Breaker struct {
+// breakerSemaphore is the semaphore guarding the active slots of the breaker.
+type breakerSemaphore interface {
+	tryAcquire() bool
+	acquire(ctx context.Context) error
+	release()
+	updateCapacity(size int)
+	Capacity() int
+}
+
+// lane holds the pending "queue" of a priority class.
+type lane struct {
 	inFlight   atomic.Int64
 	totalSlots int64
-	sem        *semaphore
 
 	// release is the callback function returned to callers by Reserve to
 	// allow the reservation made by Reserve to be released.
 	release func()
 }
 
+// Breaker is a component that enforces a concurrency limit on the
+// execution of a function. It also maintains a queue of function
+// executions in excess of the concurrency limit. Function call attempts
+// beyond the limit of the queue are failed immediately.
+// If the breaker has priority classes, each class has its own queue and
+// the free capacity goes to the queued executions of the highest class first.
+type Breaker struct {
+	// lanes has a single lane if the breaker has no priority classes.
+	lanes []lane
+	sem   breakerSemaphore
+
+	maxQueueWait time.Duration
+	// serviceTime is the moving average of the execution time in seconds,
+	// only tracked if the breaker has a maximum queue wait.
+	serviceTime atomic.Float64
+}
+
 // NewBreaker creates a Breaker with the desired queue depth,
 // concurrency limit and initial capacity.
 func NewBreaker(params BreakerParams) *Breaker {
-	if params.QueueDepth <= 0 {
-		panic(fmt.Sprintf("Queue depth must be greater than 0. Got %v.", params.QueueDepth))
+	depths := params.PriorityQueueDepths
+	if len(depths) == 0 {
+		depths = []int{params.QueueDepth}
+	}
+	for _, depth := range depths {
+		if depth <= 0 {
+			panic(fmt.Sprintf("Queue depth must be greater than 0. Got %v.", depth))
+		}
 	}
 	if params.MaxConcurrency < 0 {
 		panic(fmt.Sprintf("Max concurrency must be 0 or greater. Got %v.", params.MaxConcurrency))
@@ -70,22 +149,42 @@ func NewBreaker(params BreakerParams) *Breaker {
 		panic(fmt.Sprintf("Initial capacity must be between 0 and max concurrency. Got %v.", params.InitialCapacity))
 	}
 
-	b := &Breaker{
-		totalSlots: int64(params.QueueDepth + params.MaxConcurrency),
-		sem:        newSemaphore(params.MaxConcurrency, params.InitialCapacity),
+	if params.MaxQueueWait < 0 {
+		panic(fmt.Sprintf("Max queue wait must be 0 or greater. Got %v.", params.MaxQueueWait))
 	}
 
-	// Allocating the closure returned by Reserve here avoids an allocation in Reserve.
-	b.release = func() {
-		b.sem.release()
-		b.releasePending()
+	b := &Breaker{
+		lanes:        make([]lane, len(depths)),
+		maxQueueWait: params.MaxQueueWait,
+	}
+	if len(depths) == 1 {
+		b.sem = newSemaphore(params.MaxConcurrency, params.InitialCapacity)
+	} else {
+		b.sem = newPrioritySemaphore(len(depths), params.InitialCapacity)
+	}
+	for i := range b.lanes {
+		l := &b.lanes[i]
+		l.totalSlots = int64(depths[i] + params.MaxConcurrency)
+		// Allocating the closure returned by Reserve here avoids an allocation in Reserve.
+		l.release = func() {
+			b.sem.release()
+			l.releasePending()
+		}
 	}
 
 	return b
 }
 
+// lane returns the lane of the priority class of the request.
+func (b *Breaker) lane(ctx context.Context) *lane {
+	if len(b.lanes) == 1 {
+		return &b.lanes[0]
+	}
+	return &b.lanes[priorityClassFrom(ctx, len(b.lanes))]
+}
+
 // tryAcquirePending tries to acquire a slot on the pending "queue".
-func (b *Breaker) tryAcquirePending() bool {
+func (l *lane) tryAcquirePending() bool {
 	// This is an atomic version of:
 	//
 	// if inFlight == totalSlots {
@@ -101,50 +200,55 @@ func (b *Breaker) tryAcquirePending() bool {
 	// (it fails if we're raced to it) or if we don't fulfill the condition
 	// anymore.
 	for {
-		cur := b.inFlight.Load()
-		if cur == b.totalSlots {
+		cur := l.inFlight.Load()
+		if cur == l.totalSlots {
 			return false
 		}
-		if b.inFlight.CAS(cur, cur+1) {
+		if l.inFlight.CAS(cur, cur+1) {
 			return true
 		}
 	}
 }
 
 // releasePending releases a slot on the pending "queue".
-func (b *Breaker) releasePending() {
-	b.inFlight.Dec()
+func (l *lane) releasePending() {
+	l.inFlight.Dec()
 }
 
 // Reserve reserves an execution slot in the breaker, to permit
 // richer semantics in the caller.
 // The caller on success must execute the callback when done with work.
 func (b *Breaker) Reserve(ctx context.Context) (func(), bool) {
-	if !b.tryAcquirePending() {
+	l := b.lane(ctx)
+	if !l.tryAcquirePending() {
 		return nil, false
 	}
 
 	if !b.sem.tryAcquire() {
-		b.releasePending()
+		l.releasePending()
 		return nil, false
 	}
 
-	return b.release, true
+	return l.release, true
 }
 
 // Maybe conditionally executes thunk based on the Breaker concurrency
 // and queue parameters. If the concurrency limit and queue capacity are
 // already consumed, Maybe returns immediately without calling thunk. If
 // the thunk was executed, Maybe returns true, else false.
+// The priority class of the execution is taken from the context.
+// If the breaker has a maximum queue wait, the shed executions are reported
+// as a ShedError.
 func (b *Breaker) Maybe(ctx context.Context, thunk func()) error {
-	if !b.tryAcquirePending() {
-		return ErrRequestQueueFull
+	l := b.lane(ctx)
+	if !l.tryAcquirePending() {
+		return b.shed(ErrRequestQueueFull)
 	}
 
-	defer b.releasePending()
+	defer l.releasePending()
 
 	// Wait for capacity in the active queue.
-	if err := b.sem.acquire(ctx); err != nil {
+	if err := b.acquire(ctx); err != nil {
 		return err
 	}
 	// Defer releasing capacity in the active.
@@ -153,15 +257,101 @@ func (b *Breaker) Maybe(ctx context.Context, thunk func()) error {
 	// + release calls are equally paired.
 	defer b.sem.release()
 
-	// Do the thing.
+	if b.maxQueueWait == 0 {
+		// Do the thing.
+		thunk()
+		// Report success
+		return nil
+	}
+
+	start := time.Now()
 	thunk()
-	// Report success
+	b.observeServiceTime(time.Since(start))
+	return nil
+}
+
+// acquire waits for capacity in the active queue, for at most the maximum
+// queue wait of the breaker.
+func (b *Breaker) acquire(ctx context.Context) error {
+	if b.maxQueueWait == 0 {
+		return b.sem.acquire(ctx)
+	}
+	// Avoid the timer if there is free capacity.
+	if b.sem.tryAcquire() {
+		return nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, b.maxQueueWait)
+	defer cancel()
+	if err := b.sem.acquire(waitCtx); err != nil {
+		if ctx.Err() != nil {
+			// The request itself is done, it isn't shed.
+			return ctx.Err()
+		}
+		return b.shed(ErrQueueWaitExceeded)
+	}
 	return nil
 }
 
+// shed returns the error reporting an execution shed for the given reason.
+func (b *Breaker) shed(reason error) error {
+	if b.maxQueueWait == 0 {
+		return reason
+	}
+	return &ShedError{Err: reason, RetryAfter: b.retryAfter()}
+}
+
+// retryAfter estimates the time it takes to work off the queue, given the
+// average service time. Without any execution observed yet, the maximum
+// queue wait is used as the service time.
+func (b *Breaker) retryAfter() time.Duration {
+	serviceTime := b.serviceTime.Load()
+	if serviceTime == 0 {
+		serviceTime = b.maxQueueWait.Seconds()
+	}
+	capacity := b.Capacity()
+	if capacity < 1 {
+		capacity = 1
+	}
+	queued := b.InFlight() - capacity
+	if queued < 0 {
+		queued = 0
+	}
+	return time.Duration((float64(queued)/float64(capacity) + 1) * serviceTime * float64(time.Second))
+}
+
+// observeServiceTime folds the execution time into the moving average of
+// the service time.
+func (b *Breaker) observeServiceTime(d time.Duration) {
+	for {
+		old := b.serviceTime.Load()
+		avg := d.Seconds()
+		if old != 0 {
+			avg = old + serviceTimeEWMAWeight*(avg-old)
+		}
+		if b.serviceTime.CAS(old, avg) {
+			return
+		}
+	}
+}
+
 // InFlight returns the number of requests currently in flight in this breaker.
 func (b *Breaker) InFlight() int {
-	return int(b.inFlight.Load())
+	var total int64
+	for i := range b.lanes {
+		total += b.lanes[i].inFlight.Load()
+	}
+	return int(total)
+}
+
+// PriorityInFlight returns the number of requests currently in flight in
+// each priority class of this breaker, from the highest priority to the lowest.
+func (b *Breaker) PriorityInFlight() []int {
+	ret := make([]int, len(b.lanes))
+	for i := range b.lanes {
+		ret[i] = int(b.lanes[i].inFlight.Load())
+	}
+	return ret
 }
 
 // UpdateConcurrency updates the maximum number of in-flight requests.
@@ -18,6 +18,7 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -49,6 +50,12 @@ func TestBreakerInvalidConstructor(t *testing.T) {
 	}, {
 		name:    "InitialCapacity out-of-bounds",
 		options: BreakerParams{QueueDepth: 1, MaxConcurrency: 5, InitialCapacity: 6},
+	}, {
+		name:    "PriorityQueueDepths = 0",
+		options: BreakerParams{MaxConcurrency: 1, InitialCapacity: 1, PriorityQueueDepths: []int{1, 0}},
+	}, {
+		name:    "MaxQueueWait negative",
+		options: BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 1, MaxQueueWait: -time.Second},
 	}}
 
 	for _, test := range tests {
@@ -94,7 +101,7 @@ func TestBreakerOverloadMixed(t *testing.T) {
 	// Bring breaker to capacity.
 	reqs.request()
 	// This happens in go-routine, so spin.
-	for _, in := unpack(b.sem.state.Load()); in != 1; _, in = unpack(b.sem.state.Load()) {
+	for _, in := unpack(b.sem.(*semaphore).state.Load()); in != 1; _, in = unpack(b.sem.(*semaphore).state.Load()) {
 		time.Sleep(time.Millisecond * 2)
 	}
 	_, rr := b.Reserve(context.Background())
@@ -208,6 +215,73 @@ func TestBreakerCancel(t *testing.T) {
 	reqs.processSuccessfully(t)
 }
 
+func TestBreakerMaxQueueWait(t *testing.T) {
+	b := NewBreaker(BreakerParams{QueueDepth: 2, MaxConcurrency: 1, InitialCapacity: 1, MaxQueueWait: 10 * time.Millisecond})
+	b.observeServiceTime(2 * time.Second)
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Maybe(context.Background(), func() { <-release })
+	}()
+	defer func() {
+		close(release)
+		<-done
+	}()
+	for b.InFlight() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queued request is shed after waiting for the maximum queue wait.
+	err := b.Maybe(context.Background(), func() { t.Error("Shed request was executed") })
+	var shed *ShedError
+	if !errors.As(err, &shed) || !errors.Is(err, ErrQueueWaitExceeded) {
+		t.Fatalf("Maybe() = %v, want: a ShedError for ErrQueueWaitExceeded", err)
+	}
+	// The shed request was still queued behind the active one.
+	if got, want := shed.RetryAfter, 4*time.Second; got != want {
+		t.Errorf("RetryAfter = %v, want: %v", got, want)
+	}
+	if got, want := shed.RetryAfterSeconds(), 4; got != want {
+		t.Errorf("RetryAfterSeconds() = %d, want: %d", got, want)
+	}
+
+	// A request giving up by itself is not shed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Maybe(ctx, func() {}); !errors.Is(err, context.Canceled) || errors.As(err, &shed) {
+		t.Errorf("Maybe() = %v, want: %v", err, context.Canceled)
+	}
+}
+
+func TestBreakerMaxQueueWaitQueueFull(t *testing.T) {
+	b := NewBreaker(BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 0, MaxQueueWait: time.Second})
+	// Fill the queue and the (yet unavailable) active slot.
+	if !b.lanes[0].tryAcquirePending() || !b.lanes[0].tryAcquirePending() {
+		t.Fatal("Failed to fill the queue")
+	}
+
+	err := b.Maybe(context.Background(), func() {})
+	var shed *ShedError
+	if !errors.As(err, &shed) || !errors.Is(err, ErrRequestQueueFull) {
+		t.Fatalf("Maybe() = %v, want: a ShedError for ErrRequestQueueFull", err)
+	}
+	// Without any observed service time, the maximum queue wait is used.
+	if got, want := shed.RetryAfter, 2*time.Second; got != want {
+		t.Errorf("RetryAfter = %v, want: %v", got, want)
+	}
+}
+
+func TestBreakerServiceTime(t *testing.T) {
+	b := NewBreaker(BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 1, MaxQueueWait: time.Second})
+	b.observeServiceTime(time.Second)
+	b.observeServiceTime(2 * time.Second)
+	if got, want := b.serviceTime.Load(), 1.1; got != want {
+		t.Errorf("serviceTime = %v, want: %v", got, want)
+	}
+}
+
 func TestBreakerUpdateConcurrency(t *testing.T) {
 	params := BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 0}
 	b := NewBreaker(params)
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// MaxCustomMetricScrapeFailures is the number of consecutive failed scrapes
+// after which the value of the application-defined metric is reset, rather
+// than reporting the last scraped value forever.
+const MaxCustomMetricScrapeFailures = 3
+
+// CustomMetricScraper scrapes an application-defined metric from the
+// Prometheus endpoint exposed by the user container.
+type CustomMetricScraper struct {
+	client *http.Client
+	url    string
+	name   string
+
+	// failures is the number of consecutive failed scrapes.
+	failures int
+}
+
+// NewCustomMetricScraper creates a scraper reading the metric with the given
+// name from the given URL.
+func NewCustomMetricScraper(client *http.Client, url, name string) *CustomMetricScraper {
+	return &CustomMetricScraper{
+		client: client,
+		url:    url,
+		name:   name,
+	}
+}
+
+// Scrape returns the current value of the metric. If the metric has several
+// samples, e.g. with different labels, their sum is returned.
+// Only gauges (or untyped metrics) can be scaled on, since the value of e.g.
+// a counter does not reflect the current load.
+func (s *CustomMetricScraper) Scrape(ctx context.Context) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s returned unexpected status: %d", s.url, resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the metrics from %s: %w", s.url, err)
+	}
+	family, ok := families[s.name]
+	if !ok {
+		return 0, fmt.Errorf("metric %q not found at %s", s.name, s.url)
+	}
+
+	var sum float64
+	switch family.GetType() {
+	case dto.MetricType_GAUGE:
+		for _, m := range family.Metric {
+			sum += m.GetGauge().GetValue()
+		}
+	case dto.MetricType_UNTYPED:
+		for _, m := range family.Metric {
+			sum += m.GetUntyped().GetValue()
+		}
+	default:
+		return 0, fmt.Errorf("metric %q is a %s, want a gauge", s.name, strings.ToLower(family.GetType().String()))
+	}
+	return sum, nil
+}
+
+// Update scrapes the metric and passes its value to set. After
+// MaxCustomMetricScrapeFailures consecutive failures the value is reset to
+// zero, in which case stale is true.
+func (s *CustomMetricScraper) Update(ctx context.Context, set func(float64)) (stale bool, err error) {
+	v, err := s.Scrape(ctx)
+	if err != nil {
+		s.failures++
+		if s.failures == MaxCustomMetricScrapeFailures {
+			set(0)
+			return true, err
+		}
+		return s.failures > MaxCustomMetricScrapeFailures, err
+	}
+	s.failures = 0
+	set(v)
+	return false, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const testMetrics = `# HELP queue_depth The number of queued jobs.
+# TYPE queue_depth gauge
+queue_depth{queue="a"} 3
+queue_depth{queue="b"} 4.5
+# HELP jobs_total The number of processed jobs.
+# TYPE jobs_total counter
+jobs_total 42
+in_flight 7
+`
+
+func TestCustomMetricScraper(t *testing.T) {
+	tests := []struct {
+		name    string
+		metric  string
+		status  int
+		want    float64
+		wantErr bool
+	}{{
+		name:   "gauge with several samples",
+		metric: "queue_depth",
+		want:   7.5,
+	}, {
+		name:    "counter",
+		metric:  "jobs_total",
+		wantErr: true,
+	}, {
+		name:   "untyped",
+		metric: "in_flight",
+		want:   7,
+	}, {
+		name:    "missing metric",
+		metric:  "nope",
+		wantErr: true,
+	}, {
+		name:    "bad status",
+		metric:  "queue_depth",
+		status:  http.StatusInternalServerError,
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/metrics" {
+					t.Errorf("Path = %s, want: /metrics", r.URL.Path)
+				}
+				if test.status != 0 {
+					w.WriteHeader(test.status)
+					return
+				}
+				fmt.Fprint(w, testMetrics)
+			}))
+			defer server.Close()
+
+			scraper := NewCustomMetricScraper(server.Client(), server.URL+"/metrics", test.metric)
+			got, err := scraper.Scrape(context.Background())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Scrape() = %v, wantErr: %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Scrape() = %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCustomMetricScraperUpdate(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, testMetrics)
+	}))
+	defer server.Close()
+
+	var got float64
+	set := func(v float64) { got = v }
+	scraper := NewCustomMetricScraper(server.Client(), server.URL, "queue_depth")
+	if stale, err := scraper.Update(context.Background(), set); stale || err != nil {
+		t.Fatalf("Update() = %v, %v, want: false, nil", stale, err)
+	}
+	if got != 7.5 {
+		t.Errorf("Value = %v, want: 7.5", got)
+	}
+
+	fail.Store(true)
+	for i := 1; i < MaxCustomMetricScrapeFailures; i++ {
+		if stale, err := scraper.Update(context.Background(), set); stale || err == nil {
+			t.Fatalf("Update() = %v, %v, want: false, error", stale, err)
+		}
+		if got != 7.5 {
+			t.Errorf("Value = %v, want: 7.5", got)
+		}
+	}
+	if stale, err := scraper.Update(context.Background(), set); !stale || err == nil {
+		t.Fatalf("Update() = %v, %v, want: true, error", stale, err)
+	}
+	if got != 0 {
+		t.Errorf("Value = %v, want: 0", got)
+	}
+
+	fail.Store(false)
+	if stale, err := scraper.Update(context.Background(), set); stale || err != nil {
+		t.Fatalf("Update() = %v, %v, want: false, nil", stale, err)
+	}
+	if got != 7.5 {
+		t.Errorf("Value = %v, want: 7.5", got)
+	}
+}
+
+func TestProtobufStatsReporterCustomMetric(t *testing.T) {
+	reporter := NewProtobufStatsReporter(pod, time.Second)
+	reporter.SetCustomMetricValue(12.5)
+	reporter.Report(testCases[0].report)
+
+	if got := scrapeProtobufStat(t, reporter).CustomMetricValue; got != 12.5 {
+		t.Errorf("CustomMetricValue = %v, want: 12.5", got)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"go.opencensus.io/trace"
@@ -59,14 +60,32 @@ func ProxyHandler(breaker *Breaker, stats *network.RequestStats, tracingEnabled
 			if tracingEnabled {
 				_, waitSpan = trace.StartSpan(r.Context(), "queue_wait")
 			}
+			qs := queueStatsFrom(r.Context())
+			var start time.Time
+			if qs != nil {
+				qs.queued.Store(true)
+				start = time.Now()
+			}
 			if err := breaker.Maybe(r.Context(), func() {
 				waitSpan.End()
+				if qs != nil {
+					qs.wait.Store(time.Since(start))
+				}
 				next.ServeHTTP(w, r)
 			}); err != nil {
 				waitSpan.End()
-				if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRequestQueueFull) {
+				var shed *ShedError
+				switch {
+				case errors.As(err, &shed):
+					if qs != nil {
+						qs.wait.Store(time.Since(start))
+						qs.shed.Store(true)
+					}
+					w.Header().Set("Retry-After", strconv.Itoa(shed.RetryAfterSeconds()))
+					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrRequestQueueFull):
 					http.Error(w, err.Error(), http.StatusServiceUnavailable)
-				} else {
+				default:
 					// This line is most likely untestable :-).
 					w.WriteHeader(http.StatusInternalServerError)
 				}
@@ -120,6 +120,44 @@ func TestHandlerBreakerTimeout(t *testing.T) {
 	}
 }
 
+func TestHandlerBreakerMaxQueueWait(t *testing.T) {
+	// This test sends a request which will take a long time to complete.
+	// Then another one which waits for longer than the maximum queue wait.
+	// Verifies that the second one is shed with a Retry-After header.
+	seen := make(chan struct{})
+	resp := make(chan struct{})
+	defer close(resp) // Allow all requests to pass through.
+	blockHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- struct{}{}
+		<-resp
+	})
+	breaker := NewBreaker(BreakerParams{
+		QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 1, MaxQueueWait: 10 * time.Millisecond,
+	})
+	stats := network.NewRequestStats(time.Now())
+	h := ProxyHandler(breaker, stats, false /*tracingEnabled*/, blockHandler)
+
+	go func() {
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost:8081/time", nil))
+	}()
+
+	// Wait until the first request has entered the handler.
+	<-seen
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8081/time", nil))
+	if got, want := rec.Code, http.StatusServiceUnavailable; got != want {
+		t.Fatalf("Code = %d, want: %d", got, want)
+	}
+	if got, want := rec.Header().Get("Retry-After"), "1"; got != want {
+		t.Errorf("Retry-After = %q, want: %q", got, want)
+	}
+	want := ErrQueueWaitExceeded.Error()
+	if got := rec.Body.String(); !strings.Contains(got, want) {
+		t.Errorf("Body = %q wanted to contain %q", got, want)
+	}
+}
+
 func TestHandlerReqEvent(t *testing.T) {
 	params := BreakerParams{QueueDepth: 10, MaxConcurrency: 10, InitialCapacity: 10}
 	breaker := NewBreaker(params)
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// PathPrefixHeaderName is the name of the header holding the path prefix
+	// to rewrite in the requests.
+	PathPrefixHeaderName = "Knative-Serving-Path-Prefix"
+
+	// PathRewriteHeaderName is the name of the header holding the
+	// replacement of the path prefix of the requests.
+	PathRewriteHeaderName = "Knative-Serving-Path-Rewrite"
+)
+
+// PathRewriteHandler replaces the prefix named by the PathPrefixHeaderName
+// header of the path of the requests with the value of the
+// PathRewriteHeaderName header. Both headers are removed from the requests
+// before they reach the user container.
+// The headers are only trusted because the ingress of a DomainMapping sets
+// them on all of its paths, overwriting those of the clients. The hosts of
+// the routes expose all the paths of the revisions anyway.
+func PathRewriteHandler(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		prefix, rewrite := r.Header.Get(PathPrefixHeaderName), r.Header.Get(PathRewriteHeaderName)
+		r.Header.Del(PathPrefixHeaderName)
+		r.Header.Del(PathRewriteHeaderName)
+		if prefix != "" && rewrite != "" && hasPathPrefix(r.URL.Path, prefix) {
+			rest := r.URL.Path[len(prefix):]
+			if strings.HasSuffix(rewrite, "/") && strings.HasPrefix(rest, "/") {
+				rest = rest[1:]
+			}
+			r.URL.Path = rewrite + rest
+			// Let the path be escaped again.
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// hasPathPrefix returns whether the path starts with the prefix at the
+// boundary of a segment, i.e. "/api" is a prefix of "/api" and "/api/users",
+// but not of "/apis".
+func hasPathPrefix(path, prefix string) bool {
+	return strings.HasPrefix(path, prefix) &&
+		(len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/')
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathRewriteHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		prefix  string
+		rewrite string
+		want    string
+	}{{
+		name: "no headers",
+		path: "/api/users",
+		want: "/api/users",
+	}, {
+		name:    "strip prefix",
+		path:    "/api/users",
+		prefix:  "/api",
+		rewrite: "/",
+		want:    "/users",
+	}, {
+		name:    "whole path",
+		path:    "/api",
+		prefix:  "/api",
+		rewrite: "/",
+		want:    "/",
+	}, {
+		name:    "replace prefix",
+		path:    "/api/users",
+		prefix:  "/api",
+		rewrite: "/v2",
+		want:    "/v2/users",
+	}, {
+		name:    "trailing slashes",
+		path:    "/api/users",
+		prefix:  "/api/",
+		rewrite: "/v2/",
+		want:    "/v2/users",
+	}, {
+		name:    "other prefix",
+		path:    "/web/index.html",
+		prefix:  "/api",
+		rewrite: "/",
+		want:    "/web/index.html",
+	}, {
+		name:    "prefix of a segment",
+		path:    "/apis/users",
+		prefix:  "/api",
+		rewrite: "/",
+		want:    "/apis/users",
+	}, {
+		name:    "identity",
+		path:    "/apis/users",
+		prefix:  "/",
+		rewrite: "/",
+		want:    "/apis/users",
+	}, {
+		name:   "no rewrite",
+		path:   "/api/users",
+		prefix: "/api",
+		want:   "/api/users",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *http.Request
+			h := PathRewriteHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+			}))
+			req := httptest.NewRequest(http.MethodGet, "http://example.com"+tc.path+"?q=1", nil)
+			if tc.prefix != "" {
+				req.Header.Set(PathPrefixHeaderName, tc.prefix)
+			}
+			if tc.rewrite != "" {
+				req.Header.Set(PathRewriteHeaderName, tc.rewrite)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got.URL.Path != tc.want {
+				t.Errorf("Path = %q, want: %q", got.URL.Path, tc.want)
+			}
+			if got.URL.RawQuery != "q=1" {
+				t.Errorf("RawQuery = %q, want: q=1", got.URL.RawQuery)
+			}
+			if got.Header.Get(PathPrefixHeaderName) != "" || got.Header.Get(PathRewriteHeaderName) != "" {
+				t.Errorf("Rewrite headers were not removed: %v", got.Header)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	network "knative.dev/networking/pkg"
+)
+
+// PriorityHeaderName is the name of the header naming the priority class
+// of a request.
+const PriorityHeaderName = "Knative-Serving-Priority"
+
+type priorityClassKey struct{}
+
+// WithPriorityClass attaches the index of the priority class of a request
+// to the context. Index 0 is the highest priority.
+func WithPriorityClass(ctx context.Context, class int) context.Context {
+	return context.WithValue(ctx, priorityClassKey{}, class)
+}
+
+// priorityClassFrom returns the index of the priority class attached to the
+// context. Out of the numClasses classes, the executions without a valid
+// class get the lowest priority.
+func priorityClassFrom(ctx context.Context, numClasses int) int {
+	if class, ok := ctx.Value(priorityClassKey{}).(int); ok && class >= 0 && class < numClasses {
+		return class
+	}
+	return numClasses - 1
+}
+
+// PriorityHandler attaches the priority class named by the PriorityHeaderName
+// header of the requests, or else by their route tag, to their context.
+// classes are the names of the priority classes, from the highest priority
+// to the lowest. The requests matching no class get the lowest priority.
+func PriorityHandler(classes []string, next http.Handler) http.HandlerFunc {
+	index := make(map[string]int, len(classes))
+	for i, class := range classes {
+		index[class] = i
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(PriorityHeaderName)
+		if name == "" {
+			name = r.Header.Get(network.TagHeaderName)
+		}
+		if class, ok := index[name]; ok {
+			r = r.WithContext(WithPriorityClass(r.Context(), class))
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// prioritySemaphore is a semaphore handing the freed capacity to the waiters
+// of the highest priority class first, and to the waiters of a class in the
+// order they arrived.
+type prioritySemaphore struct {
+	mu       sync.Mutex
+	capacity int
+	inFlight int
+	// waiters are the channels of the waiters of each priority class,
+	// closed when the waiter is handed capacity.
+	waiters [][]chan struct{}
+}
+
+// newPrioritySemaphore creates a prioritySemaphore with the desired number
+// of priority classes and initial capacity.
+func newPrioritySemaphore(numClasses, initialCapacity int) *prioritySemaphore {
+	return &prioritySemaphore{
+		capacity: initialCapacity,
+		waiters:  make([][]chan struct{}, numClasses),
+	}
+}
+
+// tryAcquire receives a token from the semaphore if there is one otherwise returns false.
+func (s *prioritySemaphore) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight >= s.capacity {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// acquire acquires capacity from the semaphore, waiting behind the waiters
+// of the same or a higher priority class.
+func (s *prioritySemaphore) acquire(ctx context.Context) error {
+	class := priorityClassFrom(ctx, len(s.waiters))
+	s.mu.Lock()
+	if s.inFlight < s.capacity {
+		// The free capacity is always handed to the waiters, so nobody waits.
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	s.waiters[class] = append(s.waiters[class], ch)
+	s.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, w := range s.waiters[class] {
+		if w == ch {
+			s.waiters[class] = append(s.waiters[class][:i], s.waiters[class][i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// We were handed capacity while giving up, so pass it on.
+	s.releaseLocked()
+	return ctx.Err()
+}
+
+// release releases capacity in the semaphore.
+func (s *prioritySemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *prioritySemaphore) releaseLocked() {
+	if s.inFlight == 0 {
+		panic("release and acquire are not paired")
+	}
+	// If the capacity was reduced in between, the capacity is not handed on.
+	if s.inFlight <= s.capacity && s.handOff() {
+		return
+	}
+	s.inFlight--
+}
+
+// handOff hands capacity to the first waiter of the highest priority class
+// with waiters, if any. Must be called with the mu held.
+func (s *prioritySemaphore) handOff() bool {
+	for class, waiters := range s.waiters {
+		if len(waiters) == 0 {
+			continue
+		}
+		close(waiters[0])
+		waiters[0] = nil
+		s.waiters[class] = waiters[1:]
+		return true
+	}
+	return false
+}
+
+// updateCapacity updates the capacity of the semaphore to the desired size.
+func (s *prioritySemaphore) updateCapacity(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity = size
+	for s.inFlight < s.capacity && s.handOff() {
+		s.inFlight++
+	}
+}
+
+// Capacity is the capacity of the semaphore.
+func (s *prioritySemaphore) Capacity() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.capacity
+}
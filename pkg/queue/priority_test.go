@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	network "knative.dev/networking/pkg"
+)
+
+// waitForWaiters waits until the given number of executions of the
+// priority class wait on the semaphore.
+func waitForWaiters(t *testing.T, s *prioritySemaphore, class, want int) {
+	t.Helper()
+	if err := wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.waiters[class]) == want, nil
+	}); err != nil {
+		t.Fatalf("Timed out waiting for %d waiters of class %d", want, class)
+	}
+}
+
+func TestBreakerPriority(t *testing.T) {
+	b := NewBreaker(BreakerParams{MaxConcurrency: 1, InitialCapacity: 1, PriorityQueueDepths: []int{1, 1}})
+	sem := b.sem.(*prioritySemaphore)
+	high := WithPriorityClass(context.Background(), 0)
+	// The executions without a class get the lowest priority.
+	low := context.Background()
+
+	order := make(chan string)
+	release := make(chan struct{})
+	errCh := make(chan error, 3)
+	run := func(ctx context.Context, name string) {
+		errCh <- b.Maybe(ctx, func() {
+			order <- name
+			<-release
+		})
+	}
+
+	go run(low, "first")
+	if got := <-order; got != "first" {
+		t.Fatalf("Executed %s, want: first", got)
+	}
+	go run(low, "low")
+	waitForWaiters(t, sem, 1, 1)
+
+	// The low priority queue is full, but the high priority one is not.
+	if err := b.Maybe(low, func() {}); !errors.Is(err, ErrRequestQueueFull) {
+		t.Errorf("Maybe() = %v, want: %v", err, ErrRequestQueueFull)
+	}
+	go run(high, "high")
+	waitForWaiters(t, sem, 0, 1)
+	if got, want := b.PriorityInFlight(), []int{1, 2}; got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("PriorityInFlight() = %v, want: %v", got, want)
+	}
+	if got, want := b.InFlight(), 3; got != want {
+		t.Errorf("InFlight() = %d, want: %d", got, want)
+	}
+
+	// The high priority execution overtakes the queued low priority one.
+	for _, want := range []string{"high", "low"} {
+		release <- struct{}{}
+		if got := <-order; got != want {
+			t.Errorf("Executed %s, want: %s", got, want)
+		}
+	}
+	release <- struct{}{}
+	for i := 0; i < 3; i++ {
+		if err := <-errCh; err != nil {
+			t.Error("Maybe() =", err)
+		}
+	}
+}
+
+func TestPrioritySemaphoreCancel(t *testing.T) {
+	sem := newPrioritySemaphore(2, 0)
+	ctx, cancel := context.WithCancel(WithPriorityClass(context.Background(), 0))
+	errCh := make(chan error)
+	go func() {
+		errCh <- sem.acquire(ctx)
+	}()
+	waitForWaiters(t, sem, 0, 1)
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() = %v, want: %v", err, context.Canceled)
+	}
+	waitForWaiters(t, sem, 0, 0)
+
+	// The canceled waiter doesn't hold on to any capacity.
+	sem.updateCapacity(1)
+	if !sem.tryAcquire() {
+		t.Error("tryAcquire() = false, want: true")
+	}
+	if sem.tryAcquire() {
+		t.Error("tryAcquire() = true, want: false")
+	}
+}
+
+func TestPrioritySemaphoreUpdateCapacity(t *testing.T) {
+	sem := newPrioritySemaphore(2, 0)
+	errCh := make(chan error)
+	for class := 0; class < 2; class++ {
+		go func(class int) {
+			errCh <- sem.acquire(WithPriorityClass(context.Background(), class))
+		}(class)
+		waitForWaiters(t, sem, class, 1)
+	}
+
+	sem.updateCapacity(2)
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil {
+			t.Error("acquire() =", err)
+		}
+	}
+	if sem.tryAcquire() {
+		t.Error("tryAcquire() = true, want: false")
+	}
+
+	// Reducing the capacity leaves the freed capacity unused.
+	sem.updateCapacity(1)
+	sem.release()
+	if sem.tryAcquire() {
+		t.Error("tryAcquire() = true, want: false")
+	}
+	sem.release()
+	if !sem.tryAcquire() {
+		t.Error("tryAcquire() = false, want: true")
+	}
+}
+
+func TestPriorityHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{{
+		name:   "priority header",
+		header: http.Header{PriorityHeaderName: []string{"interactive"}},
+		want:   0,
+	}, {
+		name:   "route tag",
+		header: http.Header{network.TagHeaderName: []string{"interactive"}},
+		want:   0,
+	}, {
+		name: "priority header over route tag",
+		header: http.Header{
+			PriorityHeaderName:    []string{"bulk"},
+			network.TagHeaderName: []string{"interactive"},
+		},
+		want: 1,
+	}, {
+		name:   "unknown class",
+		header: http.Header{PriorityHeaderName: []string{"urgent"}},
+		want:   2,
+	}, {
+		name: "no class",
+		want: 2,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := -1
+			h := PriorityHandler([]string{"interactive", "bulk", "default"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = priorityClassFrom(r.Context(), 3)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = test.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != test.want {
+				t.Errorf("priority class = %d, want: %d", got, test.want)
+			}
+		})
+	}
+}
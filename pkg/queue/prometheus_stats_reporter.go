@@ -32,6 +32,7 @@ const (
 	destinationConfigLabel = "destination_configuration"
 	destinationRevLabel    = "destination_revision"
 	destinationPodLabel    = "destination_pod"
+	priorityClassLabel     = "priority_class"
 )
 
 var (
@@ -58,6 +59,13 @@ var (
 	processUptimeGV = newGV(
 		"process_uptime",
 		"The number of seconds that the process has been up")
+	priorityClassQueueDepthGV = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "queue_priority_class_depth",
+			Help: "Number of requests of the priority class queued or being handled by this pod",
+		},
+		append([]string{priorityClassLabel}, metricLabelNames...),
+	)
 )
 
 func newGV(n, h string) *prometheus.GaugeVec {
@@ -71,6 +79,7 @@ func newGV(n, h string) *prometheus.GaugeVec {
 type PrometheusStatsReporter struct {
 	handler   http.Handler
 	startTime time.Time
+	labels    prometheus.Labels
 
 	// RequestsPerSecond and ProxiedRequestsPerSecond need to be divided by the
 	// reporting period they were collected over to get a "per-second" value.
@@ -102,7 +111,7 @@ func NewPrometheusStatsReporter(namespace, config, revision, pod string, reporti
 	for _, gv := range []*prometheus.GaugeVec{
 		requestsPerSecondGV, proxiedRequestsPerSecondGV,
 		averageConcurrentRequestsGV, averageProxiedConcurrentRequestsGV,
-		processUptimeGV} {
+		processUptimeGV, priorityClassQueueDepthGV} {
 		if err := registry.Register(gv); err != nil {
 			return nil, fmt.Errorf("register metric failed: %w", err)
 		}
@@ -118,6 +127,7 @@ func NewPrometheusStatsReporter(namespace, config, revision, pod string, reporti
 	return &PrometheusStatsReporter{
 		handler:   promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
 		startTime: time.Now(),
+		labels:    labels,
 
 		reportingPeriodSeconds: reportingPeriod.Seconds(),
 
@@ -139,6 +149,19 @@ func (r *PrometheusStatsReporter) Report(stats network.RequestStatsReport) {
 	r.processUptime.Set(time.Since(r.startTime).Seconds())
 }
 
+// ReportQueueDepths captures the queue depth of each priority class. classes
+// and depths are indexed by the priority of the classes.
+func (r *PrometheusStatsReporter) ReportQueueDepths(classes []string, depths []int) {
+	for i, class := range classes {
+		labels := make(prometheus.Labels, len(r.labels)+1)
+		for k, v := range r.labels {
+			labels[k] = v
+		}
+		labels[priorityClassLabel] = class
+		priorityClassQueueDepthGV.With(labels).Set(float64(depths[i]))
+	}
+}
+
 // ServeHTTP serves the stats in prometheus format over HTTP.
 func (r *PrometheusStatsReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.handler.ServeHTTP(w, req)
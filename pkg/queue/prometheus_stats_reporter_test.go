@@ -197,3 +197,31 @@ func getData(t *testing.T, gv *prometheus.GaugeVec) float64 {
 	}
 	return m.Gauge.GetValue()
 }
+
+func TestPrometheusStatsReporterReportQueueDepths(t *testing.T) {
+	reporter, err := NewPrometheusStatsReporter(namespace, config, revision, pod, time.Second)
+	if err != nil {
+		t.Fatal("NewPrometheusStatsReporter() =", err)
+	}
+	reporter.ReportQueueDepths([]string{"interactive", "bulk"}, []int{3, 42})
+
+	for class, want := range map[string]float64{"interactive": 3, "bulk": 42} {
+		g, err := priorityClassQueueDepthGV.GetMetricWith(prometheus.Labels{
+			priorityClassLabel:     class,
+			destinationNsLabel:     namespace,
+			destinationConfigLabel: config,
+			destinationRevLabel:    revision,
+			destinationPodLabel:    pod,
+		})
+		if err != nil {
+			t.Fatal("GaugeVec.GetMetricWith() error =", err)
+		}
+		m := dto.Metric{}
+		if err := g.Write(&m); err != nil {
+			t.Fatal("Gauge.Write() error =", err)
+		}
+		if got := m.Gauge.GetValue(); got != want {
+			t.Errorf("Queue depth of %s = %v, want: %v", class, got, want)
+		}
+	}
+}
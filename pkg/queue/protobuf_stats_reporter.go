@@ -38,6 +38,9 @@ type ProtobufStatsReporter struct {
 	stat      atomic.Value
 	podName   string
 
+	// customMetricValue is the last value of the application-defined metric.
+	customMetricValue atomic.Float64
+
 	// RequestCount and ProxiedRequestCount need to be divided by the reporting period
 	// they were collected over to get a "per-second" value.
 	reportingPeriodSeconds float64
@@ -71,9 +74,16 @@ func (r *ProtobufStatsReporter) Report(stats network.RequestStatsReport) {
 		ProxiedRequestCount:              stats.ProxiedRequestCount / r.reportingPeriodSeconds,
 		AverageConcurrentRequests:        stats.AverageConcurrency,
 		AverageProxiedConcurrentRequests: stats.AverageProxiedConcurrency,
+		CustomMetricValue:                r.customMetricValue.Load(),
 	})
 }
 
+// SetCustomMetricValue sets the value of the application-defined metric
+// included in the subsequent reports.
+func (r *ProtobufStatsReporter) SetCustomMetricValue(v float64) {
+	r.customMetricValue.Store(v)
+}
+
 // ServeHTTP serves the stats in protobuf format over HTTP.
 func (r *ProtobufStatsReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 	data := r.stat.Load().(metrics.Stat)
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	network "knative.dev/networking/pkg"
+	"knative.dev/serving/pkg/apis/serving"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
+)
+
+// RateLimitError is returned for the requests exceeding a rate limit.
+type RateLimitError struct {
+	// Limit is the exceeded limit.
+	Limit serving.RateLimit
+	// RetryAfter is the time after which the request would be allowed.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *RateLimitError) Error() string {
+	return "rate limit exceeded"
+}
+
+// SetHeaders sets the rate limit headers of the 429 response to the request.
+func (e *RateLimitError) SetHeaders(h http.Header) {
+	reset := strconv.Itoa(retryAfterSeconds(e.RetryAfter))
+	h.Set("RateLimit-Limit", strconv.FormatFloat(e.Limit.Rate, 'f', -1, 64))
+	h.Set("RateLimit-Remaining", "0")
+	h.Set("RateLimit-Reset", reset)
+	h.Set("Retry-After", reset)
+}
+
+// rateBucket is the token bucket of a rate limit.
+type rateBucket struct {
+	limit   serving.RateLimit
+	limiter *rate.Limiter
+}
+
+// RateLimiter enforces the rate limits of a revision. The limits hold for
+// the revision as a whole, so they are divided across the pods enforcing them.
+type RateLimiter struct {
+	// buckets are keyed by the route tag of the limits, which are immutable.
+	buckets map[string]*rateBucket
+
+	mu    sync.Mutex
+	share asmetrics.ReadyPods
+}
+
+// NewRateLimiter creates a RateLimiter enforcing the given limits, or nil
+// if there are none.
+func NewRateLimiter(limits []serving.RateLimit) *RateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	rl := &RateLimiter{
+		buckets: make(map[string]*rateBucket, len(limits)),
+		// Enforce the whole limits until the share of the pod is known.
+		share: asmetrics.ReadyPods{Count: 1, Index: 0},
+	}
+	for _, l := range limits {
+		rl.buckets[l.Tag] = &rateBucket{
+			limit:   l,
+			limiter: rate.NewLimiter(rate.Limit(l.Rate), l.Burst),
+		}
+	}
+	return rl
+}
+
+// UpdateShare divides the limits across the ready pods of the revision.
+// The rate of each limit is divided evenly. The burst is divided evenly too,
+// rounding down, and the first pods in the order of the ready pods get one
+// more of the remainder. A pod which is not ready (yet) gets no share of the
+// remainder. Every pod gets a burst of at least one though, since a pod with
+// no burst would reject all of its requests, however low the traffic.
+func (rl *RateLimiter) UpdateShare(share asmetrics.ReadyPods) {
+	if share.Count < 1 {
+		share = asmetrics.ReadyPods{Count: 1, Index: 0}
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.share == share {
+		return
+	}
+	rl.share = share
+	for _, b := range rl.buckets {
+		burst := b.limit.Burst / share.Count
+		if share.Index >= 0 && share.Index < b.limit.Burst%share.Count {
+			burst++
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		b.limiter.SetLimit(rate.Limit(b.limit.Rate / float64(share.Count)))
+		b.limiter.SetBurst(burst)
+	}
+}
+
+// Allow returns a RateLimitError if a request routed through the given
+// route tag exceeds its limit, and takes a token from the bucket otherwise.
+func (rl *RateLimiter) Allow(tag string) error {
+	b, ok := rl.buckets[tag]
+	if !ok {
+		if b, ok = rl.buckets[""]; !ok {
+			return nil
+		}
+	}
+	now := time.Now()
+	r := b.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return &RateLimitError{Limit: b.limit, RetryAfter: maxRetryAfter}
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		if delay > maxRetryAfter {
+			delay = maxRetryAfter
+		}
+		return &RateLimitError{Limit: b.limit, RetryAfter: delay}
+	}
+	return nil
+}
+
+// maxRetryAfter caps the time after which the clients are told to retry
+// requests exceeding a rate limit, which is infinite for a bucket which
+// can never hold the token.
+const maxRetryAfter = time.Minute
+
+// RateLimitHandler rejects the requests exceeding the rate limits with a 429.
+func RateLimitHandler(limiter *RateLimiter, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if network.IsKubeletProbe(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := limiter.Allow(r.Header.Get(network.TagHeaderName)); err != nil {
+			var rle *RateLimitError
+			if errors.As(err, &rle) {
+				rle.SetHeaders(w.Header())
+			}
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// PollReadyPods periodically fetches the share of the pod among the ready
+// pods of the revision from the given URL, and divides the rate limits by it,
+// until the context is done.
+func PollReadyPods(ctx context.Context, logger *zap.SugaredLogger, limiter *RateLimiter, url string, period time.Duration) {
+	client := &http.Client{Timeout: readyPodsTimeout}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		share, err := fetchReadyPods(ctx, client, url)
+		if err != nil {
+			logger.Warnw("Failed to fetch the ready pods for the rate limits", zap.Error(err))
+		} else {
+			limiter.UpdateShare(share)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readyPodsTimeout is the timeout of fetching the ready pods.
+const readyPodsTimeout = 2 * time.Second
+
+func fetchReadyPods(ctx context.Context, client *http.Client, url string) (asmetrics.ReadyPods, error) {
+	var share asmetrics.ReadyPods
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return share, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return share, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return share, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&share)
+	return share, err
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	network "knative.dev/networking/pkg"
+	"knative.dev/serving/pkg/apis/serving"
+	asmetrics "knative.dev/serving/pkg/autoscaler/metrics"
+
+	. "knative.dev/pkg/logging/testing"
+)
+
+func TestNewRateLimiterWithoutLimits(t *testing.T) {
+	if got := NewRateLimiter(nil); got != nil {
+		t.Errorf("NewRateLimiter(nil) = %v, want: nil", got)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// The rates are low enough for no token to be refilled during the test.
+	rl := NewRateLimiter([]serving.RateLimit{
+		{Rate: 0.01, Burst: 2},
+		{Tag: "canary", Rate: 0.01, Burst: 1},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := rl.Allow(""); err != nil {
+			t.Fatalf("Allow() #%d = %v", i, err)
+		}
+	}
+	err := rl.Allow("")
+	var rle *RateLimitError
+	if !errors.As(err, &rle) {
+		t.Fatalf("Allow() = %v, want: a RateLimitError", err)
+	}
+	if rle.RetryAfter <= 0 {
+		t.Errorf("RetryAfter = %v, want: > 0", rle.RetryAfter)
+	}
+
+	// The route tags have their own buckets.
+	if err := rl.Allow("canary"); err != nil {
+		t.Fatal("Allow(canary) =", err)
+	}
+	if err := rl.Allow("canary"); err == nil {
+		t.Error("Allow(canary) = nil, want: an error")
+	}
+	// Unknown route tags fall back to the untagged bucket.
+	if err := rl.Allow("blue"); err == nil {
+		t.Error("Allow(blue) = nil, want: an error")
+	}
+}
+
+func TestRateLimiterOnlyTags(t *testing.T) {
+	rl := NewRateLimiter([]serving.RateLimit{{Tag: "canary", Rate: 0.01, Burst: 1}})
+	for i := 0; i < 3; i++ {
+		if err := rl.Allow(""); err != nil {
+			t.Fatalf("Allow() #%d = %v, want: no limit", i, err)
+		}
+	}
+}
+
+func TestRateLimiterUpdateShare(t *testing.T) {
+	tests := []struct {
+		name      string
+		share     asmetrics.ReadyPods
+		wantLimit float64
+		wantBurst int
+	}{{
+		name:      "first pod",
+		share:     asmetrics.ReadyPods{Count: 2, Index: 0},
+		wantLimit: 5,
+		wantBurst: 3,
+	}, {
+		name:      "second pod",
+		share:     asmetrics.ReadyPods{Count: 2, Index: 1},
+		wantLimit: 5,
+		wantBurst: 2,
+	}, {
+		name:      "pod not ready",
+		share:     asmetrics.ReadyPods{Count: 2, Index: -1},
+		wantLimit: 5,
+		wantBurst: 2,
+	}, {
+		name:      "more pods than burst",
+		share:     asmetrics.ReadyPods{Count: 10, Index: 7},
+		wantLimit: 1,
+		wantBurst: 1,
+	}, {
+		name:      "pod not ready among more pods than burst",
+		share:     asmetrics.ReadyPods{Count: 10, Index: -1},
+		wantLimit: 1,
+		wantBurst: 1,
+	}, {
+		name:      "no ready pods",
+		share:     asmetrics.ReadyPods{Count: 0, Index: -1},
+		wantLimit: 10,
+		wantBurst: 5,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rl := NewRateLimiter([]serving.RateLimit{{Rate: 10, Burst: 5}})
+			rl.UpdateShare(test.share)
+
+			b := rl.buckets[""]
+			if got := float64(b.limiter.Limit()); got != test.wantLimit {
+				t.Errorf("Limit = %v, want: %v", got, test.wantLimit)
+			}
+			if got := b.limiter.Burst(); got != test.wantBurst {
+				t.Errorf("Burst = %d, want: %d", got, test.wantBurst)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMorePodsThanBurst(t *testing.T) {
+	rl := NewRateLimiter([]serving.RateLimit{{Rate: 5, Burst: 5}})
+	rl.UpdateShare(asmetrics.ReadyPods{Count: 10, Index: 7})
+
+	if err := rl.Allow(""); err != nil {
+		t.Fatal("Allow() =", err)
+	}
+	err := rl.Allow("")
+	var rle *RateLimitError
+	if !errors.As(err, &rle) {
+		t.Fatalf("Allow() = %v, want: a RateLimitError", err)
+	}
+	if rle.RetryAfter <= 0 || rle.RetryAfter > maxRetryAfter {
+		t.Errorf("RetryAfter = %v, want: in (0, %v]", rle.RetryAfter, maxRetryAfter)
+	}
+}
+
+func TestRateLimiterRetryAfterCapped(t *testing.T) {
+	rl := NewRateLimiter([]serving.RateLimit{{Rate: 0.001, Burst: 1}})
+	rl.Allow("")
+	err := rl.Allow("")
+	var rle *RateLimitError
+	if !errors.As(err, &rle) {
+		t.Fatalf("Allow() = %v, want: a RateLimitError", err)
+	}
+	if rle.RetryAfter != maxRetryAfter {
+		t.Errorf("RetryAfter = %v, want: %v", rle.RetryAfter, maxRetryAfter)
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	rl := NewRateLimiter([]serving.RateLimit{{Rate: 0.5, Burst: 1}})
+	h := RateLimitHandler(rl, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8081/time", nil))
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Fatalf("Code = %d, want: %d", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8081/time", nil))
+	if got, want := rec.Code, http.StatusTooManyRequests; got != want {
+		t.Fatalf("Code = %d, want: %d", got, want)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Limit":     "0.5",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "2",
+		"Retry-After":         "2",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want: %q", header, got, want)
+		}
+	}
+
+	// Kubelet probes are never limited.
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8081/time", nil)
+	req.Header.Set(network.KubeletProbeHeaderName, "1")
+	rec = httptest.NewRecorder()
+	h(rec, req)
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("Probe Code = %d, want: %d", got, want)
+	}
+}
+
+func TestPollReadyPods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(asmetrics.ReadyPods{Count: 4, Index: 3})
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	rl := NewRateLimiter([]serving.RateLimit{{Rate: 10, Burst: 10}})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		PollReadyPods(ctx, TestLogger(t), rl, server.URL, time.Hour)
+	}()
+
+	<-polled
+	// The share is updated right after the response is read.
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return rl.share == asmetrics.ReadyPods{Count: 4, Index: 3}, nil
+	}); err != nil {
+		t.Fatal("The share was never updated:", err)
+	}
+	if got, want := rl.buckets[""].limiter.Burst(), 2; got != want {
+		t.Errorf("Burst = %d, want: %d", got, want)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestFetchReadyPodsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no revision", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if _, err := fetchReadyPods(context.Background(), server.Client(), server.URL); err == nil {
+		t.Error("fetchReadyPods() = nil, wanted an error")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	"go.uber.org/atomic"
 
 	network "knative.dev/networking/pkg"
 	pkgmetrics "knative.dev/pkg/metrics"
@@ -59,8 +60,37 @@ var (
 		"queue_depth",
 		"The current number of items in the serving and waiting queue, or not reported if unlimited concurrency.",
 		stats.UnitDimensionless)
+	queueWaitTimeInMsecM = stats.Float64(
+		"queue_wait_latencies",
+		"The time in millisecond the requests waited in the queue",
+		stats.UnitMilliseconds)
+	shedCountM = stats.Int64(
+		"queue_shed_count",
+		"The number of requests shed by queue-proxy with a maximum queue wait, because they waited too long or the queue was full",
+		stats.UnitDimensionless)
 )
 
+// queueStats are the stats of the queueing of a request, filled in by the
+// ProxyHandler for the requestMetricsHandler. The ProxyHandler might run in a
+// goroutine of a timeout handler, outliving the requestMetricsHandler, so the
+// stats are atomic.
+type queueStats struct {
+	// queued is whether the request went through the breaker.
+	queued atomic.Bool
+	// wait is the time the request waited for capacity.
+	wait atomic.Duration
+	// shed is whether the request was shed by the breaker.
+	shed atomic.Bool
+}
+
+type queueStatsKey struct{}
+
+// queueStatsFrom retrieves the queueing stats to fill in from the context, if any.
+func queueStatsFrom(ctx context.Context) *queueStats {
+	qs, _ := ctx.Value(queueStatsKey{}).(*queueStats)
+	return qs
+}
+
 type requestMetricsHandler struct {
 	next     http.Handler
 	statsCtx context.Context
@@ -75,7 +105,7 @@ type appRequestMetricsHandler struct {
 // NewRequestMetricsHandler creates an http.Handler that emits request metrics.
 func NewRequestMetricsHandler(next http.Handler,
 	ns, service, config, rev, pod string) (http.Handler, error) {
-	keys := []tag.Key{metrics.PodTagKey, metrics.ContainerTagKey, metrics.ResponseCodeKey, metrics.ResponseCodeClassKey /*, metrics.RouteTagKey*/}
+	keys := []tag.Key{metrics.PodTagKey, metrics.ContainerTagKey, metrics.ResponseCodeKey, metrics.ResponseCodeClassKey, metrics.TrafficTypeKey /*, metrics.RouteTagKey*/}
 	if err := pkgmetrics.RegisterResourceView(
 		&view.View{
 			Description: "The number of requests that are routed to queue-proxy",
@@ -89,6 +119,18 @@ func NewRequestMetricsHandler(next http.Handler,
 			Aggregation: defaultLatencyDistribution,
 			TagKeys:     keys,
 		},
+		&view.View{
+			Description: "The time in millisecond the requests waited in the queue",
+			Measure:     queueWaitTimeInMsecM,
+			Aggregation: defaultLatencyDistribution,
+			TagKeys:     keys,
+		},
+		&view.View{
+			Description: "The number of requests shed by queue-proxy with a maximum queue wait, because they waited too long or the queue was full",
+			Measure:     shedCountM,
+			Aggregation: view.Count(),
+			TagKeys:     keys,
+		},
 	); err != nil {
 		return nil, err
 	}
@@ -107,6 +149,8 @@ func NewRequestMetricsHandler(next http.Handler,
 func (h *requestMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rr := pkghttp.NewResponseRecorder(w, http.StatusOK)
 	startTime := time.Now()
+	qs := &queueStats{}
+	r = r.WithContext(context.WithValue(r.Context(), queueStatsKey{}, qs))
 
 	defer func() {
 		// Filter probe requests for revision metrics.
@@ -119,7 +163,7 @@ func (h *requestMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		latency := time.Since(startTime)
 		// routeTag := GetRouteTagNameFromRequest(r)
 		if err != nil {
-			ctx := metrics.AugmentWithResponse(h.statsCtx, http.StatusInternalServerError)
+			ctx := withTrafficType(metrics.AugmentWithResponse(h.statsCtx, http.StatusInternalServerError), r)
 			// TODO: add the routeTag back after stackdriver adds support for it.
 			// https://github.com/knative/serving/issues/8970
 			// ctx := metrics.AugmentWithResponseAndRouteTag(h.statsCtx,
@@ -128,18 +172,36 @@ func (h *requestMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 				responseTimeInMsecM.M(float64(latency.Milliseconds())))
 			panic(err)
 		}
-		ctx := metrics.AugmentWithResponse(h.statsCtx, rr.ResponseCode)
+		ctx := withTrafficType(metrics.AugmentWithResponse(h.statsCtx, rr.ResponseCode), r)
 		// TODO: add the routeTag back after stackdriver adds support for it.
 		// https://github.com/knative/serving/issues/8970
 		// ctx := metrics.AugmentWithResponseAndRouteTag(h.statsCtx,
 		// rr.ResponseCode, routeTag)
 		pkgmetrics.RecordBatch(ctx, requestCountM.M(1),
 			responseTimeInMsecM.M(float64(latency.Milliseconds())))
+		if qs.queued.Load() {
+			pkgmetrics.Record(ctx, queueWaitTimeInMsecM.M(float64(qs.wait.Load().Milliseconds())))
+		}
+		if qs.shed.Load() {
+			pkgmetrics.Record(ctx, shedCountM.M(1))
+		}
 	}()
 
 	h.next.ServeHTTP(rr, r)
 }
 
+// withTrafficType tags the requests routed by a tag of the route, so that they
+// can be told from the requests routed by the traffic split.
+// The ingress sets the tag header on the paths of the tags, while a tag
+// header on the default route is undefined and routed by the split.
+func withTrafficType(ctx context.Context, r *http.Request) context.Context {
+	if r.Header.Get(network.TagHeaderName) == "" || r.Header.Get(network.DefaultRouteHeaderName) == "true" {
+		return ctx
+	}
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.TrafficTypeKey, metrics.TaggedTrafficType))
+	return ctx
+}
+
 // NewAppRequestMetricsHandler creates an http.Handler that emits request metrics.
 func NewAppRequestMetricsHandler(next http.Handler, b *Breaker,
 	ns, service, config, rev, pod string) (http.Handler, error) {
@@ -18,15 +18,18 @@ package queue
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go.opencensus.io/resource"
 	network "knative.dev/networking/pkg"
 	"knative.dev/pkg/metrics/metricskey"
 	"knative.dev/pkg/metrics/metricstest"
 	_ "knative.dev/pkg/metrics/testing"
+	"knative.dev/serving/pkg/metrics"
 )
 
 const targetURI = "http://example.com"
@@ -77,6 +80,92 @@ func TestRequestMetricsHandler(t *testing.T) {
 	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric("request_latencies", 1, wantTags).WithResource(wantResource))
 }
 
+func TestRequestMetricsHandlerTaggedTraffic(t *testing.T) {
+	defer reset()
+	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler, err := NewRequestMetricsHandler(baseHandler, "ns", "svc", "cfg", "rev", "pod")
+	if err != nil {
+		t.Fatal("Failed to create handler:", err)
+	}
+
+	wantTags := map[string]string{
+		metricskey.PodName:                "pod",
+		metricskey.ContainerName:          "queue-proxy",
+		metricskey.LabelResponseCode:      "200",
+		metricskey.LabelResponseCodeClass: "2xx",
+	}
+	taggedTags := map[string]string{
+		metricskey.PodName:                "pod",
+		metricskey.ContainerName:          "queue-proxy",
+		metricskey.LabelResponseCode:      "200",
+		metricskey.LabelResponseCodeClass: "2xx",
+		"traffic_type":                    metrics.TaggedTrafficType,
+	}
+
+	// Routed by a tag.
+	req := httptest.NewRequest(http.MethodGet, targetURI, nil)
+	req.Header.Set(network.TagHeaderName, "beta")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// An undefined tag is routed by the traffic split.
+	req = httptest.NewRequest(http.MethodGet, targetURI, nil)
+	req.Header.Set(network.TagHeaderName, "gamma")
+	req.Header.Set(network.DefaultRouteHeaderName, "true")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := metricstest.IntMetric("request_count", 1, taggedTags)
+	want.Values = append(want.Values, metricstest.IntMetric("request_count", 1, wantTags).Values...)
+	metricstest.AssertMetric(t, want)
+}
+
+func TestRequestMetricsHandlerQueueStats(t *testing.T) {
+	defer reset()
+	breaker := NewBreaker(BreakerParams{QueueDepth: 1, MaxConcurrency: 1, InitialCapacity: 1, MaxQueueWait: time.Millisecond})
+	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	proxyHandler := ProxyHandler(breaker, network.NewRequestStats(time.Now()), false /*tracingEnabled*/, baseHandler)
+	handler, err := NewRequestMetricsHandler(proxyHandler, "ns", "svc", "cfg", "rev", "pod")
+	if err != nil {
+		t.Fatal("Failed to create handler:", err)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, targetURI, nil))
+
+	wantTags := map[string]string{
+		metricskey.PodName:                "pod",
+		metricskey.ContainerName:          "queue-proxy",
+		metricskey.LabelResponseCode:      "200",
+		metricskey.LabelResponseCodeClass: "2xx",
+	}
+	wantResource := &resource.Resource{
+		Type: "knative_revision",
+		Labels: map[string]string{
+			metricskey.LabelNamespaceName:     "ns",
+			metricskey.LabelRevisionName:      "rev",
+			metricskey.LabelServiceName:       "svc",
+			metricskey.LabelConfigurationName: "cfg",
+		},
+	}
+	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric("queue_wait_latencies", 1, wantTags).WithResource(wantResource))
+	metricstest.AssertNoMetric(t, "queue_shed_count")
+
+	// Hold the only slot, so that the next request is shed.
+	release, ok := breaker.Reserve(context.Background())
+	if !ok {
+		t.Fatal("Failed to reserve the breaker")
+	}
+	defer release()
+	reset()
+	if handler, err = NewRequestMetricsHandler(proxyHandler, "ns", "svc", "cfg", "rev", "pod"); err != nil {
+		t.Fatal("Failed to create handler:", err)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, targetURI, nil))
+
+	wantTags[metricskey.LabelResponseCode] = "503"
+	wantTags[metricskey.LabelResponseCodeClass] = "5xx"
+	metricstest.AssertMetric(t, metricstest.IntMetric("queue_shed_count", 1, wantTags).WithResource(wantResource))
+	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric("queue_wait_latencies", 1, wantTags).WithResource(wantResource))
+}
+
 /* func TestRequestMetricsHandlerWithEnablingTagOnRequestMetrics(t *testing.T) {
 	defer reset()
 	baseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -140,7 +229,7 @@ func reset() {
 	metricstest.Unregister(
 		requestCountM.Name(), appRequestCountM.Name(),
 		responseTimeInMsecM.Name(), appResponseTimeInMsecM.Name(),
-		queueDepthM.Name())
+		queueDepthM.Name(), queueWaitTimeInMsecM.Name(), shedCountM.Name())
 }
 
 func TestRequestMetricsHandlerPanickingHandler(t *testing.T) {
@@ -19,21 +19,29 @@ package hpa
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	autoscalingv2beta1listers "k8s.io/client-go/listers/autoscaling/v2beta1"
+	"k8s.io/client-go/tools/cache"
 	nv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/apis/duck"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/ptr"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
 	pareconciler "knative.dev/serving/pkg/client/injection/reconciler/autoscaling/v1alpha1/podautoscaler"
 	areconciler "knative.dev/serving/pkg/reconciler/autoscaling"
 	"knative.dev/serving/pkg/reconciler/autoscaling/config"
-	"knative.dev/serving/pkg/reconciler/autoscaling/hpa/resources"
+	hparesources "knative.dev/serving/pkg/reconciler/autoscaling/hpa/resources"
+	"knative.dev/serving/pkg/resources"
 )
 
 // Reconciler implements the control loop for the HPA resources.
@@ -42,6 +50,11 @@ type Reconciler struct {
 
 	kubeClient kubernetes.Interface
 	hpaLister  autoscalingv2beta1listers.HorizontalPodAutoscalerLister
+
+	// Used to scale the target to and from zero, which the HPA can't do.
+	dynamicClient dynamic.Interface
+	listerFactory func(schema.GroupVersionResource) (cache.GenericLister, error)
+	enqueueAfter  func(interface{}, time.Duration)
 }
 
 // Check that our Reconciler implements pareconciler.Interface
@@ -53,7 +66,7 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 	logger.Debug("PA exists")
 
 	// HPA-class PA delegates autoscaling to the Kubernetes Horizontal Pod Autoscaler.
-	desiredHpa := resources.MakeHPA(pa, config.FromContext(ctx).Autoscaler)
+	desiredHpa := hparesources.MakeHPA(pa, config.FromContext(ctx).Autoscaler)
 	hpa, err := c.hpaLister.HorizontalPodAutoscalers(pa.Namespace).Get(desiredHpa.Name)
 	if errors.IsNotFound(err) {
 		logger.Infof("Creating HPA %q", desiredHpa.Name)
@@ -75,14 +88,31 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 		}
 	}
 
+	cfgAS := config.FromContext(ctx).Autoscaler
+	scaleToZero := canScaleToZero(pa, cfgAS)
+	idle := scaleToZero && c.isIdle(pa)
+
+	// While idle the activator is put in the path, so that the requests
+	// wake the revision up.
+	mode := nv1alpha1.SKSOperationModeServe
+	if idle || areconciler.NeedsActivator(pa) {
+		mode = nv1alpha1.SKSOperationModeProxy
+	}
+
 	// 0 num activators will work as "all".
-	sks, err := c.ReconcileSKS(ctx, pa, nv1alpha1.SKSOperationModeServe, 0 /*numActivators*/)
+	sks, err := c.ReconcileSKS(ctx, pa, mode, 0 /*numActivators*/)
 	if err != nil {
 		return fmt.Errorf("error reconciling SKS: %w", err)
 	}
 
 	// Only create metrics service and metric entity if we actually need to gather metrics.
 	pa.Status.MetricsServiceName = sks.Status.PrivateServiceName
+	if scaleToZero {
+		// The Metric is only needed to observe the traffic of the revision.
+		if err := c.ReconcileMetric(ctx, pa, pa.Status.MetricsServiceName); err != nil {
+			return fmt.Errorf("error reconciling Metric: %w", err)
+		}
+	}
 
 	// Propagate the service name regardless of the status.
 	pa.Status.ServiceName = sks.Status.ServiceName
@@ -92,10 +122,109 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 		pa.Status.MarkSKSReady()
 		pa.Status.MarkScaleTargetInitialized()
 	}
-	// HPA is always _active_.
-	pa.Status.MarkActive()
-
 	pa.Status.DesiredScale = ptr.Int32(hpa.Status.DesiredReplicas)
 	pa.Status.ActualScale = ptr.Int32(hpa.Status.CurrentReplicas)
+
+	// HPA is always _active_ if it can't scale to zero. But the target is still
+	// reconciled, since it might have been left at zero replicas when scale
+	// to zero stopped applying, and the HPA never scales it back up.
+	return c.reconcileScaleToZero(ctx, pa, sks, idle)
+}
+
+// canScaleToZero returns true if the HPA-class PA may be scaled to zero.
+func canScaleToZero(pa *autoscalingv1alpha1.PodAutoscaler, cfg *autoscalerconfig.Config) bool {
+	min, _ := pa.ScaleBounds(cfg)
+	return cfg.EnableScaleToZero && cfg.EnableHPAScaleToZero && min == 0
+}
+
+// isIdle returns true if the Metric of the PA reports that the revision
+// has not received traffic over the stable window.
+func (c *Reconciler) isIdle(pa *autoscalingv1alpha1.PodAutoscaler) bool {
+	metric, err := c.MetricLister.Metrics(pa.Namespace).Get(pa.Name)
+	return err == nil && metric.Status.IsInactive()
+}
+
+// reconcileScaleToZero scales the target of an idle PA to zero once the
+// activator has been in the path for the grace period, and scales it back
+// up when the traffic resumes or the PA can no longer scale to zero.
+// The Kubernetes HPA does not act on targets scaled to zero, so both
+// transitions are done here.
+func (c *Reconciler) reconcileScaleToZero(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler,
+	sks *nv1alpha1.ServerlessService, idle bool) error {
+	logger := logging.FromContext(ctx)
+	cfgAS := config.FromContext(ctx).Autoscaler
+
+	ps, err := resources.GetScaleResource(pa.Namespace, pa.Spec.ScaleTargetRef, c.listerFactory)
+	if errors.IsNotFound(err) && !idle {
+		// The scale target is not created yet, so there is nothing to scale up.
+		pa.Status.MarkActive()
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get scale target %v: %w", pa.Spec.ScaleTargetRef, err)
+	}
+	currentScale := int32(1)
+	if ps.Spec.Replicas != nil {
+		currentScale = *ps.Spec.Replicas
+	}
+
+	if !idle {
+		pa.Status.MarkActive()
+		if currentScale != 0 {
+			return nil
+		}
+		min, _ := pa.ScaleBounds(cfgAS)
+		desiredScale := int32(1)
+		if min > desiredScale {
+			desiredScale = min
+		}
+		logger.Info("Traffic resumed, scaling to ", desiredScale)
+		pa.Status.DesiredScale = ptr.Int32(desiredScale)
+		return c.applyScale(ctx, pa, desiredScale, ps)
+	}
+
+	pa.Status.MarkInactive("NoTraffic", "The target is not receiving traffic.")
+	if currentScale == 0 {
+		pa.Status.DesiredScale = ptr.Int32(0)
+		return nil
+	}
+
+	// This enforces that the revision has been backed by the activator for at
+	// least the ScaleToZeroGracePeriod before the pods go away.
+	if sks.Spec.Mode != nv1alpha1.SKSOperationModeProxy {
+		return nil
+	}
+	if to := cfgAS.ScaleToZeroGracePeriod - sks.Status.ProxyFor(); to > 0 {
+		logger.Info("Enqueueing PA after ", to)
+		c.enqueueAfter(pa, to)
+		return nil
+	}
+	logger.Info("Scaling to 0, the target is not receiving traffic")
+	pa.Status.DesiredScale = ptr.Int32(0)
+	return c.applyScale(ctx, pa, 0, ps)
+}
+
+// applyScale patches the replicas of the scale target.
+func (c *Reconciler) applyScale(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler, desiredScale int32,
+	ps *autoscalingv1alpha1.PodScalable) error {
+	gvr, name, err := resources.ScaleResourceArguments(pa.Spec.ScaleTargetRef)
+	if err != nil {
+		return err
+	}
+
+	psNew := ps.DeepCopy()
+	psNew.Spec.Replicas = &desiredScale
+	patch, err := duck.CreatePatch(ps, psNew)
+	if err != nil {
+		return err
+	}
+	patchBytes, err := patch.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.dynamicClient.Resource(*gvr).Namespace(pa.Namespace).Patch(ctx, ps.Name, types.JSONPatchType,
+		patchBytes, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to apply scale %d to scale target %s: %w", desiredScale, name, err)
+	}
 	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	// Inject our fake informers
 	networkingclient "knative.dev/networking/pkg/client/injection/client"
@@ -28,6 +29,7 @@ import (
 	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/autoscaling/v2beta1/horizontalpodautoscaler/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/service/fake"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 	servingclient "knative.dev/serving/pkg/client/injection/client"
 	fakeservingclient "knative.dev/serving/pkg/client/injection/client/fake"
 	"knative.dev/serving/pkg/client/injection/ducks/autoscaling/v1alpha1/podscalable"
@@ -42,11 +44,14 @@ import (
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	ktesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 
 	"knative.dev/networking/pkg/apis/networking"
 	nv1a1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
@@ -55,6 +60,7 @@ import (
 	"knative.dev/pkg/system"
 	"knative.dev/serving/pkg/apis/autoscaling"
 	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	autoscalerconfig "knative.dev/serving/pkg/autoscaler/config"
 	"knative.dev/serving/pkg/deployment"
@@ -140,6 +146,20 @@ func TestReconcile(t *testing.T) {
 			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
 		},
 		Key: key(testNamespace, testRevision),
+	}, {
+		Name: "session affinity keeps the activator in the path",
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"), withSessionAffinity)),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithTraffic, WithScaleTargetInitialized,
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0),
+				withSessionAffinity),
+			deploy(testNamespace, testRevision),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+		},
+		Key: key(testNamespace, testRevision),
+		WantUpdates: []ktesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithProxyMode, WithSKSReady),
+		}},
 	}, {
 		Name: "create hpa & sks, with retry",
 		Objects: []runtime.Object{
@@ -397,12 +417,171 @@ func TestReconcile(t *testing.T) {
 		WantEvents: []string{
 			Eventf(corev1.EventTypeWarning, "InternalError", "failed to create HPA: inducing failure for create horizontalpodautoscalers"),
 		},
+	}, {
+		Name: "scale to zero enabled, create metric",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithTraffic, WithScaleTargetInitialized,
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+		},
+		Key: key(testNamespace, testRevision),
+		WantCreates: []runtime.Object{
+			metric(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu")), privateSvc),
+		},
+	}, {
+		Name: "scale to zero enabled, idle puts activator in path",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithTraffic, WithScaleTargetInitialized,
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu")), privateSvc, withMetricInactive),
+		},
+		Key: key(testNamespace, testRevision),
+		WantUpdates: []ktesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode),
+		}},
+		WantStatusUpdates: []ktesting.UpdateActionImpl{{
+			Object: pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+		}},
+	}, {
+		Name: "scale to zero enabled, idle scales to zero",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode,
+				withProxyFor(time.Hour)),
+			metric(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu")), privateSvc, withMetricInactive),
+		},
+		Key: key(testNamespace, testRevision),
+		WantPatches: []ktesting.PatchActionImpl{{
+			ActionImpl: ktesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"add","path":"/spec/replicas","value":0}]`),
+		}},
+	}, {
+		Name: "scale to zero enabled, idle within grace period",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode,
+				withProxyFor(time.Second)),
+			metric(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu")), privateSvc, withMetricInactive),
+		},
+		Key: key(testNamespace, testRevision),
+	}, {
+		Name: "scale to zero enabled, traffic resumed",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision, func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.Int32(0)
+			}),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode),
+			metric(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu")), privateSvc, withMetricActive),
+		},
+		Key: key(testNamespace, testRevision),
+		WantUpdates: []ktesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+		}},
+		WantPatches: []ktesting.PatchActionImpl{{
+			ActionImpl: ktesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":1}]`),
+		}},
+		WantStatusUpdates: []ktesting.UpdateActionImpl{{
+			Object: pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithTraffic, WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(1, 0)),
+		}},
+	}, {
+		Name: "scale to zero disabled, target left at zero",
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision, func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.Int32(0)
+			}),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode),
+		},
+		Key: key(testNamespace, testRevision),
+		WantUpdates: []ktesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+		}},
+		WantPatches: []ktesting.PatchActionImpl{{
+			ActionImpl: ktesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":1}]`),
+		}},
+		WantStatusUpdates: []ktesting.UpdateActionImpl{{
+			Object: pa(testNamespace, testRevision, WithHPAClass, WithPASKSReady, WithScaleTargetInitialized,
+				WithTraffic, WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(1, 0)),
+		}},
+	}, {
+		Name: "min scale added, target left at zero",
+		Ctx:  context.WithValue(context.Background(), configKey{}, scaleToZeroConfig()),
+		Objects: []runtime.Object{
+			hpa(pa(testNamespace, testRevision, WithHPAClass, WithLowerScaleBound(2), WithMetricAnnotation("cpu"))),
+			pa(testNamespace, testRevision, WithHPAClass, WithLowerScaleBound(2), WithPASKSReady, WithScaleTargetInitialized,
+				WithNoTraffic("NoTraffic", "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc), withScales(0, 0)),
+			deploy(testNamespace, testRevision, func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.Int32(0)
+			}),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady, WithProxyMode),
+		},
+		Key: key(testNamespace, testRevision),
+		WantUpdates: []ktesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+		}},
+		WantPatches: []ktesting.PatchActionImpl{{
+			ActionImpl: ktesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":2}]`),
+		}},
+		WantStatusUpdates: []ktesting.UpdateActionImpl{{
+			Object: pa(testNamespace, testRevision, WithHPAClass, WithLowerScaleBound(2), WithPASKSReady,
+				WithScaleTargetInitialized, WithTraffic, WithPAStatusService(testRevision),
+				WithPAMetricsService(privateSvc), withScales(2, 0)),
+		}},
 	}}
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
 		retryAttempted = false
 		ctx = podscalable.WithDuck(ctx)
 
+		testConfigs := defaultConfig()
+		if cfg := ctx.Value(configKey{}); cfg != nil {
+			testConfigs = cfg.(*config.Config)
+		}
+		psf := podscalable.Get(ctx)
 		r := &Reconciler{
 			Base: &areconciler.Base{
 				Client:           servingclient.Get(ctx),
@@ -410,13 +589,19 @@ func TestReconcile(t *testing.T) {
 				SKSLister:        listers.GetServerlessServiceLister(),
 				MetricLister:     listers.GetMetricLister(),
 			},
-			kubeClient: kubeclient.Get(ctx),
-			hpaLister:  listers.GetHorizontalPodAutoscalerLister(),
+			kubeClient:    kubeclient.Get(ctx),
+			hpaLister:     listers.GetHorizontalPodAutoscalerLister(),
+			dynamicClient: dynamicclient.Get(ctx),
+			listerFactory: func(gvr schema.GroupVersionResource) (cache.GenericLister, error) {
+				_, l, err := psf.Get(ctx, gvr)
+				return l, err
+			},
+			enqueueAfter: func(interface{}, time.Duration) {},
 		}
 		return pareconciler.NewReconciler(ctx, logging.FromContext(ctx), servingclient.Get(ctx),
 			listers.GetPodAutoscalerLister(), controller.GetEventRecorder(ctx), r, autoscaling.HPA,
 			controller.Options{
-				ConfigStore: &testConfigStore{config: defaultConfig()},
+				ConfigStore: &testConfigStore{config: testConfigs},
 			})
 	}))
 }
@@ -462,6 +647,13 @@ func withHPAOwnersRemoved(hpa *autoscalingv2beta1.HorizontalPodAutoscaler) {
 	hpa.OwnerReferences = nil
 }
 
+func withSessionAffinity(pa *autoscalingv1alpha1.PodAutoscaler) {
+	if pa.Annotations == nil {
+		pa.Annotations = make(map[string]string, 1)
+	}
+	pa.Annotations[serving.SessionAffinityAnnotationKey] = serving.SessionAffinityCookie
+}
+
 func withScales(d, a int32) PodAutoscalerOption {
 	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
 		pa.Status.DesiredScale, pa.Status.ActualScale = ptr.Int32(d), ptr.Int32(a)
@@ -506,6 +698,43 @@ func deploy(namespace, name string, opts ...deploymentOption) *appsv1.Deployment
 	return s
 }
 
+type metricOption func(*autoscalingv1alpha1.Metric)
+
+func withMetricActive(m *autoscalingv1alpha1.Metric) {
+	m.Status.MarkActive()
+}
+
+func withMetricInactive(m *autoscalingv1alpha1.Metric) {
+	m.Status.MarkInactive("NoTraffic", "The target has not received traffic for the stable window.")
+}
+
+func metric(pa *autoscalingv1alpha1.PodAutoscaler, msvcName string, opts ...metricOption) *autoscalingv1alpha1.Metric {
+	m := aresources.MakeMetric(pa, msvcName, defaultConfig().Autoscaler)
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
+
+func withProxyFor(d time.Duration) SKSOption {
+	return func(sks *nv1a1.ServerlessService) {
+		sks.Status.MarkActivatorEndpointsPopulated()
+		for i, c := range sks.Status.Conditions {
+			if c.Type == nv1a1.ActivatorEndpointsPopulated {
+				sks.Status.Conditions[i].LastTransitionTime = apis.VolatileTime{Inner: metav1.NewTime(time.Now().Add(-d))}
+			}
+		}
+	}
+}
+
+type configKey struct{}
+
+func scaleToZeroConfig() *config.Config {
+	cfg := defaultConfig()
+	cfg.Autoscaler.EnableHPAScaleToZero = true
+	return cfg
+}
+
 func defaultConfig() *config.Config {
 	autoscalerConfig, _ := autoscalerconfig.NewConfigFromMap(nil)
 	return &config.Config{
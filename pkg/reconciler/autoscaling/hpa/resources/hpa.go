@@ -21,6 +21,7 @@ import (
 
 	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/ptr"
@@ -56,16 +57,77 @@ func MakeHPA(pa *v1alpha1.PodAutoscaler, config *autoscalerconfig.Config) *autos
 		hpa.Spec.MinReplicas = &min
 	}
 
-	if pa.Metric() == autoscaling.CPU {
-		if target, ok := pa.Target(); ok {
-			hpa.Spec.Metrics = []autoscalingv2beta1.MetricSpec{{
+	if metric, ok := makeMetric(pa); ok {
+		hpa.Spec.Metrics = []autoscalingv2beta1.MetricSpec{metric}
+	}
+	return hpa
+}
+
+// makeMetric returns the HPA metric for the metric annotations of the PA.
+// It returns false if the annotations don't define a complete metric.
+func makeMetric(pa *v1alpha1.PodAutoscaler) (autoscalingv2beta1.MetricSpec, bool) {
+	target, hasTarget := pa.Target()
+	switch pa.Metric() {
+	case autoscaling.CPU:
+		if hasTarget {
+			return autoscalingv2beta1.MetricSpec{
 				Type: autoscalingv2beta1.ResourceMetricSourceType,
 				Resource: &autoscalingv2beta1.ResourceMetricSource{
 					Name:                     corev1.ResourceCPU,
 					TargetAverageUtilization: ptr.Int32(int32(math.Ceil(target))),
 				},
-			}}
+			}, true
+		}
+	case autoscaling.Memory:
+		if tu, ok := pa.TargetUtilization(); ok {
+			return autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta1.ResourceMetricSource{
+					Name:                     corev1.ResourceMemory,
+					TargetAverageUtilization: ptr.Int32(int32(math.Round(tu * 100))),
+				},
+			}, true
+		}
+		if hasTarget {
+			// The target is in MiB.
+			return autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta1.ResourceMetricSource{
+					Name:               corev1.ResourceMemory,
+					TargetAverageValue: resource.NewQuantity(int64(math.Ceil(target*1024*1024)), resource.BinarySI),
+				},
+			}, true
+		}
+	case autoscaling.Custom:
+		if name, ok := pa.MetricName(); ok && hasTarget {
+			selector, _ := pa.MetricSelector()
+			return autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.PodsMetricSourceType,
+				Pods: &autoscalingv2beta1.PodsMetricSource{
+					MetricName:         name,
+					Selector:           selector,
+					TargetAverageValue: *targetQuantity(target),
+				},
+			}, true
+		}
+	case autoscaling.External:
+		if name, ok := pa.MetricName(); ok && hasTarget {
+			selector, _ := pa.MetricSelector()
+			return autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ExternalMetricSourceType,
+				External: &autoscalingv2beta1.ExternalMetricSource{
+					MetricName:         name,
+					MetricSelector:     selector,
+					TargetAverageValue: targetQuantity(target),
+				},
+			}, true
 		}
 	}
-	return hpa
+	return autoscalingv2beta1.MetricSpec{}, false
+}
+
+// targetQuantity converts the target annotation value into a quantity,
+// keeping up to milli precision.
+func targetQuantity(target float64) *resource.Quantity {
+	return resource.NewMilliQuantity(int64(math.Ceil(target*1000)), resource.DecimalSI)
 }
@@ -30,6 +30,7 @@ import (
 
 	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "knative.dev/serving/pkg/testing"
@@ -83,6 +84,73 @@ func TestMakeHPA(t *testing.T) {
 					TargetAverageUtilization: ptr.Int32(1983),
 				},
 			})),
+	}, {
+		name: "with a memory target",
+		pa:   pa(WithTargetAnnotation("128"), WithMetricAnnotation(autoscaling.Memory)),
+		want: hpa(
+			withAnnotationValue(autoscaling.MetricAnnotationKey, autoscaling.Memory),
+			withAnnotationValue(autoscaling.TargetAnnotationKey, "128"),
+			withMetric(autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta1.ResourceMetricSource{
+					Name:               corev1.ResourceMemory,
+					TargetAverageValue: resourceQuantity("128Mi"),
+				},
+			})),
+	}, {
+		name: "with a memory utilization target",
+		pa:   pa(WithTUAnnotation("70"), WithMetricAnnotation(autoscaling.Memory)),
+		want: hpa(
+			withAnnotationValue(autoscaling.MetricAnnotationKey, autoscaling.Memory),
+			withAnnotationValue(autoscaling.TargetUtilizationPercentageKey, "70"),
+			withMetric(autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ResourceMetricSourceType,
+				Resource: &autoscalingv2beta1.ResourceMetricSource{
+					Name:                     corev1.ResourceMemory,
+					TargetAverageUtilization: ptr.Int32(70),
+				},
+			})),
+	}, {
+		name: "with a custom metric",
+		pa: pa(WithTargetAnnotation("2.5"), WithMetricAnnotation(autoscaling.Custom),
+			WithMetricNameAnnotation("http_requests"), WithMetricSelectorAnnotation("verb=GET")),
+		want: hpa(
+			withAnnotationValue(autoscaling.MetricAnnotationKey, autoscaling.Custom),
+			withAnnotationValue(autoscaling.TargetAnnotationKey, "2.5"),
+			withAnnotationValue(autoscaling.MetricNameAnnotationKey, "http_requests"),
+			withAnnotationValue(autoscaling.MetricSelectorAnnotationKey, "verb=GET"),
+			withMetric(autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.PodsMetricSourceType,
+				Pods: &autoscalingv2beta1.PodsMetricSource{
+					MetricName: "http_requests",
+					Selector: &metav1.LabelSelector{
+						MatchLabels:      map[string]string{"verb": "GET"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{},
+					},
+					TargetAverageValue: *resourceQuantity("2500m"),
+				},
+			})),
+	}, {
+		name: "with an external metric",
+		pa: pa(WithTargetAnnotation("30"), WithMetricAnnotation(autoscaling.External),
+			WithMetricNameAnnotation("queue_messages_ready")),
+		want: hpa(
+			withAnnotationValue(autoscaling.MetricAnnotationKey, autoscaling.External),
+			withAnnotationValue(autoscaling.TargetAnnotationKey, "30"),
+			withAnnotationValue(autoscaling.MetricNameAnnotationKey, "queue_messages_ready"),
+			withMetric(autoscalingv2beta1.MetricSpec{
+				Type: autoscalingv2beta1.ExternalMetricSourceType,
+				External: &autoscalingv2beta1.ExternalMetricSource{
+					MetricName:         "queue_messages_ready",
+					TargetAverageValue: resourceQuantity("30"),
+				},
+			})),
+	}, {
+		name: "with a custom metric without a name",
+		pa:   pa(WithTargetAnnotation("30"), WithMetricAnnotation(autoscaling.Custom)),
+		want: hpa(
+			withAnnotationValue(autoscaling.MetricAnnotationKey, autoscaling.Custom),
+			withAnnotationValue(autoscaling.TargetAnnotationKey, "30")),
 	}}
 
 	for _, tc := range cases {
@@ -181,6 +249,11 @@ func withMaxReplicas(i int) hpaOption {
 	}
 }
 
+func resourceQuantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
 func withMetric(m autoscalingv2beta1.MetricSpec) hpaOption {
 	return func(hpa *autoscalingv2beta1.HorizontalPodAutoscaler) {
 		hpa.Spec.Metrics = []autoscalingv2beta1.MetricSpec{m}
@@ -20,10 +20,15 @@ import (
 	"context"
 
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/cache"
 
 	networkingclient "knative.dev/networking/pkg/client/injection/client"
 	sksinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/serverlessservice"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	nsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/namespace"
 	podinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod"
 	servingclient "knative.dev/serving/pkg/client/injection/client"
 	"knative.dev/serving/pkg/client/injection/ducks/autoscaling/v1alpha1/podscalable"
@@ -57,6 +62,7 @@ func NewController(
 	paInformer := painformer.Get(ctx)
 	sksInformer := sksinformer.Get(ctx)
 	podsInformer := podinformer.Get(ctx)
+	nsInformer := nsinformer.Get(ctx)
 	metricInformer := metricinformer.Get(ctx)
 	psInformerFactory := podscalable.Get(ctx)
 
@@ -70,8 +76,12 @@ func NewController(
 			SKSLister:        sksInformer.Lister(),
 			MetricLister:     metricInformer.Lister(),
 		},
+		kubeclient: kubeclient.Get(ctx),
+		paLister:   paInformer.Lister(),
 		podsLister: podsInformer.Lister(),
+		nsLister:   nsInformer.Lister(),
 		deciders:   deciders,
+		clock:      clock.RealClock{},
 	}
 	impl := pareconciler.NewImpl(ctx, c, autoscaling.KPA, func(impl *controller.Impl) controller.Options {
 		logger.Info("Setting up ConfigMap receivers")
@@ -121,6 +131,49 @@ func NewController(
 		Handler:    controller.HandleAll(impl.EnqueueLabelOfNamespaceScopedResource("", serving.RevisionLabelKey)),
 	})
 
+	// Reconcile the PAs of a namespace when its pod budget or the demands
+	// sharing it may have changed. Only the namespaces with a pod budget
+	// share the demands.
+	resyncer := newNamespaceResyncer(namespaceResyncDelay, func(ns string) {
+		impl.FilteredGlobalResync(pkgreconciler.ChainFilterFuncs(onlyKPAClass,
+			pkgreconciler.NamespaceFilterFunc(ns)), paInformer.Informer())
+	})
+	podBudget := func(ns *corev1.Namespace) string {
+		return ns.Annotations[autoscaling.PodBudgetAnnotationKey]
+	}
+	nsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNS, newNS := oldObj.(*corev1.Namespace), newObj.(*corev1.Namespace)
+			if podBudget(oldNS) != podBudget(newNS) {
+				resyncer.Resync(newNS.Name)
+			}
+		},
+	})
+	resyncBudgetNamespace := func(obj interface{}) {
+		accessor, err := kmeta.DeletionHandlingAccessor(obj)
+		if err != nil {
+			logger.Errorw("Error accessing object", zap.Error(err))
+			return
+		}
+		ns, err := nsInformer.Lister().Get(accessor.GetNamespace())
+		if err != nil || podBudget(ns) == "" {
+			return
+		}
+		resyncer.Resync(ns.Name)
+	}
+	paInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: onlyKPAClass,
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldPA, newPA := oldObj.(*autoscalingv1alpha1.PodAutoscaler), newObj.(*autoscalingv1alpha1.PodAutoscaler)
+				if !equality.Semantic.DeepEqual(oldPA.Status.DemandedScale, newPA.Status.DemandedScale) {
+					resyncBudgetNamespace(newObj)
+				}
+			},
+			DeleteFunc: resyncBudgetNamespace,
+		},
+	})
+
 	// Have the Deciders enqueue the PAs whose decisions have changed.
 	deciders.Watch(impl.EnqueueKey)
 
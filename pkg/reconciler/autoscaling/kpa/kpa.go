@@ -32,6 +32,7 @@ import (
 	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/autoscaler/scaling"
 	pareconciler "knative.dev/serving/pkg/client/injection/reconciler/autoscaling/v1alpha1/podautoscaler"
+	listers "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
 	"knative.dev/serving/pkg/metrics"
 	areconciler "knative.dev/serving/pkg/reconciler/autoscaling"
 	"knative.dev/serving/pkg/reconciler/autoscaling/config"
@@ -41,6 +42,8 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 )
 
@@ -50,7 +53,8 @@ const (
 )
 
 // podCounts keeps record of various numbers of pods
-// for each revision.
+// for each revision. The pods of the warm pool of the revision
+// are not counted, until they are promoted to serve it.
 type podCounts struct {
 	want        int
 	ready       int
@@ -64,9 +68,13 @@ type podCounts struct {
 type Reconciler struct {
 	*areconciler.Base
 
+	kubeclient kubernetes.Interface
+	paLister   listers.PodAutoscalerLister
 	podsLister corev1listers.PodLister
+	nsLister   corev1listers.NamespaceLister
 	deciders   resources.Deciders
 	scaler     *scaler
+	clock      clock.PassiveClock
 }
 
 // Check that our Reconciler implements pareconciler.Interface
@@ -76,6 +84,18 @@ var _ pareconciler.Interface = (*Reconciler)(nil)
 func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler) pkgreconciler.Event {
 	logger := logging.FromContext(ctx)
 
+	// Apply the bounds of the scale window active now, if any, and reconcile
+	// again when the active window may change.
+	now := c.clock.Now()
+	window, next := pa.ActiveScaleWindow(now)
+	if window != pa.Status.ActiveScaleWindow {
+		logger.Infof("Active scale window changed: %q -> %q", pa.Status.ActiveScaleWindow, window)
+	}
+	pa.Status.ActiveScaleWindow = window
+	if !next.IsZero() {
+		c.scaler.enqueueCB(pa, next.Sub(now))
+	}
+
 	// We need the SKS object in order to optimize scale to zero
 	// performance. It is OK if SKS is nil at this point.
 	sksName := anames.SKS(pa.Name)
@@ -108,7 +128,11 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 
 	// Get the appropriate current scale from the metric, and right size
 	// the scaleTargetRef based on it.
-	want, err := c.scaler.scale(ctx, pa, sks, decider.Status.DesiredScale)
+	desiredScale, err := c.shareBudget(ctx, pa, decider.Status.DesiredScale)
+	if err != nil {
+		return fmt.Errorf("error sharing the pod budget: %w", err)
+	}
+	want, err := c.scaler.scale(ctx, pa, sks, desiredScale)
 	if err != nil {
 		return fmt.Errorf("error scaling target: %w", err)
 	}
@@ -121,7 +145,9 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 	//			this revision, e.g. after a restart) but PA status is inactive (it was
 	//			already scaled to 0).
 	// 2. The excess burst capacity is negative.
-	if want == 0 || decider.Status.ExcessBurstCapacity < 0 || want == scaleUnknown && pa.Status.IsInactive() {
+	// 3. The revision uses session affinity.
+	if want == 0 || decider.Status.ExcessBurstCapacity < 0 || want == scaleUnknown && pa.Status.IsInactive() ||
+		areconciler.NeedsActivator(pa) {
 		mode = nv1alpha1.SKSOperationModeProxy
 	}
 	logger.Infof("SKS should be in %s mode: want = %d, ebc = %d, #act's = %d PA Inactive? = %v",
@@ -144,6 +170,10 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, pa *autoscalingv1alpha1.
 		return fmt.Errorf("error getting pod counts %s: %w", sks.Status.PrivateServiceName, err)
 	}
 
+	if err := c.reconcileWarmPool(ctx, pa, want, ready); err != nil {
+		return fmt.Errorf("error reconciling warm pool: %w", err)
+	}
+
 	// If SKS is not ready — ensure we're not becoming ready.
 	if sks.IsReady() {
 		logger.Debug("SKS is ready, marking SKS status ready")
@@ -32,6 +32,7 @@ import (
 	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
 	fakesksinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/serverlessservice/fake"
 	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/namespace/fake"
 	fakepodsinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/pod/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/service/fake"
 	fakedynamicclient "knative.dev/pkg/injection/clients/dynamicclient/fake"
@@ -50,6 +51,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientgotesting "k8s.io/client-go/testing"
 
@@ -207,6 +209,10 @@ func kpa(ns, n string, opts ...PodAutoscalerOption) *autoscalingv1alpha1.PodAuto
 	return kpa
 }
 
+func withSessionAffinity(pa *autoscalingv1alpha1.PodAutoscaler) {
+	pa.Annotations[serving.SessionAffinityAnnotationKey] = serving.SessionAffinityCookie
+}
+
 func markResourceNotOwned(rType, name string) PodAutoscalerOption {
 	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
 		pa.Status.MarkResourceNotOwned(rType, name)
@@ -308,6 +314,264 @@ func TestReconcile(t *testing.T) {
 			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
 			metric(testNamespace, testRevision),
 			defaultDeployment, defaultReady},
+	}, {
+		Name: "scale window caps the scale",
+		Key:  key,
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScaleWindows(alwaysWindow(`"maxScale": 5`)),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":5}]`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScaleWindows(alwaysWindow(`"maxScale": 5`)), withActiveScaleWindow("always"),
+				withScales(1, 5), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+		}},
+	}, {
+		Name: "scale window ends",
+		Key:  key,
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScaleWindows(`[{"name": "never", "schedule": "0 0 30 2 *", "duration": "1h", "maxScale": 5}]`),
+				withActiveScaleWindow("never"),
+				withScales(1, 5), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			deploy(testNamespace, testRevision, func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.Int32(5)
+			}), defaultReady},
+		WantPatches: []clientgotesting.PatchActionImpl{minScalePatch},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScaleWindows(`[{"name": "never", "schedule": "0 0 30 2 *", "duration": "1h", "maxScale": 5}]`),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+		}},
+	}, {
+		Name: "throttled by quota",
+		Key:  key,
+		Ctx: context.WithValue(context.Background(), deciderKey{},
+			decider(testNamespace, testRevision, 5 /* desiredScale */, 0 /* ebc */, scaling.MinActivators)),
+		Objects: []runtime.Object{
+			namespace(testNamespace, "2"),
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":2}]`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, 2), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(5), withThrottledByQuota(5, 2, 2)),
+		}},
+	}, {
+		Name: "throttled by the demand of another revision",
+		Key:  key,
+		Objects: []runtime.Object{
+			namespace(testNamespace, "12"),
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+			kpa(testNamespace, "other-revision", withDemandedScale(9)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":6}]`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, 6), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(defaultScale), withThrottledByQuota(defaultScale, 6, 12)),
+		}},
+	}, {
+		Name: "throttled by the min scale of another revision",
+		Key:  key,
+		Objects: []runtime.Object{
+			namespace(testNamespace, "12"),
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+			kpa(testNamespace, "other-revision", withDemandedScale(0), withMinScale(8)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantPatches: []clientgotesting.PatchActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+			},
+			Name:  deployName,
+			Patch: []byte(`[{"op":"replace","path":"/spec/replicas","value":4}]`),
+		}},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, 4), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(defaultScale), withThrottledByQuota(defaultScale, 4, 12)),
+		}},
+	}, {
+		Name: "within quota",
+		Key:  key,
+		Objects: []runtime.Object{
+			namespace(testNamespace, "20"),
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, 2), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(5), withThrottledByQuota(5, 2, 2)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(defaultScale), withWithinQuota),
+		}},
+	}, {
+		Name: "pod budget removed",
+		Key:  key,
+		Objects: []runtime.Object{
+			namespace(testNamespace, ""),
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withDemandedScale(defaultScale), withWithinQuota),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1)),
+		}},
+	}, {
+		Name: "pa activates, promotes the ready warm pool pods",
+		Key:  key,
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithScaleTargetInitialized, WithNoTraffic(noTrafficReason, "The target is not receiving traffic."),
+				withScales(0, defaultScale), WithPAStatusService(testRevision), WithPAMetricsService(privateSvc),
+				withWarmPool(2)),
+			sks(testNamespace, testRevision, WithProxyMode, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment,
+			warmPod("warm-0", true), warmPod("warm-1", false), warmPod("warm-2", true),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithSKSReady,
+				WithDeployRef(deployName)),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			promotionPatchAction("warm-0"), promotionPatchAction("warm-2"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, WithScaleTargetInitialized, WithBufferedTraffic, withScales(0, defaultScale),
+				WithPASKSReady, WithPAMetricsService(privateSvc),
+				WithPAStatusService(testRevision), WithObservedGeneration(1), withWarmPool(2)),
+		}},
+	}, {
+		Name: "promoted pods are kept until the revision pods take over",
+		Key:  key,
+		Objects: append([]runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(underscale+1, defaultScale), WithPAStatusService(testRevision),
+				WithObservedGeneration(1), withWarmPool(1)),
+			defaultSKS, defaultMetric, defaultDeployment,
+			promotedPod("warm-0"),
+		}, underscaledReady...),
+	}, {
+		Name: "promoted pods are retired once the revision pods take over",
+		Key:  key,
+		Objects: append([]runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(overscale, defaultScale), WithPAStatusService(testRevision),
+				WithObservedGeneration(1), withWarmPool(1)),
+			defaultSKS, defaultMetric, defaultDeployment,
+			promotedPod("warm-0"),
+		}, preciseReady...),
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+				Verb:      "delete",
+				Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+			},
+			Name: "warm-0",
+		}},
+	}, {
+		Name: "promoted pods are kept until the revision is scaled to zero",
+		Key:  key,
+		Ctx: context.WithValue(context.Background(), deciderKey{},
+			decider(testNamespace, testRevision, 0 /* desiredScale */, 0 /* ebc */, scaling.MinActivators)),
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic, markOld,
+				withScales(0, 0), WithPAStatusService(testRevision), WithPAMetricsService(privateSvc),
+				withWarmPool(1)),
+			defaultSKS,
+			metric(testNamespace, testRevision),
+			deploy(testNamespace, testRevision), promotedPod("warm-0")},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: kpa(testNamespace, testRevision, markScaleTargetInitialized, withScales(1, 0),
+				WithPASKSReady, WithPAMetricsService(privateSvc),
+				WithNoTraffic(noTrafficReason, "The target is not receiving traffic."),
+				WithPAStatusService(testRevision), WithPAMetricsService(privateSvc),
+				WithObservedGeneration(1), withWarmPool(1)),
+		}},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithSKSReady,
+				WithDeployRef(deployName), WithProxyMode),
+		}},
+	}, {
+		Name: "promoted pods are retired once the revision is scaled to zero",
+		Key:  key,
+		Ctx: context.WithValue(context.Background(), deciderKey{},
+			decider(testNamespace, testRevision, 0 /* desiredScale */, 0 /* ebc */, scaling.MinActivators)),
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithScaleTargetInitialized, withScales(1, 0),
+				WithNoTraffic(noTrafficReason, "The target is not receiving traffic."),
+				WithPASKSReady, markOld, WithPAStatusService(testRevision),
+				WithPAMetricsService(privateSvc), WithObservedGeneration(1), withWarmPool(1)),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithProxyMode, WithSKSReady),
+			metric(testNamespace, testRevision),
+			deploy(testNamespace, testRevision, func(d *appsv1.Deployment) {
+				d.Spec.Replicas = ptr.Int32(0)
+			}),
+			promotedPod("warm-0"),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: testNamespace,
+				Verb:      "delete",
+				Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+			},
+			Name: "warm-0",
+		}},
 	}, {
 		Name: "status update retry",
 		Key:  key,
@@ -928,6 +1192,20 @@ func TestReconcile(t *testing.T) {
 			sks(testNamespace, testRevision, WithDeployRef(deployName), WithProxyMode, WithSKSReady),
 			metric(testNamespace, testRevision),
 			defaultDeployment, defaultReady},
+	}, {
+		Name: "session affinity keeps the activator in the path",
+		Key:  key,
+		Objects: []runtime.Object{
+			kpa(testNamespace, testRevision, WithPASKSReady, WithTraffic,
+				markScaleTargetInitialized, WithPAMetricsService(privateSvc),
+				withScales(1, defaultScale), WithPAStatusService(testRevision), WithObservedGeneration(1),
+				withSessionAffinity),
+			sks(testNamespace, testRevision, WithDeployRef(deployName), WithSKSReady),
+			metric(testNamespace, testRevision),
+			defaultDeployment, defaultReady},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: sks(testNamespace, testRevision, WithDeployRef(deployName), WithProxyMode, WithSKSReady),
+		}},
 	}, {
 		Name: "steady, proxy mode, many activators requested",
 		Key:  key,
@@ -1165,9 +1443,13 @@ func TestReconcile(t *testing.T) {
 				SKSLister:        listers.GetServerlessServiceLister(),
 				MetricLister:     listers.GetMetricLister(),
 			},
+			kubeclient: fakekubeclient.Get(ctx),
+			paLister:   listers.GetPodAutoscalerLister(),
 			podsLister: listers.GetPodsLister(),
+			nsLister:   listers.GetNamespaceLister(),
 			deciders:   fakeDeciders,
 			scaler:     scaler,
+			clock:      clock.RealClock{},
 		}
 		return pareconciler.NewReconciler(ctx, logging.FromContext(ctx),
 			servingclient.Get(ctx), listers.GetPodAutoscalerLister(),
@@ -1734,6 +2016,109 @@ func makeReadyPods(num int, ns, n string) []runtime.Object {
 	return r
 }
 
+// alwaysWindow returns the scale windows annotation value of a window, named
+// "always", active at any time and setting the bounds.
+func alwaysWindow(bounds string) string {
+	return `[{"name": "always", "schedule": "* * * * *", "duration": "1h", ` + bounds + `}]`
+}
+
+func withScaleWindows(windows string) PodAutoscalerOption {
+	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
+		pa.Annotations = kmeta.UnionMaps(
+			pa.Annotations,
+			map[string]string{autoscaling.ScaleWindowsAnnotationKey: windows},
+		)
+	}
+}
+
+func withActiveScaleWindow(name string) PodAutoscalerOption {
+	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
+		pa.Status.ActiveScaleWindow = name
+	}
+}
+
+func withThrottledByQuota(want, got, budget int32) PodAutoscalerOption {
+	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
+		pa.Status.MarkThrottledByQuota(want, got, budget)
+	}
+}
+
+func withWithinQuota(pa *autoscalingv1alpha1.PodAutoscaler) {
+	pa.Status.MarkWithinQuota()
+}
+
+const testRevisionUID = "test-revision-uid"
+
+func withWarmPool(size int) PodAutoscalerOption {
+	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
+		pa.Annotations[autoscaling.WarmPoolSizeAnnotationKey] = strconv.Itoa(size)
+		pa.Labels[serving.RevisionUID] = testRevisionUID
+	}
+}
+
+// warmPod returns a pod of the warm pool of the test revision.
+func warmPod(name string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{serving.WarmPoolLabelKey: testRevisionUID},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodReady,
+				Status: status,
+			}},
+		},
+	}
+}
+
+// promotedPod returns a pod of the warm pool promoted to serve the test revision.
+func promotedPod(name string) *corev1.Pod {
+	p := warmPod(name, true)
+	p.Labels = map[string]string{
+		serving.RevisionLabelKey:         testRevision,
+		serving.RevisionUID:              testRevisionUID,
+		serving.WarmPoolPromotedLabelKey: "true",
+	}
+	return p
+}
+
+func promotionPatchAction(name string) clientgotesting.PatchActionImpl {
+	return clientgotesting.PatchActionImpl{
+		ActionImpl: clientgotesting.ActionImpl{Namespace: testNamespace},
+		Name:       name,
+		Patch: []byte(`{"metadata":{"labels":{"serving.knative.dev/revision":"` + testRevision +
+			`","serving.knative.dev/revisionUID":"` + testRevisionUID +
+			`","serving.knative.dev/warmPool":null,"serving.knative.dev/warmPoolPromoted":"true"},` +
+			`"ownerReferences":[{"apiVersion":"serving.knative.dev/v1","kind":"Revision","name":"` + testRevision + `","uid":"","controller":true,"blockOwnerDeletion":true}]}}`),
+	}
+}
+
+// namespace returns the test namespace with the given pod budget, if any.
+func namespace(name, podBudget string) *corev1.Namespace {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	if podBudget != "" {
+		ns.Annotations = map[string]string{autoscaling.PodBudgetAnnotationKey: podBudget}
+	}
+	return ns
+}
+
+func withDemandedScale(scale int32) PodAutoscalerOption {
+	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
+		pa.Status.DemandedScale = ptr.Int32(scale)
+	}
+}
+
 func withMinScale(minScale int) PodAutoscalerOption {
 	return func(pa *autoscalingv1alpha1.PodAutoscaler) {
 		pa.Annotations = kmeta.UnionMaps(
@@ -0,0 +1,275 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kpa
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/ptr"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/autoscaling/config"
+)
+
+// demand is the desired scale of a revision competing for the pod budget
+// of its namespace.
+type demand struct {
+	key    types.NamespacedName
+	scale  int32
+	min    int32
+	weight float64
+}
+
+// shareBudget returns the share of the pod budget of the PA's namespace
+// granted to the desired scale of the PA, and reports whether the budget
+// throttles it.
+// The revisions of a namespace may be scaled by different autoscaler
+// replicas, so the budget is shared by the scales the PAs demand in their
+// status, which all the replicas see, rather than by the deciders of this
+// replica. Every replica computes the same shares from the same demands.
+func (c *Reconciler) shareBudget(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler, desiredScale int32) (int32, error) {
+	budget := c.podBudget(ctx, pa.Namespace)
+	if budget == 0 {
+		pa.Status.DemandedScale = nil
+		pa.Status.ClearQuota()
+		return desiredScale, nil
+	}
+	if desiredScale < 0 {
+		// Without a decision the PA keeps competing with its last demand.
+		return desiredScale, nil
+	}
+	pa.Status.DemandedScale = ptr.Int32(desiredScale)
+
+	pas, err := c.paLister.PodAutoscalers(pa.Namespace).List(labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("error listing the PAs sharing the pod budget: %w", err)
+	}
+	asConfig := config.FromContext(ctx).Autoscaler
+	demands := make([]demand, 0, len(pas)+1)
+	self := -1
+	for _, p := range pas {
+		if p.Name == pa.Name {
+			// The lister may not have our latest demand yet.
+			p, self = pa, len(demands)
+		}
+		if p.Class() != autoscaling.KPA || p.Status.DemandedScale == nil {
+			continue
+		}
+		min, _ := p.ScaleBounds(asConfig)
+		demands = append(demands, demand{
+			key:    types.NamespacedName{Namespace: p.Namespace, Name: p.Name},
+			scale:  *p.Status.DemandedScale,
+			min:    min,
+			weight: p.QuotaWeight(),
+		})
+	}
+	if self < 0 {
+		min, _ := pa.ScaleBounds(asConfig)
+		self = len(demands)
+		demands = append(demands, demand{
+			key:    types.NamespacedName{Namespace: pa.Namespace, Name: pa.Name},
+			scale:  desiredScale,
+			min:    min,
+			weight: pa.QuotaWeight(),
+		})
+	}
+
+	scale := apportion(budget, demands)[self]
+	if scale < desiredScale {
+		pa.Status.MarkThrottledByQuota(desiredScale, scale, budget)
+	} else {
+		pa.Status.MarkWithinQuota()
+	}
+	return scale, nil
+}
+
+// podBudget returns the pod budget of the namespace, or 0 if it has none.
+func (c *Reconciler) podBudget(ctx context.Context, namespace string) int32 {
+	ns, err := c.nsLister.Get(namespace)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Error retrieving the namespace for its pod budget", zap.Error(err))
+		return 0
+	}
+	v, ok := ns.Annotations[autoscaling.PodBudgetAnnotationKey]
+	if !ok {
+		return 0
+	}
+	budget, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || budget < 1 {
+		logging.FromContext(ctx).Warnf("Ignoring the invalid pod budget %q of namespace %q", v, namespace)
+		return 0
+	}
+	return int32(budget)
+}
+
+// apportion returns the scales the budget allows for the demands. If the
+// demands fit in the budget, they are granted as is. Otherwise every
+// demand first gets its min scale, which applies regardless of the budget,
+// then a single pod while the budget lasts, so that no revision with
+// traffic is starved, and then the rest of the budget is water-filled by
+// weight: the demands below their weighted share are granted in full and
+// the others split what remains by weight.
+// Ties and rounding favor the heavier demands, then the lower keys.
+func apportion(budget int32, demands []demand) []int32 {
+	scales := make([]int32, len(demands))
+	total := int64(0)
+	for i, d := range demands {
+		scales[i] = d.scale
+		if d.min > scales[i] {
+			scales[i] = d.min
+		}
+		total += int64(scales[i])
+	}
+	if total <= int64(budget) {
+		return scales
+	}
+
+	order := make([]int, len(demands))
+	remaining := int64(budget)
+	for i, d := range demands {
+		order[i] = i
+		scales[i] = d.min
+		remaining -= int64(d.min)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := demands[order[i]], demands[order[j]]
+		if wa, wb := weight(a), weight(b); wa != wb {
+			return wa > wb
+		}
+		return a.key.String() < b.key.String()
+	})
+
+	for _, i := range order {
+		if remaining <= 0 {
+			return scales
+		}
+		if demands[i].scale > 0 && scales[i] == 0 {
+			scales[i] = 1
+			remaining--
+		}
+	}
+
+	hungry := make([]int, 0, len(order))
+	for _, i := range order {
+		if scales[i] < demands[i].scale {
+			hungry = append(hungry, i)
+		}
+	}
+	for remaining > 0 && len(hungry) > 0 {
+		weights := 0.
+		for _, i := range hungry {
+			weights += weight(demands[i])
+		}
+
+		// Grant in full the demands which fit in their share.
+		granted := int64(0)
+		next := make([]int, 0, len(hungry))
+		for _, i := range hungry {
+			want := demands[i].scale - scales[i]
+			if float64(want) <= float64(remaining)*weight(demands[i])/weights {
+				scales[i] += want
+				granted += int64(want)
+			} else {
+				next = append(next, i)
+			}
+		}
+		if granted > 0 {
+			remaining -= granted
+			hungry = next
+			continue
+		}
+
+		// None fits: split the rest by weight, and the rounding remainder
+		// one pod at a time.
+		for _, i := range hungry {
+			share := int32(math.Floor(float64(remaining) * weight(demands[i]) / weights))
+			scales[i] += share
+			granted += int64(share)
+		}
+		remaining -= granted
+		for _, i := range hungry {
+			if remaining == 0 {
+				break
+			}
+			scales[i]++
+			remaining--
+		}
+		break
+	}
+	return scales
+}
+
+// weight returns the weight of the demand, defaulting to 1.
+func weight(d demand) float64 {
+	if d.weight <= 0 {
+		return 1
+	}
+	return d.weight
+}
+
+// namespaceResyncDelay is the time over which the changes to the demands of
+// a namespace are coalesced into one resync of its PAs.
+const namespaceResyncDelay = time.Second
+
+// namespaceResyncer resyncs the PAs of a namespace when the demands sharing
+// its pod budget change. Each resync reconciles all the PAs of the namespace,
+// which each list them again, so the resyncs requested within the delay of
+// the first are coalesced into it.
+type namespaceResyncer struct {
+	delay  time.Duration
+	resync func(namespace string)
+
+	mu      sync.Mutex
+	pending sets.String
+}
+
+func newNamespaceResyncer(delay time.Duration, resync func(namespace string)) *namespaceResyncer {
+	return &namespaceResyncer{
+		delay:   delay,
+		resync:  resync,
+		pending: sets.NewString(),
+	}
+}
+
+// Resync resyncs the PAs of the namespace after the delay, unless a resync
+// of the namespace is already pending.
+func (r *namespaceResyncer) Resync(namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending.Has(namespace) {
+		return
+	}
+	r.pending.Insert(namespace)
+	time.AfterFunc(r.delay, func() {
+		r.mu.Lock()
+		r.pending.Delete(namespace)
+		r.mu.Unlock()
+		r.resync(namespace)
+	})
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApportion(t *testing.T) {
+	key := func(name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: "ns", Name: name}
+	}
+	tests := []struct {
+		name    string
+		budget  int32
+		demands []demand
+		want    []int32
+	}{{
+		name:   "within budget",
+		budget: 10,
+		demands: []demand{
+			{key: key("a"), scale: 3, weight: 1},
+			{key: key("b"), scale: 7, weight: 1},
+		},
+		want: []int32{3, 7},
+	}, {
+		name:   "equal weights",
+		budget: 10,
+		demands: []demand{
+			{key: key("a"), scale: 20, weight: 1},
+			{key: key("b"), scale: 20, weight: 1},
+		},
+		want: []int32{5, 5},
+	}, {
+		name:   "by weight",
+		budget: 12,
+		demands: []demand{
+			{key: key("a"), scale: 20, weight: 1},
+			{key: key("b"), scale: 20, weight: 3},
+		},
+		want: []int32{3, 9},
+	}, {
+		name:   "small demand granted in full",
+		budget: 10,
+		demands: []demand{
+			{key: key("a"), scale: 2, weight: 1},
+			{key: key("b"), scale: 20, weight: 1},
+			{key: key("c"), scale: 20, weight: 1},
+		},
+		want: []int32{2, 4, 4},
+	}, {
+		name:   "rounding favors the heavier, then the lower key",
+		budget: 10,
+		demands: []demand{
+			{key: key("c"), scale: 20, weight: 1},
+			{key: key("b"), scale: 20, weight: 1},
+			{key: key("a"), scale: 20, weight: 2},
+		},
+		want: []int32{2, 3, 5},
+	}, {
+		name:   "one pod each before weights",
+		budget: 3,
+		demands: []demand{
+			{key: key("a"), scale: 20, weight: 100},
+			{key: key("b"), scale: 20, weight: 1},
+			{key: key("c"), scale: 20, weight: 1},
+		},
+		want: []int32{1, 1, 1},
+	}, {
+		name:   "budget smaller than the revisions",
+		budget: 2,
+		demands: []demand{
+			{key: key("c"), scale: 5, weight: 1},
+			{key: key("b"), scale: 5, weight: 1},
+			{key: key("a"), scale: 5, weight: 2},
+		},
+		want: []int32{0, 1, 1},
+	}, {
+		name:   "scaled to zero is not granted a pod",
+		budget: 2,
+		demands: []demand{
+			{key: key("a"), scale: 0, weight: 1},
+			{key: key("b"), scale: 5, weight: 1},
+		},
+		want: []int32{0, 2},
+	}, {
+		name:   "default weight",
+		budget: 4,
+		demands: []demand{
+			{key: key("a"), scale: 5},
+			{key: key("b"), scale: 5, weight: 1},
+		},
+		want: []int32{2, 2},
+	}, {
+		name:   "min scales first",
+		budget: 10,
+		demands: []demand{
+			{key: key("a"), scale: 20, min: 6, weight: 1},
+			{key: key("b"), scale: 20, weight: 1},
+		},
+		want: []int32{8, 2},
+	}, {
+		name:   "min scale above the demand",
+		budget: 10,
+		demands: []demand{
+			{key: key("a"), scale: 1, min: 4, weight: 1},
+			{key: key("b"), scale: 20, weight: 1},
+		},
+		want: []int32{4, 6},
+	}, {
+		name:   "min scales past the budget",
+		budget: 4,
+		demands: []demand{
+			{key: key("a"), scale: 20, min: 3, weight: 1},
+			{key: key("b"), scale: 20, min: 3, weight: 1},
+			{key: key("c"), scale: 20, weight: 1},
+		},
+		want: []int32{3, 3, 0},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := apportion(tc.budget, tc.demands); !cmp.Equal(got, tc.want) {
+				t.Errorf("apportion = %v, want: %v, diff(-want,+got):\n%s", got, tc.want, cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
+
+func TestNamespaceResyncer(t *testing.T) {
+	resynced := make(chan string, 10)
+	r := newNamespaceResyncer(50*time.Millisecond, func(ns string) {
+		resynced <- ns
+	})
+
+	// The resyncs of a namespace within the delay are coalesced.
+	for i := 0; i < 5; i++ {
+		r.Resync("ns")
+	}
+	r.Resync("other-ns")
+	got := map[string]int{}
+	for i := 0; i < 2; i++ {
+		got[<-resynced]++
+	}
+	if want := map[string]int{"ns": 1, "other-ns": 1}; !cmp.Equal(got, want) {
+		t.Errorf("Resynced = %v, want: %v", got, want)
+	}
+	select {
+	case ns := <-resynced:
+		t.Errorf("Resynced %s more than once", ns)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// And a later change resyncs the namespace again.
+	r.Resync("ns")
+	if got := <-resynced; got != "ns" {
+		t.Errorf("Resynced = %s, want: ns", got)
+	}
+}
@@ -151,6 +151,22 @@ func TestMakeDecider(t *testing.T) {
 			return &c
 		},
 		want: decider(withTarget(100.0), withPanicThreshold(2.0), withTotal(100), withScaleDownDelay(10*time.Minute), withDeciderScaleDownDelayAnnotation("10m")),
+	}, {
+		name: "with scaling mode from config",
+		pa:   pa(),
+		cfgOpt: func(c autoscalerconfig.Config) *autoscalerconfig.Config {
+			c.ScalingMode = autoscaling.ScalingModePredictive
+			c.ForecastHorizon = 45 * time.Second
+			return &c
+		},
+		want: decider(withTarget(100.0), withPanicThreshold(2.0), withTotal(100),
+			withScalingMode(autoscaling.ScalingModePredictive), withForecastHorizon(45*time.Second)),
+	}, {
+		name: "with scaling mode from annotation",
+		pa:   pa(withScalingModeAnnotation(autoscaling.ScalingModePredictive)),
+		want: decider(withTarget(100.0), withPanicThreshold(2.0), withTotal(100),
+			withScalingMode(autoscaling.ScalingModePredictive),
+			withDeciderScalingModeAnnotation(autoscaling.ScalingModePredictive)),
 	}, {
 		name: "with initial scale",
 		pa: pa(func(pa *v1alpha1.PodAutoscaler) {
@@ -262,6 +278,18 @@ func withDeciderScaleDownDelayAnnotation(sdd string) deciderOption {
 	}
 }
 
+func withScalingModeAnnotation(mode string) PodAutoscalerOption {
+	return func(pa *v1alpha1.PodAutoscaler) {
+		pa.Annotations[autoscaling.ScalingModeAnnotationKey] = mode
+	}
+}
+
+func withDeciderScalingModeAnnotation(mode string) deciderOption {
+	return func(d *scaling.Decider) {
+		d.Annotations[autoscaling.ScalingModeAnnotationKey] = mode
+	}
+}
+
 func withTBCAnnotation(tbc string) PodAutoscalerOption {
 	return func(pa *v1alpha1.PodAutoscaler) {
 		pa.Annotations[autoscaling.TargetBurstCapacityKey] = tbc
@@ -297,6 +325,8 @@ func decider(options ...deciderOption) *scaling.Decider {
 			StableWindow:        config.StableWindow,
 			InitialScale:        1,
 			Reachable:           true,
+			ScalingMode:         autoscaling.ScalingModeReactive,
+			ForecastHorizon:     config.ForecastHorizon,
 		},
 	}
 	for _, fn := range options {
@@ -336,6 +366,18 @@ func withScaleDownDelay(d time.Duration) deciderOption {
 	}
 }
 
+func withScalingMode(mode string) deciderOption {
+	return func(decider *scaling.Decider) {
+		decider.Spec.ScalingMode = mode
+	}
+}
+
+func withForecastHorizon(h time.Duration) deciderOption {
+	return func(decider *scaling.Decider) {
+		decider.Spec.ForecastHorizon = h
+	}
+}
+
 func withTotal(total float64) deciderOption {
 	return func(decider *scaling.Decider) {
 		decider.Spec.TotalValue = total
@@ -387,4 +429,6 @@ var config = &autoscalerconfig.Config{
 	ScaleToZeroGracePeriod:             30 * time.Second,
 	InitialScale:                       1,
 	AllowZeroInitialScale:              false,
+	ScalingMode:                        autoscaling.ScalingModeReactive,
+	ForecastHorizon:                    30 * time.Second,
 }
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kpa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/pkg/logging"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+	resourceutil "knative.dev/serving/pkg/resources"
+)
+
+// reconcileWarmPool promotes the ready pods of the warm pool of the revision
+// to serve it, when it has to scale from zero, and retires the promoted pods
+// once the revision's own pods are ready to take over, or once the revision
+// has been scaled to zero.
+// Promoting a pod relabels it so that it leaves the warm pool Deployment,
+// which replaces it, and joins the private service of the revision.
+func (c *Reconciler) reconcileWarmPool(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler, want int32, ready int) error {
+	if _, ok := pa.WarmPoolSize(); !ok {
+		return nil
+	}
+	logger := logging.FromContext(ctx)
+
+	promoted, err := c.podsLister.Pods(pa.Namespace).List(labels.SelectorFromSet(labels.Set{
+		serving.RevisionLabelKey:         pa.Labels[serving.RevisionLabelKey],
+		serving.WarmPoolPromotedLabelKey: "true",
+	}))
+	if err != nil {
+		return fmt.Errorf("error listing promoted pods: %w", err)
+	}
+
+	if want > 0 && ready == 0 && len(promoted) == 0 {
+		warm, err := c.podsLister.Pods(pa.Namespace).List(labels.SelectorFromSet(labels.Set{
+			serving.WarmPoolLabelKey: pa.Labels[serving.RevisionUID],
+		}))
+		if err != nil {
+			return fmt.Errorf("error listing warm pool pods: %w", err)
+		}
+		sort.Slice(warm, func(i, j int) bool {
+			return warm[i].Name < warm[j].Name
+		})
+		patch, err := promotionPatch(pa)
+		if err != nil {
+			return err
+		}
+		for _, p := range warm {
+			if want == 0 {
+				break
+			}
+			if p.DeletionTimestamp != nil || !podReady(p) {
+				continue
+			}
+			logger.Info("Promoting warm pool pod ", p.Name)
+			if _, err := c.kubeclient.CoreV1().Pods(p.Namespace).Patch(ctx, p.Name,
+				types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+				return fmt.Errorf("error promoting warm pool pod %s: %w", p.Name, err)
+			}
+			want--
+		}
+		return nil
+	}
+
+	if len(promoted) == 0 {
+		return nil
+	}
+	retire := false
+	switch {
+	case want > 0:
+		// The promoted pods are counted as ready, so retire them only once the
+		// other ready pods cover the desired scale.
+		retire = ready-len(promoted) >= int(want)
+	case want == 0:
+		// The scaler only scales to zero after the scale-to-zero grace period
+		// and the last pod retention, which the promoted pods honor as well.
+		if retire, err = c.scaledToZero(pa); err != nil {
+			return err
+		}
+	}
+	if !retire {
+		return nil
+	}
+	for _, p := range promoted {
+		logger.Info("Retiring promoted warm pool pod ", p.Name)
+		if err := c.kubeclient.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name,
+			metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("error retiring promoted pod %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// scaledToZero returns whether the scale target of the PA has been scaled
+// to zero.
+func (c *Reconciler) scaledToZero(pa *autoscalingv1alpha1.PodAutoscaler) (bool, error) {
+	ps, err := resourceutil.GetScaleResource(pa.Namespace, pa.Spec.ScaleTargetRef, c.scaler.listerFactory)
+	if err != nil {
+		return false, fmt.Errorf("error getting scale target %v: %w", pa.Spec.ScaleTargetRef, err)
+	}
+	return ps.Spec.Replicas != nil && *ps.Spec.Replicas == 0, nil
+}
+
+// promotionPatch returns the merge patch moving a pod of the warm pool of the
+// PA's revision over to the revision. The pod becomes controlled by the
+// revision, so that neither the ReplicaSet of the warm pool nor the one of the
+// revision claims it, and it is collected along with the revision.
+func promotionPatch(pa *autoscalingv1alpha1.PodAutoscaler) ([]byte, error) {
+	ownerRefs := []metav1.OwnerReference{}
+	if ref := metav1.GetControllerOf(pa); ref != nil {
+		ownerRefs = append(ownerRefs, *ref)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				serving.WarmPoolLabelKey:         nil,
+				serving.WarmPoolPromotedLabelKey: "true",
+				serving.RevisionLabelKey:         pa.Labels[serving.RevisionLabelKey],
+				serving.RevisionUID:              pa.Labels[serving.RevisionUID],
+			},
+			"ownerReferences": ownerRefs,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating promotion patch: %w", err)
+	}
+	return patch, nil
+}
+
+// podReady returns whether the pod's Ready condition is True.
+func podReady(p *corev1.Pod) bool {
+	for _, cond := range p.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -25,6 +25,7 @@ import (
 	nlisters "knative.dev/networking/pkg/client/listers/networking/v1alpha1"
 	"knative.dev/pkg/logging"
 	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
 	clientset "knative.dev/serving/pkg/client/clientset/versioned"
 	listers "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
 	"knative.dev/serving/pkg/reconciler/autoscaling/config"
@@ -44,6 +45,13 @@ type Base struct {
 	MetricLister     listers.MetricLister
 }
 
+// NeedsActivator returns true if the activator must stay in the request path
+// of the PA's revision regardless of its scale, since it implements the
+// session affinity of the revision.
+func NeedsActivator(pa *autoscalingv1alpha1.PodAutoscaler) bool {
+	return pa.Annotations[serving.SessionAffinityAnnotationKey] != ""
+}
+
 // ReconcileSKS reconciles a ServerlessService based on the given PodAutoscaler.
 func (c *Base) ReconcileSKS(ctx context.Context, pa *autoscalingv1alpha1.PodAutoscaler,
 	mode nv1alpha1.ServerlessServiceOperationMode, numActivators int32) (*nv1alpha1.ServerlessService, error) {
@@ -21,6 +21,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	asconfig "knative.dev/serving/pkg/autoscaler/config"
 	"knative.dev/serving/pkg/autoscaler/config/autoscalerconfig"
@@ -50,6 +51,12 @@ func MakeMetric(pa *v1alpha1.PodAutoscaler, metricSvc string, config *autoscaler
 	if panicWindow < asconfig.BucketSize {
 		panicWindow = asconfig.BucketSize
 	}
+	var metricName string
+	// Only the KPA class scales on the application-defined metrics scraped
+	// by the queue-proxy.
+	if pa.Class() == autoscaling.KPA && pa.Metric() == autoscaling.Custom {
+		metricName, _ = pa.MetricName()
+	}
 	return &v1alpha1.Metric{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:       pa.Namespace,
@@ -62,6 +69,7 @@ func MakeMetric(pa *v1alpha1.PodAutoscaler, metricSvc string, config *autoscaler
 			StableWindow: stableWindow,
 			PanicWindow:  panicWindow,
 			ScrapeTarget: metricSvc,
+			MetricName:   metricName,
 		},
 	}
 }
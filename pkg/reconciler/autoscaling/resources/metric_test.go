@@ -72,6 +72,29 @@ func TestMakeMetric(t *testing.T) {
 			withScrapeTarget("dansen"),
 			withStableWindow(time.Minute), withPanicWindow(31*time.Second),
 			withPanicWindowPercentageAnnotation("51")),
+	}, {
+		name: "with custom metric",
+		pa: pa(WithMetricAnnotation(autoscaling.Custom),
+			WithMetricNameAnnotation("queue_depth")),
+		msn: "ik",
+		want: metric(withScrapeTarget("ik"), withMetricName("queue_depth"),
+			withAnnotation(autoscaling.MetricAnnotationKey, autoscaling.Custom),
+			withAnnotation(autoscaling.MetricNameAnnotationKey, "queue_depth")),
+	}, {
+		name: "with custom metric, hpa class",
+		pa: pa(WithHPAClass, WithMetricAnnotation(autoscaling.Custom),
+			WithMetricNameAnnotation("queue_depth")),
+		msn: "ik",
+		want: metric(withScrapeTarget("ik"),
+			withAnnotation(autoscaling.ClassAnnotationKey, autoscaling.HPA),
+			withAnnotation(autoscaling.MetricAnnotationKey, autoscaling.Custom),
+			withAnnotation(autoscaling.MetricNameAnnotationKey, "queue_depth")),
+	}, {
+		name: "with metric name annotation, but not custom metric",
+		pa:   pa(WithMetricNameAnnotation("queue_depth")),
+		msn:  "ik",
+		want: metric(withScrapeTarget("ik"),
+			withAnnotation(autoscaling.MetricNameAnnotationKey, "queue_depth")),
 	}}
 
 	for _, tc := range cases {
@@ -122,6 +145,18 @@ func withPanicWindowPercentageAnnotation(percentage string) MetricOption {
 	}
 }
 
+func withMetricName(n string) MetricOption {
+	return func(metric *v1alpha1.Metric) {
+		metric.Spec.MetricName = n
+	}
+}
+
+func withAnnotation(k, v string) MetricOption {
+	return func(metric *v1alpha1.Metric) {
+		metric.Annotations[k] = v
+	}
+}
+
 func withScrapeTarget(s string) MetricOption {
 	return func(metric *v1alpha1.Metric) {
 		metric.Spec.ScrapeTarget = s
@@ -35,6 +35,10 @@ func ResolveMetricTarget(pa *v1alpha1.PodAutoscaler, config *autoscalerconfig.Co
 	case autoscaling.RPS:
 		total = config.RPSTargetDefault
 		tu = config.TargetUtilization
+	case autoscaling.Custom:
+		// There's no default for an application-defined metric,
+		// the target annotation is required by the webhook.
+		tu = config.TargetUtilization
 	default:
 		// Concurrency is used by default
 		total = float64(pa.Spec.ContainerConcurrency)
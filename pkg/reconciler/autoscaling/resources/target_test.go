@@ -170,6 +170,17 @@ func TestResolveMetricTarget(t *testing.T) {
 		pa:         pa(WithMetricAnnotation(autoscaling.RPS), WithTargetAnnotation("300")),
 		wantTarget: 210,
 		wantTotal:  300,
+	}, {
+		name:       "custom: with target annotation 10",
+		pa:         pa(WithMetricAnnotation(autoscaling.Custom), WithTargetAnnotation("10")),
+		wantTarget: 7,
+		wantTotal:  10,
+	}, {
+		name: "custom: with target annotation 10 and TU annotation 50%",
+		pa: pa(WithMetricAnnotation(autoscaling.Custom), WithTargetAnnotation("10"),
+			WithTUAnnotation("50")),
+		wantTarget: 5,
+		wantTotal:  10,
 	}}
 
 	for _, tc := range cases {
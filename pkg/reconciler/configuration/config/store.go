@@ -19,8 +19,12 @@ package config
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+
 	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/logging"
 	cfgmap "knative.dev/serving/pkg/apis/config"
+	"knative.dev/serving/pkg/gc"
 )
 
 type cfgKey struct{}
@@ -28,8 +32,9 @@ type cfgKey struct{}
 // Config holds the collection of configurations that we attach to contexts.
 // +k8s:deepcopy-gen=false
 type Config struct {
-	Defaults *cfgmap.Defaults
-	Features *cfgmap.Features
+	Defaults   *cfgmap.Defaults
+	Features   *cfgmap.Features
+	RevisionGC *gc.Config
 }
 
 // FromContext extracts a Config from the provided context.
@@ -58,6 +63,10 @@ func FromContextOrDefaults(ctx context.Context) *Config {
 		cfg.Features, _ = cfgmap.NewFeaturesConfigFromMap(nil)
 	}
 
+	if cfg.RevisionGC == nil {
+		cfg.RevisionGC, _ = gc.NewConfigFromConfigMapFunc(ctx)(&corev1.ConfigMap{})
+	}
+
 	return cfg
 }
 
@@ -74,14 +83,15 @@ type Store struct {
 }
 
 // NewStore creates a new store of Configs and optionally calls functions when ConfigMaps are updated.
-func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+func NewStore(ctx context.Context, onAfterStore ...func(name string, value interface{})) *Store {
 	store := &Store{
 		UntypedStore: configmap.NewUntypedStore(
 			"apis",
-			logger,
+			logging.FromContext(ctx),
 			configmap.Constructors{
 				cfgmap.DefaultsConfigName: cfgmap.NewDefaultsConfigFromConfigMap,
 				cfgmap.FeaturesConfigName: cfgmap.NewFeaturesConfigFromConfigMap,
+				gc.ConfigName:             gc.NewConfigFromConfigMapFunc(ctx),
 			},
 			onAfterStore...,
 		),
@@ -104,6 +114,9 @@ func (s *Store) Load() *Config {
 	if feat, ok := s.UntypedLoad(cfgmap.FeaturesConfigName).(*cfgmap.Features); ok {
 		cfg.Features = feat.DeepCopy()
 	}
+	if revGC, ok := s.UntypedLoad(gc.ConfigName).(*gc.Config); ok {
+		cfg.RevisionGC = revGC.DeepCopy()
+	}
 
 	return cfg
 }
@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	logtesting "knative.dev/pkg/logging/testing"
 	cfgmap "knative.dev/serving/pkg/apis/config"
+	"knative.dev/serving/pkg/gc"
 
 	. "knative.dev/pkg/configmap/testing"
 )
@@ -34,13 +35,16 @@ var ignoreStuff = cmp.Options{
 }
 
 func TestStoreLoadWithContext(t *testing.T) {
-	store := NewStore(logtesting.TestLogger(t))
+	ctx := logtesting.TestContextWithLogger(t)
+	store := NewStore(ctx)
 
 	defaultsConfig := ConfigMapFromTestFile(t, cfgmap.DefaultsConfigName)
 	featuresConfig := ConfigMapFromTestFile(t, cfgmap.FeaturesConfigName)
+	gcConfig := ConfigMapFromTestFile(t, gc.ConfigName)
 
 	store.OnConfigChanged(defaultsConfig)
 	store.OnConfigChanged(featuresConfig)
+	store.OnConfigChanged(gcConfig)
 
 	config := FromContextOrDefaults(store.ToContext(context.Background()))
 
@@ -57,6 +61,13 @@ func TestStoreLoadWithContext(t *testing.T) {
 			t.Errorf("Unexpected features config = %v, want: %v, diff (-want, +got):\n%s", got, want, cmp.Diff(want, got, ignoreStuff...))
 		}
 	})
+
+	t.Run("revision-gc", func(t *testing.T) {
+		expected, _ := gc.NewConfigFromConfigMapFunc(ctx)(gcConfig)
+		if got, want := config.RevisionGC, expected; !cmp.Equal(got, want) {
+			t.Errorf("Unexpected GC config = %v, want: %v, diff (-want, +got):\n%s", got, want, cmp.Diff(want, got))
+		}
+	})
 }
 
 func TestStoreLoadWithContextOrDefaults(t *testing.T) {
@@ -81,15 +92,17 @@ func TestStoreLoadWithContextOrDefaults(t *testing.T) {
 }
 
 func TestStoreImmutableConfig(t *testing.T) {
-	store := NewStore(logtesting.TestLogger(t))
+	store := NewStore(logtesting.TestContextWithLogger(t))
 
 	store.OnConfigChanged(ConfigMapFromTestFile(t, cfgmap.DefaultsConfigName))
 	store.OnConfigChanged(ConfigMapFromTestFile(t, cfgmap.FeaturesConfigName))
+	store.OnConfigChanged(ConfigMapFromTestFile(t, gc.ConfigName))
 
 	config := store.Load()
 
 	config.Defaults.RevisionTimeoutSeconds = 1234
 	config.Features.MultiContainer = cfgmap.Disabled
+	config.RevisionGC.DryRun = true
 
 	newConfig := store.Load()
 
@@ -100,4 +113,8 @@ func TestStoreImmutableConfig(t *testing.T) {
 	if newConfig.Features.MultiContainer == cfgmap.Disabled {
 		t.Error("Features config is not immutable")
 	}
+
+	if newConfig.RevisionGC.DryRun {
+		t.Error("GC config is not immutable")
+	}
 }
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/kmp"
@@ -39,7 +40,9 @@ import (
 	clientset "knative.dev/serving/pkg/client/clientset/versioned"
 	configreconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/configuration"
 	listers "knative.dev/serving/pkg/client/listers/serving/v1"
+	configns "knative.dev/serving/pkg/reconciler/configuration/config"
 	"knative.dev/serving/pkg/reconciler/configuration/resources"
+	gcreconciler "knative.dev/serving/pkg/reconciler/gc"
 )
 
 // Reconciler implements controller.Reconciler for Configuration resources.
@@ -124,6 +127,37 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, config *v1.Configuration
 	if err = c.findAndSetLatestReadyRevision(ctx, config); err != nil {
 		return fmt.Errorf("failed to find and set latest ready revision: %w", err)
 	}
+	if err = c.reportPendingRevisionGC(ctx, config); err != nil {
+		return fmt.Errorf("failed to report the revisions pending garbage collection: %w", err)
+	}
+	return nil
+}
+
+// reportPendingRevisionGC records the revisions the garbage collector would
+// delete in the status, when its policy for the configuration is a dry run,
+// and emits an event for each newly pending one.
+func (c *Reconciler) reportPendingRevisionGC(ctx context.Context, config *v1.Configuration) error {
+	cfg := configns.FromContextOrDefaults(ctx).RevisionGC.ForConfiguration(config.Namespace, config.Labels)
+	if !cfg.DryRun {
+		config.Status.PendingRevisionGC = nil
+		return nil
+	}
+
+	pending, err := gcreconciler.PendingRevisions(cfg, c.revisionLister, config, logging.FromContext(ctx))
+	if err != nil {
+		return err
+	}
+	reported := sets.NewString()
+	for _, p := range config.Status.PendingRevisionGC {
+		reported.Insert(p.RevisionName)
+	}
+	for _, p := range pending {
+		if !reported.Has(p.RevisionName) {
+			controller.GetEventRecorder(ctx).Eventf(config, corev1.EventTypeNormal, "RevisionGCDryRun",
+				"Revision %q would be deleted: %s", p.RevisionName, p.Reason)
+		}
+	}
+	config.Status.PendingRevisionGC = pending
 	return nil
 }
 
@@ -40,6 +40,7 @@ import (
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	servingclient "knative.dev/serving/pkg/client/injection/client/fake"
 	configreconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/configuration"
+	"knative.dev/serving/pkg/gc"
 	"knative.dev/serving/pkg/reconciler/configuration/config"
 
 	. "knative.dev/pkg/reconciler/testing"
@@ -526,6 +527,63 @@ func TestReconcile(t *testing.T) {
 			Eventf(corev1.EventTypeNormal, "LatestReadyUpdate", "LatestReadyRevisionName updated to %q", "lrrnotexist-00002"),
 		},
 		Key: "foo/lrrnotexist",
+	}, {
+		Name: "gc dry run, report oldest",
+		Ctx:  config.ToContext(context.Background(), dryRunConfig),
+		Objects: append([]runtime.Object{
+			cfg("keep-two", "foo", 3,
+				WithLatestCreated("keep-two-00003"),
+				WithLatestReady("keep-two-00003"),
+				WithConfigObservedGen),
+		}, gcRevisions("keep-two", now)...),
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: cfg("keep-two", "foo", 3,
+				WithLatestCreated("keep-two-00003"),
+				WithLatestReady("keep-two-00003"),
+				WithConfigObservedGen,
+				WithPendingRevisionGC(v1.PendingRevisionGC{
+					RevisionName: "keep-two-00001",
+					Reason:       "Stale",
+				})),
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "RevisionGCDryRun", `Revision "keep-two-00001" would be deleted: Stale`),
+		},
+		Key: "foo/keep-two",
+	}, {
+		Name: "gc dry run, already reported",
+		Ctx:  config.ToContext(context.Background(), dryRunConfig),
+		Objects: append([]runtime.Object{
+			cfg("keep-two", "foo", 3,
+				WithLatestCreated("keep-two-00003"),
+				WithLatestReady("keep-two-00003"),
+				WithConfigObservedGen,
+				WithPendingRevisionGC(v1.PendingRevisionGC{
+					RevisionName: "keep-two-00001",
+					Reason:       "Stale",
+				})),
+		}, gcRevisions("keep-two", now)...),
+		Key: "foo/keep-two",
+	}, {
+		Name: "gc no longer a dry run, clear report",
+		Ctx:  config.ToContext(context.Background(), config.FromContext(testCtx)),
+		Objects: append([]runtime.Object{
+			cfg("keep-two", "foo", 3,
+				WithLatestCreated("keep-two-00003"),
+				WithLatestReady("keep-two-00003"),
+				WithConfigObservedGen,
+				WithPendingRevisionGC(v1.PendingRevisionGC{
+					RevisionName: "keep-two-00001",
+					Reason:       "Stale",
+				})),
+		}, gcRevisions("keep-two", now)...),
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: cfg("keep-two", "foo", 3,
+				WithLatestCreated("keep-two-00003"),
+				WithLatestReady("keep-two-00003"),
+				WithConfigObservedGen),
+		}},
+		Key: "foo/keep-two",
 	}}
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
@@ -543,6 +601,34 @@ func TestReconcile(t *testing.T) {
 	}))
 }
 
+// dryRunConfig reports the non-active revisions of a configuration past
+// the first one, inactive for over a minute, as pending garbage collection.
+var dryRunConfig = &config.Config{
+	RevisionGC: &gc.Config{
+		RetainSinceCreateTime:     time.Minute,
+		RetainSinceLastActiveTime: time.Minute,
+		MinNonActiveRevisions:     1,
+		MaxNonActiveRevisions:     gc.Disabled,
+		DryRun:                    true,
+	},
+}
+
+// gcRevisions returns three ready revisions of the configuration, the last of
+// which is active and the others inactive for a while.
+func gcRevisions(name string, now time.Time) []runtime.Object {
+	objs := make([]runtime.Object, 0, 3)
+	for gen := int64(1); gen <= 3; gen++ {
+		state := v1.RoutingStateReserve
+		if gen == 3 {
+			state = v1.RoutingStateActive
+		}
+		objs = append(objs, rev(name, "foo", gen, MarkRevisionReady,
+			WithRoutingState(state, testClock),
+			WithRoutingStateModified(now.Add(time.Duration(gen-10)*time.Minute))))
+	}
+	return objs
+}
+
 func cfg(name, namespace string, generation int64, co ...ConfigOption) *v1.Configuration {
 	c := &v1.Configuration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -29,6 +29,7 @@ import (
 	configurationinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration"
 	revisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision"
 	configreconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/configuration"
+	"knative.dev/serving/pkg/gc"
 	"knative.dev/serving/pkg/reconciler/configuration/config"
 )
 
@@ -41,16 +42,19 @@ func NewController(
 	configurationInformer := configurationinformer.Get(ctx)
 	revisionInformer := revisioninformer.Get(ctx)
 
-	logger.Info("Setting up ConfigMap receivers")
-	configStore := config.NewStore(logger.Named("config-store"))
-	configStore.WatchConfigs(cmw)
-
 	c := &Reconciler{
 		client:         servingclient.Get(ctx),
 		revisionLister: revisionInformer.Lister(),
 		clock:          &clock.RealClock{},
 	}
-	impl := configreconciler.NewImpl(ctx, c, func(*controller.Impl) controller.Options {
+	impl := configreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
+		logger.Info("Setting up ConfigMap receivers with resync func")
+		// The revisions pending garbage collection depend on the GC config.
+		resync := configmap.TypeFilter(&gc.Config{})(func(string, interface{}) {
+			impl.GlobalResync(configurationInformer.Informer())
+		})
+		configStore := config.NewStore(logging.WithLogger(ctx, logger.Named("config-store")), resync)
+		configStore.WatchConfigs(cmw)
 		return controller.Options{ConfigStore: configStore}
 	})
 
@@ -17,11 +17,13 @@ limitations under the License.
 package domainmapping
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	kaccessor "knative.dev/serving/pkg/reconciler/accessor"
 	networkaccessor "knative.dev/serving/pkg/reconciler/accessor/networking"
@@ -30,6 +32,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 
 	networkingpkg "knative.dev/networking/pkg"
 	"knative.dev/networking/pkg/apis/networking"
@@ -43,9 +50,12 @@ import (
 	"knative.dev/pkg/network"
 	"knative.dev/pkg/reconciler"
 	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/tracker"
+	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	"knative.dev/serving/pkg/apis/serving/v1alpha1"
 	domainmappingreconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1alpha1/domainmapping"
+	servinglisters "knative.dev/serving/pkg/client/listers/serving/v1"
 	"knative.dev/serving/pkg/reconciler/domainmapping/config"
 	"knative.dev/serving/pkg/reconciler/domainmapping/resources"
 	routeresources "knative.dev/serving/pkg/reconciler/route/resources"
@@ -56,8 +66,16 @@ type Reconciler struct {
 	certificateLister networkinglisters.CertificateLister
 	ingressLister     networkinglisters.IngressLister
 	domainClaimLister networkinglisters.ClusterDomainClaimLister
+	routeLister       servinglisters.RouteLister
+	serviceLister     corev1listers.ServiceLister
+	configMapLister   corev1listers.ConfigMapLister
+	secretLister      corev1listers.SecretLister
+	kubeclient        kubernetes.Interface
 	netclient         netclientset.Interface
 	resolver          *resolver.URIResolver
+	tracker           tracker.Interface
+	clock             clock.PassiveClock
+	enqueueAfter      func(interface{}, time.Duration)
 }
 
 // Check that our Reconciler implements Interface
@@ -112,19 +130,52 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, dm *v1alpha1.DomainMappi
 	}
 
 	// Resolve the spec.Ref to a URI following the Addressable contract.
-	targetHost, targetBackendSvc, err := r.resolveRef(ctx, dm)
+	target, err := r.resolveRef(ctx, dm, &dm.Spec.Ref, dm.Spec.Tag)
 	if err != nil {
 		return err
 	}
+	logger.Debugf("Mapping %s to ref %s/%s (host: %q, svc: %q)", url, dm.Spec.Ref.Namespace, dm.Spec.Ref.Name, target.host, target.serviceName)
+	externalHosts := sets.NewString()
+	if target.external {
+		externalHosts.Insert(target.host)
+	}
+
+	// And the refs of the paths.
+	paths := make([]resources.PathTarget, 0, len(dm.Spec.Paths))
+	for i := range dm.Spec.Paths {
+		p := &dm.Spec.Paths[i]
+		pt, err := r.resolveRef(ctx, dm, &p.Ref, p.Tag)
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Mapping %s%s to ref %s/%s (host: %q, svc: %q)", url, p.Prefix, p.Ref.Namespace, p.Ref.Name, pt.host, pt.serviceName)
+		if pt.external {
+			externalHosts.Insert(pt.host)
+		}
+		paths = append(paths, resources.PathTarget{
+			Prefix:      p.Prefix,
+			Rewrite:     p.Rewrite,
+			ServiceName: pt.serviceName,
+			Host:        pt.host,
+		})
+	}
+	if err := r.reconcileExternalNameServices(ctx, dm, externalHosts); err != nil {
+		return err
+	}
+	dm.Status.MarkReferenceResolved()
 
 	// Reconcile the Ingress resource corresponding to the requested Mapping.
-	logger.Debugf("Mapping %s to ref %s/%s (host: %q, svc: %q)", url, dm.Spec.Ref.Namespace, dm.Spec.Ref.Name, targetHost, targetBackendSvc)
-	desired := resources.MakeIngress(dm, targetBackendSvc, targetHost, ingressClass, tls, acmeChallenges...)
+	desired := resources.MakeIngressWithPaths(dm, paths, target.serviceName, target.host, ingressClass, tls, acmeChallenges...)
 	ingress, err := r.reconcileIngress(ctx, dm, desired)
 	if err != nil {
 		return err
 	}
 
+	// The Ingress no longer sends requests to the stale ExternalName Services.
+	if err := r.deleteStaleExternalNameServices(ctx, dm, externalHosts); err != nil {
+		return err
+	}
+
 	// Check that the Ingress status reflects the latest ingress applied and propagate status if so.
 	if ingress.GetObjectMeta().GetGeneration() != ingress.Status.ObservedGeneration {
 		dm.Status.MarkIngressNotConfigured()
@@ -183,6 +234,11 @@ func certClass(ctx context.Context) string {
 }
 
 func (r *Reconciler) tls(ctx context.Context, dm *v1alpha1.DomainMapping) ([]netv1alpha1.IngressTLS, []netv1alpha1.HTTP01Challenge, error) {
+	if dm.Spec.TLS != nil {
+		return r.secretTLS(dm)
+	}
+	dm.Status.CertificateExpiry = nil
+
 	if !autoTLSEnabled(ctx, dm) {
 		dm.Status.MarkTLSNotEnabled(v1.AutoTLSNotEnabledMessage)
 		return nil, nil, nil
@@ -226,6 +282,48 @@ func (r *Reconciler) tls(ctx context.Context, dm *v1alpha1.DomainMapping) ([]net
 	return nil, acmeChallenges, nil
 }
 
+// secretTLS serves the domain with the certificate of the Secret configured by
+// spec.tls, which takes precedence over auto-TLS. Without a valid certificate
+// the domain is served without TLS, rather than not at all.
+func (r *Reconciler) secretTLS(dm *v1alpha1.DomainMapping) ([]netv1alpha1.IngressTLS, []netv1alpha1.HTTP01Challenge, error) {
+	name := dm.Spec.TLS.SecretName
+	dm.Status.CertificateExpiry = nil
+
+	// Track the Secret so that we re-reconcile when it is created or its
+	// certificate is rotated.
+	if err := r.tracker.TrackReference(tracker.Reference{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Namespace:  dm.Namespace,
+		Name:       name,
+	}, dm); err != nil {
+		return nil, nil, fmt.Errorf("failed to track Secret %q: %w", name, err)
+	}
+
+	secret, err := r.secretLister.Secrets(dm.Namespace).Get(name)
+	if apierrs.IsNotFound(err) {
+		dm.Status.MarkCertificateSecretInvalid(name,
+			fmt.Sprintf("the Secret does not exist or does not have the label %s", serving.DomainMappingTLSLabelKey))
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get Secret %q: %w", name, err)
+	}
+
+	now := r.clock.Now()
+	expiry, err := resources.VerifyCertificateSecret(secret, dm.Name, now)
+	if err != nil {
+		dm.Status.MarkCertificateSecretInvalid(name, err.Error())
+		return nil, nil, nil
+	}
+	dm.Status.CertificateExpiry = &metav1.Time{Time: expiry}
+	dm.Status.MarkCertificateSecretReady(name)
+	dm.Status.URL.Scheme = "https"
+
+	// Re-reconcile when the certificate expires to reflect it in the status.
+	r.enqueueAfter(dm, expiry.Sub(now))
+	return []netv1alpha1.IngressTLS{resources.MakeSecretIngressTLS(dm)}, nil, nil
+}
+
 func (r *Reconciler) reconcileIngress(ctx context.Context, dm *v1alpha1.DomainMapping, desired *netv1alpha1.Ingress) (*netv1alpha1.Ingress, error) {
 	recorder := controller.GetEventRecorder(ctx)
 	ingress, err := r.ingressLister.Ingresses(desired.Namespace).Get(desired.Name)
@@ -257,43 +355,184 @@ func (r *Reconciler) reconcileIngress(ctx context.Context, dm *v1alpha1.DomainMa
 	return ingress, err
 }
 
-func (r *Reconciler) resolveRef(ctx context.Context, dm *v1alpha1.DomainMapping) (host, backendSvc string, err error) {
-	resolved, err := r.resolver.URIFromKReference(ctx, &dm.Spec.Ref, dm)
+// resolvedTarget is a target of a DomainMapping resolved to the host the
+// requests are sent to, and the KIngress backend service of the host.
+type resolvedTarget struct {
+	host        string
+	serviceName string
+
+	// external is set when the serviceName is an ExternalName Service
+	// created for the host.
+	external bool
+}
+
+func (r *Reconciler) resolveRef(ctx context.Context, dm *v1alpha1.DomainMapping, ref *duckv1.KReference, tag string) (*resolvedTarget, error) {
+	// A target in another namespace must be granted by that namespace.
+	if ref.Namespace != dm.Namespace {
+		if err := r.checkGrant(dm, ref.Namespace); err != nil {
+			dm.Status.MarkReferenceNotResolved(err.Error())
+			return nil, err
+		}
+	}
+
+	// The resolver tracks the target, so that we are re-queued when its
+	// address changes.
+	resolved, err := r.resolver.URIFromKReference(ctx, ref, dm)
 	if err != nil {
 		dm.Status.MarkReferenceNotResolved(err.Error())
-		return "", "", fmt.Errorf("resolving reference: %w", err)
+		return nil, fmt.Errorf("resolving reference: %w", err)
 	}
 
-	// Since we do not support path-based routing in domain mappings, we cannot
+	if tag != "" {
+		if resolved, err = r.resolveTag(ctx, ref, tag); err != nil {
+			dm.Status.MarkReferenceNotResolved(err.Error())
+			return nil, fmt.Errorf("resolving reference: %w", err)
+		}
+	}
+
+	// Since the paths of the requests are only rewritten per prefix, we cannot
 	// support target references that contain a path.
 	if strings.TrimSuffix(resolved.Path, "/") != "" {
 		dm.Status.MarkReferenceNotResolved(fmt.Sprintf("resolved URI %q contains a path", resolved))
-		return "", "", fmt.Errorf("resolved URI %q contains a path", resolved)
+		return nil, fmt.Errorf("resolved URI %q contains a path", resolved)
+	}
+	// The backend services of the KIngress are only reached on port 80.
+	if resolved.URL().Port() != "" {
+		dm.Status.MarkReferenceNotResolved(fmt.Sprintf("resolved URI %q contains a port", resolved))
+		return nil, fmt.Errorf("resolved URI %q contains a port", resolved)
 	}
 
-	// The resolved hostname must be of the form {name}.{namespace}.svc.{suffix},
-	// which is the standard DNS address given by kubernetes to services. We will
-	// use `name` from this resolved hostname to determine the backend service
-	// name for the KIngress.
-	// TODO(julz) in the future we may support addressables that are not created
-	// from Services, in which case we would need to dynamically create the
-	// an ExternalName Service for the KIngress to use.
+	// When the resolved hostname is of the form {name}.{namespace}.svc.{suffix},
+	// which is the standard DNS address given by kubernetes to services, and
+	// the service is in the namespace of the DomainMapping, the service is the
+	// backend of the KIngress.
 	requiredSuffix := ".svc." + network.GetClusterDomainName()
-	parts := strings.Split(strings.TrimSuffix(resolved.Host, requiredSuffix), ".")
-	if !strings.HasSuffix(resolved.Host, requiredSuffix) || len(parts) != 2 {
-		dm.Status.MarkReferenceNotResolved(fmt.Sprintf("resolved URI %q must be of the form {name}.{namespace}%s", resolved, requiredSuffix))
-		return "", "", fmt.Errorf("resolved URI %q must be of the form {name}.{namespace}%s", resolved, requiredSuffix)
+	if strings.HasSuffix(resolved.Host, requiredSuffix) {
+		parts := strings.Split(strings.TrimSuffix(resolved.Host, requiredSuffix), ".")
+		ns := parts[len(parts)-1]
+		if len(parts) == 2 && ns == dm.Namespace {
+			return &resolvedTarget{host: resolved.Host, serviceName: parts[0]}, nil
+		}
+		// The target may resolve to a service of yet another namespace,
+		// which must grant the DomainMapping too.
+		if ns != dm.Namespace && ns != ref.Namespace {
+			if err := r.checkGrant(dm, ns); err != nil {
+				dm.Status.MarkReferenceNotResolved(err.Error())
+				return nil, err
+			}
+		}
 	}
 
-	// If the namespace part of the target isn't the same as the DomainMapping
-	// we'd need to create a cross-namespace KIngress, which isn't supported.
-	if parts[1] != dm.Namespace {
-		dm.Status.MarkReferenceNotResolved(fmt.Sprintf("resolved URI %q must be in same namespace as DomainMapping", resolved))
-		return "", "", fmt.Errorf("resolved URI %q must be in same namespace as DomainMapping", resolved)
+	// Otherwise the KIngress, which requires its backends in its namespace,
+	// reaches the host through an ExternalName Service.
+	return &resolvedTarget{
+		host:        resolved.Host,
+		serviceName: resources.ExternalNameServiceName(dm, resolved.Host),
+		external:    true,
+	}, nil
+}
+
+// resolveTag resolves the URL of the tag of the Knative Service or Route
+// identified by the ref, which is the address of the K8s Service the Route
+// creates for the tag.
+func (r *Reconciler) resolveTag(ctx context.Context, ref *duckv1.KReference, tag string) (*apis.URL, error) {
+	// The Route of a Knative Service has the name of the Service.
+	route, err := r.routeLister.Routes(ref.Namespace).Get(ref.Name)
+	if err != nil {
+		return nil, err
 	}
+	for _, tt := range route.Status.Traffic {
+		if tt.Tag != tag {
+			continue
+		}
+		buf := bytes.Buffer{}
+		if err := config.FromContext(ctx).Network.GetTagTemplate().Execute(&buf, networkingpkg.TagTemplateValues{
+			Name: route.Name,
+			Tag:  tag,
+		}); err != nil {
+			return nil, fmt.Errorf("error executing the TagTemplate: %w", err)
+		}
+		return &apis.URL{
+			Scheme: "http",
+			Host:   network.GetServiceHostname(buf.String(), route.Namespace),
+		}, nil
+	}
+	return nil, fmt.Errorf("tag %q not found in the traffic of %s/%s", tag, ref.Namespace, ref.Name)
+}
 
-	dm.Status.MarkReferenceResolved()
-	return resolved.Host, parts[0], nil
+// checkGrant returns an error unless the DomainMappingGrantName ConfigMap of
+// the namespace permits the namespace of the DomainMapping.
+func (r *Reconciler) checkGrant(dm *v1alpha1.DomainMapping, namespace string) error {
+	if err := r.tracker.TrackReference(tracker.Reference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  namespace,
+		Name:       v1alpha1.DomainMappingGrantName,
+	}, dm); err != nil {
+		return fmt.Errorf("failed to track the grant of namespace %q: %w", namespace, err)
+	}
+
+	grant, err := r.configMapLister.ConfigMaps(namespace).Get(v1alpha1.DomainMappingGrantName)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("failed to get the grant of namespace %q: %w", namespace, err)
+	} else if err == nil {
+		for _, ns := range strings.Split(grant.Data[v1alpha1.DomainMappingGrantNamespacesKey], ",") {
+			if ns = strings.TrimSpace(ns); ns == "*" || ns == dm.Namespace {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("namespace %q does not grant DomainMappings of namespace %q", namespace, dm.Namespace)
+}
+
+func (r *Reconciler) reconcileExternalNameServices(ctx context.Context, dm *v1alpha1.DomainMapping, hosts sets.String) error {
+	recorder := controller.GetEventRecorder(ctx)
+	for _, host := range hosts.List() {
+		desired := resources.MakeExternalNameService(dm, host)
+		svc, err := r.serviceLister.Services(desired.Namespace).Get(desired.Name)
+		if apierrs.IsNotFound(err) {
+			svc, err = r.kubeclient.CoreV1().Services(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+			if err != nil {
+				recorder.Eventf(dm, corev1.EventTypeWarning, "CreationFailed", "Failed to create Service: %v", err)
+				return fmt.Errorf("failed to create Service: %w", err)
+			}
+			recorder.Eventf(dm, corev1.EventTypeNormal, "Created", "Created Service %q", svc.Name)
+		} else if err != nil {
+			return fmt.Errorf("failed to get Service: %w", err)
+		} else if !metav1.IsControlledBy(svc, dm) {
+			dm.Status.MarkReferenceNotResolved(fmt.Sprintf("DomainMapping does not own Service %q", svc.Name))
+			return fmt.Errorf("domainmapping: %q does not own Service: %q", dm.Name, svc.Name)
+		} else if !equality.Semantic.DeepEqual(svc.Spec.ExternalName, desired.Spec.ExternalName) ||
+			!equality.Semantic.DeepEqual(svc.Spec.Ports, desired.Spec.Ports) {
+			// Don't modify the informers copy
+			want := svc.DeepCopy()
+			want.Spec.Type = desired.Spec.Type
+			want.Spec.ExternalName = desired.Spec.ExternalName
+			want.Spec.Ports = desired.Spec.Ports
+			if _, err := r.kubeclient.CoreV1().Services(want.Namespace).Update(ctx, want, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update Service: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) deleteStaleExternalNameServices(ctx context.Context, dm *v1alpha1.DomainMapping, hosts sets.String) error {
+	svcs, err := r.serviceLister.Services(dm.Namespace).List(labels.SelectorFromSet(labels.Set{
+		serving.DomainMappingLabelKey: dm.Name,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to list Services: %w", err)
+	}
+	for _, svc := range svcs {
+		if !metav1.IsControlledBy(svc, dm) || hosts.Has(svc.Spec.ExternalName) {
+			continue
+		}
+		if err := r.kubeclient.CoreV1().Services(svc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Service: %w", err)
+		}
+	}
+	return nil
 }
 
 func (r *Reconciler) reconcileDomainClaim(ctx context.Context, dm *v1alpha1.DomainMapping) error {
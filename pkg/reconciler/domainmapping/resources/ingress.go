@@ -17,6 +17,10 @@ limitations under the License.
 package resources
 
 import (
+	"regexp"
+	"sort"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -27,15 +31,57 @@ import (
 	"knative.dev/pkg/kmeta"
 	"knative.dev/serving/pkg/apis/serving"
 	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"knative.dev/serving/pkg/queue"
 	routeresources "knative.dev/serving/pkg/reconciler/route/resources"
 )
 
+// PathTarget is the resolved target of a path of a DomainMapping.
+type PathTarget struct {
+	// Prefix and Rewrite are those of the DomainMappingPath.
+	Prefix  string
+	Rewrite *string
+
+	// ServiceName is the name of the backend service of the target, and
+	// Host is the host the requests are rewritten to.
+	ServiceName string
+	Host        string
+}
+
 // MakeIngress creates an Ingress object for a DomainMapping.  The Ingress is
 // always created in the same namespace as the DomainMapping, and the ingress
 // backend is always in the same namespace also (as this is required by
 // KIngress).  The created ingress will contain a RewriteHost rule to cause the
 // given hostName to be used as the host.
 func MakeIngress(dm *servingv1alpha1.DomainMapping, backendServiceName, hostName, ingressClass string, tls []netv1alpha1.IngressTLS, acmeChallenges ...netv1alpha1.HTTP01Challenge) *netv1alpha1.Ingress {
+	return MakeIngressWithPaths(dm, nil, backendServiceName, hostName, ingressClass, tls, acmeChallenges...)
+}
+
+// MakeIngressWithPaths creates an Ingress object for a DomainMapping, like
+// MakeIngress, which routes the requests matching the prefixes of the paths
+// to their targets ahead of the given backend, the longest prefix first.
+// All the paths set the headers rewriting the path in queue-proxy, the
+// identity for the paths without Rewrite, so that the clients can't.
+func MakeIngressWithPaths(dm *servingv1alpha1.DomainMapping, paths []PathTarget, backendServiceName, hostName, ingressClass string, tls []netv1alpha1.IngressTLS, acmeChallenges ...netv1alpha1.HTTP01Challenge) *netv1alpha1.Ingress {
+	// The order of the paths is sensitive, always put tls challenge first
+	ingressPaths := routeresources.MakeACMEIngressPaths(acmeChallenges, dm.GetName())
+	sorted := append([]PathTarget(nil), paths...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	for _, p := range sorted {
+		path := makeIngressPath(dm, p.ServiceName, p.Host)
+		path.Path = pathRegex(p.Prefix)
+		rewrite := p.Prefix
+		if p.Rewrite != nil {
+			rewrite = *p.Rewrite
+		}
+		path.AppendHeaders = pathRewriteHeaders(p.Prefix, rewrite)
+		ingressPaths = append(ingressPaths, path)
+	}
+	backend := makeIngressPath(dm, backendServiceName, hostName)
+	backend.AppendHeaders = pathRewriteHeaders("/", "/")
+	ingressPaths = append(ingressPaths, backend)
+
 	return &netv1alpha1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      kmeta.ChildName(dm.GetName(), ""),
@@ -57,24 +103,48 @@ func MakeIngress(dm *servingv1alpha1.DomainMapping, backendServiceName, hostName
 				Hosts:      []string{dm.Name},
 				Visibility: netv1alpha1.IngressVisibilityExternalIP,
 				HTTP: &netv1alpha1.HTTPIngressRuleValue{
-					// The order of the paths is sensitive, always put tls challenge first
-					Paths: append(routeresources.MakeACMEIngressPaths(acmeChallenges, dm.GetName()),
-						[]netv1alpha1.HTTPIngressPath{{
-							RewriteHost: hostName,
-							Splits: []netv1alpha1.IngressBackendSplit{{
-								Percent: 100,
-								AppendHeaders: map[string]string{
-									network.OriginalHostHeader: dm.Name,
-								},
-								IngressBackend: netv1alpha1.IngressBackend{
-									ServiceNamespace: dm.Namespace,
-									ServiceName:      backendServiceName,
-									ServicePort:      intstr.FromInt(80),
-								},
-							}},
-						}}...),
+					Paths: ingressPaths,
 				},
 			}},
 		},
 	}
 }
+
+// pathRegex returns the regex of the Path of the KIngress matching the paths
+// which start with the prefix at the boundary of a segment, i.e. "/api"
+// matches "/api" and "/api/users", but not "/apis", as queue-proxy does.
+// The Path is matched in full.
+func pathRegex(prefix string) string {
+	if strings.HasSuffix(prefix, "/") {
+		return "^" + regexp.QuoteMeta(prefix) + ".*$"
+	}
+	return "^" + regexp.QuoteMeta(prefix) + "(/.*)?$"
+}
+
+// pathRewriteHeaders returns the headers rewriting the prefix of the path
+// of the requests in queue-proxy.
+func pathRewriteHeaders(prefix, rewrite string) map[string]string {
+	return map[string]string{
+		queue.PathPrefixHeaderName:  prefix,
+		queue.PathRewriteHeaderName: rewrite,
+	}
+}
+
+// makeIngressPath makes the path sending the requests to the backend
+// service with their host rewritten.
+func makeIngressPath(dm *servingv1alpha1.DomainMapping, backendServiceName, hostName string) netv1alpha1.HTTPIngressPath {
+	return netv1alpha1.HTTPIngressPath{
+		RewriteHost: hostName,
+		Splits: []netv1alpha1.IngressBackendSplit{{
+			Percent: 100,
+			AppendHeaders: map[string]string{
+				network.OriginalHostHeader: dm.Name,
+			},
+			IngressBackend: netv1alpha1.IngressBackend{
+				ServiceNamespace: dm.Namespace,
+				ServiceName:      backendServiceName,
+				ServicePort:      intstr.FromInt(80),
+			},
+		}},
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package resources
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -28,8 +29,10 @@ import (
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/ptr"
 	"knative.dev/serving/pkg/apis/serving"
 	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"knative.dev/serving/pkg/queue"
 )
 
 func TestMakeIngress(t *testing.T) {
@@ -84,6 +87,10 @@ func TestMakeIngress(t *testing.T) {
 									ServicePort:      intstr.FromInt(80),
 								},
 							}},
+							AppendHeaders: map[string]string{
+								queue.PathPrefixHeaderName:  "/",
+								queue.PathRewriteHeaderName: "/",
+							},
 						}},
 					},
 				}},
@@ -138,6 +145,10 @@ func TestMakeIngress(t *testing.T) {
 									ServicePort:      intstr.FromInt(80),
 								},
 							}},
+							AppendHeaders: map[string]string{
+								queue.PathPrefixHeaderName:  "/",
+								queue.PathRewriteHeaderName: "/",
+							},
 						}},
 					},
 				}},
@@ -212,6 +223,10 @@ func TestMakeIngress(t *testing.T) {
 									ServicePort:      intstr.FromInt(80),
 								},
 							}},
+							AppendHeaders: map[string]string{
+								queue.PathPrefixHeaderName:  "/",
+								queue.PathRewriteHeaderName: "/",
+							},
 						}},
 					},
 				}},
@@ -234,3 +249,120 @@ func TestMakeIngress(t *testing.T) {
 	}
 
 }
+
+func TestMakeIngressWithPaths(t *testing.T) {
+	dm := &v1alpha1.DomainMapping{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mapping.com",
+			Namespace: "the-namespace",
+		},
+	}
+	split := func(svc string) []netv1alpha1.IngressBackendSplit {
+		return []netv1alpha1.IngressBackendSplit{{
+			Percent: 100,
+			AppendHeaders: map[string]string{
+				network.OriginalHostHeader: "mapping.com",
+			},
+			IngressBackend: netv1alpha1.IngressBackend{
+				ServiceNamespace: "the-namespace",
+				ServiceName:      svc,
+				ServicePort:      intstr.FromInt(80),
+			},
+		}}
+	}
+
+	got := MakeIngressWithPaths(dm, []PathTarget{{
+		Prefix:      "/api",
+		Rewrite:     ptr.String("/"),
+		ServiceName: "api-svc",
+		Host:        "api-svc.the-namespace.svc.cluster.local",
+	}, {
+		Prefix:      "/api/v2",
+		ServiceName: "api-v2-svc",
+		Host:        "api-v2-svc.the-namespace.svc.cluster.local",
+	}, {
+		Prefix:      "/docs/v1.0",
+		ServiceName: "docs-svc",
+		Host:        "docs-svc.the-namespace.svc.cluster.local",
+	}}, "the-target-svc", "the-rewrite-host", "the-ingress-class", nil)
+
+	want := []netv1alpha1.HTTPIngressPath{{
+		// The prefix is quoted in the regex.
+		Path:        `^/docs/v1\.0(/.*)?$`,
+		RewriteHost: "docs-svc.the-namespace.svc.cluster.local",
+		Splits:      split("docs-svc"),
+		AppendHeaders: map[string]string{
+			queue.PathPrefixHeaderName:  "/docs/v1.0",
+			queue.PathRewriteHeaderName: "/docs/v1.0",
+		},
+	}, {
+		Path:        "^/api/v2(/.*)?$",
+		RewriteHost: "api-v2-svc.the-namespace.svc.cluster.local",
+		Splits:      split("api-v2-svc"),
+		AppendHeaders: map[string]string{
+			queue.PathPrefixHeaderName:  "/api/v2",
+			queue.PathRewriteHeaderName: "/api/v2",
+		},
+	}, {
+		Path:        "^/api(/.*)?$",
+		RewriteHost: "api-svc.the-namespace.svc.cluster.local",
+		Splits:      split("api-svc"),
+		AppendHeaders: map[string]string{
+			queue.PathPrefixHeaderName:  "/api",
+			queue.PathRewriteHeaderName: "/",
+		},
+	}, {
+		RewriteHost: "the-rewrite-host",
+		Splits:      split("the-target-svc"),
+		AppendHeaders: map[string]string{
+			queue.PathPrefixHeaderName:  "/",
+			queue.PathRewriteHeaderName: "/",
+		},
+	}}
+	if diff := cmp.Diff(want, got.Spec.Rules[0].HTTP.Paths); diff != "" {
+		t.Errorf("Unexpected Paths (-want, +got):\n%s", diff)
+	}
+
+	// Only whole segments match, "/apis" is left to the backend.
+	for _, p := range got.Spec.Rules[0].HTTP.Paths[:3] {
+		if regexp.MustCompile(p.Path).MatchString("/apis") {
+			t.Errorf("Path %s matches /apis", p.Path)
+		}
+	}
+}
+
+func TestPathRegex(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		matches   []string
+		noMatches []string
+	}{{
+		prefix:    "/api",
+		matches:   []string{"/api", "/api/", "/api/users"},
+		noMatches: []string{"/apis", "/apis/users", "/", "/v1/api"},
+	}, {
+		prefix:    "/api/",
+		matches:   []string{"/api/", "/api/users"},
+		noMatches: []string{"/api", "/apis"},
+	}, {
+		prefix:    "/docs/v1.0",
+		matches:   []string{"/docs/v1.0", "/docs/v1.0/index.html"},
+		noMatches: []string{"/docs/v1x0", "/docs/v1.0.1"},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			re := regexp.MustCompile(pathRegex(test.prefix))
+			for _, p := range test.matches {
+				if !re.MatchString(p) {
+					t.Errorf("%s does not match %q", re, p)
+				}
+			}
+			for _, p := range test.noMatches {
+				if re.MatchString(p) {
+					t.Errorf("%s matches %q", re, p)
+				}
+			}
+		})
+	}
+}
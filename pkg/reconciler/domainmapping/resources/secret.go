@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+)
+
+// VerifyCertificateSecret verifies that the Secret holds a certificate and
+// the matching private key, and that the certificate covers the host and is
+// valid at the given time. It returns the time the certificate expires.
+func VerifyCertificateSecret(secret *corev1.Secret, host string, now time.Time) (time.Time, error) {
+	certPEM, keyPEM := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return time.Time{}, fmt.Errorf("the keys %s and %s are required", corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// The first certificate of the chain is the one presented for the host.
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := cert.VerifyHostname(host); err != nil {
+		return time.Time{}, err
+	}
+	if now.Before(cert.NotBefore) {
+		return time.Time{}, fmt.Errorf("the certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if !now.Before(cert.NotAfter) {
+		return time.Time{}, fmt.Errorf("the certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return cert.NotAfter, nil
+}
+
+// MakeSecretIngressTLS creates the IngressTLS serving the domain of the
+// DomainMapping with the certificate of the Secret configured by spec.tls.
+func MakeSecretIngressTLS(dm *v1alpha1.DomainMapping) netv1alpha1.IngressTLS {
+	return netv1alpha1.IngressTLS{
+		Hosts:           []string{dm.Name},
+		SecretName:      dm.Spec.TLS.SecretName,
+		SecretNamespace: dm.Namespace,
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+)
+
+var (
+	notBefore = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter  = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func certificateSecret(t *testing.T, dnsNames ...string) *corev1.Secret {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("GenerateKey() =", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("CreateCertificate() =", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("MarshalECPrivateKey() =", err)
+	}
+	return &corev1.Secret{
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+func TestVerifyCertificateSecret(t *testing.T) {
+	valid := certificateSecret(t, "mapping.com", "*.wildcard.com")
+	other := certificateSecret(t, "mapping.com")
+	mismatched := valid.DeepCopy()
+	mismatched.Data[corev1.TLSPrivateKeyKey] = other.Data[corev1.TLSPrivateKeyKey]
+
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		host    string
+		now     time.Time
+		wantErr string
+	}{{
+		name:   "valid",
+		secret: valid,
+		host:   "mapping.com",
+		now:    notBefore.Add(time.Hour),
+	}, {
+		name:   "wildcard",
+		secret: valid,
+		host:   "foo.wildcard.com",
+		now:    notBefore.Add(time.Hour),
+	}, {
+		name:    "missing keys",
+		secret:  &corev1.Secret{},
+		host:    "mapping.com",
+		now:     notBefore.Add(time.Hour),
+		wantErr: "the keys tls.crt and tls.key are required",
+	}, {
+		name:    "mismatched key",
+		secret:  mismatched,
+		host:    "mapping.com",
+		now:     notBefore.Add(time.Hour),
+		wantErr: "tls: private key does not match public key",
+	}, {
+		name:    "host not covered",
+		secret:  valid,
+		host:    "other.com",
+		now:     notBefore.Add(time.Hour),
+		wantErr: "x509: certificate is valid for mapping.com, *.wildcard.com, not other.com",
+	}, {
+		name:    "not yet valid",
+		secret:  valid,
+		host:    "mapping.com",
+		now:     notBefore.Add(-time.Hour),
+		wantErr: "the certificate is not valid before 2026-01-01T00:00:00Z",
+	}, {
+		name:    "expired",
+		secret:  valid,
+		host:    "mapping.com",
+		now:     notAfter,
+		wantErr: "the certificate expired at 2027-01-01T00:00:00Z",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := VerifyCertificateSecret(test.secret, test.host, test.now)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("VerifyCertificateSecret() = %v, want error %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("VerifyCertificateSecret() =", err)
+			}
+			if !got.Equal(notAfter) {
+				t.Errorf("VerifyCertificateSecret() = %v, want: %v", got, notAfter)
+			}
+		})
+	}
+}
+
+func TestMakeSecretIngressTLS(t *testing.T) {
+	got := MakeSecretIngressTLS(&v1alpha1.DomainMapping{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mapping.com",
+			Namespace: "the-namespace",
+		},
+		Spec: v1alpha1.DomainMappingSpec{
+			TLS: &v1alpha1.DomainMappingTLS{SecretName: "my-cert"},
+		},
+	})
+	want := netv1alpha1.IngressTLS{
+		Hosts:           []string{"mapping.com"},
+		SecretName:      "my-cert",
+		SecretNamespace: "the-namespace",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("Unexpected IngressTLS (-want, +got):\n%s", cmp.Diff(want, got))
+	}
+}
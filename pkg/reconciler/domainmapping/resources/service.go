@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/serving"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+)
+
+// ExternalNameServiceName returns the name of the ExternalName Service
+// through which the DomainMapping sends requests to the given host.
+func ExternalNameServiceName(dm *v1alpha1.DomainMapping, host string) string {
+	// DomainMapping names are domains, while Service names must be DNS
+	// labels starting with a letter.
+	return kmeta.ChildName("dm-"+strings.ReplaceAll(dm.Name, ".", "-"),
+		fmt.Sprintf("-%x", sha256.Sum256([]byte(host)))[:9])
+}
+
+// MakeExternalNameService creates an ExternalName Service in the namespace
+// of the DomainMapping resolving to the given host. KIngress backends must
+// be Services in the namespace of the KIngress, so the Service stands for
+// the targets which are not.
+func MakeExternalNameService(dm *v1alpha1.DomainMapping, host string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ExternalNameServiceName(dm, host),
+			Namespace: dm.Namespace,
+			Labels: map[string]string{
+				serving.DomainMappingLabelKey: dm.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(dm)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:            corev1.ServiceTypeExternalName,
+			ExternalName:    host,
+			SessionAffinity: corev1.ServiceAffinityNone,
+			Ports: []corev1.ServicePort{{
+				Name:       networking.ServicePortNameHTTP1,
+				Port:       80,
+				TargetPort: intstr.FromInt(80),
+			}},
+		},
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"knative.dev/networking/pkg/apis/networking"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/serving"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestMakeExternalNameService(t *testing.T) {
+	dm := &v1alpha1.DomainMapping{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mapping.com",
+			Namespace: "the-namespace",
+		},
+	}
+
+	got := MakeExternalNameService(dm, "broker.other.svc.cluster.local")
+	want := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      got.Name,
+			Namespace: "the-namespace",
+			Labels: map[string]string{
+				serving.DomainMappingLabelKey: "mapping.com",
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(dm)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:            corev1.ServiceTypeExternalName,
+			ExternalName:    "broker.other.svc.cluster.local",
+			SessionAffinity: corev1.ServiceAffinityNone,
+			Ports: []corev1.ServicePort{{
+				Name:       networking.ServicePortNameHTTP1,
+				Port:       80,
+				TargetPort: intstr.FromInt(80),
+			}},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("Unexpected Service (-want, +got):\n%s", cmp.Diff(want, got))
+	}
+	if !strings.HasPrefix(got.Name, "dm-mapping-com-") {
+		t.Errorf("Name = %q, want prefix dm-mapping-com-", got.Name)
+	}
+}
+
+func TestExternalNameServiceName(t *testing.T) {
+	dm := &v1alpha1.DomainMapping{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "1." + strings.Repeat("very-long.", 10) + "com",
+		},
+	}
+
+	a, b := ExternalNameServiceName(dm, "a.ns.svc.cluster.local"), ExternalNameServiceName(dm, "b.ns.svc.cluster.local")
+	if a == b {
+		t.Errorf("Names of different hosts are both %q", a)
+	}
+	for _, name := range []string{a, b} {
+		if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+			t.Errorf("Name %q is not a DNS 1035 label: %v", name, errs)
+		}
+	}
+	if got := ExternalNameServiceName(dm, "a.ns.svc.cluster.local"); got != a {
+		t.Errorf("Name = %q, want stable name %q", got, a)
+	}
+}
@@ -18,13 +18,21 @@ package domainmapping
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clientgotesting "k8s.io/client-go/testing"
 
@@ -34,11 +42,13 @@ import (
 	networkingclient "knative.dev/networking/pkg/client/injection/client/fake"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	pkgnetwork "knative.dev/pkg/network"
+	"knative.dev/pkg/ptr"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/pkg/resolver"
 	"knative.dev/serving/pkg/apis/serving"
@@ -97,6 +107,78 @@ func TestReconcile(t *testing.T) {
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
 			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "first-reconcile.com"),
 		},
+	}, {
+		Name: "first reconcile with paths",
+		Key:  "default/paths.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "the-target-svc.default.svc.cluster.local", ""),
+			ksvc("default", "api", "the-api-svc.default.svc.cluster.local", ""),
+			domainMapping("default", "paths.com", withRef("default", "target"), withPath("/api", "api", "/")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "paths.com",
+				withRef("default", "target"),
+				withPath("/api", "api", "/"),
+				withURL("http", "paths.com"),
+				withAddress("http", "paths.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "paths.com", withRef("default", "target"))),
+			resources.MakeIngressWithPaths(
+				domainMapping("default", "paths.com", withRef("default", "target"), withPath("/api", "api", "/")),
+				[]resources.PathTarget{{
+					Prefix:      "/api",
+					Rewrite:     ptr.String("/"),
+					ServiceName: "the-api-svc",
+					Host:        "the-api-svc.default.svc.cluster.local",
+				}},
+				"the-target-svc", "the-target-svc.default.svc.cluster.local", "the-ingress-class", nil /* tls */),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "paths.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "paths.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "paths.com"),
+		},
+	}, {
+		Name: "path ref not found",
+		Key:  "default/paths.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "the-target-svc.default.svc.cluster.local", ""),
+			domainMapping("default", "paths.com", withRef("default", "target"), withPath("/api", "api", "/")),
+		},
+		WantErr: true,
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "paths.com",
+				withRef("default", "target"),
+				withPath("/api", "api", "/"),
+				withURL("http", "paths.com"),
+				withAddress("http", "paths.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withReferenceNotResolved(`services.serving.knative.dev "api" not found`),
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "paths.com", withRef("default", "target"))),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "paths.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "paths.com"),
+			Eventf(corev1.EventTypeWarning, "InternalError", `resolving reference: services.serving.knative.dev "api" not found`),
+		},
 	}, {
 		Name: "finalize cleans up claim",
 		Key:  "default/cleanup.on.aisle-three",
@@ -263,6 +345,41 @@ func TestReconcile(t *testing.T) {
 			ksvc("default", "target", "notasvc.cluster.local", ""),
 			domainMapping("default", "first-reconcile.com", withRef("default", "target")),
 		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("default", "target"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("default", "target"))),
+			resources.MakeIngress(domainMapping("default", "first-reconcile.com", withRef("default", "target")),
+				resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "notasvc.cluster.local"),
+				"notasvc.cluster.local", "the-ingress-class", nil /* tls */),
+			resources.MakeExternalNameService(domainMapping("default", "first-reconcile.com", withRef("default", "target")), "notasvc.cluster.local"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Service %q", resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "notasvc.cluster.local")),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "first-reconcile.com"),
+		},
+	}, {
+		Name: "first reconcile, ref has a port",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "the-target-svc.default.svc.cluster.local:8080", ""),
+			domainMapping("default", "first-reconcile.com", withRef("default", "target")),
+		},
 		WantErr: true,
 		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
 			Object: domainMapping("default", "first-reconcile.com",
@@ -272,7 +389,7 @@ func TestReconcile(t *testing.T) {
 				withInitDomainMappingConditions,
 				withTLSNotEnabled,
 				withDomainClaimed,
-				withReferenceNotResolved(`resolved URI "http://notasvc.cluster.local" must be of the form {name}.{namespace}.svc.cluster.local`),
+				withReferenceNotResolved(`resolved URI "http://the-target-svc.default.svc.cluster.local:8080" contains a port`),
 			),
 		}},
 		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
@@ -284,10 +401,10 @@ func TestReconcile(t *testing.T) {
 		},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
-			Eventf(corev1.EventTypeWarning, "InternalError", `resolved URI "http://notasvc.cluster.local" must be of the form {name}.{namespace}.svc.cluster.local`),
+			Eventf(corev1.EventTypeWarning, "InternalError", `resolved URI "http://the-target-svc.default.svc.cluster.local:8080" contains a port`),
 		},
 	}, {
-		Name: "first reconcile, resolved URL in wrong namespace",
+		Name: "first reconcile, resolved URL in another namespace without grant",
 		Key:  "default/first-reconcile.com",
 		Objects: []runtime.Object{
 			ksvc("default", "target", "name.anothernamespace.svc.cluster.local", ""),
@@ -302,19 +419,223 @@ func TestReconcile(t *testing.T) {
 				withInitDomainMappingConditions,
 				withTLSNotEnabled,
 				withDomainClaimed,
-				withReferenceNotResolved(`resolved URI "http://name.anothernamespace.svc.cluster.local" must be in same namespace as DomainMapping`),
+				withReferenceNotResolved(`namespace "anothernamespace" does not grant DomainMappings of namespace "default"`),
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("default", "target"))),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeWarning, "InternalError", `namespace "anothernamespace" does not grant DomainMappings of namespace "default"`),
+		},
+	}, {
+		Name: "first reconcile, resolved URL in another namespace with grant",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "name.anothernamespace.svc.cluster.local", ""),
+			grant("anothernamespace", "default"),
+			domainMapping("default", "first-reconcile.com", withRef("default", "target")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("default", "target"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("default", "target"))),
+			resources.MakeIngress(domainMapping("default", "first-reconcile.com", withRef("default", "target")),
+				resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "name.anothernamespace.svc.cluster.local"),
+				"name.anothernamespace.svc.cluster.local", "the-ingress-class", nil /* tls */),
+			resources.MakeExternalNameService(domainMapping("default", "first-reconcile.com", withRef("default", "target")), "name.anothernamespace.svc.cluster.local"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Service %q", resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "name.anothernamespace.svc.cluster.local")),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "first-reconcile.com"),
+		},
+	}, {
+		Name: "first reconcile, ref in another namespace without grant",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			ksvc("other", "target", "target.other.svc.cluster.local", ""),
+			grant("other", "some-namespace, another-namespace"),
+			domainMapping("default", "first-reconcile.com", withRef("other", "target")),
+		},
+		WantErr: true,
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("other", "target"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withReferenceNotResolved(`namespace "other" does not grant DomainMappings of namespace "default"`),
 			),
 		}},
 		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
 		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("other", "target"))),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeWarning, "InternalError", `namespace "other" does not grant DomainMappings of namespace "default"`),
+		},
+	}, {
+		Name: "first reconcile, ref in another namespace with grant",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			ksvc("other", "target", "target.other.svc.cluster.local", ""),
+			grant("other", "some-namespace, default"),
+			domainMapping("default", "first-reconcile.com", withRef("other", "target")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("other", "target"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("other", "target"))),
+			resources.MakeIngress(domainMapping("default", "first-reconcile.com", withRef("other", "target")),
+				resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "target.other.svc.cluster.local"),
+				"target.other.svc.cluster.local", "the-ingress-class", nil /* tls */),
+			resources.MakeExternalNameService(domainMapping("default", "first-reconcile.com", withRef("other", "target")), "target.other.svc.cluster.local"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Service %q", resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "target.other.svc.cluster.local")),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "first-reconcile.com"),
+		},
+	}, {
+		Name: "reconcile ref moved back to the namespace, stale service deleted",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "the-target-svc.default.svc.cluster.local", ""),
+			domainMapping("default", "first-reconcile.com", withRef("default", "target")),
 			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com", withRef("default", "target"))),
+			resources.MakeIngress(domainMapping("default", "first-reconcile.com", withRef("default", "target")),
+				"the-target-svc", "the-target-svc.default.svc.cluster.local", "the-ingress-class", nil /* tls */),
+			resources.MakeExternalNameService(domainMapping("default", "first-reconcile.com", withRef("default", "target")), "target.other.svc.cluster.local"),
 		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("default", "target"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "default",
+				Verb:      "delete",
+				Resource:  corev1.SchemeGroupVersion.WithResource("services"),
+			},
+			Name: resources.ExternalNameServiceName(domainMapping("default", "first-reconcile.com"), "target.other.svc.cluster.local"),
+		}},
 		WantPatches: []clientgotesting.PatchActionImpl{
 			patchAddFinalizerAction("default", "first-reconcile.com"),
 		},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
-			Eventf(corev1.EventTypeWarning, "InternalError", `resolved URI "http://name.anothernamespace.svc.cluster.local" must be in same namespace as DomainMapping`),
+		},
+	}, {
+		Name: "first reconcile, tag of a route",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			route("default", "target", "target.default.svc.cluster.local", "candidate"),
+			domainMapping("default", "first-reconcile.com", withRef("default", "target", withAPIVersionKind("serving.knative.dev/v1", "Route")), withTag("candidate")),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("default", "target", withAPIVersionKind("serving.knative.dev/v1", "Route")),
+				withTag("candidate"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withIngressNotConfigured,
+				withReferenceResolved,
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com")),
+			resources.MakeIngress(domainMapping("default", "first-reconcile.com", withRef("default", "target", withAPIVersionKind("serving.knative.dev/v1", "Route")), withTag("candidate")),
+				"candidate-target", "candidate-target.default.svc.cluster.local", "the-ingress-class", nil /* tls */),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "first-reconcile.com"),
+		},
+	}, {
+		Name: "first reconcile, tag not found",
+		Key:  "default/first-reconcile.com",
+		Objects: []runtime.Object{
+			route("default", "target", "target.default.svc.cluster.local", "candidate"),
+			domainMapping("default", "first-reconcile.com", withRef("default", "target", withAPIVersionKind("serving.knative.dev/v1", "Route")), withTag("latest")),
+		},
+		WantErr: true,
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "first-reconcile.com",
+				withRef("default", "target", withAPIVersionKind("serving.knative.dev/v1", "Route")),
+				withTag("latest"),
+				withURL("http", "first-reconcile.com"),
+				withAddress("http", "first-reconcile.com"),
+				withInitDomainMappingConditions,
+				withTLSNotEnabled,
+				withDomainClaimed,
+				withReferenceNotResolved(`tag "latest" not found in the traffic of default/target`),
+			),
+		}},
+		SkipNamespaceValidation: true, // allow creation of ClusterDomainClaim.
+		WantCreates: []runtime.Object{
+			resources.MakeDomainClaim(domainMapping("default", "first-reconcile.com")),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "first-reconcile.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "first-reconcile.com"),
+			Eventf(corev1.EventTypeWarning, "InternalError", `resolving reference: tag "latest" not found in the traffic of default/target`),
 		},
 	}, {
 		Name: "first reconcile, pre-owned domain claim",
@@ -678,6 +999,14 @@ func TestReconcile(t *testing.T) {
 			netclient:         networkingclient.Get(ctx),
 			resolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
 			domainClaimLister: listers.GetDomainClaimLister(),
+			routeLister:       listers.GetRouteLister(),
+			serviceLister:     listers.GetK8sServiceLister(),
+			configMapLister:   listers.GetConfigMapLister(),
+			kubeclient:        kubeclient.Get(ctx),
+			tracker:           &NullTracker{},
+			secretLister:      listers.GetSecretLister(),
+			clock:             clock.NewFakeClock(certNow),
+			enqueueAfter:      func(interface{}, time.Duration) {},
 		}
 
 		return domainmappingreconciler.NewReconciler(ctx, logging.FromContext(ctx),
@@ -687,6 +1016,7 @@ func TestReconcile(t *testing.T) {
 					Network: &network.Config{
 						DefaultIngressClass:           "the-ingress-class",
 						AutocreateClusterDomainClaims: true,
+						TagTemplate:                   network.DefaultTagTemplate,
 					},
 				},
 			}},
@@ -806,6 +1136,14 @@ func TestReconcileAutocreateClaimsDisabled(t *testing.T) {
 			netclient:         networkingclient.Get(ctx),
 			resolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
 			domainClaimLister: listers.GetDomainClaimLister(),
+			routeLister:       listers.GetRouteLister(),
+			serviceLister:     listers.GetK8sServiceLister(),
+			configMapLister:   listers.GetConfigMapLister(),
+			kubeclient:        kubeclient.Get(ctx),
+			tracker:           &NullTracker{},
+			secretLister:      listers.GetSecretLister(),
+			clock:             clock.NewFakeClock(certNow),
+			enqueueAfter:      func(interface{}, time.Duration) {},
 		}
 
 		return domainmappingreconciler.NewReconciler(ctx, logging.FromContext(ctx),
@@ -1114,6 +1452,14 @@ func TestReconcileTLSEnabled(t *testing.T) {
 			domainClaimLister: listers.GetDomainClaimLister(),
 			netclient:         networkingclient.Get(ctx),
 			resolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+			routeLister:       listers.GetRouteLister(),
+			serviceLister:     listers.GetK8sServiceLister(),
+			configMapLister:   listers.GetConfigMapLister(),
+			kubeclient:        kubeclient.Get(ctx),
+			tracker:           &NullTracker{},
+			secretLister:      listers.GetSecretLister(),
+			clock:             clock.NewFakeClock(certNow),
+			enqueueAfter:      func(interface{}, time.Duration) {},
 		}
 
 		return domainmappingreconciler.NewReconciler(ctx, logging.FromContext(ctx),
@@ -1179,6 +1525,14 @@ func TestReconcileTLSEnabledButDowngraded(t *testing.T) {
 			ingressLister:     listers.GetIngressLister(),
 			netclient:         networkingclient.Get(ctx),
 			resolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+			routeLister:       listers.GetRouteLister(),
+			serviceLister:     listers.GetK8sServiceLister(),
+			configMapLister:   listers.GetConfigMapLister(),
+			kubeclient:        kubeclient.Get(ctx),
+			tracker:           &NullTracker{},
+			secretLister:      listers.GetSecretLister(),
+			clock:             clock.NewFakeClock(certNow),
+			enqueueAfter:      func(interface{}, time.Duration) {},
 		}
 
 		return domainmappingreconciler.NewReconciler(ctx, logging.FromContext(ctx),
@@ -1197,6 +1551,183 @@ func TestReconcileTLSEnabledButDowngraded(t *testing.T) {
 	}))
 }
 
+func TestReconcileTLSSecret(t *testing.T) {
+	valid := certificateSecret(t, "default", "my-cert", "secret.tls.com", certNow.Add(-time.Hour), certNow.Add(time.Hour))
+	expired := certificateSecret(t, "default", "my-cert", "secret.tls.com", certNow.Add(-2*time.Hour), certNow.Add(-time.Hour))
+	otherHost := certificateSecret(t, "default", "my-cert", "other.com", certNow.Add(-time.Hour), certNow.Add(time.Hour))
+
+	table := TableTest{{
+		Name: "first reconcile, secret takes precedence over auto-TLS",
+		Key:  "default/secret.tls.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "target.default.svc.cluster.local", ""),
+			valid,
+			domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")),
+			resources.MakeDomainClaim(domainMapping("default", "secret.tls.com")),
+		},
+		WantCreates: []runtime.Object{
+			ingress(domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")), "the-ingress-class",
+				withIngressTLS(netv1alpha1.IngressTLS{
+					Hosts:           []string{"secret.tls.com"},
+					SecretName:      "my-cert",
+					SecretNamespace: "default",
+				})),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "secret.tls.com",
+				withRef("default", "target"),
+				withTLSSecret("my-cert"),
+				withURL("https", "secret.tls.com"),
+				withAddress("https", "secret.tls.com"),
+				withInitDomainMappingConditions,
+				withCertificateSecretReady("my-cert"),
+				withCertificateExpiry(certNow.Add(time.Hour)),
+				withIngressNotConfigured,
+				withDomainClaimed,
+				withReferenceResolved,
+			),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "secret.tls.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "secret.tls.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "secret.tls.com"),
+		},
+	}, {
+		Name: "secret does not exist",
+		Key:  "default/secret.tls.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "target.default.svc.cluster.local", ""),
+			domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")),
+			resources.MakeDomainClaim(domainMapping("default", "secret.tls.com")),
+		},
+		WantCreates: []runtime.Object{
+			// Served without TLS.
+			ingress(domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")), "the-ingress-class"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "secret.tls.com",
+				withRef("default", "target"),
+				withTLSSecret("my-cert"),
+				withURL("http", "secret.tls.com"),
+				withAddress("http", "secret.tls.com"),
+				withInitDomainMappingConditions,
+				withCertificateSecretInvalid("my-cert", "the Secret does not exist or does not have the label serving.knative.dev/domainMappingTLS"),
+				withIngressNotConfigured,
+				withDomainClaimed,
+				withReferenceResolved,
+			),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "secret.tls.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "secret.tls.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "secret.tls.com"),
+		},
+	}, {
+		Name: "certificate expired",
+		Key:  "default/secret.tls.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "target.default.svc.cluster.local", ""),
+			expired,
+			domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert"),
+				withCertificateExpiry(certNow.Add(-time.Hour))),
+			resources.MakeDomainClaim(domainMapping("default", "secret.tls.com")),
+		},
+		WantCreates: []runtime.Object{
+			// Served without TLS.
+			ingress(domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")), "the-ingress-class"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "secret.tls.com",
+				withRef("default", "target"),
+				withTLSSecret("my-cert"),
+				withURL("http", "secret.tls.com"),
+				withAddress("http", "secret.tls.com"),
+				withInitDomainMappingConditions,
+				withCertificateSecretInvalid("my-cert", "the certificate expired at "+certNow.Add(-time.Hour).Format(time.RFC3339)),
+				withIngressNotConfigured,
+				withDomainClaimed,
+				withReferenceResolved,
+			),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "secret.tls.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "secret.tls.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "secret.tls.com"),
+		},
+	}, {
+		Name: "certificate does not cover the domain",
+		Key:  "default/secret.tls.com",
+		Objects: []runtime.Object{
+			ksvc("default", "target", "target.default.svc.cluster.local", ""),
+			otherHost,
+			domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")),
+			resources.MakeDomainClaim(domainMapping("default", "secret.tls.com")),
+		},
+		WantCreates: []runtime.Object{
+			// Served without TLS.
+			ingress(domainMapping("default", "secret.tls.com", withRef("default", "target"), withTLSSecret("my-cert")), "the-ingress-class"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: domainMapping("default", "secret.tls.com",
+				withRef("default", "target"),
+				withTLSSecret("my-cert"),
+				withURL("http", "secret.tls.com"),
+				withAddress("http", "secret.tls.com"),
+				withInitDomainMappingConditions,
+				withCertificateSecretInvalid("my-cert", "x509: certificate is valid for other.com, not secret.tls.com"),
+				withIngressNotConfigured,
+				withDomainClaimed,
+				withReferenceResolved,
+			),
+		}},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddFinalizerAction("default", "secret.tls.com"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", "secret.tls.com"),
+			Eventf(corev1.EventTypeNormal, "Created", "Created Ingress %q", "secret.tls.com"),
+		},
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		ctx = addressable.WithDuck(ctx)
+		r := &Reconciler{
+			certificateLister: listers.GetCertificateLister(),
+			domainClaimLister: listers.GetDomainClaimLister(),
+			ingressLister:     listers.GetIngressLister(),
+			netclient:         networkingclient.Get(ctx),
+			resolver:          resolver.NewURIResolver(ctx, func(types.NamespacedName) {}),
+			routeLister:       listers.GetRouteLister(),
+			serviceLister:     listers.GetK8sServiceLister(),
+			configMapLister:   listers.GetConfigMapLister(),
+			kubeclient:        kubeclient.Get(ctx),
+			tracker:           &NullTracker{},
+			secretLister:      listers.GetSecretLister(),
+			clock:             clock.NewFakeClock(certNow),
+			enqueueAfter:      func(interface{}, time.Duration) {},
+		}
+
+		return domainmappingreconciler.NewReconciler(ctx, logging.FromContext(ctx),
+			servingclient.Get(ctx), listers.GetDomainMappingLister(), controller.GetEventRecorder(ctx), r,
+			controller.Options{ConfigStore: &testConfigStore{
+				config: &config.Config{
+					Network: &network.Config{
+						DefaultIngressClass:     "the-ingress-class",
+						DefaultCertificateClass: "the-cert-class",
+						AutoTLS:                 true,
+					},
+				},
+			}},
+		)
+	}))
+}
+
 type domainMappingOption func(dm *v1alpha1.DomainMapping)
 
 func domainMapping(namespace, name string, opt ...domainMappingOption) *v1alpha1.DomainMapping {
@@ -1239,6 +1770,54 @@ func withRef(namespace, name string, opt ...refOption) domainMappingOption {
 	}
 }
 
+func withPath(prefix, name, rewrite string) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		p := v1alpha1.DomainMappingPath{
+			Prefix: prefix,
+			Ref: duckv1.KReference{
+				Namespace:  dm.Namespace,
+				Name:       name,
+				APIVersion: "serving.knative.dev/v1",
+				Kind:       "Service",
+			},
+		}
+		if rewrite != "" {
+			p.Rewrite = ptr.String(rewrite)
+		}
+		dm.Spec.Paths = append(dm.Spec.Paths, p)
+	}
+}
+
+func withTag(tag string) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		dm.Spec.Tag = tag
+	}
+}
+
+func withTLSSecret(name string) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		dm.Spec.TLS = &v1alpha1.DomainMappingTLS{SecretName: name}
+	}
+}
+
+func withCertificateExpiry(expiry time.Time) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		dm.Status.CertificateExpiry = &metav1.Time{Time: expiry}
+	}
+}
+
+func withCertificateSecretReady(name string) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		dm.Status.MarkCertificateSecretReady(name)
+	}
+}
+
+func withCertificateSecretInvalid(name, reason string) domainMappingOption {
+	return func(dm *v1alpha1.DomainMapping) {
+		dm.Status.MarkCertificateSecretInvalid(name, reason)
+	}
+}
+
 func withAPIVersionKind(apiVersion, kind string) refOption {
 	return func(ref *duckv1.KReference) {
 		ref.APIVersion = apiVersion
@@ -1386,6 +1965,78 @@ func ksvc(ns, name, host, path string) *servingv1.Service {
 	}
 }
 
+func route(ns, name, host, tag string) *servingv1.Route {
+	return &servingv1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Status: servingv1.RouteStatus{
+			RouteStatusFields: servingv1.RouteStatusFields{
+				Address: &duckv1.Addressable{
+					URL: &apis.URL{
+						Scheme: "http",
+						Host:   host,
+					},
+				},
+				Traffic: []servingv1.TrafficTarget{{
+					Tag:          tag,
+					RevisionName: name + "-00001",
+					Percent:      ptr.Int64(0),
+				}},
+			},
+		},
+	}
+}
+
+func grant(ns, namespaces string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v1alpha1.DomainMappingGrantName,
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			v1alpha1.DomainMappingGrantNamespacesKey: namespaces,
+		},
+	}
+}
+
+// certNow is the time of the clock of the reconcilers under test.
+var certNow = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func certificateSecret(t *testing.T, ns, name, host string, notBefore, notAfter time.Time) *corev1.Secret {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("GenerateKey() =", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{host},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}, &x509.Certificate{SerialNumber: big.NewInt(1)}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("CreateCertificate() =", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("MarshalECPrivateKey() =", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    map[string]string{serving.DomainMappingTLSLabelKey: "true"},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
 func readyCertStatus() netv1alpha1.CertificateStatus {
 	certStatus := &netv1alpha1.CertificateStatus{}
 	certStatus.MarkReady()
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/gc"
+	"knative.dev/serving/pkg/reconciler/gc/resources"
+)
+
+// maxArchiveSize is the size in bytes the data of an archive is kept under,
+// which leaves room for its metadata below the 1MiB limit of a ConfigMap.
+const maxArchiveSize = 768 * 1024
+
+// archive snapshots the revisions into the archive of the configuration, and
+// drops from the archive the revisions past its retention.
+func (c *reconciler) archive(ctx context.Context, config *v1.Configuration, cfg *gc.Config, revs []*v1.Revision) error {
+	now := c.clock.Now()
+
+	archive, err := c.configMapLister.ConfigMaps(config.Namespace).Get(resources.ArchiveName(config))
+	if apierrs.IsNotFound(err) {
+		if len(revs) == 0 {
+			return nil
+		}
+		archive = nil
+	} else if err != nil {
+		return err
+	} else if !metav1.IsControlledBy(archive, config) {
+		return fmt.Errorf("configuration: %q does not own configmap: %q", config.Name, archive.Name)
+	}
+
+	data := make(map[string]string, len(revs))
+	if archive != nil {
+		for k, v := range archive.Data {
+			data[k] = v
+		}
+	}
+	for _, rev := range revs {
+		b, err := json.Marshal(resources.MakeArchivedRevision(rev, now))
+		if err != nil {
+			return fmt.Errorf("failed to archive revision %q: %w", rev.Name, err)
+		}
+		data[rev.Name] = string(b)
+	}
+	pruneArchive(ctx, data, cfg, now)
+
+	switch {
+	case archive == nil:
+		_, err = c.kubeclient.CoreV1().ConfigMaps(config.Namespace).Create(ctx, resources.MakeArchive(config, data), metav1.CreateOptions{})
+	case !equality.Semantic.DeepEqual(archive.Data, data):
+		archive = archive.DeepCopy()
+		archive.Data = data
+		_, err = c.kubeclient.CoreV1().ConfigMaps(config.Namespace).Update(ctx, archive, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// pruneArchive drops the revisions archived for longer than
+// RetainArchivedRevisionsTime, and the oldest revisions past
+// MaxArchivedRevisions or past maxArchiveSize, even when MaxArchivedRevisions
+// is disabled. Unparsable entries are dropped too.
+func pruneArchive(ctx context.Context, data map[string]string, cfg *gc.Config, now time.Time) {
+	logger := logging.FromContext(ctx)
+
+	archivedAt := make(map[string]time.Time, len(data))
+	names := make([]string, 0, len(data))
+	for name, entry := range data {
+		var archived resources.ArchivedRevision
+		if err := json.Unmarshal([]byte(entry), &archived); err != nil {
+			logger.Warnf("Dropping unparsable archived revision %s: %v", name, err)
+			delete(data, name)
+			continue
+		}
+		if cfg.RetainArchivedRevisionsTime != gc.Disabled && now.Sub(archived.ArchivedAt.Time) > cfg.RetainArchivedRevisionsTime {
+			logger.Info("Dropping expired archived revision: ", name)
+			delete(data, name)
+			continue
+		}
+		archivedAt[name] = archived.ArchivedAt.Time
+		names = append(names, name)
+	}
+
+	// Sort by archiving time descending (newest first).
+	sort.Slice(names, func(i, j int) bool {
+		a, b := archivedAt[names[i]], archivedAt[names[j]]
+		if a.Equal(b) {
+			return names[i] > names[j]
+		}
+		return a.After(b)
+	})
+	max, size := int(cfg.MaxArchivedRevisions), 0
+	for i, name := range names {
+		size += len(name) + len(data[name])
+		switch {
+		case max != gc.Disabled && i >= max:
+			logger.Info("Dropping archived revision past max-archived-revisions: ", name)
+			delete(data, name)
+		case size > maxArchiveSize:
+			logger.Info("Dropping archived revision past the size of the archive: ", name)
+			delete(data, name)
+		}
+	}
+}
+
+// restore recreates the revision named by the restore annotation of the
+// configuration from its archive, unless it exists.
+func (c *reconciler) restore(ctx context.Context, config *v1.Configuration) pkgreconciler.Event {
+	name := config.Annotations[serving.RestoreRevisionAnnotationKey]
+	if name == "" {
+		return nil
+	}
+	if _, err := c.revisionLister.Revisions(config.Namespace).Get(name); err == nil {
+		return nil
+	} else if !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	archive, err := c.configMapLister.ConfigMaps(config.Namespace).Get(resources.ArchiveName(config))
+	if apierrs.IsNotFound(err) {
+		return pkgreconciler.NewEvent(corev1.EventTypeWarning, "RestoreFailed",
+			"Revision %q is not in the archive", name)
+	} else if err != nil {
+		return err
+	}
+	entry, ok := archive.Data[name]
+	if !ok || !metav1.IsControlledBy(archive, config) {
+		return pkgreconciler.NewEvent(corev1.EventTypeWarning, "RestoreFailed",
+			"Revision %q is not in the archive", name)
+	}
+	var archived resources.ArchivedRevision
+	if err := json.Unmarshal([]byte(entry), &archived); err != nil {
+		return pkgreconciler.NewEvent(corev1.EventTypeWarning, "RestoreFailed",
+			"Failed to parse archived revision %q: %v", name, err)
+	}
+
+	rev := resources.MakeRestoredRevision(config, name, &archived, c.clock.Now())
+	if _, err := c.client.ServingV1().Revisions(config.Namespace).Create(ctx, rev, metav1.CreateOptions{}); err != nil {
+		if apierrs.IsAlreadyExists(err) {
+			return nil // The informer has yet to see the restored revision.
+		}
+		return fmt.Errorf("failed to restore revision %q: %w", name, err)
+	}
+	controller.GetEventRecorder(ctx).Eventf(config, corev1.EventTypeNormal, "Restored",
+		"Restored Revision %q from the archive", name)
+	return nil
+}
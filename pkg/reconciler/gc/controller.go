@@ -19,10 +19,14 @@ package gc
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/tools/cache"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/filtered"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	servingclient "knative.dev/serving/pkg/client/injection/client"
 	configurationinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration"
@@ -42,10 +46,14 @@ func NewController(
 	logger := logging.FromContext(ctx)
 	configurationInformer := configurationinformer.Get(ctx)
 	revisionInformer := revisioninformer.Get(ctx)
+	configMapInformer := configmapinformer.Get(ctx, serving.ConfigurationLabelKey)
 
 	c := &reconciler{
-		client:         servingclient.Get(ctx),
-		revisionLister: revisionInformer.Lister(),
+		client:          servingclient.Get(ctx),
+		kubeclient:      kubeclient.Get(ctx),
+		revisionLister:  revisionInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		clock:           clock.RealClock{},
 	}
 	return configreconciler.NewImpl(ctx, c, func(impl *controller.Impl) controller.Options {
 		logger.Info("Setting up event handlers")
@@ -23,8 +23,10 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	"knative.dev/serving/pkg/apis/serving"
@@ -35,34 +37,117 @@ import (
 	configns "knative.dev/serving/pkg/reconciler/gc/config"
 )
 
-// collect deletes stale revisions if they are sufficiently old
+// Reasons of the revisions pending deletion in dry-run mode.
+const (
+	// reasonStale is the reason of a revision that is not retained by
+	// any of the time-bounds and above min-non-active-revisions.
+	reasonStale = "Stale"
+	// reasonMaxNonActive is the reason of a revision that is past
+	// max-non-active-revisions.
+	reasonMaxNonActive = "MaxNonActiveRevisions"
+)
+
+// archiveFunc snapshots the revisions into the archive of the configuration
+// before their deletion.
+type archiveFunc func(ctx context.Context, config *v1.Configuration, cfg *gc.Config, revs []*v1.Revision) error
+
+// collectable is a revision to delete, with the reason why.
+type collectable struct {
+	rev    *v1.Revision
+	reason string
+}
+
+// collect deletes stale revisions if they are sufficiently old. The revisions
+// are archived beforehand when the configuration's policy archives them.
+// Nothing is deleted when its policy is a dry run, the configuration
+// reconciler reports the revisions pending deletion instead.
 func collect(
 	ctx context.Context,
 	client clientset.Interface,
 	revisionLister listers.RevisionLister,
-	config *v1.Configuration) pkgreconciler.Event {
-	cfg := configns.FromContext(ctx).RevisionGC
+	config *v1.Configuration,
+	archive archiveFunc) pkgreconciler.Event {
+	cfg := configns.FromContext(ctx).RevisionGC.ForConfiguration(config.Namespace, config.Labels)
+	if cfg.DryRun {
+		return nil
+	}
 	logger := logging.FromContext(ctx)
 
+	collectables, err := collectableRevisions(cfg, revisionLister, config, logger)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ArchiveRevisions && archive != nil {
+		revs := make([]*v1.Revision, 0, len(collectables))
+		for _, c := range collectables {
+			revs = append(revs, c.rev)
+		}
+		// A failure to archive must not hold the collection, or the revisions
+		// would pile up for as long as the archive cannot be written.
+		if err := archive(ctx, config, cfg, revs); err != nil {
+			logger.Errorw("Failed to archive revisions", zap.Error(err))
+			controller.GetEventRecorder(ctx).Eventf(config, corev1.EventTypeWarning, "ArchiveFailed",
+				"Failed to archive revisions: %v", err)
+		}
+	}
+
+	for _, c := range collectables {
+		logger.Infof("Deleting revision %s: %s", c.rev.Name, c.reason)
+		if err := client.ServingV1().Revisions(c.rev.Namespace).Delete(ctx, c.rev.Name, metav1.DeleteOptions{}); err != nil {
+			logger.Errorw("Failed to GC revision: "+c.rev.Name, zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// PendingRevisions returns the revisions of the configuration that collection
+// would delete under the given settings, with the reason why.
+func PendingRevisions(
+	cfg *gc.Config,
+	revisionLister listers.RevisionLister,
+	config *v1.Configuration,
+	logger *zap.SugaredLogger) ([]v1.PendingRevisionGC, error) {
+	collectables, err := collectableRevisions(cfg, revisionLister, config, logger)
+	if err != nil || len(collectables) == 0 {
+		return nil, err
+	}
+	pending := make([]v1.PendingRevisionGC, 0, len(collectables))
+	for _, c := range collectables {
+		pending = append(pending, v1.PendingRevisionGC{
+			RevisionName: c.rev.Name,
+			Reason:       c.reason,
+		})
+	}
+	return pending, nil
+}
+
+// collectableRevisions returns the revisions of the configuration to delete
+// with the reason why.
+func collectableRevisions(
+	cfg *gc.Config,
+	revisionLister listers.RevisionLister,
+	config *v1.Configuration,
+	logger *zap.SugaredLogger) ([]collectable, error) {
 	min, max := int(cfg.MinNonActiveRevisions), int(cfg.MaxNonActiveRevisions)
 	if max == gc.Disabled && cfg.RetainSinceCreateTime == gc.Disabled && cfg.RetainSinceLastActiveTime == gc.Disabled {
-		return nil // all deletion settings are disabled
+		return nil, nil // all deletion settings are disabled
 	}
 
 	selector := labels.SelectorFromSet(labels.Set{serving.ConfigurationLabelKey: config.Name})
 	revs, err := revisionLister.Revisions(config.Namespace).List(selector)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(revs) <= min {
-		return nil // not enough total revs
+		return nil, nil // not enough total revs
 	}
 
 	// Filter out active revs
 	revs = nonactiveRevisions(revs, config)
 
 	if len(revs) <= min {
-		return nil // not enough non-active revs
+		return nil, nil // not enough non-active revs
 	}
 
 	// Sort by last active ascending (oldest first)
@@ -71,7 +156,9 @@ func collect(
 		return a.Before(b)
 	})
 
-	// Delete stale revisions while more than min remain, swap nonstale revisions to the end.
+	var collectables []collectable
+
+	// Collect stale revisions while more than min remain, swap nonstale revisions to the end.
 	swap := len(revs)
 
 	// If we need `min` to remain, this is the max index i can reach.
@@ -80,13 +167,10 @@ func collect(
 		rev := revs[i]
 		switch {
 		case i >= maxIdx:
-			return nil
+			return collectables, nil
 		case isRevisionStale(cfg, rev, logger):
 			i++
-			logger.Info("Deleting stale revision: ", rev.ObjectMeta.Name)
-			if err := client.ServingV1().Revisions(rev.Namespace).Delete(ctx, rev.Name, metav1.DeleteOptions{}); err != nil {
-				logger.Errorw("Failed to GC revision: "+rev.Name, zap.Error(err))
-			}
+			collectables = append(collectables, collectable{rev: rev, reason: reasonStale})
 		default:
 			swap--
 			revs[i], revs[swap] = revs[swap], revs[i]
@@ -95,19 +179,16 @@ func collect(
 	revs = revs[swap:] // Reslice to include the nonstale revisions, which are now in reverse order
 
 	if max == gc.Disabled || len(revs) <= max {
-		return nil
+		return collectables, nil
 	}
 
-	// Delete extra revisions past max.
-	logger.Infof("Maximum number of revisions (%d) reached, deleting oldest non-active (%d) revisions",
+	// Collect extra revisions past max.
+	logger.Infof("Maximum number of revisions (%d) reached, collecting oldest non-active (%d) revisions",
 		max, len(revs)-max)
 	for _, rev := range revs[max:] {
-		logger.Info("Deleting non-active revision: ", rev.ObjectMeta.Name)
-		if err := client.ServingV1().Revisions(rev.Namespace).Delete(ctx, rev.Name, metav1.DeleteOptions{}); err != nil {
-			logger.Errorw("Failed to GC revision: "+rev.Name, zap.Error(err))
-		}
+		collectables = append(collectables, collectable{rev: rev, reason: reasonMaxNonActive})
 	}
-	return nil
+	return collectables, nil
 }
 
 // nonactiveRevisions swaps keeps only non active revisions.
@@ -132,6 +213,9 @@ func isRevisionActive(rev *v1.Revision, config *v1.Configuration) bool {
 	if strings.EqualFold(rev.Annotations[serving.RevisionPreservedAnnotationKey], "true") {
 		return true
 	}
+	if config.Annotations[serving.RestoreRevisionAnnotationKey] == rev.Name {
+		return true // never delete the revision restored from the archive.
+	}
 	// Anything that the labeler hasn't explicitly labelled as inactive.
 	// Revisions which do not yet have any annotation are not eligible for deletion.
 	return rev.GetRoutingState() != v1.RoutingStateReserve
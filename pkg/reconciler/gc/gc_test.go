@@ -422,7 +422,7 @@ func runTest(
 
 	recorderList := ActionRecorderList{client}
 
-	collect(ctx, client, ri.Lister(), cfg)
+	collect(ctx, client, ri.Lister(), cfg, nil)
 
 	actions, err := recorderList.ActionsByVerb()
 	if err != nil {
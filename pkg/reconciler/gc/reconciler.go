@@ -19,6 +19,9 @@ package gc
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	clientset "knative.dev/serving/pkg/client/clientset/versioned"
@@ -28,10 +31,14 @@ import (
 
 // reconciler implements controller.Reconciler for garbage collected resources.
 type reconciler struct {
-	client clientset.Interface
+	client     clientset.Interface
+	kubeclient kubernetes.Interface
 
 	// listers index properties about resources
-	revisionLister listers.RevisionLister
+	revisionLister  listers.RevisionLister
+	configMapLister corev1listers.ConfigMapLister
+
+	clock clock.PassiveClock
 }
 
 // Check that our reconciler implements configreconciler.Interface
@@ -39,5 +46,10 @@ var _ configreconciler.Interface = (*reconciler)(nil)
 
 // ReconcileKind implements Interface.ReconcileKind.
 func (c *reconciler) ReconcileKind(ctx context.Context, config *v1.Configuration) pkgreconciler.Event {
-	return collect(ctx, c.client, c.revisionLister, config)
+	// A failed restore must not hold the collection of the other revisions.
+	restoreEvent := c.restore(ctx, config)
+	if err := collect(ctx, c.client, c.revisionLister, config, c.archive); err != nil {
+		return err
+	}
+	return restoreEvent
 }
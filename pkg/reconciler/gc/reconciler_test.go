@@ -18,24 +18,30 @@ package gc
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
 	clientgotesting "k8s.io/client-go/testing"
 
+	kubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	pkgrec "knative.dev/pkg/reconciler"
+	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	servingclient "knative.dev/serving/pkg/client/injection/client/fake"
 	configreconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/configuration"
 	"knative.dev/serving/pkg/gc"
 	"knative.dev/serving/pkg/reconciler/configuration/resources"
 	"knative.dev/serving/pkg/reconciler/gc/config"
+	gcresources "knative.dev/serving/pkg/reconciler/gc/resources"
 
 	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration/fake"
 	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision/fake"
@@ -60,6 +66,24 @@ func TestGCReconcile(t *testing.T) {
 					RetainSinceLastActiveTime: 5 * time.Minute,
 					MinNonActiveRevisions:     1,
 					MaxNonActiveRevisions:     gc.Disabled,
+					Policies: []gc.Policy{{
+						Namespaces: []string{"dry"},
+						Settings: gc.Config{
+							RetainSinceCreateTime:     5 * time.Minute,
+							RetainSinceLastActiveTime: 5 * time.Minute,
+							MinNonActiveRevisions:     1,
+							MaxNonActiveRevisions:     gc.Disabled,
+							DryRun:                    true,
+						},
+					}, {
+						Selector: map[string]string{"tier": "prod"},
+						Settings: gc.Config{
+							RetainSinceCreateTime:     5 * time.Minute,
+							RetainSinceLastActiveTime: 5 * time.Minute,
+							MinNonActiveRevisions:     5,
+							MaxNonActiveRevisions:     gc.Disabled,
+						},
+					}},
 				},
 			},
 		}}
@@ -94,6 +118,49 @@ func TestGCReconcile(t *testing.T) {
 			Name: "5554",
 		}},
 		Key: "foo/keep-two",
+	}, {
+		Name: "dry run, keep all",
+		Objects: []runtime.Object{
+			cfg("keep-two", "dry", 5556,
+				WithLatestCreated("5556"),
+				WithLatestReady("5556"),
+				WithConfigObservedGen),
+			rev("keep-two", "dry", 5554, MarkRevisionReady,
+				WithRevName("5554"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(oldest)),
+			rev("keep-two", "dry", 5555, MarkRevisionReady,
+				WithRevName("5555"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(older)),
+			rev("keep-two", "dry", 5556, MarkRevisionReady,
+				WithRevName("5556"),
+				WithRoutingState(v1.RoutingStateActive, fc),
+				WithRoutingStateModified(old)),
+		},
+		Key: "dry/keep-two",
+	}, {
+		Name: "policy by selector keeps all",
+		Objects: []runtime.Object{
+			cfg("keep-all", "foo", 5556,
+				WithConfigLabel("tier", "prod"),
+				WithLatestCreated("5556"),
+				WithLatestReady("5556"),
+				WithConfigObservedGen),
+			rev("keep-all", "foo", 5554, MarkRevisionReady,
+				WithRevName("5554"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(oldest)),
+			rev("keep-all", "foo", 5555, MarkRevisionReady,
+				WithRevName("5555"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(older)),
+			rev("keep-all", "foo", 5556, MarkRevisionReady,
+				WithRevName("5556"),
+				WithRoutingState(v1.RoutingStateActive, fc),
+				WithRoutingStateModified(old)),
+		},
+		Key: "foo/keep-all",
 	}}
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
@@ -107,6 +174,229 @@ func TestGCReconcile(t *testing.T) {
 	}))
 }
 
+func TestGCReconcileArchive(t *testing.T) {
+	now := time.Now()
+
+	old := now.Add(-11 * time.Minute)
+	older := now.Add(-12 * time.Minute)
+	oldest := now.Add(-13 * time.Minute)
+
+	controllerOpts := controller.Options{
+		ConfigStore: &testConfigStore{
+			config: &config.Config{
+				RevisionGC: &gc.Config{
+					RetainSinceCreateTime:       5 * time.Minute,
+					RetainSinceLastActiveTime:   5 * time.Minute,
+					MinNonActiveRevisions:       1,
+					MaxNonActiveRevisions:       gc.Disabled,
+					ArchiveRevisions:            true,
+					MaxArchivedRevisions:        2,
+					RetainArchivedRevisionsTime: time.Hour,
+				},
+			},
+		}}
+
+	fc := clock.NewFakeClock(now)
+	revs := func(namespace string) []*v1.Revision {
+		return []*v1.Revision{
+			rev("keep-two", namespace, 5554, MarkRevisionReady,
+				WithRevName("5554"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(oldest),
+				withImageDigests),
+			rev("keep-two", namespace, 5555, MarkRevisionReady,
+				WithRevName("5555"),
+				WithRoutingState(v1.RoutingStateReserve, fc),
+				WithRoutingStateModified(older)),
+			rev("keep-two", namespace, 5556, MarkRevisionReady,
+				WithRevName("5556"),
+				WithRoutingState(v1.RoutingStateActive, fc),
+				WithRoutingStateModified(old)),
+		}
+	}
+	config := cfg("keep-two", "foo", 5556,
+		WithLatestCreated("5556"),
+		WithLatestReady("5556"),
+		WithConfigObservedGen)
+	archived := func(rev *v1.Revision, at time.Time) string {
+		b, err := json.Marshal(gcresources.MakeArchivedRevision(rev, at))
+		if err != nil {
+			t.Fatal("Failed to archive revision:", err)
+		}
+		return string(b)
+	}
+	fooRevs := revs("foo")
+	bigRev := fooRevs[1].DeepCopy()
+	bigRev.Annotations["big"] = strings.Repeat("x", maxArchiveSize)
+
+	table := TableTest{{
+		Name: "archive oldest before deletion",
+		Objects: []runtime.Object{
+			config, fooRevs[0], fooRevs[1], fooRevs[2],
+		},
+		WantCreates: []runtime.Object{
+			gcresources.MakeArchive(config, map[string]string{
+				"5554": archived(fooRevs[0], now),
+			}),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "foo",
+				Verb:      "delete",
+				Resource:  v1.SchemeGroupVersion.WithResource("revisions"),
+			},
+			Name: "5554",
+		}},
+		Key: "foo/keep-two",
+	}, {
+		Name: "archive oldest, prune expired and past max",
+		Objects: []runtime.Object{
+			config, fooRevs[0], fooRevs[1], fooRevs[2],
+			gcresources.MakeArchive(config, map[string]string{
+				"5550": archived(fooRevs[1], now.Add(-2*time.Hour)),
+				"5551": archived(fooRevs[1], now.Add(-30*time.Minute)),
+				"5552": archived(fooRevs[1], now.Add(-20*time.Minute)),
+				"5553": "not json",
+			}),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: gcresources.MakeArchive(config, map[string]string{
+				"5552": archived(fooRevs[1], now.Add(-20*time.Minute)),
+				"5554": archived(fooRevs[0], now),
+			}),
+		}},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "foo",
+				Verb:      "delete",
+				Resource:  v1.SchemeGroupVersion.WithResource("revisions"),
+			},
+			Name: "5554",
+		}},
+		Key: "foo/keep-two",
+	}, {
+		Name: "archive oldest, prune past the size of the archive",
+		Objects: []runtime.Object{
+			config, fooRevs[0], fooRevs[1], fooRevs[2],
+			gcresources.MakeArchive(config, map[string]string{
+				"5552": archived(bigRev, now.Add(-20*time.Minute)),
+			}),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: gcresources.MakeArchive(config, map[string]string{
+				"5554": archived(fooRevs[0], now),
+			}),
+		}},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "foo",
+				Verb:      "delete",
+				Resource:  v1.SchemeGroupVersion.WithResource("revisions"),
+			},
+			Name: "5554",
+		}},
+		Key: "foo/keep-two",
+	}, {
+		Name: "archive owned by another resource",
+		Objects: []runtime.Object{
+			config, fooRevs[0], fooRevs[1], fooRevs[2],
+			func() *corev1.ConfigMap {
+				cm := gcresources.MakeArchive(config, nil)
+				cm.OwnerReferences = nil
+				return cm
+			}(),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "foo",
+				Verb:      "delete",
+				Resource:  v1.SchemeGroupVersion.WithResource("revisions"),
+			},
+			Name: "5554",
+		}},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "ArchiveFailed",
+				`Failed to archive revisions: configuration: "keep-two" does not own configmap: "keep-two-revision-archive"`),
+		},
+		Key: "foo/keep-two",
+	}, {
+		Name: "restore archived revision",
+		Objects: []runtime.Object{
+			cfg("keep-two", "foo", 5556,
+				WithConfigAnn(serving.RestoreRevisionAnnotationKey, "keep-two-5553"),
+				WithLatestCreated("5556"),
+				WithLatestReady("5556"),
+				WithConfigObservedGen),
+			fooRevs[1], fooRevs[2],
+			gcresources.MakeArchive(config, map[string]string{
+				"keep-two-5553": archived(fooRevs[1], now.Add(-20*time.Minute)),
+			}),
+		},
+		WantCreates: []runtime.Object{
+			func() *v1.Revision {
+				r := fooRevs[1].DeepCopy()
+				r.Name = "keep-two-5553"
+				r.Status = v1.RevisionStatus{}
+				r.SetRoutingState(v1.RoutingStatePending, now)
+				return r
+			}(),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "Restored", `Restored Revision "keep-two-5553" from the archive`),
+		},
+		Key: "foo/keep-two",
+	}, {
+		Name: "restore revision not in the archive",
+		Objects: []runtime.Object{
+			cfg("keep-two", "foo", 5556,
+				WithConfigAnn(serving.RestoreRevisionAnnotationKey, "keep-two-5553"),
+				WithLatestCreated("5556"),
+				WithLatestReady("5556"),
+				WithConfigObservedGen),
+			fooRevs[1], fooRevs[2],
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeWarning, "RestoreFailed", `Revision "keep-two-5553" is not in the archive`),
+		},
+		Key: "foo/keep-two",
+	}, {
+		Name: "keep restored revision",
+		Objects: []runtime.Object{
+			cfg("keep-two", "foo", 5556,
+				WithConfigAnn(serving.RestoreRevisionAnnotationKey, "5554"),
+				WithLatestCreated("5556"),
+				WithLatestReady("5556"),
+				WithConfigObservedGen),
+			fooRevs[0], fooRevs[1], fooRevs[2],
+		},
+		Key: "foo/keep-two",
+	}}
+
+	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
+		r := &reconciler{
+			client:          servingclient.Get(ctx),
+			kubeclient:      kubeclient.Get(ctx),
+			revisionLister:  listers.GetRevisionLister(),
+			configMapLister: listers.GetConfigMapLister(),
+			clock:           fc,
+		}
+		return configreconciler.NewReconciler(ctx, logging.FromContext(ctx),
+			servingclient.Get(ctx), listers.GetConfigurationLister(),
+			controller.GetEventRecorder(ctx), r, controllerOpts)
+	}))
+}
+
+// withImageDigests sets the resolved image digests of the revision's
+// containers.
+func withImageDigests(r *v1.Revision) {
+	for _, c := range r.Spec.Containers {
+		r.Status.ContainerStatuses = append(r.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:        c.Name,
+			ImageDigest: c.Image + "@sha256:deadbeef",
+		})
+	}
+}
+
 func cfg(name, namespace string, generation int64, co ...ConfigOption) *v1.Configuration {
 	c := &v1.Configuration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// ArchivedRevision is the snapshot of a Revision in the archive of its
+// Configuration. The archive is a ConfigMap holding the JSON encoded
+// snapshots by Revision name.
+type ArchivedRevision struct {
+	// ArchivedAt is when the Revision was archived.
+	ArchivedAt metav1.Time `json:"archivedAt"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	Spec v1.RevisionSpec `json:"spec"`
+
+	// ImageDigests are the resolved images of the containers, by name.
+	ImageDigests map[string]string `json:"imageDigests,omitempty"`
+}
+
+// ArchiveName returns the name of the revision archive of the Configuration.
+func ArchiveName(config *v1.Configuration) string {
+	return kmeta.ChildName(config.Name, "-revision-archive")
+}
+
+// MakeArchive creates the revision archive of the Configuration, holding the
+// data.
+func MakeArchive(config *v1.Configuration, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ArchiveName(config),
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				serving.ConfigurationLabelKey: config.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(config)},
+		},
+		Data: data,
+	}
+}
+
+// MakeArchivedRevision snapshots the Revision at time now.
+func MakeArchivedRevision(rev *v1.Revision, now time.Time) *ArchivedRevision {
+	archived := &ArchivedRevision{
+		ArchivedAt:  metav1.NewTime(now),
+		Labels:      kmeta.CopyMap(rev.Labels),
+		Annotations: kmeta.CopyMap(rev.Annotations),
+		Spec:        *rev.Spec.DeepCopy(),
+	}
+	for _, status := range rev.Status.ContainerStatuses {
+		if status.ImageDigest == "" {
+			continue
+		}
+		if archived.ImageDigests == nil {
+			archived.ImageDigests = make(map[string]string, len(rev.Status.ContainerStatuses))
+		}
+		archived.ImageDigests[status.Name] = status.ImageDigest
+	}
+	return archived
+}
+
+// MakeRestoredRevision recreates the archived Revision of the Configuration,
+// running the resolved images of the snapshot.
+func MakeRestoredRevision(config *v1.Configuration, name string, archived *ArchivedRevision, now time.Time) *v1.Revision {
+	rev := &v1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       config.Namespace,
+			Labels:          kmeta.CopyMap(archived.Labels),
+			Annotations:     kmeta.CopyMap(archived.Annotations),
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(config)},
+		},
+		Spec: *archived.Spec.DeepCopy(),
+	}
+	for i := range rev.Spec.Containers {
+		if digest, ok := archived.ImageDigests[rev.Spec.Containers[i].Name]; ok {
+			rev.Spec.Containers[i].Image = digest
+		}
+	}
+
+	// Pending tells the labeler that we have not processed this revision.
+	rev.SetRoutingState(v1.RoutingStatePending, now)
+	return rev
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/pkg/kmeta"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+var config = &v1.Configuration{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "the-config",
+		Namespace: "the-namespace",
+	},
+}
+
+func TestMakeArchive(t *testing.T) {
+	got := MakeArchive(config, map[string]string{"rev": "{}"})
+	want := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "the-config-revision-archive",
+			Namespace: "the-namespace",
+			Labels: map[string]string{
+				serving.ConfigurationLabelKey: "the-config",
+			},
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(config)},
+		},
+		Data: map[string]string{"rev": "{}"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error("MakeArchive (-want, +got):", cmp.Diff(want, got))
+	}
+}
+
+func TestArchiveRestoreRoundTrip(t *testing.T) {
+	archivedAt := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	restoredAt := archivedAt.Add(time.Hour)
+
+	rev := &v1.Revision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "the-config-00001",
+			Namespace: "the-namespace",
+			Labels: map[string]string{
+				serving.ConfigurationLabelKey: "the-config",
+			},
+			Annotations: map[string]string{
+				"foo": "bar",
+			},
+		},
+		Spec: v1.RevisionSpec{
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:  "user-container",
+					Image: "example.com/app:latest",
+				}, {
+					Name:  "sidecar",
+					Image: "example.com/sidecar:latest",
+				}},
+			},
+		},
+		Status: v1.RevisionStatus{
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:        "user-container",
+				ImageDigest: "example.com/app@sha256:deadbeef",
+			}, {
+				Name: "sidecar",
+			}},
+		},
+	}
+	rev.SetRoutingState(v1.RoutingStateReserve, archivedAt)
+
+	b, err := json.Marshal(MakeArchivedRevision(rev, archivedAt))
+	if err != nil {
+		t.Fatal("Failed to encode archived revision:", err)
+	}
+	var archived ArchivedRevision
+	if err := json.Unmarshal(b, &archived); err != nil {
+		t.Fatal("Failed to decode archived revision:", err)
+	}
+	if got, want := archived.ArchivedAt.Time, archivedAt; !got.Equal(want) {
+		t.Errorf("ArchivedAt = %v, want: %v", got, want)
+	}
+
+	got := MakeRestoredRevision(config, "the-config-00001", &archived, restoredAt)
+	want := rev.DeepCopy()
+	want.Status = v1.RevisionStatus{}
+	want.OwnerReferences = []metav1.OwnerReference{*kmeta.NewControllerRef(config)}
+	want.Spec.Containers[0].Image = "example.com/app@sha256:deadbeef"
+	want.SetRoutingState(v1.RoutingStatePending, restoredAt)
+	if !cmp.Equal(got, want) {
+		t.Error("MakeRestoredRevision (-want, +got):", cmp.Diff(want, got))
+	}
+}
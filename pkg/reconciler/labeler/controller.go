@@ -24,6 +24,7 @@ import (
 
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	servingclient "knative.dev/serving/pkg/client/injection/client"
+	painformer "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/podautoscaler"
 	configurationinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration"
 	revisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision"
 	routeinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/route"
@@ -48,7 +49,7 @@ func NewController(
 	revisionInformer := revisioninformer.Get(ctx)
 
 	logger.Info("Setting up ConfigMap receivers")
-	configStore := config.NewStore(logger.Named("config-store"))
+	configStore := config.NewStore(logging.WithLogger(ctx, logger.Named("config-store")))
 	configStore.WatchConfigs(cmw)
 
 	c := &Reconciler{}
@@ -88,6 +89,8 @@ func NewController(
 	clock := &clock.RealClock{}
 	c.caccV2 = newConfigurationAccessor(client, tracker, configInformer.Lister(), configInformer.Informer().GetIndexer(), clock)
 	c.raccV2 = newRevisionAccessor(client, tracker, revisionInformer.Lister(), revisionInformer.Informer().GetIndexer(), clock)
+	c.client = client
+	c.podAutoscalerLister = painformer.Get(ctx).Lister()
 
 	return impl
 }
@@ -18,28 +18,65 @@ package labeler
 
 import (
 	"context"
+	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	clientset "knative.dev/serving/pkg/client/clientset/versioned"
 	routereconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/route"
+	palisters "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/route/resources"
 )
 
 // Reconciler implements controller.Reconciler for Route resources.
 type Reconciler struct {
 	caccV2 *configurationAccessor
 	raccV2 *revisionAccessor
+
+	client              clientset.Interface
+	podAutoscalerLister palisters.PodAutoscalerLister
 }
 
 // Check that our Reconciler implements routereconciler.Interface
 var _ routereconciler.Interface = (*Reconciler)(nil)
 var _ routereconciler.Finalizer = (*Reconciler)(nil)
 
-// FinalizeKind removes all Route reference metadata from its traffic targets.
+// FinalizeKind removes all Route reference metadata from its traffic targets,
+// and releases the pre-warm floors the Route set on their PodAutoscalers.
 // This does not modify or observe spec for the Route itself.
 func (rec *Reconciler) FinalizeKind(ctx context.Context, r *v1.Route) pkgreconciler.Event {
 	if err := clearRoutingMeta(ctx, r, rec.caccV2, rec.raccV2); err != nil {
 		return err
 	}
+	return rec.releasePreWarm(ctx, r)
+}
+
+// releasePreWarm releases the pre-warm floors owned by the Route, which
+// would otherwise keep the revisions it was rolling out at the old scale.
+func (rec *Reconciler) releasePreWarm(ctx context.Context, r *v1.Route) error {
+	pas, err := rec.podAutoscalerLister.PodAutoscalers(r.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, pa := range pas {
+		if pa.Annotations[autoscaling.RolloutMinScaleOwnerAnnotationKey] != r.Name {
+			continue
+		}
+		logging.FromContext(ctx).Info("Releasing the pre-warm floor of revision ", pa.Name)
+		patch, err := resources.MakePreWarmPatch(0, "")
+		if err != nil {
+			return err
+		}
+		if _, err := rec.client.AutoscalingV1alpha1().PodAutoscalers(r.Namespace).Patch(
+			ctx, pa.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to patch PodAutoscaler %q: %w", pa.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 
 	// Inject the fake informers that this controller needs.
 	servingclient "knative.dev/serving/pkg/client/injection/client/fake"
+	_ "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/podautoscaler/fake"
 	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration/fake"
 	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision/fake"
 	_ "knative.dev/serving/pkg/client/injection/informers/serving/v1/route/fake"
@@ -41,9 +42,12 @@ import (
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/ptr"
 	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	av1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	cfgmap "knative.dev/serving/pkg/apis/config"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	autoscalercfg "knative.dev/serving/pkg/autoscaler/config"
+	"knative.dev/serving/pkg/gc"
 
 	. "knative.dev/pkg/reconciler/testing"
 	. "knative.dev/serving/pkg/reconciler/testing/v1"
@@ -111,6 +115,24 @@ func TestV2Reconcile(t *testing.T) {
 				WithRoutingStateModified(now.Time)),
 		},
 		Key: "default/steady-state",
+	}, {
+		Name: "label mirror revision",
+		Objects: []runtime.Object{
+			simpleRunLatest("default", "mirrored-route", "the-config", WithRouteFinalizer,
+				WithSpecTraffic(mirrorTraffic(configTraffic("the-config"), "the-mirror"))),
+			simpleConfig("default", "the-config",
+				WithConfigAnn("serving.knative.dev/routes", "mirrored-route")),
+			rev("default", "the-config",
+				WithRevisionAnn("serving.knative.dev/routes", "mirrored-route"),
+				WithRoutingState(v1.RoutingStateActive, clock),
+				WithRoutingStateModified(now.Time)),
+			rev("default", "the-config", WithRevName("the-mirror")),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchAddRouteAndServingStateLabel(
+				"default", "the-mirror", "mirrored-route", now.Time),
+		},
+		Key: "default/mirrored-route",
 	}, {
 		Name: "no ready revision",
 		Objects: []runtime.Object{
@@ -261,6 +283,24 @@ func TestV2Reconcile(t *testing.T) {
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", `Updated "delete-route" finalizers`),
 		},
 		Key: "default/delete-route",
+	}, {
+		Name: "delete route releases its pre-warm floors",
+		Objects: []runtime.Object{
+			simpleRunLatest("default", "delete-route", "the-config", WithRouteFinalizer, WithRouteDeletionTimestamp(&now)),
+			simpleConfig("default", "the-config",
+				WithConfigAnn("serving.knative.dev/routes", "delete-route")),
+			preWarmedPA("default", "the-config-pre-warmed", "delete-route"),
+			preWarmedPA("default", "other-config-pre-warmed", "another-route"),
+		},
+		WantPatches: []clientgotesting.PatchActionImpl{
+			patchRemoveRouteAnn("default", "the-config"),
+			patchReleasePreWarm("default", "the-config-pre-warmed"),
+			patchRemoveFinalizerAction("default", "delete-route"),
+		},
+		WantEvents: []string{
+			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", `Updated "delete-route" finalizers`),
+		},
+		Key: "default/delete-route",
 	}, {
 		Name:    "delete route failure",
 		WantErr: true,
@@ -343,8 +383,10 @@ func TestV2Reconcile(t *testing.T) {
 		rLister := listers.GetRevisionLister()
 		rIndexer := listers.IndexerFor(&v1.Revision{})
 		r := &Reconciler{
-			caccV2: newConfigurationAccessor(client, &NullTracker{}, cLister, cIndexer, clock),
-			raccV2: newRevisionAccessor(client, &NullTracker{}, rLister, rIndexer, clock),
+			caccV2:              newConfigurationAccessor(client, &NullTracker{}, cLister, cIndexer, clock),
+			raccV2:              newRevisionAccessor(client, &NullTracker{}, rLister, rIndexer, clock),
+			client:              client,
+			podAutoscalerLister: listers.GetPodAutoscalerLister(),
 		}
 
 		return routereconciler.NewReconciler(ctx, logging.FromContext(ctx), servingclient.Get(ctx),
@@ -360,6 +402,14 @@ func configTraffic(name string) v1.TrafficTarget {
 	}
 }
 
+func mirrorTraffic(tt v1.TrafficTarget, mirror string) v1.TrafficTarget {
+	tt.Mirror = &v1.TrafficMirror{
+		RevisionName: mirror,
+		Percent:      10,
+	}
+	return tt
+}
+
 func revTraffic(name string, latest bool) v1.TrafficTarget {
 	return v1.TrafficTarget{
 		RevisionName:   name,
@@ -422,6 +472,29 @@ func rev(namespace, name string, opts ...RevisionOption) *v1.Revision {
 	return rev
 }
 
+// preWarmedPA returns a PodAutoscaler with a pre-warm floor owned by the route.
+func preWarmedPA(namespace, name, route string) *av1alpha1.PodAutoscaler {
+	return &av1alpha1.PodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Annotations: map[string]string{
+				autoscaling.RolloutMinScaleAnnotationKey:      "3",
+				autoscaling.RolloutMinScaleOwnerAnnotationKey: route,
+			},
+		},
+	}
+}
+
+func patchReleasePreWarm(namespace, name string) clientgotesting.PatchActionImpl {
+	return clientgotesting.PatchActionImpl{
+		Name:       name,
+		ActionImpl: clientgotesting.ActionImpl{Namespace: namespace},
+		Patch: []byte(`{"metadata":{"annotations":{"autoscaling.knative.dev/rolloutMinScale":null,` +
+			`"autoscaling.knative.dev/rolloutMinScaleOwner":null}}}`),
+	}
+}
+
 func patchRemoveRouteAnn(namespace, name string) clientgotesting.PatchActionImpl {
 	return patchAddRouteAnn(namespace, name, "null")
 }
@@ -505,6 +578,12 @@ func TestNew(t *testing.T) {
 			Namespace: system.Namespace(),
 		},
 		Data: map[string]string{},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gc.ConfigName,
+			Namespace: system.Namespace(),
+		},
+		Data: map[string]string{},
 	})
 
 	c := NewController(ctx, configMapWatcher)
@@ -38,7 +38,15 @@ func syncRoutingMeta(ctx context.Context, r *v1.Route, cacc *configurationAccess
 
 	// Walk the Route's .status.traffic and .spec.traffic and build a list
 	// of revisions and configurations to label
-	for _, tt := range append(r.Status.Traffic, r.Spec.Traffic...) {
+	targets := append(r.Status.Traffic, r.Spec.Traffic...)
+	// The mirror revisions are routed copies of the requests, so they are
+	// labeled as well.
+	for _, tt := range r.Spec.Traffic {
+		if tt.Mirror != nil {
+			targets = append(targets, v1.TrafficTarget{RevisionName: tt.Mirror.RevisionName})
+		}
+	}
+	for _, tt := range targets {
 		revName := tt.RevisionName
 		configName := tt.ConfigurationName
 
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 
+	"k8s.io/apimachinery/pkg/types"
 	"knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	"knative.dev/serving/pkg/autoscaler/metrics"
 
@@ -53,5 +54,13 @@ func (r *reconciler) ReconcileKind(_ context.Context, metric *v1alpha1.Metric) p
 	}
 
 	metric.Status.MarkMetricReady()
+	key := types.NamespacedName{Namespace: metric.Namespace, Name: metric.Name}
+	if idle, ok := r.collector.IsIdle(key); ok {
+		if idle {
+			metric.Status.MarkInactive("NoTraffic", "The target has not received traffic for the stable window.")
+		} else {
+			metric.Status.MarkActive()
+		}
+	}
 	return nil
 }
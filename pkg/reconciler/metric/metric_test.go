@@ -165,6 +165,30 @@ func TestReconcile(t *testing.T) {
 			Object: metric("bad", "collector", failed("DidNotReceiveStat",
 				metrics.ErrDidNotReceiveStat.Error())),
 		}},
+	}, {
+		Name: "idle target",
+		Ctx: context.WithValue(context.Background(), collectorKey{},
+			&testCollector{idle: true, idleKnown: true},
+		),
+		Key: "status/idle",
+		Objects: []runtime.Object{
+			metric("status", "idle"),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: metric("status", "idle", ready, inactive),
+		}},
+	}, {
+		Name: "active target",
+		Ctx: context.WithValue(context.Background(), collectorKey{},
+			&testCollector{idleKnown: true},
+		),
+		Key: "status/active",
+		Objects: []runtime.Object{
+			metric("status", "active", ready, inactive),
+		},
+		WantStatusUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: metric("status", "active", ready, active),
+		}},
 	}}
 
 	table.Test(t, MakeFactory(func(ctx context.Context, listers *Listers, cmw configmap.Watcher) controller.Reconciler {
@@ -243,6 +267,14 @@ func ready(m *autoscalingv1alpha1.Metric) {
 	m.Status.MarkMetricReady()
 }
 
+func active(m *autoscalingv1alpha1.Metric) {
+	m.Status.MarkActive()
+}
+
+func inactive(m *autoscalingv1alpha1.Metric) {
+	m.Status.MarkInactive("NoTraffic", "The target has not received traffic for the stable window.")
+}
+
 func metric(namespace, name string, opts ...metricOption) *autoscalingv1alpha1.Metric {
 	m := &autoscalingv1alpha1.Metric{
 		ObjectMeta: metav1.ObjectMeta{
@@ -267,6 +299,8 @@ type testCollector struct {
 	createOrUpdateError error
 
 	deleteCalls atomic.Int32
+
+	idle, idleKnown bool
 }
 
 func (c *testCollector) CreateOrUpdate(metric *autoscalingv1alpha1.Metric) error {
@@ -279,3 +313,7 @@ func (c *testCollector) Delete(namespace, name string) {
 }
 
 func (c *testCollector) Watch(func(types.NamespacedName)) {}
+
+func (c *testCollector) IsIdle(types.NamespacedName) (bool, bool) {
+	return c.idle, c.idleKnown
+}
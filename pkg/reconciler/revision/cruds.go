@@ -94,6 +94,33 @@ func (c *Reconciler) checkAndUpdateDeployment(ctx context.Context, rev *v1.Revis
 	return d, nil
 }
 
+func (c *Reconciler) createWarmPool(ctx context.Context, rev *v1.Revision, size int32) (*appsv1.Deployment, error) {
+	pool, err := resources.MakeWarmPool(rev, config.FromContext(ctx), size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make warm pool: %w", err)
+	}
+	return c.kubeclient.AppsV1().Deployments(pool.Namespace).Create(ctx, pool, metav1.CreateOptions{})
+}
+
+func (c *Reconciler) checkAndUpdateWarmPool(ctx context.Context, rev *v1.Revision, have *appsv1.Deployment, size int32) (*appsv1.Deployment, error) {
+	pool, err := resources.MakeWarmPool(rev, config.FromContext(ctx), size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update warm pool: %w", err)
+	}
+
+	// Preserve the label selector since it's immutable.
+	pool.Spec.Selector = have.Spec.Selector
+
+	if equality.Semantic.DeepEqual(have.Spec, pool.Spec) {
+		return have, nil
+	}
+
+	desiredPool := have.DeepCopy()
+	desiredPool.Spec = pool.Spec
+	desiredPool.Labels = kmeta.UnionMaps(pool.Labels, desiredPool.Labels)
+	return c.kubeclient.AppsV1().Deployments(pool.Namespace).Update(ctx, desiredPool, metav1.UpdateOptions{})
+}
+
 func (c *Reconciler) createImageCache(ctx context.Context, rev *v1.Revision, containerName, imageDigest string) (*caching.Image, error) {
 	image := resources.MakeImageCache(rev, containerName, imageDigest)
 	return c.cachingclient.CachingV1alpha1().Images(image.Namespace).Create(ctx, image, metav1.CreateOptions{})
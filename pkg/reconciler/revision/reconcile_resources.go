@@ -19,6 +19,7 @@ package revision
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"go.uber.org/zap"
 
@@ -32,6 +33,7 @@ import (
 	"knative.dev/pkg/kmp"
 	"knative.dev/pkg/logging"
 	"knative.dev/pkg/logging/logkey"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	"knative.dev/serving/pkg/reconciler/revision/resources"
 	resourcenames "knative.dev/serving/pkg/reconciler/revision/resources/names"
@@ -115,6 +117,53 @@ func (c *Reconciler) reconcileDeployment(ctx context.Context, rev *v1.Revision)
 	return nil
 }
 
+func (c *Reconciler) reconcileWarmPool(ctx context.Context, rev *v1.Revision) error {
+	ns := rev.Namespace
+	poolName := resourcenames.WarmPool(rev)
+	logger := logging.FromContext(ctx).With(zap.String(logkey.Deployment, poolName))
+
+	size := warmPoolSize(rev)
+	pool, err := c.deploymentLister.Deployments(ns).Get(poolName)
+	switch {
+	case apierrs.IsNotFound(err):
+		if size == 0 {
+			return nil
+		}
+		if _, err := c.createWarmPool(ctx, rev, size); err != nil {
+			return fmt.Errorf("failed to create warm pool %q: %w", poolName, err)
+		}
+		logger.Infof("Created warm pool %q", poolName)
+	case err != nil:
+		return fmt.Errorf("failed to get warm pool %q: %w", poolName, err)
+	case !metav1.IsControlledBy(pool, rev):
+		// Surface an error in the revision's status, and return an error.
+		rev.Status.MarkResourcesAvailableFalse(v1.ReasonNotOwned, v1.ResourceNotOwnedMessage("Deployment", poolName))
+		return fmt.Errorf("revision: %q does not own Deployment: %q", rev.Name, poolName)
+	case size == 0:
+		if err := c.kubeclient.AppsV1().Deployments(ns).Delete(ctx, poolName, metav1.DeleteOptions{}); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("failed to delete warm pool %q: %w", poolName, err)
+		}
+		logger.Infof("Deleted warm pool %q", poolName)
+	default:
+		if _, err := c.checkAndUpdateWarmPool(ctx, rev, pool, size); err != nil {
+			return fmt.Errorf("failed to update warm pool %q: %w", poolName, err)
+		}
+	}
+	return nil
+}
+
+// warmPoolSize returns the number of pods the warm pool of the revision keeps
+// ready. Only the revisions the routes can send traffic to keep a warm pool.
+func warmPoolSize(rev *v1.Revision) int32 {
+	ann, ok := rev.Annotations[autoscaling.WarmPoolSizeAnnotationKey]
+	if !ok || !rev.IsReachable() {
+		return 0
+	}
+	// Ignore errors and no error checking because already validated in webhook.
+	size, _ := strconv.ParseInt(ann, 10, 32)
+	return int32(size)
+}
+
 func (c *Reconciler) reconcileImageCache(ctx context.Context, rev *v1.Revision) error {
 	logger := logging.FromContext(ctx)
 
@@ -226,6 +226,20 @@ func buildUserPortEnv(userPort string) corev1.EnvVar {
 	}
 }
 
+// MakeWarmPool constructs the K8s Deployment resource of the warm pool of a
+// revision, keeping size pods of the revision ready out of its endpoints.
+func MakeWarmPool(rev *v1.Revision, cfg *config.Config, size int32) (*appsv1.Deployment, error) {
+	deployment, err := MakeDeployment(rev, cfg)
+	if err != nil {
+		return nil, err
+	}
+	deployment.Name = names.WarmPool(rev)
+	deployment.Spec.Replicas = ptr.Int32(size)
+	deployment.Spec.Selector = makeWarmPoolSelector(rev)
+	deployment.Spec.Template.Labels = makeWarmPoolLabels(rev)
+	return deployment, nil
+}
+
 // MakeDeployment constructs a K8s Deployment resource from a revision.
 func MakeDeployment(rev *v1.Revision, cfg *config.Config) (*appsv1.Deployment, error) {
 	podSpec, err := makePodSpec(rev, cfg)
@@ -181,6 +181,21 @@ var (
 		}, {
 			Name:  "METRICS_COLLECTOR_ADDRESS",
 			Value: "",
+		}, {
+			Name:  "CUSTOM_METRIC_NAME",
+			Value: "",
+		}, {
+			Name:  "CUSTOM_METRIC_PATH",
+			Value: "",
+		}, {
+			Name:  "QUEUE_PRIORITY_CLASSES",
+			Value: "",
+		}, {
+			Name:  "QUEUE_MAX_WAIT",
+			Value: "",
+		}, {
+			Name:  "QUEUE_RATE_LIMITS",
+			Value: "",
 		}},
 	}
 
@@ -1259,3 +1274,46 @@ func TestMakeDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeWarmPool(t *testing.T) {
+	rev := revision("bar", "foo",
+		withoutLabels,
+		withContainers([]corev1.Container{{
+			Name:           servingContainerName,
+			Image:          "ubuntu",
+			ReadinessProbe: withTCPReadinessProbe(12345),
+		}}),
+		WithContainerStatuses([]v1.ContainerStatus{{
+			ImageDigest: "busybox@sha256:deadbeef",
+		}}))
+	cfg := (&revCfg).DeepCopy()
+	cfg.Autoscaler = &autoscalerconfig.Config{InitialScale: 1}
+	cfg.Deployment = &deployment.Config{}
+
+	podSpec, err := makePodSpec(rev, cfg)
+	if err != nil {
+		t.Fatal("makePodSpec returned error:", err)
+	}
+	want := appsv1deployment(func(deploy *appsv1.Deployment) {
+		deploy.Name = "bar-warm-pool"
+		deploy.Spec.Replicas = ptr.Int32(3)
+		deploy.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				serving.WarmPoolLabelKey: "1234",
+			},
+		}
+		deploy.Spec.Template.Labels = map[string]string{
+			serving.WarmPoolLabelKey: "1234",
+			AppLabelKey:              "bar",
+		}
+		deploy.Spec.Template.Spec = *podSpec
+	})
+
+	got, err := MakeWarmPool(rev, cfg, 3)
+	if err != nil {
+		t.Fatal("Got unexpected error:", err)
+	}
+	if diff := cmp.Diff(want, got, quantityComparer); diff != "" {
+		t.Errorf("MakeWarmPool (-want, +got) =\n%s", diff)
+	}
+}
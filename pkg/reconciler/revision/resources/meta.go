@@ -59,6 +59,27 @@ func makeAnnotations(revision *v1.Revision) map[string]string {
 	return kmeta.FilterMap(revision.GetAnnotations(), excludeAnnotations.Has)
 }
 
+// makeWarmPoolLabels constructs the labels we will apply to the pods of the
+// warm pool. They reference the revision by WarmPoolLabelKey rather than by
+// RevisionLabelKey and RevisionUID, so the pods are not in the serving
+// endpoints and not counted by the autoscaler until they are promoted.
+func makeWarmPoolLabels(revision *v1.Revision) map[string]string {
+	labels := makeLabels(revision)
+	delete(labels, serving.RevisionLabelKey)
+	delete(labels, serving.RevisionUID)
+	labels[serving.WarmPoolLabelKey] = string(revision.UID)
+	return labels
+}
+
+// makeWarmPoolSelector constructs the Selector we will apply to the warm pool.
+func makeWarmPoolSelector(revision *v1.Revision) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			serving.WarmPoolLabelKey: string(revision.UID),
+		},
+	}
+}
+
 // makeSelector constructs the Selector we will apply to K8s resources.
 func makeSelector(revision *v1.Revision) *metav1.LabelSelector {
 	return &metav1.LabelSelector{
@@ -23,6 +23,11 @@ func Deployment(rev kmeta.Accessor) string {
 	return kmeta.ChildName(rev.GetName(), "-deployment")
 }
 
+// WarmPool returns the precomputed name for the revision warm pool deployment.
+func WarmPool(rev kmeta.Accessor) string {
+	return kmeta.ChildName(rev.GetName(), "-warm-pool")
+}
+
 // ImageCache returns the precomputed name for the image cache.
 func ImageCache(rev kmeta.Accessor) string {
 	return kmeta.ChildName(rev.GetName(), "-cache")
@@ -59,6 +59,15 @@ func TestNamer(t *testing.T) {
 		},
 		f:    Deployment,
 		want: "foo-deployment",
+	}, {
+		name: "WarmPool",
+		rev: &v1.Revision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		f:    WarmPool,
+		want: "foo-warm-pool",
 	}, {
 		name: "ImageCache, barely fits",
 		rev: &v1.Revision{
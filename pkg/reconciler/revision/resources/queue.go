@@ -32,6 +32,7 @@ import (
 	"knative.dev/pkg/profiling"
 	"knative.dev/pkg/ptr"
 	"knative.dev/pkg/system"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	"knative.dev/serving/pkg/deployment"
@@ -235,6 +236,19 @@ func makeQueueContainer(rev *v1.Revision, cfg *config.Config) (*corev1.Container
 
 	container := rev.Spec.GetContainer()
 
+	// The application-defined metric to scale on, if any, is scraped
+	// from the user container by the queue-proxy. The HPA class reads
+	// the custom metrics from the Kubernetes custom metrics API instead.
+	var customMetricName, customMetricPath string
+	anns := rev.GetAnnotations()
+	if anns[autoscaling.MetricAnnotationKey] == autoscaling.Custom && isKPAClass(anns, cfg) {
+		customMetricName = anns[autoscaling.MetricNameAnnotationKey]
+		customMetricPath = autoscaling.MetricPathDefault
+		if p, ok := anns[autoscaling.MetricPathAnnotationKey]; ok {
+			customMetricPath = p
+		}
+	}
+
 	// During startup we want to poll the container faster than Kubernetes will
 	// allow, so we use an ExecProbe which starts immediately and then polls
 	// every 25ms. We encode the original probe as JSON in an environment
@@ -356,6 +370,21 @@ func makeQueueContainer(rev *v1.Revision, cfg *config.Config) (*corev1.Container
 		}, {
 			Name:  "METRICS_COLLECTOR_ADDRESS",
 			Value: cfg.Observability.MetricsCollectorAddress,
+		}, {
+			Name:  "CUSTOM_METRIC_NAME",
+			Value: customMetricName,
+		}, {
+			Name:  "CUSTOM_METRIC_PATH",
+			Value: customMetricPath,
+		}, {
+			Name:  "QUEUE_PRIORITY_CLASSES",
+			Value: anns[serving.QueueSideCarPriorityClassesAnnotation],
+		}, {
+			Name:  "QUEUE_MAX_WAIT",
+			Value: anns[serving.MaxQueueWaitAnnotationKey],
+		}, {
+			Name:  "QUEUE_RATE_LIMITS",
+			Value: anns[serving.RateLimitAnnotationKey],
 		}},
 	}, nil
 }
@@ -393,3 +422,13 @@ func applyReadinessProbeDefaultsForExec(p *corev1.Probe, port int32) {
 		p.TimeoutSeconds = 1
 	}
 }
+
+// isKPAClass returns true if the revision is autoscaled by the KPA, either
+// explicitly or through the cluster default class.
+func isKPAClass(anns map[string]string, cfg *config.Config) bool {
+	class, ok := anns[autoscaling.ClassAnnotationKey]
+	if !ok {
+		class = cfg.Autoscaler.PodAutoscalerClass
+	}
+	return class == autoscaling.KPA
+}
@@ -40,6 +40,7 @@ import (
 	"knative.dev/pkg/ptr"
 	"knative.dev/pkg/system"
 	tracingconfig "knative.dev/pkg/tracing/config"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	apicfg "knative.dev/serving/pkg/apis/config"
 	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -71,6 +72,7 @@ var (
 	asConfig = autoscalerconfig.Config{
 		InitialScale:          1,
 		AllowZeroInitialScale: false,
+		PodAutoscalerClass:    autoscaling.KPA,
 	}
 	deploymentConfig = deployment.Config{
 		ProgressDeadline: 5678 * time.Second,
@@ -281,6 +283,119 @@ func TestMakeQueueContainer(t *testing.T) {
 			})
 			c.Ports = append(queueNonServingPorts, profilingPort, queueHTTPPort)
 		}),
+	}, {
+		name: "custom metric",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					autoscaling.MetricAnnotationKey:     autoscaling.Custom,
+					autoscaling.MetricNameAnnotationKey: "queue_depth",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{
+				"CUSTOM_METRIC_NAME": "queue_depth",
+				"CUSTOM_METRIC_PATH": autoscaling.MetricPathDefault,
+			})
+		}),
+	}, {
+		name: "priority classes",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					serving.QueueSideCarPriorityClassesAnnotation: "interactive=10,bulk=100",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{
+				"QUEUE_PRIORITY_CLASSES": "interactive=10,bulk=100",
+			})
+		}),
+	}, {
+		name: "max queue wait",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					serving.MaxQueueWaitAnnotationKey: "2s",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{
+				"QUEUE_MAX_WAIT": "2s",
+			})
+		}),
+	}, {
+		name: "rate limits",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					serving.RateLimitAnnotationKey: "100/20,canary=5",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{
+				"QUEUE_RATE_LIMITS": "100/20,canary=5",
+			})
+		}),
+	}, {
+		name: "custom metric with path",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					autoscaling.MetricAnnotationKey:     autoscaling.Custom,
+					autoscaling.MetricNameAnnotationKey: "queue_depth",
+					autoscaling.MetricPathAnnotationKey: "/stats",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{
+				"CUSTOM_METRIC_NAME": "queue_depth",
+				"CUSTOM_METRIC_PATH": "/stats",
+			})
+		}),
+	}, {
+		name: "custom metric, hpa class",
+		rev: revision("bar", "foo",
+			withContainers(containers),
+			func(revision *v1.Revision) {
+				revision.Annotations = map[string]string{
+					autoscaling.ClassAnnotationKey:      autoscaling.HPA,
+					autoscaling.MetricAnnotationKey:     autoscaling.Custom,
+					autoscaling.MetricNameAnnotationKey: "queue_depth",
+				}
+			},
+		),
+		dc: deployment.Config{
+			ProgressDeadline: 5678 * time.Second,
+		},
+		want: queueContainer(func(c *corev1.Container) {
+			c.Env = env(map[string]string{})
+		}),
 	}, {
 		name: "custom TimeoutSeconds",
 		dc: deployment.Config{
@@ -362,6 +477,7 @@ func TestMakeQueueContainer(t *testing.T) {
 				}
 			}
 			cfg := &config.Config{
+				Config:        revCfg.Config,
 				Tracing:       &traceConfig,
 				Logging:       &test.lc,
 				Observability: &test.oc,
@@ -875,9 +991,14 @@ func TestTCPProbeGeneration(t *testing.T) {
 
 var defaultEnv = map[string]string{
 	"CONTAINER_CONCURRENCY":                 "0",
+	"CUSTOM_METRIC_NAME":                    "",
+	"CUSTOM_METRIC_PATH":                    "",
 	"ENABLE_PROFILING":                      "false",
 	"METRICS_DOMAIN":                        metrics.Domain(),
 	"METRICS_COLLECTOR_ADDRESS":             "",
+	"QUEUE_MAX_WAIT":                        "",
+	"QUEUE_PRIORITY_CLASSES":                "",
+	"QUEUE_RATE_LIMITS":                     "",
 	"QUEUE_SERVING_PORT":                    "8012",
 	"REVISION_TIMEOUT_SECONDS":              "45",
 	"SERVING_CONFIGURATION":                 "",
@@ -149,6 +149,7 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, rev *v1.Revision) pkgrec
 
 	for _, phase := range []func(context.Context, *v1.Revision) error{
 		c.reconcileDeployment,
+		c.reconcileWarmPool,
 		c.reconcileImageCache,
 		c.reconcilePA,
 	} {
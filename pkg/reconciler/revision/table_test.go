@@ -38,6 +38,7 @@ import (
 	"knative.dev/pkg/metrics"
 	pkgreconciler "knative.dev/pkg/reconciler"
 	tracingconfig "knative.dev/pkg/tracing/config"
+	"knative.dev/serving/pkg/apis/autoscaling"
 	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	defaultconfig "knative.dev/serving/pkg/apis/config"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -414,6 +415,58 @@ func TestReconcile(t *testing.T) {
 				WithPAStatusService("fix-mutated-pa"), WithReachabilityReachable),
 		}},
 		Key: "foo/fix-mutated-pa",
+	}, {
+		Name: "create warm pool",
+		Objects: []runtime.Object{
+			Revision("foo", "warm-pool", WithK8sServiceName, WithLogURL, MarkRevisionReady,
+				WithRoutingState(v1.RoutingStateActive, fc), withWarmPoolSize("2"),
+				withDefaultContainerStatuses(), WithRevisionObservedGeneration(1)),
+			pa("foo", "warm-pool", WithTraffic, WithPASKSReady, WithScaleTargetInitialized,
+				WithReachabilityReachable, WithPAStatusService("warm-pool")),
+			deploy(t, "foo", "warm-pool", withWarmPoolSize("2")),
+			image("foo", "warm-pool"),
+		},
+		WantCreates: []runtime.Object{
+			warmPool(t, "foo", "warm-pool", 2, withWarmPoolSize("2")),
+		},
+		Key: "foo/warm-pool",
+	}, {
+		Name: "resize warm pool",
+		Objects: []runtime.Object{
+			Revision("foo", "warm-pool", WithK8sServiceName, WithLogURL, MarkRevisionReady,
+				WithRoutingState(v1.RoutingStateActive, fc), withWarmPoolSize("3"),
+				withDefaultContainerStatuses(), WithRevisionObservedGeneration(1)),
+			pa("foo", "warm-pool", WithTraffic, WithPASKSReady, WithScaleTargetInitialized,
+				WithReachabilityReachable, WithPAStatusService("warm-pool")),
+			deploy(t, "foo", "warm-pool", withWarmPoolSize("3")),
+			warmPool(t, "foo", "warm-pool", 2, withWarmPoolSize("3")),
+			image("foo", "warm-pool"),
+		},
+		WantUpdates: []clientgotesting.UpdateActionImpl{{
+			Object: warmPool(t, "foo", "warm-pool", 3, withWarmPoolSize("3")),
+		}},
+		Key: "foo/warm-pool",
+	}, {
+		Name: "unreachable revision has no warm pool",
+		Objects: []runtime.Object{
+			Revision("foo", "warm-pool", WithK8sServiceName, WithLogURL, MarkRevisionReady,
+				WithRoutingState(v1.RoutingStateReserve, fc), withWarmPoolSize("2"),
+				withDefaultContainerStatuses(), WithRevisionObservedGeneration(1)),
+			pa("foo", "warm-pool", WithTraffic, WithPASKSReady, WithScaleTargetInitialized,
+				WithReachabilityUnreachable, WithPAStatusService("warm-pool")),
+			deploy(t, "foo", "warm-pool", withWarmPoolSize("2")),
+			warmPool(t, "foo", "warm-pool", 2, withWarmPoolSize("2")),
+			image("foo", "warm-pool"),
+		},
+		WantDeletes: []clientgotesting.DeleteActionImpl{{
+			ActionImpl: clientgotesting.ActionImpl{
+				Namespace: "foo",
+				Verb:      "delete",
+				Resource:  appsv1.SchemeGroupVersion.WithResource("deployments"),
+			},
+			Name: "warm-pool-warm-pool",
+		}},
+		Key: "foo/warm-pool",
 	}, {
 		Name: "mutated pa gets error during the fix",
 		// Same as above, but will fail during the update.
@@ -758,6 +811,21 @@ func deploy(t *testing.T, namespace, name string, opts ...interface{}) *appsv1.D
 	return deployment
 }
 
+func withWarmPoolSize(size string) RevisionOption {
+	return WithRevisionAnn(autoscaling.WarmPoolSizeAnnotationKey, size)
+}
+
+func warmPool(t *testing.T, namespace, name string, size int32, opts ...RevisionOption) *appsv1.Deployment {
+	t.Helper()
+	rev := Revision(namespace, name, opts...)
+	rev.SetDefaults(context.Background())
+	pool, err := resources.MakeWarmPool(rev, reconcilerTestConfig(), size)
+	if err != nil {
+		t.Fatal("failed to create warm pool")
+	}
+	return pool
+}
+
 func image(namespace, name string, co ...configOption) *caching.Image {
 	config := reconcilerTestConfig()
 	for _, opt := range co {
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	cm "knative.dev/pkg/configmap"
+)
+
+// RolloutAnalysisConfigName is the config map name for the rollout analysis configuration.
+const RolloutAnalysisConfigName = "config-rollout-analysis"
+
+// RolloutAnalysis is the configuration of the analysis of the metrics of the
+// revisions being rolled out.
+type RolloutAnalysis struct {
+	// PrometheusURL is the URL of the Prometheus server scraping the request
+	// metrics of queue-proxy. The rollouts are not analyzed if it is empty.
+	PrometheusURL string
+
+	// TrafficObservationInterval is the interval between the observations of
+	// the split of the traffic of the Routes, in the request metrics of the
+	// Prometheus server. The traffic is not observed if it is 0, or without
+	// a PrometheusURL.
+	TrafficObservationInterval time.Duration
+
+	// TrafficDeviationTolerance is the number of percentage points the
+	// observed share of a revision may deviate from the configured one.
+	TrafficDeviationTolerance int64
+}
+
+// TrafficObserved returns true if the split of the traffic of the Routes
+// is to be observed.
+func (ra *RolloutAnalysis) TrafficObserved() bool {
+	return ra != nil && ra.PrometheusURL != "" && ra.TrafficObservationInterval > 0
+}
+
+// NewRolloutAnalysisFromConfigMap creates a RolloutAnalysis from the supplied ConfigMap.
+func NewRolloutAnalysisFromConfigMap(configMap *corev1.ConfigMap) (*RolloutAnalysis, error) {
+	ra := &RolloutAnalysis{
+		TrafficDeviationTolerance: 10,
+	}
+	if err := cm.Parse(configMap.Data,
+		cm.AsString("prometheus-url", &ra.PrometheusURL),
+		cm.AsDuration("traffic-observation-interval", &ra.TrafficObservationInterval),
+		cm.AsInt64("traffic-deviation-tolerance", &ra.TrafficDeviationTolerance),
+	); err != nil {
+		return nil, fmt.Errorf("failed to parse data: %w", err)
+	}
+	if ra.TrafficObservationInterval < 0 {
+		return nil, fmt.Errorf("traffic-observation-interval = %v must be non-negative", ra.TrafficObservationInterval)
+	}
+	if ra.TrafficDeviationTolerance < 0 || ra.TrafficDeviationTolerance > 100 {
+		return nil, fmt.Errorf("traffic-deviation-tolerance = %d must be in [0, 100]", ra.TrafficDeviationTolerance)
+	}
+	if ra.PrometheusURL != "" {
+		u, err := url.Parse(ra.PrometheusURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("prometheus-url = %q must be an absolute URL", ra.PrometheusURL)
+		}
+	}
+	return ra, nil
+}
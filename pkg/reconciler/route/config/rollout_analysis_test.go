@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/pkg/system"
+
+	. "knative.dev/pkg/configmap/testing"
+	_ "knative.dev/pkg/system/testing"
+)
+
+func TestOurRolloutAnalysis(t *testing.T) {
+	cm, example := ConfigMapsFromTestFile(t, RolloutAnalysisConfigName)
+	if _, err := NewRolloutAnalysisFromConfigMap(cm); err != nil {
+		t.Error("NewRolloutAnalysisFromConfigMap(actual) =", err)
+	}
+	got, err := NewRolloutAnalysisFromConfigMap(example)
+	if err != nil {
+		t.Fatal("NewRolloutAnalysisFromConfigMap(example) =", err)
+	}
+	want := &RolloutAnalysis{
+		PrometheusURL:             "http://prometheus.monitoring.svc.cluster.local:9090",
+		TrafficDeviationTolerance: 10,
+	}
+	if !cmp.Equal(got, want) {
+		t.Error("Example config (-want, +got):", cmp.Diff(want, got))
+	}
+}
+
+func TestRolloutAnalysisConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    *RolloutAnalysis
+		wantErr bool
+	}{{
+		name: "default",
+		data: map[string]string{},
+		want: &RolloutAnalysis{TrafficDeviationTolerance: 10},
+	}, {
+		name: "prometheus url",
+		data: map[string]string{"prometheus-url": "http://prometheus:9090"},
+		want: &RolloutAnalysis{PrometheusURL: "http://prometheus:9090", TrafficDeviationTolerance: 10},
+	}, {
+		name: "traffic observation",
+		data: map[string]string{
+			"traffic-observation-interval": "1m",
+			"traffic-deviation-tolerance":  "5",
+		},
+		want: &RolloutAnalysis{TrafficObservationInterval: time.Minute, TrafficDeviationTolerance: 5},
+	}, {
+		name:    "negative interval",
+		data:    map[string]string{"traffic-observation-interval": "-1m"},
+		wantErr: true,
+	}, {
+		name:    "tolerance out of bounds",
+		data:    map[string]string{"traffic-deviation-tolerance": "101"},
+		wantErr: true,
+	}, {
+		name:    "relative url",
+		data:    map[string]string{"prometheus-url": "prometheus:9090/api"},
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewRolloutAnalysisFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: system.Namespace(),
+					Name:      RolloutAnalysisConfigName,
+				},
+				Data: tc.data,
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewRolloutAnalysisFromConfigMap() = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error("NewRolloutAnalysisFromConfigMap (-want, +got):", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+}
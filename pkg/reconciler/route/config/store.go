@@ -31,10 +31,11 @@ type cfgKey struct{}
 // Config is the configuration for the route reconciler.
 // +k8s:deepcopy-gen=false
 type Config struct {
-	Domain   *Domain
-	GC       *gc.Config
-	Network  *network.Config
-	Features *cfgmap.Features
+	Domain          *Domain
+	GC              *gc.Config
+	Network         *network.Config
+	Features        *cfgmap.Features
+	RolloutAnalysis *RolloutAnalysis
 }
 
 // FromContext obtains a Config injected into the passed context.
@@ -54,6 +55,10 @@ func FromContextOrDefaults(ctx context.Context) *Config {
 		cfg.Features, _ = cfgmap.NewFeaturesConfigFromMap(map[string]string{})
 	}
 
+	if cfg.RolloutAnalysis == nil {
+		cfg.RolloutAnalysis = &RolloutAnalysis{}
+	}
+
 	return cfg
 }
 
@@ -91,6 +96,7 @@ func NewStore(ctx context.Context, onAfterStore ...func(name string, value inter
 				gc.ConfigName:             gc.NewConfigFromConfigMapFunc(ctx),
 				network.ConfigName:        network.NewConfigFromConfigMap,
 				cfgmap.FeaturesConfigName: cfgmap.NewFeaturesConfigFromConfigMap,
+				RolloutAnalysisConfigName: NewRolloutAnalysisFromConfigMap,
 			},
 			onAfterStore...,
 		),
@@ -107,15 +113,20 @@ func (s *Store) ToContext(ctx context.Context) context.Context {
 // Load creates a Config for this store.
 func (s *Store) Load() *Config {
 	config := &Config{
-		Domain:   s.UntypedLoad(DomainConfigName).(*Domain).DeepCopy(),
-		GC:       s.UntypedLoad(gc.ConfigName).(*gc.Config).DeepCopy(),
-		Network:  s.UntypedLoad(network.ConfigName).(*network.Config).DeepCopy(),
-		Features: nil,
+		Domain:          s.UntypedLoad(DomainConfigName).(*Domain).DeepCopy(),
+		GC:              s.UntypedLoad(gc.ConfigName).(*gc.Config).DeepCopy(),
+		Network:         s.UntypedLoad(network.ConfigName).(*network.Config).DeepCopy(),
+		Features:        nil,
+		RolloutAnalysis: &RolloutAnalysis{},
 	}
 
 	if featureConfig := s.UntypedLoad(cfgmap.FeaturesConfigName); featureConfig != nil {
 		config.Features = featureConfig.(*cfgmap.Features).DeepCopy()
 	}
 
+	if analysisConfig := s.UntypedLoad(RolloutAnalysisConfigName); analysisConfig != nil {
+		config.RolloutAnalysis = analysisConfig.(*RolloutAnalysis).DeepCopy()
+	}
+
 	return config
 }
@@ -37,11 +37,13 @@ func TestStoreLoadWithContext(t *testing.T) {
 	gcConfig := ConfigMapFromTestFile(t, gc.ConfigName)
 	networkConfig := ConfigMapFromTestFile(t, network.ConfigName)
 	featureConfig := ConfigMapFromTestFile(t, cfgmap.FeaturesConfigName)
+	analysisConfig := ConfigMapFromTestFile(t, RolloutAnalysisConfigName)
 
 	store.OnConfigChanged(domainConfig)
 	store.OnConfigChanged(gcConfig)
 	store.OnConfigChanged(networkConfig)
 	store.OnConfigChanged(featureConfig)
+	store.OnConfigChanged(analysisConfig)
 
 	config := FromContext(store.ToContext(context.Background()))
 
@@ -61,6 +63,13 @@ func TestStoreLoadWithContext(t *testing.T) {
 			t.Error("Unexpected controller config (-want, +got):", diff)
 		}
 	})
+
+	t.Run("rollout-analysis", func(t *testing.T) {
+		expected, _ := NewRolloutAnalysisFromConfigMap(analysisConfig)
+		if diff := cmp.Diff(expected, config.RolloutAnalysis); diff != "" {
+			t.Error("Unexpected controller config (-want, +got):", diff)
+		}
+	})
 }
 
 func TestStoreLoadWithContextOrDefaults(t *testing.T) {
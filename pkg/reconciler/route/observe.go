@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/pkg/logging"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/config"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// trafficObservations caches the requests recently served by the revisions
+// of the Routes. The requests are observed in the background, so that the
+// reconciliations don't wait for the metrics, and they use the previous
+// observation until the next one is in.
+type trafficObservations struct {
+	mu       sync.Mutex
+	entries  map[types.NamespacedName]trafficObservation
+	inFlight map[types.NamespacedName]struct{}
+}
+
+type trafficObservation struct {
+	at       time.Time
+	requests map[string]float64
+	err      error
+}
+
+// covers returns whether the observation includes all the revisions
+// receiving a percentage of the traffic.
+func (o trafficObservation) covers(targets []v1.TrafficTarget) bool {
+	for _, tt := range targets {
+		if _, seen := o.requests[tt.RevisionName]; !seen && tt.Percent != nil && *tt.Percent > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// get returns the last observation of the route.
+func (o *trafficObservations) get(key types.NamespacedName) (trafficObservation, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e, ok := o.entries[key]
+	return e, ok
+}
+
+// start returns whether an observation of the route may start, which is
+// unless one is already in flight.
+func (o *trafficObservations) start(key types.NamespacedName) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.inFlight[key]; ok {
+		return false
+	}
+	if o.inFlight == nil {
+		o.inFlight = make(map[types.NamespacedName]struct{}, 1)
+	}
+	o.inFlight[key] = struct{}{}
+	return true
+}
+
+// finish records the observation of the route, and forgets the
+// observations older than the given time.
+func (o *trafficObservations) finish(key types.NamespacedName, e trafficObservation, expiry time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.inFlight, key)
+	if o.entries == nil {
+		o.entries = make(map[types.NamespacedName]trafficObservation, 1)
+	}
+	for k, e := range o.entries {
+		if e.at.Before(expiry) {
+			delete(o.entries, k)
+		}
+	}
+	o.entries[key] = e
+}
+
+// observeTraffic publishes the observed share of the requests of the traffic
+// targets in the status of the route, and whether it deviates from the
+// configured split beyond the tolerance.
+func (c *Reconciler) observeTraffic(ctx context.Context, r *v1.Route, ra *config.RolloutAnalysis) {
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+	o, ok := c.observations.get(key)
+	// The observation is due when it's old, or when the traffic moved
+	// to revisions missing from it.
+	if !ok || o.at.Before(c.clock.Now().Add(-ra.TrafficObservationInterval)) ||
+		(o.err == nil && !o.covers(r.Status.Traffic)) {
+		c.startObservation(ctx, r, ra)
+	}
+
+	switch {
+	case !ok:
+		// Not observed yet.
+		return
+	case o.err != nil:
+		r.Status.MarkTrafficObservationFailed(o.err.Error())
+		return
+	case !o.covers(r.Status.Traffic):
+		// Keep the condition until the new revisions are observed.
+		return
+	}
+	switch o := traffic.ObserveTargets(r.Status.Traffic, o.requests); {
+	case o == nil:
+		r.Status.ClearTrafficObserved()
+	case o.Deviation() > ra.TrafficDeviationTolerance:
+		r.Status.MarkTrafficDeviation(o.RevisionName, o.Observed, o.Configured)
+	default:
+		r.Status.MarkTrafficObserved()
+	}
+}
+
+// startObservation observes the requests of the revisions of the route in
+// the background, unless an observation is already in flight, and reconciles
+// the route once it's in and again when the next one is due.
+func (c *Reconciler) startObservation(ctx context.Context, r *v1.Route, ra *config.RolloutAnalysis) {
+	key := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+	if !c.observations.start(key) {
+		return
+	}
+	logger := logging.FromContext(ctx)
+	src := c.metricsSource(ra.PrometheusURL)
+	targets := append([]v1.TrafficTarget(nil), r.Status.Traffic...)
+	interval := ra.TrafficObservationInterval
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Namespace: r.Namespace, Name: r.Name}}
+	go func() {
+		requests, err := traffic.ObserveRequests(logging.WithLogger(context.Background(), logger),
+			src, key.Namespace, targets, interval)
+		if err != nil {
+			logger.Warnw("Failed to observe the traffic", zap.Error(err))
+		}
+		now := c.clock.Now()
+		c.observations.finish(key, trafficObservation{at: now, requests: requests, err: err},
+			now.Add(-2*interval))
+		c.enqueueAfter(route, 0)
+		c.enqueueAfter(route, interval)
+	}()
+}
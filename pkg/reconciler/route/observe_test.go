@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/ptr"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/config"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+
+	. "knative.dev/serving/pkg/testing/v1"
+)
+
+// requestSource returns the split requests of the revisions keyed by name,
+// and counts the queries.
+type requestSource struct {
+	requests map[string]float64
+	err      error
+	queries  int
+}
+
+func (s *requestSource) RevisionMetrics(context.Context, string, string, time.Duration, []float64) (traffic.RevisionMetrics, error) {
+	return traffic.RevisionMetrics{}, errors.New("not the split requests")
+}
+
+func (s *requestSource) SplitRequests(_ context.Context, _, revision string, _ time.Duration) (float64, error) {
+	s.queries++
+	return s.requests[revision], s.err
+}
+
+func TestObserveTraffic(t *testing.T) {
+	fakeClock := clock.NewFakePassiveClock(time.Unix(19551982, 0))
+	src := &requestSource{requests: map[string]float64{"mjolnir": 55, "stormbreaker": 45}}
+	enqueued := make(chan time.Duration, 2)
+	c := &Reconciler{
+		clock:            fakeClock,
+		enqueueAfter:     func(_ interface{}, d time.Duration) { enqueued <- d },
+		newMetricsSource: func(string) traffic.MetricsSource { return src },
+	}
+	ra := &config.RolloutAnalysis{
+		PrometheusURL:              "http://prometheus:9090",
+		TrafficObservationInterval: time.Minute,
+		TrafficDeviationTolerance:  10,
+	}
+	ctx := TestContextWithLogger(t)
+
+	r := Route(testNamespace, "observed-route")
+	r.Status.Traffic = []v1.TrafficTarget{{
+		RevisionName: "mjolnir",
+		Percent:      ptr.Int64(50),
+	}, {
+		RevisionName: "stormbreaker",
+		Percent:      ptr.Int64(50),
+	}}
+	check := func(wantStatus corev1.ConditionStatus, wantObserved ...int64) {
+		t.Helper()
+		if got := r.Status.GetCondition(v1.RouteConditionTrafficObserved); got == nil || got.Status != wantStatus {
+			t.Errorf("TrafficObserved = %v, want: %v", got, wantStatus)
+		}
+		for i, want := range wantObserved {
+			if got := r.Status.Traffic[i].ObservedPercent; got == nil || *got != want {
+				t.Errorf("Traffic[%d].ObservedPercent = %v, want: %d", i, got, want)
+			}
+		}
+	}
+	// observe reconciles the route, which doesn't wait for the observation
+	// it starts, and reconciles it again once the observation is in.
+	observe := func(wantQueries int) {
+		t.Helper()
+		src.queries = 0
+		c.observeTraffic(ctx, r, ra)
+		if wantQueries > 0 {
+			if got := <-enqueued; got != 0 {
+				t.Errorf("Enqueued after %v, want: 0", got)
+			}
+			if got := <-enqueued; got != time.Minute {
+				t.Errorf("Enqueued after %v, want: %v", got, time.Minute)
+			}
+			c.observeTraffic(ctx, r, ra)
+		}
+		if src.queries != wantQueries {
+			t.Errorf("Queries = %d, want: %d", src.queries, wantQueries)
+		}
+		select {
+		case got := <-enqueued:
+			t.Error("Unexpectedly enqueued after", got)
+		default:
+		}
+	}
+
+	// Within the tolerance.
+	observe(2)
+	check(corev1.ConditionTrue, 55, 45)
+
+	// The observation is cached until the next one is due.
+	src.requests = map[string]float64{"mjolnir": 90, "stormbreaker": 10}
+	observe(0)
+	check(corev1.ConditionTrue, 55, 45)
+
+	// And then deviates.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute + time.Second))
+	observe(2)
+	check(corev1.ConditionFalse, 90, 10)
+
+	// The traffic moved to a revision missing from the cached observation.
+	r.Status.Traffic[1].RevisionName = "gungnir"
+	src.requests["gungnir"] = 10
+	observe(2)
+	check(corev1.ConditionFalse, 90, 10)
+
+	// The metrics are unavailable.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute + time.Second))
+	src.err = errors.New("prometheus is down")
+	observe(1)
+	check(corev1.ConditionUnknown)
+}
+
+func TestObserveTrafficInFlight(t *testing.T) {
+	src := &blockingSource{release: make(chan struct{})}
+	enqueued := make(chan time.Duration, 2)
+	c := &Reconciler{
+		clock:            clock.NewFakePassiveClock(time.Unix(19551982, 0)),
+		enqueueAfter:     func(_ interface{}, d time.Duration) { enqueued <- d },
+		newMetricsSource: func(string) traffic.MetricsSource { return src },
+	}
+	ra := &config.RolloutAnalysis{
+		PrometheusURL:              "http://prometheus:9090",
+		TrafficObservationInterval: time.Minute,
+	}
+	ctx := TestContextWithLogger(t)
+
+	r := Route(testNamespace, "observed-route")
+	r.Status.Traffic = []v1.TrafficTarget{{
+		RevisionName: "mjolnir",
+		Percent:      ptr.Int64(100),
+	}}
+
+	// The reconciliations don't wait for the observation, nor start another
+	// one while it's in flight.
+	c.observeTraffic(ctx, r, ra)
+	c.observeTraffic(ctx, r, ra)
+	if got := r.Status.GetCondition(v1.RouteConditionTrafficObserved); got != nil {
+		t.Errorf("TrafficObserved = %v, want: nil", got)
+	}
+	close(src.release)
+	<-enqueued
+	<-enqueued
+	if got := src.queries.Load(); got != 1 {
+		t.Errorf("Queries = %d, want: 1", got)
+	}
+}
+
+// blockingSource serves the split requests once released.
+type blockingSource struct {
+	requestSource
+	release chan struct{}
+	queries atomic.Int32
+}
+
+func (s *blockingSource) SplitRequests(context.Context, string, string, time.Duration) (float64, error) {
+	s.queries.Inc()
+	<-s.release
+	return 1, nil
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -31,12 +32,17 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"knative.dev/networking/pkg/apis/networking"
 	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	av1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	"knative.dev/serving/pkg/reconciler/route/config"
 	"knative.dev/serving/pkg/reconciler/route/resources"
@@ -232,20 +238,22 @@ func (c *Reconciler) reconcileRollout(
 	cfg := config.FromContext(ctx)
 
 	// Is there rollout duration specified?
-	rd := int(r.RolloutDuration().Seconds())
+	strategy := r.RolloutStrategy()
+	rd := int(strategy.Duration.Seconds())
 	if rd == 0 {
 		// If not, check if there's a cluster-wide default.
 		rd = cfg.Network.RolloutDurationSecs
 	}
 	curRO := tc.BuildRollout()
 	// When rollout is disabled just create the baseline annotation.
-	if rd <= 0 {
+	// Scheduled and blue/green rollouts need no duration.
+	if rd <= 0 && strategy.Type == serving.RolloutStrategyLinear {
 		return curRO
 	}
 	// Get the current rollout state as described by the traffic.
 	nextStepTime := int64(0)
 	logger := logging.FromContext(ctx).Desugar().With(
-		zap.Int("durationSecs", rd))
+		zap.String("strategy", string(strategy.Type)), zap.Int("durationSecs", rd))
 	logger.Debug("Rollout is enabled. Stepping from previous state.")
 	// Get the previous rollout state from the annotation.
 	// If it's corrupt, inexistent, or otherwise incorrect,
@@ -263,7 +271,23 @@ func (c *Reconciler) reconcileRollout(
 		prevRO.ObserveReady(ctx, now, float64(rd))
 	}
 
-	effectiveRO, nextStepTime := curRO.Step(ctx, prevRO, now)
+	// Apply the manual control and analyze the revisions due to step before stepping them.
+	if prevRO != nil {
+		prevRO.ApplyControl(ctx, r.Annotations[serving.RolloutControlKey],
+			r.Annotations[serving.RolloutControlModifierKey], now)
+	}
+	if ra := cfg.RolloutAnalysis; ra != nil && ra.PrometheusURL != "" && prevRO != nil {
+		if th := r.RolloutThresholds(); !th.IsEmpty() {
+			prevRO.Analyze(ctx, c.metricsSource(ra.PrometheusURL), r.Namespace, th, now)
+		}
+	}
+
+	// Cut the blue/green rollouts over to their warm revisions.
+	if prevRO != nil {
+		prevRO.CutOver(ctx, traffic.NewScaleSource(c.podAutoscalerLister), r.Namespace, now)
+	}
+
+	effectiveRO, nextStepTime := curRO.Step(ctx, prevRO, strategy, now)
 	if nextStepTime > 0 {
 		nextStepTime -= now
 		c.enqueueAfter(r, time.Duration(nextStepTime))
@@ -279,3 +303,83 @@ func (c *Reconciler) reconcileRollout(
 	}
 	return effectiveRO
 }
+
+// reconcilePreWarm raises the min scale of the newest revisions of the
+// rollouts in progress to the scale of the revisions they replace, for the
+// blue/green rollouts and all the others when the route opts in, and
+// releases it once the rollouts are done, or the route stops routing their
+// configurations. The labeler releases the floors of the deleted routes.
+func (c *Reconciler) reconcilePreWarm(ctx context.Context, r *v1.Route, tc *traffic.Config, ro *traffic.Rollout) error {
+	warm := ro.PreWarmScales(ctx, traffic.NewScaleSource(c.podAutoscalerLister), r.Namespace, r.RolloutPreWarm())
+
+	// The floors are owned by the route name, which can't be selected on,
+	// and the route may no longer route the configurations it pre-warmed.
+	pas, err := c.podAutoscalerLister.PodAutoscalers(r.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+	for _, pa := range pas {
+		_, routed := tc.Configurations[pa.Labels[serving.ConfigurationLabelKey]]
+		if !routed && pa.Annotations[autoscaling.RolloutMinScaleOwnerAnnotationKey] != r.Name {
+			continue
+		}
+		_, hasFloor := pa.Annotations[autoscaling.RolloutMinScaleAnnotationKey]
+		if hasFloor && !c.ownsFloor(r, pa) {
+			// The configuration is routed by several routes, and another
+			// one pre-warms the revision.
+			continue
+		}
+		floor, want := warm[pa.Name]
+		owner := ""
+		switch {
+		case want && (!hasFloor || pa.Annotations[autoscaling.RolloutMinScaleOwnerAnnotationKey] != r.Name):
+			// The floor is set once, at the start of the rollout, since the
+			// scale of the previous revisions drops as the traffic shifts.
+			if max, ok := maxScale(pa); ok && floor > max {
+				floor = max
+			}
+			logger.Infof("Pre-warming revision %s to %d pods", pa.Name, floor)
+			owner = r.Name
+		case !want && hasFloor:
+			logger.Info("Releasing the pre-warm floor of revision ", pa.Name)
+		default:
+			continue
+		}
+		patch, err := resources.MakePreWarmPatch(floor, owner)
+		if err != nil {
+			return err
+		}
+		if _, err := c.client.AutoscalingV1alpha1().PodAutoscalers(r.Namespace).Patch(
+			ctx, pa.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to patch PodAutoscaler %q: %w", pa.Name, err)
+		}
+	}
+	return nil
+}
+
+// ownsFloor returns whether the route may change the pre-warm floor of the
+// PodAutoscaler, i.e. it set it, or the route which set it is gone.
+func (c *Reconciler) ownsFloor(r *v1.Route, pa *av1alpha1.PodAutoscaler) bool {
+	owner := pa.Annotations[autoscaling.RolloutMinScaleOwnerAnnotationKey]
+	if owner == "" || owner == r.Name {
+		return true
+	}
+	_, err := c.routeLister.Routes(r.Namespace).Get(owner)
+	return apierrs.IsNotFound(err)
+}
+
+// maxScale returns the max scale annotation of the PodAutoscaler, if any.
+// The cluster max scale is applied by the autoscaler.
+func maxScale(pa *av1alpha1.PodAutoscaler) (int32, bool) {
+	v, ok := pa.Annotations[autoscaling.MaxScaleAnnotationKey]
+	if !ok {
+		return 0, false
+	}
+	max, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || max <= 0 {
+		return 0, false
+	}
+	return int32(max), true
+}
@@ -33,10 +33,14 @@ import (
 	fakenetworkingclient "knative.dev/networking/pkg/client/injection/client/fake"
 	fakeingressinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
 	"knative.dev/pkg/ptr"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	av1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
 	"knative.dev/serving/pkg/apis/serving"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	fakeservingclient "knative.dev/serving/pkg/client/injection/client/fake"
+	fakepainformer "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/podautoscaler/fake"
 	fakerevisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision/fake"
+	fakerouteinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/route/fake"
 	"knative.dev/serving/pkg/reconciler/route/config"
 	"knative.dev/serving/pkg/reconciler/route/resources"
 	"knative.dev/serving/pkg/reconciler/route/traffic"
@@ -383,6 +387,462 @@ func TestReconcileIngressUpdateReenqueueRolloutAnnotation(t *testing.T) {
 	}
 }
 
+type fakeMetricsSource traffic.RevisionMetrics
+
+func (f fakeMetricsSource) RevisionMetrics(context.Context, string, string, time.Duration, []float64) (traffic.RevisionMetrics, error) {
+	return traffic.RevisionMetrics(f), nil
+}
+
+func (f fakeMetricsSource) SplitRequests(context.Context, string, string, time.Duration) (float64, error) {
+	return f.Requests, nil
+}
+
+func TestReconcileIngressRolloutAnalysisRollback(t *testing.T) {
+	var reconciler *Reconciler
+	fakeClock := clock.NewFakePassiveClock(time.Unix(19551982, 0))
+	ctx, _, _, _, cancel := newTestSetup(t, func(r *Reconciler) {
+		r.clock = fakeClock
+		r.enqueueAfter = func(interface{}, time.Duration) {}
+		r.newMetricsSource = func(url string) traffic.MetricsSource {
+			if got, want := url, "http://prometheus:9090"; got != want {
+				t.Errorf("Prometheus URL = %s, want: %s", got, want)
+			}
+			return fakeMetricsSource{Requests: 100, Errors: 30}
+		}
+		reconciler = r
+	})
+	defer cancel()
+
+	r := Route(testNamespace, "analyzed-route")
+	r.Annotations = map[string]string{
+		serving.RolloutDurationKey:     "100s",
+		serving.RolloutMaxErrorRateKey: "0.1",
+	}
+	tc, tls := testIngressParams(t, r, func(tc *traffic.Config) {
+		tc.Targets = map[string]traffic.RevisionTargets{
+			traffic.DefaultTarget: {{
+				TrafficTarget: v1.TrafficTarget{
+					ConfigurationName: "thor",
+					RevisionName:      "mjolnir",
+					Percent:           ptr.Int64(100),
+					LatestRevision:    ptr.Bool(true),
+				},
+				Protocol: networking.ProtocolHTTP1,
+			}},
+		}
+	})
+	cfg := reconcilerTestConfig(false)
+	cfg.RolloutAnalysis.PrometheusURL = "http://prometheus:9090"
+	ctx = config.ToContext(ctx, cfg)
+
+	reconcile := func() *traffic.Rollout {
+		t.Helper()
+		_, ro, err := reconciler.reconcileIngress(ctx, r, tc, tls, "foo-ingress-class")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		ing := getRouteIngressFromClient(ctx, t, r)
+		ing.Status.MarkLoadBalancerReady(nil, nil)
+		ing.Status.MarkNetworkConfigured()
+		fakeingressinformer.Get(ctx).Informer().GetIndexer().Add(ing)
+		return ro
+	}
+	reconcile()
+
+	// Start the rollout of a new revision, and observe the ingress ready.
+	tc.Targets[traffic.DefaultTarget][0].RevisionName = "stormbreaker"
+	reconcile()
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	if ro := reconcile(); ro.Done() || ro.Configurations[0].StepParams.StepSize == 0 {
+		t.Fatalf("Rollout = %#v, want one in progress", ro.Configurations[0])
+	}
+
+	// The analysis of the first step reverts the traffic.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	ro := reconcile()
+	want := &traffic.ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           100,
+		Revisions: []traffic.RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      100,
+		}},
+		Analysis: &traffic.RolloutAnalysis{
+			Verdict:              traffic.VerdictFailed,
+			RevisionName:         "stormbreaker",
+			PreviousRevisionName: "mjolnir",
+			Reason:               "the error rate 0.3 exceeds 0.1",
+		},
+	}
+	if got := ro.Configurations[0]; !cmp.Equal(got, want) {
+		t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got))
+	}
+
+	// And the reverted revision is not rolled out again.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	if got := reconcile().Configurations[0]; !cmp.Equal(got, want) {
+		t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got))
+	}
+	ing := getRouteIngressFromClient(ctx, t, r)
+	for _, rule := range ing.Spec.Rules {
+		for _, split := range rule.HTTP.Paths[0].Splits {
+			if got, want := split.ServiceName, "mjolnir"; got != want {
+				t.Errorf("Split ServiceName = %s, want: %s", got, want)
+			}
+		}
+	}
+}
+
+func TestReconcileIngressRolloutControl(t *testing.T) {
+	var reconciler *Reconciler
+	fakeClock := clock.NewFakePassiveClock(time.Unix(19551982, 0))
+	ctx, _, _, _, cancel := newTestSetup(t, func(r *Reconciler) {
+		r.clock = fakeClock
+		r.enqueueAfter = func(interface{}, time.Duration) {}
+		reconciler = r
+	})
+	defer cancel()
+
+	r := Route(testNamespace, "controlled-route")
+	r.Annotations = map[string]string{
+		serving.RolloutDurationKey: "100s",
+	}
+	tc, tls := testIngressParams(t, r, func(tc *traffic.Config) {
+		tc.Targets = map[string]traffic.RevisionTargets{
+			traffic.DefaultTarget: {{
+				TrafficTarget: v1.TrafficTarget{
+					ConfigurationName: "thor",
+					RevisionName:      "mjolnir",
+					Percent:           ptr.Int64(100),
+					LatestRevision:    ptr.Bool(true),
+				},
+				Protocol: networking.ProtocolHTTP1,
+			}},
+		}
+	})
+	ctx = config.ToContext(ctx, reconcilerTestConfig(false))
+
+	reconcile := func() *traffic.Rollout {
+		t.Helper()
+		_, ro, err := reconciler.reconcileIngress(ctx, r, tc, tls, "foo-ingress-class")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		ing := getRouteIngressFromClient(ctx, t, r)
+		ing.Status.MarkLoadBalancerReady(nil, nil)
+		ing.Status.MarkNetworkConfigured()
+		fakeingressinformer.Get(ctx).Informer().GetIndexer().Add(ing)
+		return ro
+	}
+	reconcile()
+
+	// Start the rollout of a new revision, and observe the ingress ready.
+	tc.Targets[traffic.DefaultTarget][0].RevisionName = "stormbreaker"
+	reconcile()
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	ro := reconcile()
+	if ro.Done() || ro.Configurations[0].StepParams.StepSize == 0 {
+		t.Fatalf("Rollout = %#v, want one in progress", ro.Configurations[0])
+	}
+	split := ro.Configurations[0].Revisions
+
+	// A paused rollout keeps its traffic split.
+	r.Annotations[serving.RolloutControlKey] = serving.RolloutControlPaused
+	r.Annotations[serving.RolloutControlModifierKey] = "oveja@knative.dev"
+	reconcile()
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	ro = reconcile()
+	if !ro.Paused() || ro.Done() {
+		t.Fatalf("Paused = %v, Done = %v, want a paused rollout in progress", ro.Paused(), ro.Done())
+	}
+	if got := ro.Configurations[0].Revisions; !cmp.Equal(got, split) {
+		t.Errorf("Revisions mismatch: diff(-want,+got):\n%s", cmp.Diff(split, got))
+	}
+
+	// Promoting it routes all the traffic to the new revision.
+	r.Annotations[serving.RolloutControlKey] = serving.RolloutControlPromote
+	r.Annotations[serving.RolloutControlModifierKey] = "cabra@knative.dev"
+	ro = reconcile()
+	want := &traffic.Rollout{
+		Configurations: []*traffic.ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []traffic.RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		}},
+		Control: &traffic.RolloutControl{
+			State: serving.RolloutControlPromote,
+			By:    "cabra@knative.dev",
+			Time:  fakeClock.Now().UnixNano(),
+		},
+	}
+	if !cmp.Equal(ro, want) {
+		t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(want, ro))
+	}
+}
+
+func TestReconcileIngressBlueGreen(t *testing.T) {
+	var reconciler *Reconciler
+	fakeClock := clock.NewFakePassiveClock(time.Unix(19551982, 0))
+	ctx, _, _, _, cancel := newTestSetup(t, func(r *Reconciler) {
+		r.clock = fakeClock
+		r.enqueueAfter = func(interface{}, time.Duration) {}
+		reconciler = r
+	})
+	defer cancel()
+
+	r := Route(testNamespace, "blue-green-route")
+	r.Annotations = map[string]string{
+		serving.RolloutDurationKey: serving.RolloutBlueGreen,
+	}
+	tc, tls := testIngressParams(t, r, func(tc *traffic.Config) {
+		tc.Targets = map[string]traffic.RevisionTargets{
+			traffic.DefaultTarget: {{
+				TrafficTarget: v1.TrafficTarget{
+					ConfigurationName: "thor",
+					RevisionName:      "mjolnir",
+					Percent:           ptr.Int64(100),
+					LatestRevision:    ptr.Bool(true),
+				},
+				Protocol: networking.ProtocolHTTP1,
+			}},
+		}
+	})
+	// Rollouts are disabled cluster-wide, but not for blue/green routes.
+	ctx = config.ToContext(ctx, reconcilerTestConfig(false))
+
+	reconcile := func() *traffic.Rollout {
+		t.Helper()
+		_, ro, err := reconciler.reconcileIngress(ctx, r, tc, tls, "foo-ingress-class")
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		ing := getRouteIngressFromClient(ctx, t, r)
+		ing.Status.MarkLoadBalancerReady(nil, nil)
+		ing.Status.MarkNetworkConfigured()
+		fakeingressinformer.Get(ctx).Informer().GetIndexer().Add(ing)
+		return ro
+	}
+	setScale := func(rev string, scale int32) {
+		fakepainformer.Get(ctx).Informer().GetIndexer().Add(&av1alpha1.PodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: rev},
+			Status:     av1alpha1.PodAutoscalerStatus{ActualScale: ptr.Int32(scale)},
+		})
+	}
+	reconcile()
+	setScale("mjolnir", 3)
+
+	// The new revision gets no traffic until it is warm.
+	tc.Targets[traffic.DefaultTarget][0].RevisionName = "stormbreaker"
+	setScale("stormbreaker", 1)
+	reconcile()
+	for i := 0; i < 2; i++ {
+		fakeClock.SetTime(fakeClock.Now().Add(10 * time.Second))
+		ro := reconcile()
+		want := []traffic.RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      100,
+		}, {
+			RevisionName: "stormbreaker",
+		}}
+		if got := ro.Configurations[0].Revisions; !cmp.Equal(got, want) {
+			t.Errorf("Revisions mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got))
+		}
+		if got, want := ro.Configurations[0].StepParams.Strategy, serving.RolloutStrategyBlueGreen; got != want {
+			t.Errorf("Strategy = %q, want: %q", got, want)
+		}
+	}
+
+	// Once it is, all the traffic is shifted to it at once.
+	setScale("stormbreaker", 3)
+	fakeClock.SetTime(fakeClock.Now().Add(10 * time.Second))
+	ro := reconcile()
+	want := &traffic.Rollout{
+		Configurations: []*traffic.ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []traffic.RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		}},
+	}
+	if !cmp.Equal(ro, want) {
+		t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(want, ro))
+	}
+}
+
+func TestReconcilePreWarm(t *testing.T) {
+	var reconciler *Reconciler
+	ctx, _, _, _, cancel := newTestSetup(t, func(r *Reconciler) {
+		reconciler = r
+	})
+	defer cancel()
+
+	r := Route(testNamespace, "pre-warm-route")
+	r.Annotations = map[string]string{serving.RolloutPreWarmKey: "true"}
+	tc := &traffic.Config{
+		Configurations: map[string]*v1.Configuration{"thor": {}},
+	}
+	pas := fakeservingclient.Get(ctx).AutoscalingV1alpha1().PodAutoscalers(testNamespace)
+	for rev, scale := range map[string]int32{"mjolnir": 3, "stormbreaker": 1} {
+		pa := &av1alpha1.PodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      rev,
+				Labels:    map[string]string{serving.ConfigurationLabelKey: "thor"},
+			},
+			Status: av1alpha1.PodAutoscalerStatus{ActualScale: ptr.Int32(scale)},
+		}
+		fakepainformer.Get(ctx).Informer().GetIndexer().Add(pa)
+		pas.Create(ctx, pa, metav1.CreateOptions{})
+	}
+	floor := func() (string, bool) {
+		t.Helper()
+		pa, err := pas.Get(ctx, "stormbreaker", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal("Failed to get PodAutoscaler:", err)
+		}
+		// Mirror the update into the informer.
+		fakepainformer.Get(ctx).Informer().GetIndexer().Update(pa)
+		v, ok := pa.Annotations[autoscaling.RolloutMinScaleAnnotationKey]
+		return v, ok
+	}
+	floorOwner := func() string {
+		t.Helper()
+		pa, err := pas.Get(ctx, "stormbreaker", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal("Failed to get PodAutoscaler:", err)
+		}
+		return pa.Annotations[autoscaling.RolloutMinScaleOwnerAnnotationKey]
+	}
+	rollout := func(revs ...traffic.RevisionRollout) *traffic.Rollout {
+		return &traffic.Rollout{
+			Configurations: []*traffic.ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions:         revs,
+			}},
+		}
+	}
+	inProgress := rollout(traffic.RevisionRollout{
+		RevisionName: "mjolnir",
+		Percent:      99,
+	}, traffic.RevisionRollout{
+		RevisionName: "stormbreaker",
+		Percent:      1,
+	})
+
+	// The new revision is pre-warmed to the scale of the old one.
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "3" {
+		t.Errorf("Floor = %q, %v, want: 3", got, ok)
+	}
+
+	// And the floor is kept as the old revision scales down.
+	fakepainformer.Get(ctx).Informer().GetIndexer().Update(&av1alpha1.PodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      "mjolnir",
+			Labels:    map[string]string{serving.ConfigurationLabelKey: "thor"},
+		},
+		Status: av1alpha1.PodAutoscalerStatus{ActualScale: ptr.Int32(2)},
+	})
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "3" {
+		t.Errorf("Floor = %q, %v, want: 3", got, ok)
+	}
+
+	// Another route of the configuration leaves the floor alone, both
+	// without a rollout and with one.
+	other := Route(testNamespace, "other-route")
+	fakerouteinformer.Get(ctx).Informer().GetIndexer().Add(r)
+	done := rollout(traffic.RevisionRollout{RevisionName: "stormbreaker", Percent: 100})
+	if err := reconciler.reconcilePreWarm(ctx, other, tc, done); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "3" {
+		t.Errorf("Floor = %q, %v, want: 3", got, ok)
+	}
+	other.Annotations = map[string]string{serving.RolloutPreWarmKey: "true"}
+	if err := reconciler.reconcilePreWarm(ctx, other, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "3" {
+		t.Errorf("Floor = %q, %v, want: 3", got, ok)
+	}
+	if got := floorOwner(); got != r.Name {
+		t.Errorf("Floor owner = %q, want: %q", got, r.Name)
+	}
+
+	// It is released once the rollout is done.
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, done); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); ok {
+		t.Errorf("Floor = %q, want: none", got)
+	}
+	if got := floorOwner(); got != "" {
+		t.Errorf("Floor owner = %q, want: none", got)
+	}
+
+	// The floor is capped by the max scale of the revision.
+	pa, _ := pas.Get(ctx, "stormbreaker", metav1.GetOptions{})
+	pa.Annotations = map[string]string{autoscaling.MaxScaleAnnotationKey: "1"}
+	pas.Update(ctx, pa, metav1.UpdateOptions{})
+	fakepainformer.Get(ctx).Informer().GetIndexer().Update(pa)
+	if err := reconciler.reconcilePreWarm(ctx, other, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "1" {
+		t.Errorf("Floor = %q, %v, want: 1", got, ok)
+	}
+
+	// And the floor of a deleted route is taken over.
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := floorOwner(); got != r.Name {
+		t.Errorf("Floor owner = %q, want: %q", got, r.Name)
+	}
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, done); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// The floor is released once the route stops routing the configuration,
+	// even mid-rollout.
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); !ok || got != "1" {
+		t.Errorf("Floor = %q, %v, want: 1", got, ok)
+	}
+	if err := reconciler.reconcilePreWarm(ctx, r, &traffic.Config{}, &traffic.Rollout{}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); ok {
+		t.Errorf("Floor = %q, want: none", got)
+	}
+	if got := floorOwner(); got != "" {
+		t.Errorf("Floor owner = %q, want: none", got)
+	}
+
+	// Nothing is pre-warmed without opting in.
+	r.Annotations = nil
+	if err := reconciler.reconcilePreWarm(ctx, r, tc, inProgress); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got, ok := floor(); ok {
+		t.Errorf("Floor = %q, want: none", got)
+	}
+}
+
 func TestReconcileIngressUpdateNoRollout(t *testing.T) {
 	var reconciler *Reconciler
 	ctx, _, _, _, cancel := newTestSetup(t, func(r *Reconciler) {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"sort"
+	"strconv"
 
 	"github.com/davecgh/go-spew/spew"
 	"go.uber.org/zap"
@@ -34,10 +35,12 @@ import (
 	ingress "knative.dev/networking/pkg/ingress"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
 	"knative.dev/serving/pkg/activator"
 	apicfg "knative.dev/serving/pkg/apis/config"
 	"knative.dev/serving/pkg/apis/serving"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingnetworking "knative.dev/serving/pkg/networking"
 	"knative.dev/serving/pkg/reconciler/route/config"
 	"knative.dev/serving/pkg/reconciler/route/domains"
 	"knative.dev/serving/pkg/reconciler/route/resources/labels"
@@ -151,38 +154,44 @@ func makeIngressSpec(
 			}
 			rule := makeIngressRule(domains, r.Namespace,
 				visibility, tc.Targets[name], ro.RolloutsByTag(name))
-			if featuresConfig.TagHeaderBasedRouting == apicfg.Enabled {
-				if rule.HTTP.Paths[0].AppendHeaders == nil {
-					rule.HTTP.Paths[0].AppendHeaders = make(map[string]string, 1)
-				}
-
-				if name == traffic.DefaultTarget {
-					// To provide information if a request is routed via the "default route" or not,
-					// the header "Knative-Serving-Default-Route: true" is appended here.
-					// If the header has "true" and there is a "Knative-Serving-Tag" header,
-					// then the request is having the undefined tag header,
-					// which will be observed in queue-proxy.
-					rule.HTTP.Paths[0].AppendHeaders[network.DefaultRouteHeaderName] = "true"
-
-					// Add ingress paths for a request with the tag header.
-					// If a request has one of the `names` (tag name), specified as the
-					// Knative-Serving-Tag header, except for the DefaultTarget,
-					// the request will be routed to that target.
-					// corresponding to the tag name.
-					// Since names are sorted `DefaultTarget == ""` is the first one,
-					// so just pass the subslice.
-					rule.HTTP.Paths = append(
-						makeTagBasedRoutingIngressPaths(r.Namespace, tc, ro, names[1:]), rule.HTTP.Paths...)
-				} else {
-					// If a request is routed by a tag-attached hostname instead of the tag header,
-					// the request may not have the tag header "Knative-Serving-Tag",
-					// even though the ingress path used in the case is also originated
-					// from the same Knative route with the ingress path for the tag based routing.
-					//
-					// To prevent such inconsistency,
-					// the tag header is appended with the tag corresponding to the tag-attached hostname
-					rule.HTTP.Paths[0].AppendHeaders[network.TagHeaderName] = name
-				}
+			if (name != traffic.DefaultTarget || featuresConfig.TagHeaderBasedRouting == apicfg.Enabled) &&
+				rule.HTTP.Paths[0].AppendHeaders == nil {
+				rule.HTTP.Paths[0].AppendHeaders = make(map[string]string, 1)
+			}
+			if name != traffic.DefaultTarget {
+				// If a request is routed by a tag-attached hostname instead of the tag header,
+				// the request may not have the tag header "Knative-Serving-Tag",
+				// even though the ingress path used in the case is also originated
+				// from the same Knative route with the ingress path for the tag based routing.
+				//
+				// To prevent such inconsistency, and to let the revision tell the
+				// requests routed by a tag from the ones routed by the traffic split,
+				// the tag header is appended with the tag corresponding to the tag-attached hostname
+				rule.HTTP.Paths[0].AppendHeaders[network.TagHeaderName] = name
+			} else if featuresConfig.TagHeaderBasedRouting == apicfg.Enabled {
+				// To provide information if a request is routed via the "default route" or not,
+				// the header "Knative-Serving-Default-Route: true" is appended here.
+				// If the header has "true" and there is a "Knative-Serving-Tag" header,
+				// then the request is having the undefined tag header,
+				// which will be observed in queue-proxy.
+				rule.HTTP.Paths[0].AppendHeaders[network.DefaultRouteHeaderName] = "true"
+
+				// Add ingress paths for a request with the tag header.
+				// If a request has one of the `names` (tag name), specified as the
+				// Knative-Serving-Tag header, except for the DefaultTarget,
+				// the request will be routed to that target.
+				// corresponding to the tag name.
+				// Since names are sorted `DefaultTarget == ""` is the first one,
+				// so just pass the subslice.
+				rule.HTTP.Paths = append(
+					makeTagBasedRoutingIngressPaths(r.Namespace, tc, ro, names[1:]), rule.HTTP.Paths...)
+			}
+			if name == traffic.DefaultTarget {
+				// Route the requests matching the rules of the tagged targets
+				// to them, after the tag header paths but ahead of the split.
+				paths, last := rule.HTTP.Paths, len(rule.HTTP.Paths)-1
+				rule.HTTP.Paths = append(append(paths[:last:last],
+					makeMatchIngressPaths(r.Namespace, tc, ro, names[1:])...), paths[last])
 			}
 			// If this is a public rule, we need to configure ACME challenge paths.
 			if visibility == netv1alpha1.IngressVisibilityExternalIP {
@@ -284,6 +293,28 @@ func makeTagBasedRoutingIngressPaths(ns string, tc *traffic.Config, ro *traffic.
 	return paths
 }
 
+// makeMatchIngressPaths returns the ingress paths routing the requests
+// matching the rules of the targets to them.
+// `names` must not include `""` — the DefaultTarget.
+func makeMatchIngressPaths(ns string, tc *traffic.Config, ro *traffic.Rollout, names []string) []netv1alpha1.HTTPIngressPath {
+	var paths []netv1alpha1.HTTPIngressPath
+	for _, name := range names {
+		targets := tc.Targets[name]
+		for _, m := range targets[0].Match {
+			path := makeBaseIngressPath(ns, targets, ro.RolloutsByTag(name))
+			path.Headers = make(map[string]netv1alpha1.HeaderMatch, len(m.Headers))
+			for h, hm := range m.Headers {
+				path.Headers[h] = netv1alpha1.HeaderMatch{Exact: hm.Exact}
+			}
+			// As for the tag-attached hostname, let the revision know the tag
+			// the request was routed by.
+			path.AppendHeaders = map[string]string{network.TagHeaderName: name}
+			paths = append(paths, *path)
+		}
+	}
+	return paths
+}
+
 func rolloutConfig(cfgName string, ros []*traffic.ConfigurationRollout) *traffic.ConfigurationRollout {
 	idx := sort.Search(len(ros), func(i int) bool {
 		return ros[i].ConfigurationName >= cfgName
@@ -308,8 +339,9 @@ func makeBaseIngressPath(ns string, targets traffic.RevisionTargets,
 		if t.LatestRevision != nil && *t.LatestRevision {
 			cfg = rolloutConfig(t.ConfigurationName, roCfgs)
 		}
-		if cfg == nil || len(cfg.Revisions) < 2 {
-			// No rollout in progress.
+		first := len(splits)
+		if cfg == nil || (len(cfg.Revisions) < 2 && !cfg.RolledBack()) {
+			// No rollout in progress, nor reverted.
 			splits = append(splits, netv1alpha1.IngressBackendSplit{
 				IngressBackend: netv1alpha1.IngressBackend{
 					ServiceNamespace: ns,
@@ -327,6 +359,10 @@ func makeBaseIngressPath(ns string, targets traffic.RevisionTargets,
 		} else {
 			for i := range cfg.Revisions {
 				rev := &cfg.Revisions[i]
+				if rev.Percent == 0 {
+					// E.g. the new revision of a blue/green rollout until it is warm.
+					continue
+				}
 				splits = append(splits, netv1alpha1.IngressBackendSplit{
 					IngressBackend: netv1alpha1.IngressBackend{
 						ServiceNamespace: ns,
@@ -343,9 +379,26 @@ func makeBaseIngressPath(ns string, targets traffic.RevisionTargets,
 				})
 			}
 		}
+		if t.Mirror != nil {
+			for i := range splits[first:] {
+				mirrorSplit(&splits[first+i], t.Mirror)
+			}
+		}
 	}
 
 	return &netv1alpha1.HTTPIngressPath{
 		Splits: splits,
 	}
 }
+
+// mirrorSplit routes the split through the activator, which copies a sample
+// of the requests to the mirror revision, since the Ingress cannot mirror them.
+// The activator serves both protocols on the same ports as the revisions.
+// This costs all the requests of the split an extra hop, not only the sampled
+// ones, and the activators have to be scaled for the traffic of the split.
+func mirrorSplit(split *netv1alpha1.IngressBackendSplit, m *servingv1.TrafficMirror) {
+	split.ServiceNamespace = system.Namespace()
+	split.ServiceName = servingnetworking.ActivatorServiceName
+	split.AppendHeaders[activator.MirrorRevisionHeaderName] = m.RevisionName
+	split.AppendHeaders[activator.MirrorPercentHeaderName] = strconv.FormatInt(m.Percent, 10)
+}
@@ -395,6 +395,9 @@ func TestMakeIngressWithActualRollout(t *testing.T) {
 						"Knative-Serving-Namespace": ns,
 					},
 				}},
+				AppendHeaders: map[string]string{
+					"Knative-Serving-Tag": "hammer",
+				},
 			}},
 		},
 		Visibility: netv1alpha1.IngressVisibilityClusterLocal,
@@ -449,6 +452,9 @@ func TestMakeIngressWithActualRollout(t *testing.T) {
 						"Knative-Serving-Namespace": ns,
 					},
 				}},
+				AppendHeaders: map[string]string{
+					"Knative-Serving-Tag": "hammer",
+				},
 			}},
 		},
 		Visibility: netv1alpha1.IngressVisibilityExternalIP,
@@ -557,6 +563,9 @@ func TestMakeIngressSpecCorrectRules(t *testing.T) {
 						"Knative-Serving-Namespace": ns,
 					},
 				}},
+				AppendHeaders: map[string]string{
+					"Knative-Serving-Tag": "v1",
+				},
 			}},
 		},
 		Visibility: netv1alpha1.IngressVisibilityClusterLocal,
@@ -578,6 +587,9 @@ func TestMakeIngressSpecCorrectRules(t *testing.T) {
 						"Knative-Serving-Namespace": ns,
 					},
 				}},
+				AppendHeaders: map[string]string{
+					"Knative-Serving-Tag": "v1",
+				},
 			}},
 		},
 		Visibility: netv1alpha1.IngressVisibilityExternalIP,
@@ -850,6 +862,241 @@ func TestMakeIngressSpecCorrectRulesWithTagBasedRouting(t *testing.T) {
 	}
 }
 
+func TestMakeIngressSpecMatchRules(t *testing.T) {
+	beta := v1.TrafficTarget{
+		Tag:               "beta",
+		ConfigurationName: "config",
+		RevisionName:      "v1",
+		Percent:           ptr.Int64(0),
+		Match: []v1.TrafficMatch{{
+			Headers: map[string]v1.HeaderMatch{"X-User-Group": {Exact: "beta"}},
+		}, {
+			Headers: map[string]v1.HeaderMatch{
+				"X-User-Group": {Exact: "staff"},
+				"X-Region":     {Exact: "eu"},
+			},
+		}},
+	}
+	targets := map[string]traffic.RevisionTargets{
+		traffic.DefaultTarget: {{
+			TrafficTarget: v1.TrafficTarget{
+				ConfigurationName: "config",
+				RevisionName:      "v2",
+				Percent:           ptr.Int64(100),
+			},
+		}, {
+			TrafficTarget: beta,
+		}},
+		"beta": {{
+			TrafficTarget: beta,
+		}},
+	}
+	targets["beta"][0].Percent = ptr.Int64(100)
+
+	split := func(rev string) []netv1alpha1.IngressBackendSplit {
+		return []netv1alpha1.IngressBackendSplit{{
+			IngressBackend: netv1alpha1.IngressBackend{
+				ServiceNamespace: ns,
+				ServiceName:      rev,
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+			AppendHeaders: map[string]string{
+				"Knative-Serving-Revision":  rev,
+				"Knative-Serving-Namespace": ns,
+			},
+		}}
+	}
+	r := Route(ns, "test-route", WithURL)
+
+	t.Run("without tag header based routing", func(t *testing.T) {
+		want := []netv1alpha1.HTTPIngressPath{{
+			Headers:       map[string]netv1alpha1.HeaderMatch{"X-User-Group": {Exact: "beta"}},
+			Splits:        split("v1"),
+			AppendHeaders: map[string]string{"Knative-Serving-Tag": "beta"},
+		}, {
+			Headers: map[string]netv1alpha1.HeaderMatch{
+				"X-User-Group": {Exact: "staff"},
+				"X-Region":     {Exact: "eu"},
+			},
+			Splits:        split("v1"),
+			AppendHeaders: map[string]string{"Knative-Serving-Tag": "beta"},
+		}, {
+			Splits: split("v2"),
+		}}
+
+		tc := &traffic.Config{Targets: targets}
+		ci, err := makeIngressSpec(testContext(), r, nil /*tls*/, tc, tc.BuildRollout())
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		// Both the cluster local and the public rules of the main route
+		// route the matching requests, but not the rules of the tag.
+		for i, rule := range ci.Rules[:2] {
+			if !cmp.Equal(rule.HTTP.Paths, want) {
+				t.Errorf("Unexpected paths of rule %d (-want, +got): %s", i, cmp.Diff(want, rule.HTTP.Paths))
+			}
+		}
+		for i, rule := range ci.Rules[2:] {
+			if got := len(rule.HTTP.Paths); got != 1 {
+				t.Errorf("Rule %d has %d paths, want: 1", i+2, got)
+			}
+		}
+	})
+
+	t.Run("with tag header based routing", func(t *testing.T) {
+		want := []netv1alpha1.HTTPIngressPath{{
+			Headers: map[string]netv1alpha1.HeaderMatch{"Knative-Serving-Tag": {Exact: "beta"}},
+			Splits:  split("v1"),
+		}, {
+			Headers:       map[string]netv1alpha1.HeaderMatch{"X-User-Group": {Exact: "beta"}},
+			Splits:        split("v1"),
+			AppendHeaders: map[string]string{"Knative-Serving-Tag": "beta"},
+		}, {
+			Headers: map[string]netv1alpha1.HeaderMatch{
+				"X-User-Group": {Exact: "staff"},
+				"X-Region":     {Exact: "eu"},
+			},
+			Splits:        split("v1"),
+			AppendHeaders: map[string]string{"Knative-Serving-Tag": "beta"},
+		}, {
+			Splits:        split("v2"),
+			AppendHeaders: map[string]string{"Knative-Serving-Default-Route": "true"},
+		}}
+
+		ctx := testContext()
+		config.FromContext(ctx).Features.TagHeaderBasedRouting = apicfg.Enabled
+		tc := &traffic.Config{Targets: targets}
+		ci, err := makeIngressSpec(ctx, r, nil /*tls*/, tc, tc.BuildRollout())
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if got := ci.Rules[1].HTTP.Paths; !cmp.Equal(got, want) {
+			t.Error("Unexpected paths (-want, +got):", cmp.Diff(want, got))
+		}
+	})
+}
+
+func TestMakeBaseIngressPathMirror(t *testing.T) {
+	targets := traffic.RevisionTargets{{
+		TrafficTarget: v1.TrafficTarget{
+			ConfigurationName: "config",
+			RevisionName:      "config-00001",
+			LatestRevision:    ptr.Bool(true),
+			Percent:           ptr.Int64(90),
+			Mirror: &v1.TrafficMirror{
+				RevisionName: "shadow-00001",
+				Percent:      25,
+			},
+		},
+	}, {
+		TrafficTarget: v1.TrafficTarget{
+			ConfigurationName: "other",
+			RevisionName:      "other-00001",
+			Percent:           ptr.Int64(10),
+		},
+	}}
+	mirrored := func(rev string, percent int) netv1alpha1.IngressBackendSplit {
+		return netv1alpha1.IngressBackendSplit{
+			IngressBackend: netv1alpha1.IngressBackend{
+				ServiceNamespace: system.Namespace(),
+				ServiceName:      "activator-service",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: percent,
+			AppendHeaders: map[string]string{
+				"Knative-Serving-Revision":        rev,
+				"Knative-Serving-Namespace":       ns,
+				"Knative-Serving-Mirror-Revision": "shadow-00001",
+				"Knative-Serving-Mirror-Percent":  "25",
+			},
+		}
+	}
+	other := netv1alpha1.IngressBackendSplit{
+		IngressBackend: netv1alpha1.IngressBackend{
+			ServiceNamespace: ns,
+			ServiceName:      "other-00001",
+			ServicePort:      intstr.FromInt(80),
+		},
+		Percent: 10,
+		AppendHeaders: map[string]string{
+			"Knative-Serving-Revision":  "other-00001",
+			"Knative-Serving-Namespace": ns,
+		},
+	}
+
+	t.Run("without rollout", func(t *testing.T) {
+		want := &netv1alpha1.HTTPIngressPath{
+			Splits: []netv1alpha1.IngressBackendSplit{mirrored("config-00001", 90), other},
+		}
+		if got := makeBaseIngressPath(ns, targets, nil); !cmp.Equal(got, want) {
+			t.Error("Unexpected path (-want, +got):", cmp.Diff(want, got))
+		}
+	})
+
+	t.Run("with rollout", func(t *testing.T) {
+		// All the revisions of the rollout are mirrored.
+		ro := []*traffic.ConfigurationRollout{{
+			ConfigurationName: "config",
+			Percent:           90,
+			Revisions: []traffic.RevisionRollout{{
+				RevisionName: "config-00000",
+				Percent:      60,
+			}, {
+				RevisionName: "config-00001",
+				Percent:      30,
+			}},
+		}}
+		want := &netv1alpha1.HTTPIngressPath{
+			Splits: []netv1alpha1.IngressBackendSplit{
+				mirrored("config-00000", 60), mirrored("config-00001", 30), other,
+			},
+		}
+		if got := makeBaseIngressPath(ns, targets, ro); !cmp.Equal(got, want) {
+			t.Error("Unexpected path (-want, +got):", cmp.Diff(want, got))
+		}
+	})
+}
+
+func TestMakeBaseIngressPathBlueGreen(t *testing.T) {
+	targets := traffic.RevisionTargets{{
+		TrafficTarget: v1.TrafficTarget{
+			ConfigurationName: "config",
+			RevisionName:      "config-00002",
+			LatestRevision:    ptr.Bool(true),
+			Percent:           ptr.Int64(100),
+		},
+	}}
+	ro := []*traffic.ConfigurationRollout{{
+		ConfigurationName: "config",
+		Percent:           100,
+		Revisions: []traffic.RevisionRollout{{
+			RevisionName: "config-00001",
+			Percent:      100,
+		}, {
+			RevisionName: "config-00002",
+		}},
+	}}
+	// The new revision is left out until it gets traffic.
+	want := &netv1alpha1.HTTPIngressPath{
+		Splits: []netv1alpha1.IngressBackendSplit{{
+			IngressBackend: netv1alpha1.IngressBackend{
+				ServiceNamespace: ns,
+				ServiceName:      "config-00001",
+				ServicePort:      intstr.FromInt(80),
+			},
+			Percent: 100,
+			AppendHeaders: map[string]string{
+				"Knative-Serving-Revision":  "config-00001",
+				"Knative-Serving-Namespace": ns,
+			},
+		}},
+	}
+	if got := makeBaseIngressPath(ns, targets, ro); !cmp.Equal(got, want) {
+		t.Error("Unexpected path (-want, +got):", cmp.Diff(want, got))
+	}
+}
+
 // One active target.
 func TestMakeIngressRuleVanilla(t *testing.T) {
 	domains := []string{"a.com", "b.org"}
@@ -897,6 +1144,60 @@ func TestMakeIngressRuleVanilla(t *testing.T) {
 	}
 }
 
+// One latest revision target whose rollout was reverted.
+func TestMakeIngressRuleRolledBack(t *testing.T) {
+	domains := []string{"a.com"}
+	targets := traffic.RevisionTargets{{
+		TrafficTarget: v1.TrafficTarget{
+			ConfigurationName: "config",
+			RevisionName:      "revision-shark",
+			Percent:           ptr.Int64(100),
+			LatestRevision:    ptr.Bool(true),
+		},
+	}}
+	ro := &traffic.Rollout{
+		Configurations: []*traffic.ConfigurationRollout{{
+			ConfigurationName: "config",
+			Percent:           100,
+			Revisions: []traffic.RevisionRollout{{
+				RevisionName: "revision-whale",
+				Percent:      100,
+			}},
+			Analysis: &traffic.RolloutAnalysis{
+				Verdict:              traffic.VerdictFailed,
+				RevisionName:         "revision-shark",
+				PreviousRevisionName: "revision-whale",
+			},
+		}},
+	}
+	rule := makeIngressRule(domains, ns,
+		netv1alpha1.IngressVisibilityExternalIP, targets, ro.RolloutsByTag(traffic.DefaultTarget))
+	expected := netv1alpha1.IngressRule{
+		Hosts: []string{"a.com"},
+		HTTP: &netv1alpha1.HTTPIngressRuleValue{
+			Paths: []netv1alpha1.HTTPIngressPath{{
+				Splits: []netv1alpha1.IngressBackendSplit{{
+					IngressBackend: netv1alpha1.IngressBackend{
+						ServiceNamespace: ns,
+						ServiceName:      "revision-whale",
+						ServicePort:      intstr.FromInt(80),
+					},
+					Percent: 100,
+					AppendHeaders: map[string]string{
+						"Knative-Serving-Revision":  "revision-whale",
+						"Knative-Serving-Namespace": ns,
+					},
+				}},
+			}},
+		},
+		Visibility: netv1alpha1.IngressVisibilityExternalIP,
+	}
+
+	if !cmp.Equal(expected, rule) {
+		t.Error("Unexpected rule (-want, +got):", cmp.Diff(expected, rule))
+	}
+}
+
 // One active target and a target of zero percent.
 func TestMakeIngressRuleZeroPercentTarget(t *testing.T) {
 	targets := []traffic.RevisionTarget{{
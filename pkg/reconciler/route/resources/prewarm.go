@@ -0,0 +1,42 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"knative.dev/serving/pkg/apis/autoscaling"
+)
+
+// MakePreWarmPatch returns the merge patch of a PodAutoscaler setting its
+// pre-warm floor to the given scale on behalf of the named Route, or
+// releasing the floor if the route is empty.
+func MakePreWarmPatch(floor int32, route string) ([]byte, error) {
+	var value, owner interface{}
+	if route != "" {
+		value, owner = strconv.Itoa(int(floor)), route
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				autoscaling.RolloutMinScaleAnnotationKey:      value,
+				autoscaling.RolloutMinScaleOwnerAnnotationKey: owner,
+			},
+		},
+	})
+}
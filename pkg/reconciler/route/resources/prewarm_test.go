@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import "testing"
+
+func TestMakePreWarmPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		floor int32
+		route string
+		want  string
+	}{{
+		name:  "set",
+		floor: 5,
+		route: "the-route",
+		want:  `{"metadata":{"annotations":{"autoscaling.knative.dev/rolloutMinScale":"5","autoscaling.knative.dev/rolloutMinScaleOwner":"the-route"}}}`,
+	}, {
+		name: "release",
+		want: `{"metadata":{"annotations":{"autoscaling.knative.dev/rolloutMinScale":null,"autoscaling.knative.dev/rolloutMinScaleOwner":null}}}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := MakePreWarmPatch(test.floor, test.route)
+			if err != nil {
+				t.Fatal("MakePreWarmPatch() =", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("MakePreWarmPatch() = %s, want: %s", got, test.want)
+			}
+		})
+	}
+}
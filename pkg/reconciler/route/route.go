@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	kubelabels "k8s.io/apimachinery/pkg/labels"
@@ -45,6 +46,7 @@ import (
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
 	clientset "knative.dev/serving/pkg/client/clientset/versioned"
 	routereconciler "knative.dev/serving/pkg/client/injection/reconciler/serving/v1/route"
+	palisters "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
 	listers "knative.dev/serving/pkg/client/listers/serving/v1"
 	kaccessor "knative.dev/serving/pkg/reconciler/accessor"
 	networkaccessor "knative.dev/serving/pkg/reconciler/accessor/networking"
@@ -64,20 +66,74 @@ type Reconciler struct {
 	netclient  netclientset.Interface
 
 	// Listers index properties about resources
+	routeLister         listers.RouteLister
 	configurationLister listers.ConfigurationLister
 	revisionLister      listers.RevisionLister
 	serviceLister       corev1listers.ServiceLister
 	ingressLister       networkinglisters.IngressLister
 	certificateLister   networkinglisters.CertificateLister
+	podAutoscalerLister palisters.PodAutoscalerLister
 	tracker             tracker.Interface
 
 	clock        clock.PassiveClock
 	enqueueAfter func(interface{}, time.Duration)
+
+	// newMetricsSource creates the source of the metrics of the rollout
+	// analysis from the URL of the Prometheus server.
+	newMetricsSource func(url string) traffic.MetricsSource
+
+	// metricsSources are the sources created by newMetricsSource keyed by
+	// the URL, reused so that their metrics are cached across reconciliations.
+	metricsSourcesMu sync.Mutex
+	metricsSources   map[string]traffic.MetricsSource
+
+	// observations caches the requests served by the revisions of the routes.
+	observations trafficObservations
 }
 
 // Check that our Reconciler implements routereconciler.Interface
 var _ routereconciler.Interface = (*Reconciler)(nil)
 
+// metricsSource returns the source of the metrics of the Prometheus server
+// at the given URL.
+func (c *Reconciler) metricsSource(url string) traffic.MetricsSource {
+	c.metricsSourcesMu.Lock()
+	defer c.metricsSourcesMu.Unlock()
+	src, ok := c.metricsSources[url]
+	if !ok {
+		// The URL rarely changes, so forget the sources of the former ones.
+		src = c.newMetricsSource(url)
+		c.metricsSources = map[string]traffic.MetricsSource{url: src}
+	}
+	return src
+}
+
+// markRolloutAnalysis reflects the verdicts of the rollout analyses in the
+// status, the worst verdict first.
+func markRolloutAnalysis(rs *v1.RouteStatus, ro *traffic.Rollout) {
+	var passed, inconclusive *traffic.RolloutAnalysis
+	for _, c := range ro.Configurations {
+		switch a := c.Analysis; {
+		case a == nil:
+		case c.RolledBack():
+			rs.MarkRolloutRolledBack(a.RevisionName, a.PreviousRevisionName, a.Reason)
+			return
+		case a.Verdict == traffic.VerdictInconclusive:
+			inconclusive = a
+		case a.Verdict == traffic.VerdictPassed:
+			passed = a
+		}
+	}
+	switch {
+	case inconclusive != nil:
+		rs.MarkRolloutAnalysisInconclusive(inconclusive.RevisionName, inconclusive.Reason)
+	case passed != nil:
+		rs.MarkRolloutAnalysisPassed(passed.RevisionName)
+	default:
+		rs.ClearRolloutAnalysis()
+	}
+}
+
 func ingressClassForRoute(ctx context.Context, r *v1.Route) string {
 	if ingressClass := r.Annotations[networking.IngressClassAnnotationKey]; ingressClass != "" {
 		return ingressClass
@@ -155,7 +211,12 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, r *v1.Route) pkgreconcil
 		return err
 	}
 
+	if err := c.reconcilePreWarm(ctx, r, traffic, effectiveRO); err != nil {
+		return err
+	}
+
 	roInProgress := !effectiveRO.Done()
+	markRolloutAnalysis(&r.Status, effectiveRO)
 	if ingress.GetObjectMeta().GetGeneration() != ingress.Status.ObservedGeneration {
 		r.Status.MarkIngressNotConfigured()
 	} else if !roInProgress {
@@ -174,17 +235,28 @@ func (c *Reconciler) ReconcileKind(ctx context.Context, r *v1.Route) pkgreconcil
 	if roInProgress {
 		logger.Info("Rollout is in progress")
 		// Rollout in progress, so mark the status as such.
-		r.Status.MarkIngressRolloutInProgress()
+		if effectiveRO.Paused() {
+			r.Status.MarkIngressRolloutPaused(effectiveRO.Control.By)
+		} else {
+			r.Status.MarkIngressRolloutInProgress()
+		}
+	}
+	if roInProgress || effectiveRO.RolledBack() {
 		// Update the route.Status.Traffic to contain correct traffic
 		// distribution based on rollout status.
 		r.Status.Traffic, err = traffic.GetRevisionTrafficTargets(ctx, r, effectiveRO)
 		if err != nil {
 			return err
 		}
-		return nil
 	}
-
-	logger.Info("Route successfully synced")
+	if ra := config.FromContext(ctx).RolloutAnalysis; ra.TrafficObserved() {
+		c.observeTraffic(ctx, r, ra)
+	} else {
+		r.Status.ClearTrafficObserved()
+	}
+	if !roInProgress {
+		logger.Info("Route successfully synced")
+	}
 	return nil
 }
 
@@ -29,6 +29,7 @@ import (
 	fakeingressinformer "knative.dev/networking/pkg/client/injection/informers/networking/v1alpha1/ingress/fake"
 	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/service/fake"
 	fakeservingclient "knative.dev/serving/pkg/client/injection/client/fake"
+	_ "knative.dev/serving/pkg/client/injection/informers/autoscaling/v1alpha1/podautoscaler/fake"
 	fakecfginformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/configuration/fake"
 	fakerevisioninformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/revision/fake"
 	fakerouteinformer "knative.dev/serving/pkg/client/injection/informers/serving/v1/route/fake"
@@ -60,6 +61,7 @@ import (
 	"knative.dev/serving/pkg/gc"
 	"knative.dev/serving/pkg/reconciler/route/config"
 	"knative.dev/serving/pkg/reconciler/route/domains"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
 
 	_ "knative.dev/pkg/metrics/testing"
 	. "knative.dev/pkg/reconciler/testing"
@@ -123,6 +125,11 @@ func newTestSetup(t *testing.T, opts ...reconcilerOption) (
 			Name:      cfgmap.FeaturesConfigName,
 			Namespace: system.Namespace(),
 		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.RolloutAnalysisConfigName,
+			Namespace: system.Namespace(),
+		},
 	}} {
 		configMapWatcher.OnChange(cfg)
 	}
@@ -677,6 +684,9 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "test-revision-1",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityClusterLocal,
@@ -698,6 +708,9 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "test-revision-1",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityExternalIP,
@@ -721,6 +734,9 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "test-revision-2",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityClusterLocal,
@@ -742,6 +758,9 @@ func TestCreateRouteWithDuplicateTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "test-revision-2",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityExternalIP,
@@ -880,6 +899,9 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "bar",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityClusterLocal,
@@ -899,6 +921,9 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "bar",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityExternalIP,
@@ -922,6 +947,9 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "foo",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityClusterLocal,
@@ -941,6 +969,9 @@ func TestCreateRouteWithNamedTargets(t *testing.T) {
 							"Knative-Serving-Namespace": testNamespace,
 						},
 					}},
+					AppendHeaders: map[string]string{
+						network.TagHeaderName: "foo",
+					},
 				}},
 			},
 			Visibility: v1alpha1.IngressVisibilityExternalIP,
@@ -1639,3 +1670,51 @@ func TestAutoTLSEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestMarkRolloutAnalysis(t *testing.T) {
+	passed := &traffic.RolloutAnalysis{Verdict: traffic.VerdictPassed, RevisionName: "a"}
+	inconclusive := &traffic.RolloutAnalysis{Verdict: traffic.VerdictInconclusive, RevisionName: "b"}
+	failed := &traffic.RolloutAnalysis{Verdict: traffic.VerdictFailed, RevisionName: "c", PreviousRevisionName: "d"}
+	tests := []struct {
+		name     string
+		analyses []*traffic.RolloutAnalysis
+		want     corev1.ConditionStatus
+	}{{
+		name:     "none",
+		analyses: []*traffic.RolloutAnalysis{nil},
+	}, {
+		name:     "passed",
+		analyses: []*traffic.RolloutAnalysis{nil, passed},
+		want:     corev1.ConditionTrue,
+	}, {
+		name:     "inconclusive",
+		analyses: []*traffic.RolloutAnalysis{passed, inconclusive},
+		want:     corev1.ConditionUnknown,
+	}, {
+		name:     "failed",
+		analyses: []*traffic.RolloutAnalysis{inconclusive, failed, passed},
+		want:     corev1.ConditionFalse,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ro := &traffic.Rollout{}
+			for _, a := range tc.analyses {
+				ro.Configurations = append(ro.Configurations, &traffic.ConfigurationRollout{Analysis: a})
+			}
+			rs := &v1.RouteStatus{}
+			rs.InitializeConditions()
+			rs.MarkRolloutAnalysisPassed("stale")
+			markRolloutAnalysis(rs, ro)
+			c := rs.GetCondition(v1.RouteConditionRolloutAnalysis)
+			if tc.want == "" {
+				if c != nil {
+					t.Errorf("RolloutAnalysis condition = %#v, want none", c)
+				}
+				return
+			}
+			if c == nil || c.Status != tc.want {
+				t.Errorf("RolloutAnalysis condition = %#v, want status %s", c, tc.want)
+			}
+		})
+	}
+}
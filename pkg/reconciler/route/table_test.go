@@ -1967,10 +1967,12 @@ func TestReconcile(t *testing.T) {
 			kubeclient:          kubeclient.Get(ctx),
 			client:              servingclient.Get(ctx),
 			netclient:           networkingclient.Get(ctx),
+			routeLister:         listers.GetRouteLister(),
 			configurationLister: listers.GetConfigurationLister(),
 			revisionLister:      listers.GetRevisionLister(),
 			serviceLister:       listers.GetK8sServiceLister(),
 			ingressLister:       listers.GetIngressLister(),
+			podAutoscalerLister: listers.GetPodAutoscalerLister(),
 			tracker:             ctx.Value(TrackerKey).(tracker.Interface),
 			clock:               clock.NewFakePassiveClock(fakeCurTime),
 			enqueueAfter:        func(interface{}, time.Duration) {},
@@ -2519,11 +2521,13 @@ func TestReconcileEnableAutoTLS(t *testing.T) {
 			kubeclient:          kubeclient.Get(ctx),
 			client:              servingclient.Get(ctx),
 			netclient:           networkingclient.Get(ctx),
+			routeLister:         listers.GetRouteLister(),
 			configurationLister: listers.GetConfigurationLister(),
 			revisionLister:      listers.GetRevisionLister(),
 			serviceLister:       listers.GetK8sServiceLister(),
 			ingressLister:       listers.GetIngressLister(),
 			certificateLister:   listers.GetCertificateLister(),
+			podAutoscalerLister: listers.GetPodAutoscalerLister(),
 			tracker:             &NullTracker{},
 			clock:               clock.NewFakePassiveClock(fakeCurTime),
 		}
@@ -2635,11 +2639,13 @@ func TestReconcileEnableAutoTLSHTTPDisabled(t *testing.T) {
 			kubeclient:          kubeclient.Get(ctx),
 			client:              servingclient.Get(ctx),
 			netclient:           networkingclient.Get(ctx),
+			routeLister:         listers.GetRouteLister(),
 			configurationLister: listers.GetConfigurationLister(),
 			revisionLister:      listers.GetRevisionLister(),
 			serviceLister:       listers.GetK8sServiceLister(),
 			ingressLister:       listers.GetIngressLister(),
 			certificateLister:   listers.GetCertificateLister(),
+			podAutoscalerLister: listers.GetPodAutoscalerLister(),
 			tracker:             &NullTracker{},
 			clock:               clock.NewFakePassiveClock(fakeCurTime),
 		}
@@ -2813,6 +2819,7 @@ func reconcilerTestConfig(enableAutoTLS bool) *config.Config {
 			PodSpecTolerations:    cfgmap.Disabled,
 			TagHeaderBasedRouting: cfgmap.Disabled,
 		},
+		RolloutAnalysis: &config.RolloutAnalysis{},
 	}
 }
 
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// analysis.go contains the analysis of the metrics of the newest
+// revision between the steps of a gradual rollout.
+
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// Verdict is the outcome of the analysis of a rollout step.
+type Verdict string
+
+const (
+	// VerdictPassed means the newest revision is within the thresholds
+	// and the rollout may progress.
+	VerdictPassed Verdict = "Passed"
+	// VerdictInconclusive means there is not enough data to decide, so the
+	// rollout is paused until the next analysis.
+	VerdictInconclusive Verdict = "Inconclusive"
+	// VerdictFailed means the newest revision breached the thresholds, so the
+	// traffic was reverted to the previous revision.
+	VerdictFailed Verdict = "Failed"
+	// VerdictAborted means the rollout was aborted manually, so the traffic
+	// was reverted to the previous revision.
+	VerdictAborted Verdict = "Aborted"
+)
+
+// RolloutAnalysis is the outcome of the last analysis of the newest
+// revision of a ConfigurationRollout.
+type RolloutAnalysis struct {
+	// Verdict of the analysis.
+	Verdict Verdict `json:"verdict"`
+	// RevisionName is the name of the analyzed revision.
+	RevisionName string `json:"revisionName"`
+	// PreviousRevisionName is the name of the revision the traffic was
+	// reverted to, if the rollout was rolled back.
+	PreviousRevisionName string `json:"previousRevisionName,omitempty"`
+	// Reason describes the verdict.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RevisionMetrics are the request metrics of a revision over a time window.
+type RevisionMetrics struct {
+	// Requests is the number of served requests.
+	Requests float64
+	// Errors is the number of requests which failed with a 5xx response.
+	Errors float64
+	// Latencies are the latencies of the requests keyed by percentile. A
+	// missing percentile means its latency is unknown.
+	Latencies map[float64]time.Duration
+}
+
+// MetricsSource provides the request metrics of the revisions.
+type MetricsSource interface {
+	// RevisionMetrics returns the metrics of the revision over the window
+	// ending now, with the latencies of the given percentiles.
+	RevisionMetrics(ctx context.Context, namespace, revision string, window time.Duration, percentiles []float64) (RevisionMetrics, error)
+
+	// SplitRequests returns the number of requests the revision served over
+	// the window ending now, which were routed to it by the traffic split
+	// rather than by a tag.
+	SplitRequests(ctx context.Context, namespace, revision string, window time.Duration) (float64, error)
+}
+
+// RolledBack returns true if the traffic of any Configuration was reverted
+// to its previous revision.
+func (cur *Rollout) RolledBack() bool {
+	for _, c := range cur.Configurations {
+		if c.RolledBack() {
+			return true
+		}
+	}
+	return false
+}
+
+// RolledBack returns true if the traffic of the configuration was reverted
+// to its previous revision.
+func (cur *ConfigurationRollout) RolledBack() bool {
+	return cur.Analysis != nil &&
+		(cur.Analysis.Verdict == VerdictFailed || cur.Analysis.Verdict == VerdictAborted)
+}
+
+// Analyze analyzes the metrics of the newest revision of the configurations
+// due to step, against the thresholds.
+// The step is postponed by a step duration if the metrics are inconclusive,
+// and all the traffic of the configuration is reverted to the previous
+// revision if the thresholds are breached.
+// Analyze is expected to be invoked on the previous rollout state, before Step.
+func (cur *Rollout) Analyze(ctx context.Context, src MetricsSource, namespace string,
+	thresholds serving.RolloutThresholds, nowTS int64) {
+	logger := logging.FromContext(ctx)
+	for _, c := range cur.Configurations {
+		// Only analyze the configurations that are due to step.
+		if c.done() || c.StepParams.StepSize == 0 || c.StepParams.Paused || nowTS < c.StepParams.NextStepTime {
+			continue
+		}
+		rev := c.Revisions[len(c.Revisions)-1].RevisionName
+		verdict, reason := analyzeRevision(ctx, src, namespace, rev,
+			time.Duration(c.StepParams.StepDuration), thresholds)
+		logger.Infof("Rollout analysis of revision %s of config %s: %s: %s",
+			rev, c.ConfigurationName, verdict, reason)
+		c.Analysis = &RolloutAnalysis{
+			Verdict:      verdict,
+			RevisionName: rev,
+			Reason:       reason,
+		}
+		switch verdict {
+		case VerdictInconclusive:
+			c.StepParams.NextStepTime = nowTS + c.StepParams.StepDuration
+		case VerdictFailed:
+			c.rollback()
+		}
+	}
+}
+
+// rollback reverts all the traffic of the configuration to the revision
+// preceding the newest one, and stops the rollout.
+// Pre: there are at least two revisions.
+func (cur *ConfigurationRollout) rollback() {
+	prev := cur.Revisions[len(cur.Revisions)-2]
+	prev.Percent = cur.Percent
+	cur.Revisions = []RevisionRollout{prev}
+	cur.StepParams = RolloutParams{}
+	cur.Analysis.PreviousRevisionName = prev.RevisionName
+}
+
+// analyzeRevision fetches the metrics of the revision over the window and
+// judges them against the thresholds.
+func analyzeRevision(ctx context.Context, src MetricsSource, namespace, rev string,
+	window time.Duration, thresholds serving.RolloutThresholds) (Verdict, string) {
+	percentiles := make([]float64, len(thresholds.MaxLatencies))
+	for i, l := range thresholds.MaxLatencies {
+		percentiles[i] = l.Percentile
+	}
+	m, err := src.RevisionMetrics(ctx, namespace, rev, window, percentiles)
+	if err != nil {
+		return VerdictInconclusive, fmt.Sprint("failed to fetch the metrics: ", err)
+	}
+	if m.Requests < float64(thresholds.MinRequests) {
+		return VerdictInconclusive, fmt.Sprintf("%v requests were served, at least %d are needed",
+			math.Round(m.Requests), thresholds.MinRequests)
+	}
+	if thresholds.MaxErrorRate >= 0 {
+		if rate := m.Errors / m.Requests; rate > thresholds.MaxErrorRate {
+			return VerdictFailed, fmt.Sprintf("the error rate %.4g exceeds %v", rate, thresholds.MaxErrorRate)
+		}
+	}
+	for _, l := range thresholds.MaxLatencies {
+		d, ok := m.Latencies[l.Percentile]
+		if !ok {
+			return VerdictInconclusive, fmt.Sprintf("the %s latency is unknown", l)
+		}
+		if d > l.Max {
+			return VerdictFailed, fmt.Sprintf("the %s latency %v exceeds %v", l, d, l.Max)
+		}
+	}
+	return VerdictPassed, "the metrics are within the thresholds"
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+type fakeMetricsSource struct {
+	metrics RevisionMetrics
+	err     error
+
+	revision    string
+	window      time.Duration
+	percentiles []float64
+}
+
+func (f *fakeMetricsSource) RevisionMetrics(_ context.Context, _, revision string,
+	window time.Duration, percentiles []float64) (RevisionMetrics, error) {
+	f.revision, f.window, f.percentiles = revision, window, percentiles
+	return f.metrics, f.err
+}
+
+func (f *fakeMetricsSource) SplitRequests(_ context.Context, _, revision string, window time.Duration) (float64, error) {
+	f.revision, f.window = revision, window
+	return f.metrics.Requests, f.err
+}
+
+func TestAnalyze(t *testing.T) {
+	const (
+		now          = 2020_000_000_000
+		stepDuration = int64(30 * time.Second)
+	)
+	thresholds := serving.RolloutThresholds{
+		MaxErrorRate: 0.1,
+		MaxLatencies: []serving.LatencyThreshold{{Percentile: 99, Max: 500 * time.Millisecond}},
+		MinRequests:  10,
+	}
+	inRollout := func() *ConfigurationRollout {
+		return &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           90,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      10,
+			}, {
+				RevisionName: "stormbreaker",
+				Percent:      30,
+			}, {
+				RevisionName: "jarnbjorn",
+				Percent:      50,
+			}},
+			StepParams: RolloutParams{
+				StartTime:    now - 3*stepDuration,
+				NextStepTime: now,
+				StepDuration: stepDuration,
+				StepSize:     20,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ro      *ConfigurationRollout
+		metrics RevisionMetrics
+		err     error
+		want    *ConfigurationRollout
+	}{{
+		name: "not due",
+		ro: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + 1
+			return ro
+		}(),
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + 1
+			return ro
+		}(),
+	}, {
+		name: "not observed ready yet",
+		ro: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams = RolloutParams{StartTime: now}
+			return ro
+		}(),
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams = RolloutParams{StartTime: now}
+			return ro
+		}(),
+	}, {
+		name: "passed",
+		ro:   inRollout(),
+		metrics: RevisionMetrics{
+			Requests:  100,
+			Errors:    10,
+			Latencies: map[float64]time.Duration{99: 500 * time.Millisecond},
+		},
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictPassed,
+				RevisionName: "jarnbjorn",
+				Reason:       "the metrics are within the thresholds",
+			}
+			return ro
+		}(),
+	}, {
+		name:    "too few requests",
+		ro:      inRollout(),
+		metrics: RevisionMetrics{Requests: 9},
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + stepDuration
+			ro.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictInconclusive,
+				RevisionName: "jarnbjorn",
+				Reason:       "9 requests were served, at least 10 are needed",
+			}
+			return ro
+		}(),
+	}, {
+		name: "no metrics",
+		ro:   inRollout(),
+		err:  errors.New("connection refused"),
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + stepDuration
+			ro.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictInconclusive,
+				RevisionName: "jarnbjorn",
+				Reason:       "failed to fetch the metrics: connection refused",
+			}
+			return ro
+		}(),
+	}, {
+		name:    "unknown latency",
+		ro:      inRollout(),
+		metrics: RevisionMetrics{Requests: 100},
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + stepDuration
+			ro.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictInconclusive,
+				RevisionName: "jarnbjorn",
+				Reason:       "the p99 latency is unknown",
+			}
+			return ro
+		}(),
+	}, {
+		name:    "error rate exceeded",
+		ro:      inRollout(),
+		metrics: RevisionMetrics{Requests: 100, Errors: 25},
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           90,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      90,
+			}},
+			Analysis: &RolloutAnalysis{
+				Verdict:              VerdictFailed,
+				RevisionName:         "jarnbjorn",
+				PreviousRevisionName: "stormbreaker",
+				Reason:               "the error rate 0.25 exceeds 0.1",
+			},
+		},
+	}, {
+		name: "latency exceeded",
+		ro:   inRollout(),
+		metrics: RevisionMetrics{
+			Requests:  100,
+			Latencies: map[float64]time.Duration{99: 501 * time.Millisecond},
+		},
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           90,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      90,
+			}},
+			Analysis: &RolloutAnalysis{
+				Verdict:              VerdictFailed,
+				RevisionName:         "jarnbjorn",
+				PreviousRevisionName: "stormbreaker",
+				Reason:               "the p99 latency 501ms exceeds 500ms",
+			},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			src := &fakeMetricsSource{metrics: tc.metrics, err: tc.err}
+			ro := &Rollout{Configurations: []*ConfigurationRollout{tc.ro}}
+			ro.Analyze(TestContextWithLogger(t), src, "asgard", thresholds, now)
+			if got := ro.Configurations[0]; !cmp.Equal(got, tc.want) {
+				t.Errorf("Analyzed rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(tc.want, got))
+			}
+			if tc.want.Analysis == nil {
+				return
+			}
+			if got, want := src.revision, "jarnbjorn"; got != want {
+				t.Errorf("Analyzed revision = %s, want: %s", got, want)
+			}
+			if got, want := src.window, time.Duration(stepDuration); got != want {
+				t.Errorf("Analyzed window = %v, want: %v", got, want)
+			}
+			if got, want := src.percentiles, []float64{99}; !cmp.Equal(got, want) {
+				t.Errorf("Analyzed percentiles = %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStepAfterAnalysis(t *testing.T) {
+	const now = 2020_000_000_000
+	rolledBack := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      100,
+			}},
+			Analysis: &RolloutAnalysis{
+				Verdict:              VerdictFailed,
+				RevisionName:         "stormbreaker",
+				PreviousRevisionName: "mjolnir",
+				Reason:               "the error rate 1 exceeds 0",
+			},
+		}},
+	}
+	t.Run("reverted revision is not rolled out again", func(t *testing.T) {
+		got, _ := goal("stormbreaker", 100).Step(TestContextWithLogger(t), rolledBack, linear, now)
+		if !cmp.Equal(got, rolledBack) {
+			t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(rolledBack, got))
+		}
+		if !got.RolledBack() || !got.Done() {
+			t.Errorf("RolledBack = %v, Done = %v, want both true", got.RolledBack(), got.Done())
+		}
+	})
+
+	t.Run("reverted revision follows the configuration percent", func(t *testing.T) {
+		prev := &Rollout{Configurations: []*ConfigurationRollout{{}}}
+		*prev.Configurations[0] = *rolledBack.Configurations[0]
+		prev.Configurations[0].Revisions = []RevisionRollout{{RevisionName: "mjolnir", Percent: 100}}
+		got, _ := goal("stormbreaker", 60).Step(TestContextWithLogger(t), prev, linear, now)
+		want := []RevisionRollout{{RevisionName: "mjolnir", Percent: 60}}
+		if !cmp.Equal(got.Configurations[0].Revisions, want) {
+			t.Errorf("Revisions mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got.Configurations[0].Revisions))
+		}
+	})
+
+	t.Run("newer revision is rolled out", func(t *testing.T) {
+		got, _ := goal("jarnbjorn", 100).Step(TestContextWithLogger(t), rolledBack, linear, now)
+		want := &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions: []RevisionRollout{{
+					RevisionName: "mjolnir",
+					Percent:      99,
+				}, {
+					RevisionName: "jarnbjorn",
+					Percent:      1,
+				}},
+				StepParams: RolloutParams{StartTime: now},
+			}},
+		}
+		if !cmp.Equal(got, want) {
+			t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got))
+		}
+	})
+
+	t.Run("analysis is kept during the rollout", func(t *testing.T) {
+		analysis := &RolloutAnalysis{
+			Verdict:      VerdictInconclusive,
+			RevisionName: "stormbreaker",
+			Reason:       "0 requests were served, at least 10 are needed",
+		}
+		prev := &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions: []RevisionRollout{{
+					RevisionName: "mjolnir",
+					Percent:      99,
+				}, {
+					RevisionName: "stormbreaker",
+					Percent:      1,
+				}},
+				StepParams: RolloutParams{
+					StartTime:    now - 10,
+					NextStepTime: now + 10,
+					StepDuration: 10,
+					StepSize:     33,
+				},
+				Analysis: analysis,
+			}},
+		}
+		got, nextStep := goal("stormbreaker", 100).Step(TestContextWithLogger(t), prev, linear, now)
+		if !cmp.Equal(got, prev) {
+			t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(prev, got))
+		}
+		if got, want := nextStep, int64(now+10); got != want {
+			t.Errorf("Next step = %d, want: %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// bluegreen.go contains the cut over of the blue/green rollouts.
+
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+
+	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
+	palisters "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+// warmCheckInterval is the number of nanoseconds between the checks of the
+// scale of the new revision of a blue/green rollout.
+const warmCheckInterval = int64(10 * time.Second)
+
+// ScaleSource provides the observed scale of the revisions.
+type ScaleSource interface {
+	// ActualScale returns the number of ready pods of the revision, 0 if unknown.
+	ActualScale(namespace, revision string) (int32, error)
+}
+
+// NewScaleSource returns a ScaleSource reading the actual scale of the
+// revisions from the status of their PodAutoscalers.
+func NewScaleSource(lister palisters.PodAutoscalerLister) ScaleSource {
+	return paScaleSource{lister: lister}
+}
+
+type paScaleSource struct {
+	lister palisters.PodAutoscalerLister
+}
+
+func (s paScaleSource) ActualScale(namespace, revision string) (int32, error) {
+	// The PodAutoscaler is named after its revision.
+	pa, err := s.lister.PodAutoscalers(namespace).Get(revision)
+	if apierrs.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if pa.Status.ActualScale == nil {
+		return 0, nil
+	}
+	return *pa.Status.ActualScale, nil
+}
+
+// CutOver shifts all the traffic of the blue/green rollouts due to check to
+// their newest revision, once it has at least as many ready pods as the
+// revisions it replaces. The check is repeated otherwise, until the deadline
+// of the rollout, past which the traffic is reverted to the previous revision.
+// CutOver is expected to be invoked on the previous rollout state, before Step.
+func (cur *Rollout) CutOver(ctx context.Context, src ScaleSource, namespace string, nowTS int64) {
+	logger := logging.FromContext(ctx)
+	for _, c := range cur.Configurations {
+		if c.done() || c.StepParams.Strategy != serving.RolloutStrategyBlueGreen ||
+			c.StepParams.Paused || nowTS < c.StepParams.NextStepTime {
+			continue
+		}
+		rev := c.Revisions[len(c.Revisions)-1].RevisionName
+		switch got, want, err := c.scales(src, namespace); {
+		case err != nil:
+			logger.Warnw("Failed to get the scale of the revisions of config "+c.ConfigurationName, zap.Error(err))
+		case got >= want:
+			logger.Infof("Revision %s of config %s is warm at %d pods, shifting all the traffic to it",
+				rev, c.ConfigurationName, got)
+			c.promote()
+			continue
+		case c.StepParams.Deadline > 0 && nowTS >= c.StepParams.Deadline:
+			c.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictFailed,
+				RevisionName: rev,
+				Reason:       fmt.Sprintf("the revision had %d of %d pods at the cut-over deadline", got, want),
+			}
+			logger.Infof("Rollout of revision %s of config %s is aborted: %s", rev, c.ConfigurationName, c.Analysis.Reason)
+			c.rollback()
+			continue
+		default:
+			logger.Debugf("Revision %s of config %s has %d of %d pods", rev, c.ConfigurationName, got, want)
+		}
+		c.StepParams.NextStepTime = nowTS + warmCheckInterval
+	}
+}
+
+// scales returns the scale of the newest revision and the total scale of
+// the previous revisions which receive traffic.
+func (cur *ConfigurationRollout) scales(src ScaleSource, namespace string) (int32, int32, error) {
+	last := len(cur.Revisions) - 1
+	var want int32
+	for _, r := range cur.Revisions[:last] {
+		if r.Percent == 0 {
+			continue
+		}
+		s, err := src.ActualScale(namespace, r.RevisionName)
+		if err != nil {
+			return 0, 0, err
+		}
+		want += s
+	}
+	got, err := src.ActualScale(namespace, cur.Revisions[last].RevisionName)
+	return got, want, err
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/pkg/ptr"
+	av1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"knative.dev/serving/pkg/apis/serving"
+	palisters "knative.dev/serving/pkg/client/listers/autoscaling/v1alpha1"
+)
+
+// fakeScaleSource returns the scales of the revisions keyed by name.
+type fakeScaleSource map[string]int32
+
+func (s fakeScaleSource) ActualScale(namespace, revision string) (int32, error) {
+	if namespace != "asgard" {
+		return 0, errors.New("unexpected namespace " + namespace)
+	}
+	if revision == "broken" {
+		return 0, errors.New("broken")
+	}
+	return s[revision], nil
+}
+
+func TestCutOver(t *testing.T) {
+	const now = 1982
+	blueGreen := func(nextStepTime int64, paused bool, revs ...RevisionRollout) *Rollout {
+		return &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions:         revs,
+				StepParams: RolloutParams{
+					StartTime:    1977,
+					NextStepTime: nextStepTime,
+					Strategy:     serving.RolloutStrategyBlueGreen,
+					Paused:       paused,
+					Deadline:     now + 1,
+				},
+			}},
+		}
+	}
+	pastDeadline := func(revs ...RevisionRollout) *Rollout {
+		ro := blueGreen(now, false, revs...)
+		ro.Configurations[0].StepParams.Deadline = now
+		return ro
+	}
+	old := RevisionRollout{RevisionName: "mjolnir", Percent: 100}
+	stacked := []RevisionRollout{{
+		RevisionName: "mjolnir",
+		Percent:      60,
+	}, {
+		RevisionName: "gungnir",
+		Percent:      40,
+	}, {
+		RevisionName: "stormbreaker",
+	}}
+	cutOver := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		ro     *Rollout
+		scales fakeScaleSource
+		want   *Rollout
+	}{{
+		name:   "warm",
+		ro:     blueGreen(now, false, old, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{"mjolnir": 3, "stormbreaker": 4},
+		want:   cutOver,
+	}, {
+		name:   "warm stacked",
+		ro:     blueGreen(now, false, stacked...),
+		scales: fakeScaleSource{"mjolnir": 2, "gungnir": 1, "stormbreaker": 3},
+		want:   cutOver,
+	}, {
+		name:   "idle",
+		ro:     blueGreen(now, false, old, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{},
+		want:   cutOver,
+	}, {
+		name:   "cold",
+		ro:     blueGreen(now, false, stacked...),
+		scales: fakeScaleSource{"mjolnir": 2, "gungnir": 1, "stormbreaker": 2},
+		want:   blueGreen(now+warmCheckInterval, false, stacked...),
+	}, {
+		name:   "warm at the deadline",
+		ro:     pastDeadline(old, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{"mjolnir": 3, "stormbreaker": 3},
+		want:   cutOver,
+	}, {
+		name:   "cold at the deadline",
+		ro:     pastDeadline(stacked...),
+		scales: fakeScaleSource{"mjolnir": 2, "gungnir": 1, "stormbreaker": 2},
+		want: &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions: []RevisionRollout{{
+					RevisionName: "gungnir",
+					Percent:      100,
+				}},
+				Analysis: &RolloutAnalysis{
+					Verdict:              VerdictFailed,
+					RevisionName:         "stormbreaker",
+					PreviousRevisionName: "gungnir",
+					Reason:               "the revision had 2 of 3 pods at the cut-over deadline",
+				},
+			}},
+		},
+	}, {
+		name:   "unknown scale",
+		ro:     blueGreen(now, false, RevisionRollout{RevisionName: "broken", Percent: 100}, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{"stormbreaker": 2},
+		want:   blueGreen(now+warmCheckInterval, false, RevisionRollout{RevisionName: "broken", Percent: 100}, RevisionRollout{RevisionName: "stormbreaker"}),
+	}, {
+		name:   "too soon",
+		ro:     blueGreen(now+1, false, old, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{"mjolnir": 3, "stormbreaker": 4},
+		want:   blueGreen(now+1, false, old, RevisionRollout{RevisionName: "stormbreaker"}),
+	}, {
+		name:   "paused",
+		ro:     blueGreen(now, true, old, RevisionRollout{RevisionName: "stormbreaker"}),
+		scales: fakeScaleSource{"mjolnir": 3, "stormbreaker": 4},
+		want:   blueGreen(now, true, old, RevisionRollout{RevisionName: "stormbreaker"}),
+	}, {
+		name: "linear",
+		ro: &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions:         []RevisionRollout{{RevisionName: "mjolnir", Percent: 99}, {RevisionName: "stormbreaker", Percent: 1}},
+				StepParams:        RolloutParams{StartTime: 1977},
+			}},
+		},
+		scales: fakeScaleSource{"mjolnir": 3, "stormbreaker": 4},
+		want: &Rollout{
+			Configurations: []*ConfigurationRollout{{
+				ConfigurationName: "thor",
+				Percent:           100,
+				Revisions:         []RevisionRollout{{RevisionName: "mjolnir", Percent: 99}, {RevisionName: "stormbreaker", Percent: 1}},
+				StepParams:        RolloutParams{StartTime: 1977},
+			}},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ro.CutOver(TestContextWithLogger(t), tc.scales, "asgard", now)
+			if !cmp.Equal(tc.ro, tc.want) {
+				t.Error("Unexpected rollout (-want, +got):", cmp.Diff(tc.want, tc.ro))
+			}
+		})
+	}
+}
+
+func TestPodAutoscalerScaleSource(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&av1alpha1.PodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "asgard", Name: "mjolnir"},
+		Status:     av1alpha1.PodAutoscalerStatus{ActualScale: ptr.Int32(3)},
+	})
+	indexer.Add(&av1alpha1.PodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "asgard", Name: "stormbreaker"},
+	})
+	src := NewScaleSource(palisters.NewPodAutoscalerLister(indexer))
+
+	for rev, want := range map[string]int32{
+		"mjolnir":      3,
+		"stormbreaker": 0, // Not observed yet.
+		"gungnir":      0, // Missing.
+	} {
+		if got, err := src.ActualScale("asgard", rev); err != nil || got != want {
+			t.Errorf("ActualScale(%s) = %d, %v, want: %d", rev, got, err, want)
+		}
+	}
+}
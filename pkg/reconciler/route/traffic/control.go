@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// control.go contains the manual control of the gradual rollouts.
+
+package traffic
+
+import (
+	"context"
+
+	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// RolloutControl records the last change of the manual control of the rollout.
+type RolloutControl struct {
+	// State is the value of the serving.RolloutControlKey annotation,
+	// empty when the rollouts are not controlled manually.
+	State string `json:"state,omitempty"`
+
+	// By is the user who changed the state, if known.
+	By string `json:"by,omitempty"`
+
+	// Time is the Unix timestamp in ns when the change was observed.
+	Time int64 `json:"time"`
+}
+
+// ApplyControl applies the manual control state to the rollout and records the
+// change of the state, along with the user who made it.
+// Paused configurations keep their current traffic split, and take their
+// next step a step duration after they are resumed. Promote and abort act
+// once, when the state changes to them: promote completes the rollouts in
+// progress and abort reverts them to their previous revisions.
+// ApplyControl is expected to be invoked on the previous rollout state, before Step.
+func (cur *Rollout) ApplyControl(ctx context.Context, state, by string, nowTS int64) {
+	prevState := ""
+	if cur.Control != nil {
+		prevState = cur.Control.State
+	}
+	changed := state != prevState
+	if changed {
+		logging.FromContext(ctx).Infof("Rollout control changed from %q to %q by %q", prevState, state, by)
+		cur.Control = &RolloutControl{
+			State: state,
+			By:    by,
+			Time:  nowTS,
+		}
+	}
+
+	for _, c := range cur.Configurations {
+		if c.done() {
+			continue
+		}
+		switch {
+		case state == serving.RolloutControlPaused:
+			c.StepParams.Paused = true
+		case c.StepParams.Paused:
+			c.StepParams.Paused = false
+			if c.StepParams.StepDuration > 0 {
+				c.StepParams.NextStepTime = nowTS + c.StepParams.StepDuration
+			}
+		}
+		if !changed {
+			continue
+		}
+		switch state {
+		case serving.RolloutControlPromote:
+			c.promote()
+		case serving.RolloutControlAbort:
+			rev := c.Revisions[len(c.Revisions)-1].RevisionName
+			c.Analysis = &RolloutAnalysis{
+				Verdict:      VerdictAborted,
+				RevisionName: rev,
+				Reason:       "the rollout was aborted by " + userOrUnknown(by),
+			}
+			c.rollback()
+		}
+	}
+}
+
+// Paused returns true if the rollouts in progress are paused manually.
+func (cur *Rollout) Paused() bool {
+	return cur.Control != nil && cur.Control.State == serving.RolloutControlPaused
+}
+
+// promote routes all the traffic of the configuration to the newest revision,
+// and stops the rollout.
+func (cur *ConfigurationRollout) promote() {
+	last := cur.Revisions[len(cur.Revisions)-1]
+	last.Percent = cur.Percent
+	cur.Revisions = []RevisionRollout{last}
+	cur.StepParams = RolloutParams{}
+}
+
+func userOrUnknown(by string) string {
+	if by == "" {
+		return "an unknown user"
+	}
+	return by
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+func TestApplyControl(t *testing.T) {
+	const (
+		now          = 2020_000_000_000
+		stepDuration = int64(30 * time.Second)
+		user         = "oveja@knative.dev"
+	)
+	inRollout := func() *ConfigurationRollout {
+		return &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      70,
+			}, {
+				RevisionName: "stormbreaker",
+				Percent:      30,
+			}},
+			StepParams: RolloutParams{
+				StartTime:    now - 3*stepDuration,
+				NextStepTime: now + 10,
+				StepDuration: stepDuration,
+				StepSize:     10,
+			},
+		}
+	}
+	paused := func() *ConfigurationRollout {
+		ro := inRollout()
+		ro.StepParams.Paused = true
+		return ro
+	}
+
+	tests := []struct {
+		name        string
+		ro          *ConfigurationRollout
+		control     *RolloutControl
+		state       string
+		want        *ConfigurationRollout
+		wantControl *RolloutControl
+	}{{
+		name: "no control",
+		ro:   inRollout(),
+		want: inRollout(),
+	}, {
+		name:        "pause",
+		ro:          inRollout(),
+		state:       serving.RolloutControlPaused,
+		want:        paused(),
+		wantControl: &RolloutControl{State: serving.RolloutControlPaused, By: user, Time: now},
+	}, {
+		name:        "stay paused",
+		ro:          paused(),
+		control:     &RolloutControl{State: serving.RolloutControlPaused, By: user, Time: now - 100},
+		state:       serving.RolloutControlPaused,
+		want:        paused(),
+		wantControl: &RolloutControl{State: serving.RolloutControlPaused, By: user, Time: now - 100},
+	}, {
+		name:    "resume",
+		ro:      paused(),
+		control: &RolloutControl{State: serving.RolloutControlPaused, By: user, Time: now - 100},
+		want: func() *ConfigurationRollout {
+			ro := inRollout()
+			ro.StepParams.NextStepTime = now + stepDuration
+			return ro
+		}(),
+		wantControl: &RolloutControl{By: user, Time: now},
+	}, {
+		name:  "promote",
+		ro:    inRollout(),
+		state: serving.RolloutControlPromote,
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		},
+		wantControl: &RolloutControl{State: serving.RolloutControlPromote, By: user, Time: now},
+	}, {
+		name:  "promote paused",
+		ro:    paused(),
+		state: serving.RolloutControlPromote,
+		control: &RolloutControl{
+			State: serving.RolloutControlPaused, By: user, Time: now - 100,
+		},
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		},
+		wantControl: &RolloutControl{State: serving.RolloutControlPromote, By: user, Time: now},
+	}, {
+		name:        "promote only once",
+		ro:          inRollout(),
+		control:     &RolloutControl{State: serving.RolloutControlPromote, By: user, Time: now - 100},
+		state:       serving.RolloutControlPromote,
+		want:        inRollout(),
+		wantControl: &RolloutControl{State: serving.RolloutControlPromote, By: user, Time: now - 100},
+	}, {
+		name:  "abort",
+		ro:    inRollout(),
+		state: serving.RolloutControlAbort,
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      100,
+			}},
+			Analysis: &RolloutAnalysis{
+				Verdict:              VerdictAborted,
+				RevisionName:         "stormbreaker",
+				PreviousRevisionName: "mjolnir",
+				Reason:               "the rollout was aborted by " + user,
+			},
+		},
+		wantControl: &RolloutControl{State: serving.RolloutControlAbort, By: user, Time: now},
+	}, {
+		name:        "abort only once",
+		ro:          inRollout(),
+		control:     &RolloutControl{State: serving.RolloutControlAbort, By: user, Time: now - 100},
+		state:       serving.RolloutControlAbort,
+		want:        inRollout(),
+		wantControl: &RolloutControl{State: serving.RolloutControlAbort, By: user, Time: now - 100},
+	}, {
+		name: "done",
+		ro: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      100,
+			}},
+		},
+		state: serving.RolloutControlAbort,
+		want: &ConfigurationRollout{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      100,
+			}},
+		},
+		wantControl: &RolloutControl{State: serving.RolloutControlAbort, By: user, Time: now},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ro := &Rollout{
+				Configurations: []*ConfigurationRollout{tc.ro},
+				Control:        tc.control,
+			}
+			ro.ApplyControl(TestContextWithLogger(t), tc.state, user, now)
+			if got := ro.Configurations[0]; !cmp.Equal(got, tc.want) {
+				t.Errorf("Controlled rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(tc.want, got))
+			}
+			if !cmp.Equal(ro.Control, tc.wantControl) {
+				t.Errorf("Control mismatch: diff(-want,+got):\n%s", cmp.Diff(tc.wantControl, ro.Control))
+			}
+			if got, want := ro.Paused(), tc.state == serving.RolloutControlPaused; got != want {
+				t.Errorf("Paused = %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStepPaused(t *testing.T) {
+	const (
+		now          = 2020_000_000_000
+		stepDuration = int64(30 * time.Second)
+	)
+	control := &RolloutControl{State: serving.RolloutControlPaused, By: "oveja@knative.dev", Time: now - 100}
+	prev := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      70,
+			}, {
+				RevisionName: "stormbreaker",
+				Percent:      30,
+			}},
+			StepParams: RolloutParams{
+				StartTime:    now - 3*stepDuration,
+				NextStepTime: now - 10,
+				StepDuration: stepDuration,
+				StepSize:     10,
+				Paused:       true,
+			},
+		}},
+		Control: control,
+	}
+	goal := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "stormbreaker",
+				Percent:      100,
+			}},
+		}},
+	}
+
+	got, nextStep := goal.Step(TestContextWithLogger(t), prev, linear, now)
+	if !cmp.Equal(got, prev) {
+		t.Errorf("Rollout mismatch: diff(-want,+got):\n%s", cmp.Diff(prev, got))
+	}
+	if nextStep != 0 {
+		t.Errorf("Next step = %d, want: 0", nextStep)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// observe.go contains the observation of the actual split of the traffic.
+
+package traffic
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"knative.dev/pkg/ptr"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// TrafficObservation is the observed share of the requests of a revision.
+type TrafficObservation struct {
+	RevisionName string
+	// Configured is the percent of the traffic configured for the revision.
+	Configured int64
+	// Observed is the percent of the requests it served.
+	Observed int64
+}
+
+// Deviation returns the number of percentage points the observed share
+// deviates from the configured one.
+func (o *TrafficObservation) Deviation() int64 {
+	d := o.Observed - o.Configured
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// ObserveRequests returns the number of requests routed by the traffic split
+// over the window to each revision receiving a percentage of the traffic.
+func ObserveRequests(ctx context.Context, src MetricsSource, namespace string,
+	targets []v1.TrafficTarget, window time.Duration) (map[string]float64, error) {
+	ret := make(map[string]float64, len(targets))
+	for _, tt := range targets {
+		if _, ok := ret[tt.RevisionName]; ok || tt.Percent == nil || *tt.Percent == 0 {
+			continue
+		}
+		n, err := src.SplitRequests(ctx, namespace, tt.RevisionName, window)
+		if err != nil {
+			return nil, err
+		}
+		ret[tt.RevisionName] = n
+	}
+	return ret, nil
+}
+
+// ObserveTargets sets the ObservedPercent of the targets to the share of the
+// requests served by their revisions, split among the targets of the same
+// revision in proportion to their percent. It returns the observation of
+// the revision deviating the most from its configured percent, or nil if
+// there are no requests to observe.
+// The revisions missing from the requests are assumed to serve none.
+func ObserveTargets(targets []v1.TrafficTarget, requests map[string]float64) *TrafficObservation {
+	configured := make(map[string]int64, len(targets))
+	for _, tt := range targets {
+		if tt.Percent != nil {
+			configured[tt.RevisionName] += *tt.Percent
+		}
+	}
+	var total float64
+	for rev, r := range requests {
+		// The requests sent to the tags of the revisions without a
+		// percentage of the traffic are not part of the split.
+		if configured[rev] > 0 {
+			total += r
+		}
+	}
+	if total == 0 {
+		for i := range targets {
+			targets[i].ObservedPercent = nil
+		}
+		return nil
+	}
+
+	var worst *TrafficObservation
+	for i := range targets {
+		tt := &targets[i]
+		cp := configured[tt.RevisionName]
+		if tt.Percent == nil || *tt.Percent == 0 || cp == 0 {
+			tt.ObservedPercent = ptr.Int64(0)
+			continue
+		}
+		share := 100 * requests[tt.RevisionName] / total
+		tt.ObservedPercent = ptr.Int64(int64(math.Round(share * float64(*tt.Percent) / float64(cp))))
+
+		o := &TrafficObservation{
+			RevisionName: tt.RevisionName,
+			Configured:   cp,
+			Observed:     int64(math.Round(share)),
+		}
+		if worst == nil || o.Deviation() > worst.Deviation() {
+			worst = o
+		}
+	}
+	return worst
+}
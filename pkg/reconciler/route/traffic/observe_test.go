@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/pkg/ptr"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// fakeRequestSource returns the requests of the revisions keyed by name.
+type fakeRequestSource map[string]float64
+
+func (s fakeRequestSource) RevisionMetrics(context.Context, string, string, time.Duration, []float64) (RevisionMetrics, error) {
+	return RevisionMetrics{}, errors.New("not the split requests")
+}
+
+func (s fakeRequestSource) SplitRequests(_ context.Context, _, revision string, _ time.Duration) (float64, error) {
+	if revision == "broken" {
+		return 0, errors.New("broken")
+	}
+	return s[revision], nil
+}
+
+func TestObserveRequests(t *testing.T) {
+	targets := []v1.TrafficTarget{{
+		RevisionName: "mjolnir",
+		Percent:      ptr.Int64(60),
+	}, {
+		Tag:          "hammer",
+		RevisionName: "mjolnir",
+		Percent:      ptr.Int64(0),
+	}, {
+		Tag:          "axe",
+		RevisionName: "stormbreaker",
+		Percent:      ptr.Int64(40),
+	}, {
+		Tag:          "spear",
+		RevisionName: "gungnir",
+	}}
+	src := fakeRequestSource{"mjolnir": 60, "stormbreaker": 30, "gungnir": 10}
+
+	got, err := ObserveRequests(context.Background(), src, "asgard", targets, time.Minute)
+	if err != nil {
+		t.Fatal("ObserveRequests() =", err)
+	}
+	if want := map[string]float64{"mjolnir": 60, "stormbreaker": 30}; !cmp.Equal(got, want) {
+		t.Error("ObserveRequests (-want, +got):", cmp.Diff(want, got))
+	}
+
+	targets = append(targets, v1.TrafficTarget{RevisionName: "broken", Percent: ptr.Int64(1)})
+	if _, err := ObserveRequests(context.Background(), src, "asgard", targets, time.Minute); err == nil {
+		t.Error("ObserveRequests() = nil, want an error")
+	}
+}
+
+func TestObserveTargets(t *testing.T) {
+	tests := []struct {
+		name         string
+		targets      []v1.TrafficTarget
+		requests     map[string]float64
+		wantObserved []*int64
+		want         *TrafficObservation
+	}{{
+		name: "as configured",
+		targets: []v1.TrafficTarget{{
+			RevisionName: "mjolnir",
+			Percent:      ptr.Int64(60),
+		}, {
+			RevisionName: "stormbreaker",
+			Percent:      ptr.Int64(40),
+		}},
+		requests:     map[string]float64{"mjolnir": 61, "stormbreaker": 39},
+		wantObserved: []*int64{ptr.Int64(61), ptr.Int64(39)},
+		want:         &TrafficObservation{RevisionName: "mjolnir", Configured: 60, Observed: 61},
+	}, {
+		name: "unready revision",
+		targets: []v1.TrafficTarget{{
+			RevisionName: "mjolnir",
+			Percent:      ptr.Int64(50),
+		}, {
+			RevisionName: "stormbreaker",
+			Percent:      ptr.Int64(50),
+		}},
+		requests:     map[string]float64{"mjolnir": 90, "stormbreaker": 10},
+		wantObserved: []*int64{ptr.Int64(90), ptr.Int64(10)},
+		want:         &TrafficObservation{RevisionName: "mjolnir", Configured: 50, Observed: 90},
+	}, {
+		name: "shared revision and tags",
+		targets: []v1.TrafficTarget{{
+			RevisionName: "mjolnir",
+			Percent:      ptr.Int64(30),
+		}, {
+			Tag:          "hammer",
+			RevisionName: "mjolnir",
+			Percent:      ptr.Int64(10),
+		}, {
+			Tag:          "axe",
+			RevisionName: "stormbreaker",
+			Percent:      ptr.Int64(60),
+		}, {
+			Tag:          "spear",
+			RevisionName: "gungnir",
+		}},
+		requests:     map[string]float64{"mjolnir": 40, "stormbreaker": 60, "gungnir": 1000},
+		wantObserved: []*int64{ptr.Int64(30), ptr.Int64(10), ptr.Int64(60), ptr.Int64(0)},
+		want:         &TrafficObservation{RevisionName: "mjolnir", Configured: 40, Observed: 40},
+	}, {
+		name: "no requests",
+		targets: []v1.TrafficTarget{{
+			RevisionName:    "mjolnir",
+			Percent:         ptr.Int64(100),
+			ObservedPercent: ptr.Int64(100),
+		}},
+		requests:     map[string]float64{},
+		wantObserved: []*int64{nil},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ObserveTargets(tc.targets, tc.requests)
+			if !cmp.Equal(got, tc.want) {
+				t.Error("ObserveTargets (-want, +got):", cmp.Diff(tc.want, got))
+			}
+			observed := make([]*int64, 0, len(tc.targets))
+			for _, tt := range tc.targets {
+				observed = append(observed, tt.ObservedPercent)
+			}
+			if !cmp.Equal(observed, tc.wantObserved) {
+				t.Error("ObservedPercent (-want, +got):", cmp.Diff(tc.wantObserved, observed))
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// prewarm.go contains the computation of the scale the new revisions are
+// pre-warmed to during the rollouts.
+
+package traffic
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+// PreWarmScales returns the scale to pre-warm the newest revision of each
+// rollout in progress to, keyed by the revision name. It is the observed
+// scale of the previous revisions which receive traffic.
+// The blue/green rollouts are always pre-warmed, since their newest revision
+// receives no traffic to scale it up before the cut over, and the other ones
+// only if all is true.
+// The revisions whose previous revisions are idle or whose scale is
+// unknown are omitted.
+func (cur *Rollout) PreWarmScales(ctx context.Context, src ScaleSource, namespace string, all bool) map[string]int32 {
+	logger := logging.FromContext(ctx)
+	ret := make(map[string]int32, len(cur.Configurations))
+	for _, c := range cur.Configurations {
+		if c.done() || (!all && c.StepParams.Strategy != serving.RolloutStrategyBlueGreen) {
+			continue
+		}
+		_, want, err := c.scales(src, namespace)
+		if err != nil {
+			logger.Warnw("Failed to get the scale of the revisions of config "+c.ConfigurationName, zap.Error(err))
+			continue
+		}
+		if want > 0 {
+			ret[c.Revisions[len(c.Revisions)-1].RevisionName] = want
+		}
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/serving"
+)
+
+func TestPreWarmScales(t *testing.T) {
+	ro := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      60,
+			}, {
+				RevisionName: "gungnir",
+				Percent:      40,
+			}, {
+				RevisionName: "stormbreaker",
+			}},
+		}, {
+			ConfigurationName: "loki",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "tesseract",
+				Percent:      100,
+			}},
+		}, {
+			ConfigurationName: "odin",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "broken",
+				Percent:      99,
+			}, {
+				RevisionName: "draupnir",
+				Percent:      1,
+			}},
+		}, {
+			ConfigurationName: "heimdall",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "gjallarhorn",
+				Percent:      100,
+			}, {
+				RevisionName: "hofud",
+			}},
+			StepParams: RolloutParams{Strategy: serving.RolloutStrategyBlueGreen},
+		}, {
+			ConfigurationName: "frigga",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "idle",
+				Percent:      99,
+			}, {
+				RevisionName: "brisingamen",
+				Percent:      1,
+			}},
+		}},
+	}
+	scales := fakeScaleSource{"mjolnir": 2, "gungnir": 1, "stormbreaker": 1, "tesseract": 4, "gjallarhorn": 2}
+
+	got := ro.PreWarmScales(TestContextWithLogger(t), scales, "asgard", true)
+	if want := map[string]int32{"stormbreaker": 3, "hofud": 2}; !cmp.Equal(got, want) {
+		t.Error("PreWarmScales (-want, +got):", cmp.Diff(want, got))
+	}
+
+	// The blue/green rollouts are pre-warmed regardless.
+	got = ro.PreWarmScales(TestContextWithLogger(t), scales, "asgard", false)
+	if want := map[string]int32{"hofud": 2}; !cmp.Equal(got, want) {
+		t.Error("PreWarmScales (-want, +got):", cmp.Diff(want, got))
+	}
+}
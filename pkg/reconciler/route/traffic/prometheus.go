@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"knative.dev/serving/pkg/metrics"
+)
+
+const (
+	// minMetricsWindow is the smallest window of the queries, so that it
+	// spans several scrapes of the metrics.
+	minMetricsWindow = time.Minute
+
+	// prometheusTimeout bounds the duration of all the queries of the
+	// metrics of a revision, since they run within a reconciliation.
+	prometheusTimeout = 2 * time.Second
+
+	// metricsCacheTTL is how long the metrics of a revision are reused,
+	// which is about the scrape interval of the metrics.
+	metricsCacheTTL = 15 * time.Second
+)
+
+// prometheusSource is a MetricsSource querying the request metrics
+// of queue-proxy from a Prometheus server.
+type prometheusSource struct {
+	url    string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[metricsKey]cachedMetrics
+}
+
+// metricsKey identifies a query of the metrics of a revision.
+type metricsKey struct {
+	namespace, revision string
+	window              time.Duration
+	percentiles         string
+}
+
+type cachedMetrics struct {
+	at      time.Time
+	metrics RevisionMetrics
+}
+
+// NewPrometheusSource creates a MetricsSource querying the Prometheus
+// server at the given URL. The metrics are cached for a short while, so
+// the source is meant to be reused across the reconciliations.
+func NewPrometheusSource(url string) MetricsSource {
+	return &prometheusSource{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: prometheusTimeout},
+		cache:  make(map[metricsKey]cachedMetrics),
+	}
+}
+
+// RevisionMetrics implements MetricsSource.
+func (p *prometheusSource) RevisionMetrics(ctx context.Context, namespace, revision string,
+	window time.Duration, percentiles []float64) (RevisionMetrics, error) {
+	if window < minMetricsWindow {
+		window = minMetricsWindow
+	}
+	key := metricsKey{
+		namespace:   namespace,
+		revision:    revision,
+		window:      window,
+		percentiles: fmt.Sprint(percentiles),
+	}
+	now := time.Now()
+	p.mu.Lock()
+	c, ok := p.cache[key]
+	p.mu.Unlock()
+	if ok && now.Sub(c.at) < metricsCacheTTL {
+		return c.metrics, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, prometheusTimeout)
+	defer cancel()
+	m, err := p.queryMetrics(ctx, namespace, revision, window, percentiles)
+	if err != nil {
+		return m, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, c := range p.cache {
+		if now.Sub(c.at) >= metricsCacheTTL {
+			delete(p.cache, k)
+		}
+	}
+	p.cache[key] = cachedMetrics{at: now, metrics: m}
+	return m, nil
+}
+
+// SplitRequests implements MetricsSource. The requests routed by a tag are
+// tagged as such by queue-proxy.
+func (p *prometheusSource) SplitRequests(ctx context.Context, namespace, revision string,
+	window time.Duration) (float64, error) {
+	if window < minMetricsWindow {
+		window = minMetricsWindow
+	}
+	ctx, cancel := context.WithTimeout(ctx, prometheusTimeout)
+	defer cancel()
+	return p.query(ctx, fmt.Sprintf(`sum(increase(revision_request_count{namespace_name=%q,revision_name=%q,%s!=%q}[%ds]))`,
+		namespace, revision, metrics.TrafficTypeKey.Name(), metrics.TaggedTrafficType, int64(window.Seconds())))
+}
+
+// queryMetrics queries the metrics of the revision over the window.
+func (p *prometheusSource) queryMetrics(ctx context.Context, namespace, revision string,
+	window time.Duration, percentiles []float64) (RevisionMetrics, error) {
+	selector := fmt.Sprintf(`namespace_name=%q,revision_name=%q`, namespace, revision)
+	rng := fmt.Sprintf("[%ds]", int64(window.Seconds()))
+
+	var (
+		m   RevisionMetrics
+		err error
+	)
+	if m.Requests, err = p.query(ctx,
+		"sum(increase(revision_request_count{"+selector+"}"+rng+"))"); err != nil {
+		return m, err
+	}
+	if m.Errors, err = p.query(ctx,
+		"sum(increase(revision_request_count{"+selector+`,response_code_class="5xx"}`+rng+"))"); err != nil {
+		return m, err
+	}
+	m.Latencies = make(map[float64]time.Duration, len(percentiles))
+	for _, pc := range percentiles {
+		ms, err := p.query(ctx, fmt.Sprintf(
+			"histogram_quantile(%v, sum(rate(revision_request_latencies_bucket{%s}%s)) by (le))",
+			pc/100, selector, rng))
+		if err != nil {
+			return m, err
+		}
+		// There is no latency without requests.
+		if !math.IsNaN(ms) && !math.IsInf(ms, 0) {
+			m.Latencies[pc] = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return m, nil
+}
+
+// promResponse is the part of the response of the Prometheus query API
+// we care about.
+type promResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// query runs an instant query yielding a scalar vector, and returns its value.
+// An empty result is 0.
+func (p *prometheusSource) query(ctx context.Context, q string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		p.url+"/api/v1/query?query="+url.QueryEscape(q), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var pr promResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return 0, fmt.Errorf("failed to decode the response to %q with status %d: %w", q, resp.StatusCode, err)
+	}
+	if pr.Status != "success" {
+		return 0, fmt.Errorf("query %q failed: %s", q, pr.Error)
+	}
+	if len(pr.Data.Result) == 0 {
+		return 0, nil
+	}
+	if v := pr.Data.Result[0].Value; len(v) == 2 {
+		if s, ok := v[1].(string); ok {
+			return strconv.ParseFloat(s, 64)
+		}
+	}
+	return 0, fmt.Errorf("unexpected result of query %q: %v", q, pr.Data.Result[0].Value)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrometheusSource(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Path, "/api/v1/query"; got != want {
+			t.Errorf("Path = %s, want: %s", got, want)
+		}
+		q := r.URL.Query().Get("query")
+		queries = append(queries, q)
+		var value string
+		switch {
+		case strings.Contains(q, "histogram_quantile(0.5,"):
+			// No latency without requests.
+			value = "NaN"
+		case strings.HasPrefix(q, "histogram_quantile"):
+			value = "250.5"
+		case strings.Contains(q, `response_code_class="5xx"`):
+			// No errors, no series.
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		default:
+			value = "42"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000.1,%q]}]}}`, value)
+	}))
+	defer server.Close()
+
+	src := NewPrometheusSource(server.URL + "/")
+	got, err := src.RevisionMetrics(context.Background(), "asgard", "thor-00001", 90*time.Second, []float64{50, 99})
+	if err != nil {
+		t.Fatal("RevisionMetrics() =", err)
+	}
+	want := RevisionMetrics{
+		Requests:  42,
+		Latencies: map[float64]time.Duration{99: 250500 * time.Microsecond},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("RevisionMetrics mismatch: diff(-want,+got):\n%s", cmp.Diff(want, got))
+	}
+
+	wantQueries := []string{
+		`sum(increase(revision_request_count{namespace_name="asgard",revision_name="thor-00001"}[90s]))`,
+		`sum(increase(revision_request_count{namespace_name="asgard",revision_name="thor-00001",response_code_class="5xx"}[90s]))`,
+		`histogram_quantile(0.5, sum(rate(revision_request_latencies_bucket{namespace_name="asgard",revision_name="thor-00001"}[90s])) by (le))`,
+		`histogram_quantile(0.99, sum(rate(revision_request_latencies_bucket{namespace_name="asgard",revision_name="thor-00001"}[90s])) by (le))`,
+	}
+	if !cmp.Equal(queries, wantQueries) {
+		t.Errorf("Queries mismatch: diff(-want,+got):\n%s", cmp.Diff(wantQueries, queries))
+	}
+
+	// The requests routed by a tag aren't part of the split.
+	queries = nil
+	if got, err := src.SplitRequests(context.Background(), "asgard", "thor-00001", 90*time.Second); err != nil {
+		t.Fatal("SplitRequests() =", err)
+	} else if got != 42 {
+		t.Errorf("SplitRequests = %v, want: 42", got)
+	}
+	wantQueries = []string{
+		`sum(increase(revision_request_count{namespace_name="asgard",revision_name="thor-00001",traffic_type!="tagged"}[90s]))`,
+	}
+	if !cmp.Equal(queries, wantQueries) {
+		t.Errorf("Queries mismatch: diff(-want,+got):\n%s", cmp.Diff(wantQueries, queries))
+	}
+}
+
+func TestPrometheusSourceMinWindow(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+	defer server.Close()
+
+	if _, err := NewPrometheusSource(server.URL).RevisionMetrics(context.Background(), "asgard", "thor-00001", time.Second, nil); err != nil {
+		t.Fatal("RevisionMetrics() =", err)
+	}
+	if !strings.Contains(query, "[60s]") {
+		t.Errorf("Query %q does not span the minimum window", query)
+	}
+}
+
+func TestPrometheusSourceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{{
+		name: "query error",
+		body: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+	}, {
+		name: "not json",
+		body: `<html>Gateway Timeout</html>`,
+	}, {
+		name: "not a vector",
+		body: `{"status":"success","data":{"resultType":"scalar","result":[{"value":[1600000000.1]}]}}`,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			if _, err := NewPrometheusSource(server.URL).RevisionMetrics(context.Background(), "asgard", "thor-00001", time.Minute, nil); err == nil {
+				t.Error("RevisionMetrics() = nil, wanted an error")
+			}
+		})
+	}
+}
+
+func TestPrometheusSourceCache(t *testing.T) {
+	queries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000.1,"42"]}]}}`)
+	}))
+	defer server.Close()
+
+	src := NewPrometheusSource(server.URL)
+	for i := 0; i < 2; i++ {
+		got, err := src.RevisionMetrics(context.Background(), "asgard", "thor-00001", time.Minute, nil)
+		if err != nil {
+			t.Fatal("RevisionMetrics() =", err)
+		}
+		if got.Requests != 42 {
+			t.Errorf("Requests = %v, want: 42", got.Requests)
+		}
+	}
+	if got, want := queries, 2; got != want {
+		t.Errorf("Queries = %d, want: %d (cached)", got, want)
+	}
+
+	// Another revision is not cached.
+	if _, err := src.RevisionMetrics(context.Background(), "asgard", "loki-00001", time.Minute, nil); err != nil {
+		t.Fatal("RevisionMetrics() =", err)
+	}
+	if got, want := queries, 4; got != want {
+		t.Errorf("Queries = %d, want: %d", got, want)
+	}
+}
+
+func TestPrometheusSourceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	if _, err := NewPrometheusSource(server.URL).RevisionMetrics(context.Background(), "asgard", "thor-00001", time.Minute, nil); err == nil {
+		t.Error("RevisionMetrics() = nil, wanted an error")
+	}
+	if d := time.Since(start); d > 2*prometheusTimeout {
+		t.Errorf("RevisionMetrics took %v, want at most about %v", d, prometheusTimeout)
+	}
+}
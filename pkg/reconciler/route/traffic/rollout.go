@@ -29,6 +29,7 @@ import (
 
 	"go.uber.org/zap"
 	"knative.dev/pkg/logging"
+	"knative.dev/serving/pkg/apis/serving"
 )
 
 // Rollout encapsulates the current rollout state of the system.
@@ -39,6 +40,9 @@ import (
 type Rollout struct {
 	// Configurations are sorted by tag first and within same tag, by configuration name.
 	Configurations []*ConfigurationRollout `json:"configurations,omitempty"`
+
+	// Control records the last change of the manual control of the rollouts.
+	Control *RolloutControl `json:"control,omitempty"`
 }
 
 // ConfigurationRollout describes the rollout state for a given config+tag pair.
@@ -64,6 +68,11 @@ type ConfigurationRollout struct {
 
 	// StepParams describes rollout params for the configuration.
 	StepParams RolloutParams `json:"stepParams"`
+
+	// Analysis is the outcome of the last analysis of the newest revision,
+	// if the rollout is analyzed. It is kept until a new revision is rolled
+	// out, so that a reverted revision is not rolled out again.
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
 }
 
 // RolloutParams contains the timing and sizing parameters for the
@@ -84,6 +93,30 @@ type RolloutParams struct {
 
 	// How much traffic to move in a single step.
 	StepSize int `json:"stepSize,omitempty"`
+
+	// Paused is true if the rollout is paused manually at its current
+	// traffic split.
+	Paused bool `json:"paused,omitempty"`
+
+	// Strategy is the strategy of the rollout, empty for linear rollouts.
+	Strategy serving.RolloutStrategyType `json:"strategy,omitempty"`
+
+	// Schedule holds the steps of a scheduled rollout.
+	Schedule []ScheduledStep `json:"schedule,omitempty"`
+
+	// Deadline is the Unix timestamp in ns by when a blue/green rollout
+	// must cut over, or it is aborted.
+	Deadline int64 `json:"deadline,omitempty"`
+}
+
+// ScheduledStep is a step of a scheduled rollout.
+type ScheduledStep struct {
+	// Percent is the share of the traffic of the configuration routed to
+	// the newest revision at this step.
+	Percent int `json:"percent"`
+
+	// Hold is the number of nanoseconds the step is held before the next one.
+	Hold int64 `json:"hold,omitempty"`
 }
 
 // RevisionRollout describes the revision in the config rollout.
@@ -142,6 +175,10 @@ func (cur *Rollout) Validate() bool {
 		if c.StepParams.StepSize < 0 || c.StepParams.StepSize > c.Percent {
 			return false
 		}
+		// Scheduled rollouts need their schedule.
+		if c.StepParams.Strategy == serving.RolloutStrategySchedule && len(c.StepParams.Schedule) == 0 {
+			return false
+		}
 		// If total % values in the revision do not add up — discard.
 		tot := 0
 		for _, r := range c.Revisions {
@@ -156,16 +193,23 @@ func (cur *Rollout) Validate() bool {
 
 // ObserveReady traverses the configs and the ones that are in rollout
 // but have not observed step time yet, will have it set, to
-// max(1, nowTS-cfg.StartTime).
+// max(1, nowTS-cfg.StartTime), or to the hold time of the first step of
+// a scheduled rollout.
+// Blue/green rollouts have no steps to time.
 func (cur *Rollout) ObserveReady(ctx context.Context, nowTS int64, durationSecs float64) {
 	logger := logging.FromContext(ctx)
 	for i := range cur.Configurations {
 		c := cur.Configurations[i]
-		if c.StepParams.StepDuration == 0 && c.StepParams.StartTime > 0 {
-			// In really ceil(nowTS-params.StartTime) should always give 1s, but
-			// given possible time drift, we'll ensure that at least 1s is returned.
-			minStepSec := math.Max(1, math.Ceil(time.Duration(nowTS-c.StepParams.StartTime).Seconds()))
-			c.computeProperties(float64(nowTS), minStepSec, durationSecs)
+		if c.StepParams.StepDuration == 0 && c.StepParams.StartTime > 0 &&
+			c.StepParams.Strategy != serving.RolloutStrategyBlueGreen {
+			if c.StepParams.Strategy == serving.RolloutStrategySchedule {
+				c.scheduleNextStep(nowTS)
+			} else {
+				// In really ceil(nowTS-params.StartTime) should always give 1s, but
+				// given possible time drift, we'll ensure that at least 1s is returned.
+				minStepSec := math.Max(1, math.Ceil(time.Duration(nowTS-c.StepParams.StartTime).Seconds()))
+				c.computeProperties(float64(nowTS), minStepSec, durationSecs)
+			}
 			logger.Debugf("Computed rollout properties for %s: %#v", c.ConfigurationName, c.StepParams)
 		} else {
 			logger.Debugf("Existing rollout properties for %s: %#v", c.ConfigurationName, c.StepParams)
@@ -177,10 +221,11 @@ func (cur *Rollout) ObserveReady(ctx context.Context, nowTS int64, durationSecs
 // returns a new Rollout object representing the merged state.
 // At the end of the call the returned object will contain the
 // desired traffic shape.
+// The rollouts of new revisions are started with the given strategy.
 // Step will return cur if no previous state was available.
 // Second return value is the Unix timestamp in ns of the closest
 // rollout action to take or 0, if no rollout is currently scheduled.
-func (cur *Rollout) Step(ctx context.Context, prev *Rollout, nowTS int64) (*Rollout, int64) {
+func (cur *Rollout) Step(ctx context.Context, prev *Rollout, strategy serving.RolloutStrategy, nowTS int64) (*Rollout, int64) {
 	logger := logging.FromContext(ctx)
 	if prev == nil || len(prev.Configurations) == 0 {
 		logger.Debug("No previous Rollout to Step")
@@ -227,10 +272,10 @@ func (cur *Rollout) Step(ctx context.Context, prev *Rollout, nowTS int64) (*Roll
 				// altogether.
 				switch p := ccfgs[i].Percent; {
 				case p > 1:
-					sc := stepConfig(ccfgs[i], pcfgs[j], nowTS, logger)
+					sc := stepConfig(ccfgs[i], pcfgs[j], strategy, nowTS, logger)
 					ret = append(ret, sc)
-					// Keep the minimum value if it is not 0.
-					if nst := sc.StepParams.NextStepTime; nst > 0 && nst < returnTS {
+					// Keep the minimum value if it is not 0, and the rollout is not paused.
+					if nst := sc.StepParams.NextStepTime; nst > 0 && nst < returnTS && !sc.StepParams.Paused {
 						returnTS = nst
 					}
 				case p == 1:
@@ -256,7 +301,7 @@ func (cur *Rollout) Step(ctx context.Context, prev *Rollout, nowTS int64) (*Roll
 			}
 		}
 	}
-	ro := &Rollout{Configurations: ret}
+	ro := &Rollout{Configurations: ret, Control: prev.Control}
 	// We need to sort the rollout, since we have map iterations in between,
 	// which are random.
 	sortRollout(ro)
@@ -307,7 +352,7 @@ func adjustPercentage(goal int, cr *ConfigurationRollout, logger *zap.SugaredLog
 func stepRevisions(goal *ConfigurationRollout, nowTS int64) {
 	// Not yet ready to adjust the steps or we're done
 	// (shouldn't really be here, but better be defensive).
-	if nowTS < goal.StepParams.NextStepTime || len(goal.Revisions) < 2 {
+	if nowTS < goal.StepParams.NextStepTime || len(goal.Revisions) < 2 || goal.StepParams.Paused {
 		return
 	}
 
@@ -352,9 +397,12 @@ func stepRevisions(goal *ConfigurationRollout, nowTS int64) {
 	// And cull the tail portion of it.
 	goal.Revisions = goal.Revisions[:writePos+1]
 	// Also set the next time.
-	if len(goal.Revisions) > 1 {
+	switch {
+	case len(goal.Revisions) > 1 && goal.StepParams.Strategy == serving.RolloutStrategySchedule:
+		goal.scheduleNextStep(nowTS)
+	case len(goal.Revisions) > 1:
 		goal.StepParams.NextStepTime = nowTS + goal.StepParams.StepDuration
-	} else {
+	default:
 		// This is the last step, we're done! Clear the params out.
 		goal.StepParams = RolloutParams{}
 	}
@@ -362,7 +410,8 @@ func stepRevisions(goal *ConfigurationRollout, nowTS int64) {
 
 // stepConfig takes previous and goal configuration shapes and returns a new
 // config rollout, after computing the percetage allocations.
-func stepConfig(goal, prev *ConfigurationRollout, nowTS int64, logger *zap.SugaredLogger) *ConfigurationRollout {
+func stepConfig(goal, prev *ConfigurationRollout, strategy serving.RolloutStrategy, nowTS int64,
+	logger *zap.SugaredLogger) *ConfigurationRollout {
 	pc := len(prev.Revisions)
 	ret := &ConfigurationRollout{
 		ConfigurationName: goal.ConfigurationName,
@@ -380,8 +429,18 @@ func stepConfig(goal, prev *ConfigurationRollout, nowTS int64, logger *zap.Sugar
 	// goal will always have just one revision in the list – the current desired revision.
 	// If it matches the last revision of the previous rollout state (or there were no revisions)
 	// then no new rollout has begun for this configuration.
-	if len(prev.Revisions) == 0 || goal.Revisions[0].RevisionName == prev.Revisions[pc-1].RevisionName {
+	// Neither has it if the desired revision is the one whose rollout was reverted.
+	goalName := goal.Revisions[0].RevisionName
+	if prev.Analysis != nil && prev.Analysis.RevisionName == goalName {
+		ret.Analysis = prev.Analysis
+	}
+	if len(prev.Revisions) == 0 || goalName == prev.Revisions[pc-1].RevisionName || ret.RolledBack() {
 		logger.Debug("No new revision to roll out for config: ", goal.ConfigurationName)
+		if ret.RolledBack() && len(prev.Revisions) > 0 {
+			// Keep routing the traffic to the previous revision.
+			ret.Revisions = prev.Revisions
+			return ret
+		}
 		// So if |prev.revisions| == 0 => then there was no rollout —
 		// nothing is required to step.
 		// If |prev.revisions| == 1 and it matches current revision then
@@ -409,18 +468,41 @@ func stepConfig(goal, prev *ConfigurationRollout, nowTS int64, logger *zap.Sugar
 	// Otherwise we start a rollout, which means we need to stamp the starttime,
 	// the rest of the fields will remain unset and `ObserveReady` will
 	// compute them when the ingress becomes ready.
-	logger.Debugf("Starting a new revision rollout for configuration %s and revision %s at %d",
-		goal.ConfigurationName, goal.Revisions[0].RevisionName, nowTS)
+	logger.Debugf("Starting a new %s revision rollout for configuration %s and revision %s at %d",
+		strategy.Type, goal.ConfigurationName, goal.Revisions[0].RevisionName, nowTS)
 	ret.StepParams.StartTime = nowTS
 
-	// Go backwards and find first revision with traffic assignment > 0.
-	// Reduce it by one, so we can give that 1% to the new revision.
+	// Linear rollouts start with 1% of the traffic, scheduled ones with the
+	// share of their first step and blue/green ones with none, until the
+	// new revision is warm.
+	initial := 1
+	switch strategy.Type {
+	case serving.RolloutStrategySchedule:
+		ret.StepParams.Strategy = strategy.Type
+		ret.StepParams.Schedule = make([]ScheduledStep, len(strategy.Steps))
+		for i, s := range strategy.Steps {
+			ret.StepParams.Schedule[i] = ScheduledStep{Percent: s.Percent, Hold: int64(s.Hold)}
+		}
+		initial = scheduledShare(goal.Percent, strategy.Steps[0].Percent)
+	case serving.RolloutStrategyBlueGreen:
+		ret.StepParams.Strategy = strategy.Type
+		ret.StepParams.NextStepTime = nowTS + warmCheckInterval
+		if strategy.Duration > 0 {
+			ret.StepParams.Deadline = nowTS + int64(strategy.Duration)
+		}
+		initial = 0
+	}
+
+	// Go backwards and take the initial share of the new revision from the
+	// revisions with traffic assignment > 0.
 	// By design we drain newest revision first.
-	for i := len(prev.Revisions) - 1; i >= 0; i-- {
-		if prev.Revisions[i].Percent > 0 {
-			prev.Revisions[i].Percent--
-			break
+	for i, rem := len(prev.Revisions)-1, initial; i >= 0 && rem > 0; i-- {
+		d := prev.Revisions[i].Percent
+		if d > rem {
+			d = rem
 		}
+		prev.Revisions[i].Percent -= d
+		rem -= d
 	}
 
 	// Allocate optimistically.
@@ -440,11 +522,45 @@ func stepConfig(goal, prev *ConfigurationRollout, nowTS int64, logger *zap.Sugar
 	// Append the new revision, to the list of previous ones.
 	// This is how we start the rollout.
 	goalRev := goal.Revisions[0]
-	goalRev.Percent = 1
+	goalRev.Percent = initial
 	ret.Revisions = append(out, goalRev)
+	if len(ret.Revisions) < 2 {
+		// The new revision got all the traffic right away, e.g. the schedule
+		// starts at 100%, so there is nothing to roll out.
+		ret.StepParams = RolloutParams{}
+	}
 	return ret
 }
 
+// scheduledShare returns the share of the traffic of the configuration
+// routed to the newest revision at a step of the given percent, rounded up.
+func scheduledShare(total, percent int) int {
+	return (total*percent + 99) / 100
+}
+
+// scheduleNextStep computes the size and the time of the next step of a
+// scheduled rollout, from the step the newest revision reached.
+// Pre: there are at least two revisions.
+func (cur *ConfigurationRollout) scheduleNextStep(nowTS int64) {
+	p := &cur.StepParams
+	last := cur.Revisions[len(cur.Revisions)-1].Percent
+	// The reached step is the last one whose share the newest revision has,
+	// so the steps too small to shift any traffic are skipped.
+	i := 0
+	for i < len(p.Schedule)-1 && scheduledShare(cur.Percent, p.Schedule[i+1].Percent) <= last {
+		i++
+	}
+	p.StepSize = cur.Percent - last
+	if i < len(p.Schedule)-1 {
+		p.StepSize = scheduledShare(cur.Percent, p.Schedule[i+1].Percent) - last
+	}
+	if p.StepSize < 1 {
+		p.StepSize = 1
+	}
+	p.StepDuration = p.Schedule[i].Hold
+	p.NextStepTime = nowTS + p.StepDuration
+}
+
 // computeProperties computes the time between steps, each step size
 // and next reconcile time. This is invoked when the rollout just starts.
 // nowTS current unix timestamp in ns.
@@ -30,8 +30,27 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	. "knative.dev/pkg/logging/testing"
+	"knative.dev/serving/pkg/apis/serving"
 )
 
+// linear is the strategy of the rollouts without explicit strategy.
+var linear = serving.RolloutStrategy{Type: serving.RolloutStrategyLinear}
+
+// goal returns the rollout routing the given percent of the traffic to the
+// revision of the "thor" configuration.
+func goal(rev string, percent int) *Rollout {
+	return &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           percent,
+			Revisions: []RevisionRollout{{
+				RevisionName: rev,
+				Percent:      percent,
+			}},
+		}},
+	}
+}
+
 func TestStep(t *testing.T) {
 	const now = 2020
 	tests := []struct {
@@ -993,7 +1012,7 @@ func TestStep(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			ctx := TestContextWithLogger(t)
-			got, gotNS := tc.cur.Step(ctx, tc.prev, now)
+			got, gotNS := tc.cur.Step(ctx, tc.prev, linear, now)
 			if want := tc.want; !cmp.Equal(got, want, cmpopts.EquateEmpty()) {
 				t.Errorf("Wrong rolled rollout, diff(-want,+got):\n%s", cmp.Diff(want, got))
 			}
@@ -1007,6 +1026,265 @@ func TestStep(t *testing.T) {
 	}
 }
 
+func TestStepSchedule(t *testing.T) {
+	const (
+		start = 1982
+		ready = 1984
+	)
+	strategy := serving.RolloutStrategy{
+		Type: serving.RolloutStrategySchedule,
+		Steps: []serving.RolloutStep{
+			{Percent: 10, Hold: time.Minute},
+			{Percent: 15, Hold: time.Minute},
+			{Percent: 50, Hold: 2 * time.Minute},
+			{Percent: 100},
+		},
+	}
+	schedule := []ScheduledStep{
+		{Percent: 10, Hold: int64(time.Minute)},
+		{Percent: 15, Hold: int64(time.Minute)},
+		{Percent: 50, Hold: int64(2 * time.Minute)},
+		{Percent: 100},
+	}
+	ctx := TestContextWithLogger(t)
+	step := func(ro *Rollout, now int64) *Rollout {
+		t.Helper()
+		got, _ := goal("sif", 20).Step(ctx, ro, strategy, now)
+		if !got.Validate() {
+			t.Fatalf("Step returned an invalid config:\n%#v", got)
+		}
+		return got
+	}
+	check := func(name string, got *Rollout, want *ConfigurationRollout) {
+		t.Helper()
+		if !cmp.Equal(got.Configurations[0], want) {
+			t.Errorf("Unexpected rollout after %s (-want, +got):\n%s", name, cmp.Diff(want, got.Configurations[0]))
+		}
+	}
+
+	// The configuration gets 20% of the traffic, so the steps are rounded up
+	// to 2%, 3%, 10% and 20%.
+	ro := step(&Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           20,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      20,
+			}},
+		}},
+	}, start)
+	check("start", ro, &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           20,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      18,
+		}, {
+			RevisionName: "sif",
+			Percent:      2,
+		}},
+		StepParams: RolloutParams{
+			StartTime: start,
+			Strategy:  serving.RolloutStrategySchedule,
+			Schedule:  schedule,
+		},
+	})
+
+	ro.ObserveReady(ctx, ready, 0)
+	check("ready", ro, &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           20,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      18,
+		}, {
+			RevisionName: "sif",
+			Percent:      2,
+		}},
+		StepParams: RolloutParams{
+			StartTime:    start,
+			StepSize:     1,
+			StepDuration: int64(time.Minute),
+			NextStepTime: ready + int64(time.Minute),
+			Strategy:     serving.RolloutStrategySchedule,
+			Schedule:     schedule,
+		},
+	})
+
+	// Too soon.
+	ro = step(ro, ready+int64(time.Second))
+	if got, want := ro.Configurations[0].Revisions[1].Percent, 2; got != want {
+		t.Errorf("Percent = %d, want: %d", got, want)
+	}
+
+	now := ready + int64(time.Minute)
+	ro = step(ro, now)
+	check("first step", ro, &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           20,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      17,
+		}, {
+			RevisionName: "sif",
+			Percent:      3,
+		}},
+		StepParams: RolloutParams{
+			StartTime:    start,
+			StepSize:     7,
+			StepDuration: int64(time.Minute),
+			NextStepTime: now + int64(time.Minute),
+			Strategy:     serving.RolloutStrategySchedule,
+			Schedule:     schedule,
+		},
+	})
+
+	now += int64(time.Minute)
+	ro = step(ro, now)
+	check("second step", ro, &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           20,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      10,
+		}, {
+			RevisionName: "sif",
+			Percent:      10,
+		}},
+		StepParams: RolloutParams{
+			StartTime:    start,
+			StepSize:     10,
+			StepDuration: int64(2 * time.Minute),
+			NextStepTime: now + int64(2*time.Minute),
+			Strategy:     serving.RolloutStrategySchedule,
+			Schedule:     schedule,
+		},
+	})
+
+	now += int64(2 * time.Minute)
+	ro = step(ro, now)
+	check("last step", ro, &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           20,
+		Revisions: []RevisionRollout{{
+			RevisionName: "sif",
+			Percent:      20,
+		}},
+	})
+}
+
+func TestStepScheduleSkipsSmallSteps(t *testing.T) {
+	cfg := &ConfigurationRollout{
+		Percent: 4,
+		Revisions: []RevisionRollout{{
+			Percent: 3,
+		}, {
+			Percent: 1,
+		}},
+		StepParams: RolloutParams{
+			Strategy: serving.RolloutStrategySchedule,
+			// 1%, 5% and 25% of 4% are all 1%.
+			Schedule: []ScheduledStep{
+				{Percent: 1, Hold: 1},
+				{Percent: 5, Hold: 2},
+				{Percent: 25, Hold: 3},
+				{Percent: 100},
+			},
+		},
+	}
+	cfg.scheduleNextStep(1982)
+	want := RolloutParams{
+		StepSize:     3,
+		StepDuration: 3,
+		NextStepTime: 1985,
+		Strategy:     serving.RolloutStrategySchedule,
+		Schedule:     cfg.StepParams.Schedule,
+	}
+	if !cmp.Equal(cfg.StepParams, want) {
+		t.Error("Unexpected step params (-want, +got):", cmp.Diff(want, cfg.StepParams))
+	}
+}
+
+func TestStepBlueGreen(t *testing.T) {
+	const now = 1982
+	strategy := serving.RolloutStrategy{Type: serving.RolloutStrategyBlueGreen, Duration: time.Minute}
+	ctx := TestContextWithLogger(t)
+
+	prev := &Rollout{
+		Configurations: []*ConfigurationRollout{{
+			ConfigurationName: "thor",
+			Percent:           100,
+			Revisions: []RevisionRollout{{
+				RevisionName: "mjolnir",
+				Percent:      100,
+			}},
+		}},
+	}
+	got, nextStep := goal("stormbreaker", 100).Step(ctx, prev, strategy, now)
+	want := &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           100,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      100,
+		}, {
+			RevisionName: "stormbreaker",
+			Percent:      0,
+		}},
+		StepParams: RolloutParams{
+			StartTime:    now,
+			NextStepTime: now + warmCheckInterval,
+			Strategy:     serving.RolloutStrategyBlueGreen,
+			Deadline:     now + int64(time.Minute),
+		},
+	}
+	if !cmp.Equal(got.Configurations[0], want) {
+		t.Error("Unexpected rollout (-want, +got):", cmp.Diff(want, got.Configurations[0]))
+	}
+	if !got.Validate() {
+		t.Errorf("Step returned an invalid config:\n%#v", got)
+	}
+	if got, want := nextStep, now+warmCheckInterval; got != want {
+		t.Errorf("NextStepTime = %d, want: %d", got, want)
+	}
+
+	// Not stepped, since the new revision is not warm yet.
+	ro, _ := goal("stormbreaker", 100).Step(ctx, got, strategy, now+warmCheckInterval)
+	if !cmp.Equal(ro.Configurations[0], want) {
+		t.Error("Unexpected rollout (-want, +got):", cmp.Diff(want, ro.Configurations[0]))
+	}
+
+	// A newer revision replaces the one which is not warm yet.
+	ro, _ = goal("jarnbjorn", 100).Step(ctx, got, strategy, now+1)
+	want = &ConfigurationRollout{
+		ConfigurationName: "thor",
+		Percent:           100,
+		Revisions: []RevisionRollout{{
+			RevisionName: "mjolnir",
+			Percent:      100,
+		}, {
+			RevisionName: "jarnbjorn",
+			Percent:      0,
+		}},
+		StepParams: RolloutParams{
+			StartTime:    now + 1,
+			NextStepTime: now + 1 + warmCheckInterval,
+			Strategy:     serving.RolloutStrategyBlueGreen,
+			Deadline:     now + 1 + int64(time.Minute),
+		},
+	}
+	if !cmp.Equal(ro.Configurations[0], want) {
+		t.Error("Unexpected rollout (-want, +got):", cmp.Diff(want, ro.Configurations[0]))
+	}
+
+	// Blue/green rollouts have no steps to time.
+	ro.ObserveReady(ctx, now+2, 120)
+	if !cmp.Equal(ro.Configurations[0], want) {
+		t.Error("Unexpected rollout (-want, +got):", cmp.Diff(want, ro.Configurations[0]))
+	}
+}
+
 func TestObserveReady(t *testing.T) {
 	const (
 		now         = 200620092020 + 1982
@@ -43,7 +43,13 @@ func MakeConfigurationFromExisting(service *v1.Service, existing *v1.Configurati
 	anns := kmeta.FilterMap(service.GetAnnotations(), func(key string) bool {
 		return key == corev1.LastAppliedConfigAnnotation ||
 			// Configs & Revisions don't use rollout information, it is only for routes.
-			key == serving.RolloutDurationKey
+			key == serving.RolloutDurationKey ||
+			key == serving.RolloutMaxErrorRateKey ||
+			key == serving.RolloutMaxLatencyKey ||
+			key == serving.RolloutMinRequestsKey ||
+			key == serving.RolloutControlKey ||
+			key == serving.RolloutControlModifierKey ||
+			key == serving.RolloutPreWarmKey
 	})
 
 	routeName := names.Route(service)
@@ -32,7 +32,10 @@ func TestConfigurationSpec(t *testing.T) {
 	s := createService()
 	s.Annotations = kmeta.UnionMaps(s.Annotations,
 		map[string]string{
-			serving.RolloutDurationKey: "2021s",
+			serving.RolloutDurationKey:     "2021s",
+			serving.RolloutMaxErrorRateKey: "0.05",
+			serving.RolloutMaxLatencyKey:   "p99=1s",
+			serving.RolloutMinRequestsKey:  "100",
 		},
 	)
 
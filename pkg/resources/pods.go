@@ -80,6 +80,18 @@ func (pa PodAccessor) ReadyCount() (int, error) {
 	return r, err
 }
 
+// ReadyPodNames returns the names of the ready pods of the revision, sorted.
+func (pa PodAccessor) ReadyPodNames() ([]string, error) {
+	var names []string
+	if err := pa.ProcessPods(func(p *corev1.Pod) {
+		names = append(names, p.Name)
+	}, podRunning, podReady); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // NotReadyCount implements EndpointsCounter.
 func (pa PodAccessor) NotReadyCount() (int, error) {
 	_, nr, _, _, err := pa.PodCountsByState()
@@ -115,6 +115,13 @@ func TestPodReadyUnreadyCount(t *testing.T) {
 			if got != tc.want {
 				t.Errorf("ReadyCount = %d, want: %d", got, tc.want)
 			}
+			names, err := podCounter.ReadyPodNames()
+			if err != nil {
+				t.Fatal("ReadyPodNames failed:", err)
+			}
+			if len(names) != tc.want || !sort.StringsAreSorted(names) {
+				t.Errorf("ReadyPodNames = %v, want %d sorted names", names, tc.want)
+			}
 			got, err = podCounter.NotReadyCount()
 			if err != nil {
 				t.Fatal("NotReadyCount failed:", err)
@@ -205,6 +205,16 @@ func WithMetricAnnotation(metric string) PodAutoscalerOption {
 	return withAnnotationValue(autoscaling.MetricAnnotationKey, metric)
 }
 
+// WithMetricNameAnnotation adds a metric name annotation to the PA.
+func WithMetricNameAnnotation(name string) PodAutoscalerOption {
+	return withAnnotationValue(autoscaling.MetricNameAnnotationKey, name)
+}
+
+// WithMetricSelectorAnnotation adds a metric selector annotation to the PA.
+func WithMetricSelectorAnnotation(selector string) PodAutoscalerOption {
+	return withAnnotationValue(autoscaling.MetricSelectorAnnotationKey, selector)
+}
+
 // WithObservedGeneration returns a PodAutoScalerOption which sets
 // the Status.ObservedGeneration field to the given generation.
 func WithObservedGeneration(gen int64) PodAutoscalerOption {
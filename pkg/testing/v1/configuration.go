@@ -106,6 +106,14 @@ func WithConfigAnn(key, value string) ConfigOption {
 	}
 }
 
+// WithPendingRevisionGC sets the revisions pending garbage collection in the
+// configuration's status.
+func WithPendingRevisionGC(pending ...v1.PendingRevisionGC) ConfigOption {
+	return func(config *v1.Configuration) {
+		config.Status.PendingRevisionGC = pending
+	}
+}
+
 // WithConfigOwnersRemoved clears the owner references of this Configuration.
 func WithConfigOwnersRemoved(cfg *v1.Configuration) {
 	cfg.OwnerReferences = nil